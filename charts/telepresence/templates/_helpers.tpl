@@ -88,6 +88,21 @@ RBAC rules required to create an intercept in a namespace; excludes any rules th
 - apiGroups: ["apps"]
   resources: ["deployments", "replicasets", "statefulsets"]
   verbs: ["get", "watch", "list"]
+{{- if and .Values.workloads .Values.workloads.daemonSets .Values.workloads.daemonSets.enabled }}
+- apiGroups: ["apps"]
+  resources: ["daemonsets"]
+  verbs: ["get", "watch", "list"]
+{{- end }}
+{{- if and .Values.workloads .Values.workloads.jobs .Values.workloads.jobs.enabled }}
+- apiGroups: ["batch"]
+  resources: ["jobs"]
+  verbs: ["get", "watch", "list"]
+{{- end }}
+{{- if and .Values.workloads .Values.workloads.cronJobs .Values.workloads.cronJobs.enabled }}
+- apiGroups: ["batch"]
+  resources: ["cronjobs"]
+  verbs: ["get", "watch", "list"]
+{{- end }}
 {{- if and .Values.workloads .Values.workloads.argoRollouts .Values.workloads.argoRollouts.enabled }}
 - apiGroups: ["argoproj.io"]
   resources: ["rollouts"]
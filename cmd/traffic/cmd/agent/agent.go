@@ -135,6 +135,8 @@ func Main(ctx context.Context, _ ...string) error {
 		return err
 	}
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 	g := dgroup.NewGroup(ctx, dgroup.GroupConfig{
 		EnableSignalHandling: true,
 	})
@@ -150,13 +152,32 @@ func Main(ctx context.Context, _ ...string) error {
 		return sidecar(ctx, s, info)
 	})
 
+	appExited := false
+	if dos.Getenv(ctx, agentconfig.EnvExitWithApp) != "" {
+		g.Go("app-watcher", func(ctx context.Context) error {
+			if err := waitForAppExit(ctx, "/proc"); err != nil || ctx.Err() != nil {
+				return err
+			}
+			dlog.Info(ctx, "The app containers have terminated")
+			appExited = true
+			cancel()
+			return nil
+		})
+	}
+
 	// Wait for exit
-	return g.Wait()
+	err = g.Wait()
+	if appExited {
+		// The pod of a Job can only complete when all of its containers exit successfully.
+		return nil
+	}
+	return err
 }
 
 func sidecar(ctx context.Context, s State, info *rpc.AgentInfo) error {
 	// Manage the forwarders
 	ac := s.AgentConfig()
+	info.Containers = make(map[string]*rpc.AgentInfo_ContainerInfo, len(ac.Containers))
 	for _, cn := range ac.Containers {
 		env, err := AppEnvironment(ctx, cn)
 		if err != nil {
@@ -164,6 +185,10 @@ func sidecar(ctx context.Context, s State, info *rpc.AgentInfo) error {
 		}
 		cnMountPoint := filepath.Join(agentconfig.ExportsMountPoint, filepath.Base(cn.MountPoint))
 		s.AddContainerState(cn.Name, NewContainerState(cnMountPoint, env))
+		info.Containers[cn.Name] = &rpc.AgentInfo_ContainerInfo{
+			Environment: env,
+			MountPoint:  cnMountPoint,
+		}
 
 		// Group the containers intercepts by agent port
 		icStates := make(map[agentconfig.PortAndProto][]*agentconfig.Intercept, len(cn.Intercepts))
@@ -318,12 +343,19 @@ func StartServices(ctx context.Context, g *dgroup.Group, config Config, srv Stat
 		ApiPort:   int32(grpcPort),
 		Product:   "telepresence",
 		Version:   version.Version,
+		FtpPort:   int32(ftpPort),
+		SftpPort:  int32(sftpPort),
 		Mechanisms: []*rpc.AgentInfo_Mechanism{
 			{
 				Name:    "tcp",
 				Product: "telepresence",
 				Version: version.Version,
 			},
+			{
+				Name:    "http",
+				Product: "telepresence",
+				Version: version.Version,
+			},
 		},
 	}, nil
 }
@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+)
+
+// appWatchInterval is how often the processes of the app containers are checked.
+const appWatchInterval = 2 * time.Second
+
+// waitForAppExit returns when the processes of the app containers have terminated. It is used by the agent
+// of a Job or CronJob that isn't a native sidecar, so that the agent doesn't keep the pod running. The pod
+// must share its process namespace, so that the processes of the other containers are visible in procDir.
+func waitForAppExit(ctx context.Context, procDir string) error {
+	ticker := time.NewTicker(appWatchInterval)
+	defer ticker.Stop()
+	seen := false
+	for {
+		n, err := countAppProcesses(procDir, os.Getpid())
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			seen = true
+		} else if seen {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// countAppProcesses returns the number of processes in procDir that don't belong to the agent. Process 1 is
+// the pause container of the pod.
+func countAppProcesses(procDir string, self int) (int, error) {
+	des, err := os.ReadDir(procDir)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, de := range des {
+		if !de.IsDir() {
+			continue
+		}
+		pid, err := strconv.Atoi(de.Name())
+		if err != nil || pid == 1 || pid == self {
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,28 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_countAppProcesses(t *testing.T) {
+	procDir := t.TempDir()
+	for _, name := range []string{"1", "7", "42", "self", "net"} {
+		require.NoError(t, os.Mkdir(filepath.Join(procDir, name), 0o700))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(procDir, "99"), nil, 0o600))
+
+	// Process 1 is the pause container and 7 is the agent itself.
+	n, err := countAppProcesses(procDir, 7)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	require.NoError(t, os.Remove(filepath.Join(procDir, "42")))
+	n, err = countAppProcesses(procDir, 7)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
@@ -3,22 +3,30 @@ package agent
 import (
 	"context"
 	"fmt"
+	"maps"
 	"net/http"
 	"time"
 
+	core "k8s.io/api/core/v1"
+
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/forwarder"
+	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
 	"github.com/telepresenceio/telepresence/v2/pkg/restapi"
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 )
 
 type fwdState struct {
 	*state
-	intercept       InterceptTarget
-	container       string
-	forwarder       forwarder.Interceptor
-	chosenIntercept *manager.InterceptInfo
+	intercept InterceptTarget
+	container string
+	forwarder forwarder.Interceptor
+
+	// chosenIntercepts are the intercepts that this agent has chosen to serve. There's either one single
+	// intercept that doesn't use the "http" mechanism, or any number of intercepts that all use it. The
+	// same is true for the mirroring intercepts.
+	chosenIntercepts []*manager.InterceptInfo
 }
 
 // NewInterceptState creates an InterceptState that performs intercepts by using an Interceptor which indiscriminately
@@ -37,14 +45,13 @@ func (fs *fwdState) Target() InterceptTarget {
 }
 
 func (fs *fwdState) InterceptInfo(ctx context.Context, callerID, path string, containerPort uint16, headers http.Header) (*restapi.InterceptInfo, error) {
-	// The OSS agent is either intercepting or it isn't. There's no way to tell what it is that's being intercepted.
 	fw := fs.forwarder
 	if containerPort == 0 {
-		return fw.InterceptInfo(), nil
+		return fw.InterceptInfo(path, headers), nil
 	}
 	_, port := fw.Target()
 	if containerPort == port {
-		return fw.InterceptInfo(), nil
+		return fw.InterceptInfo(path, headers), nil
 	}
 	portInfo := ""
 	if containerPort != 0 {
@@ -72,49 +79,49 @@ func (pm *ProviderMux) CreateClientStream(ctx context.Context, sessionID string,
 }
 
 func (fs *fwdState) HandleIntercepts(ctx context.Context, cepts []*manager.InterceptInfo) []*manager.ReviewInterceptRequest {
-	var myChoice, activeIntercept *manager.InterceptInfo
-	if fs.chosenIntercept != nil {
-		chosenID := fs.chosenIntercept.Id
-		for _, is := range cepts {
-			if chosenID == is.Id {
-				fs.chosenIntercept = is
-				myChoice = is
-				break
+	if len(fs.chosenIntercepts) > 0 {
+		// Refresh the chosen intercepts from the snapshot, and forget those that are no longer present.
+		chosen := make([]*manager.InterceptInfo, 0, len(fs.chosenIntercepts))
+		for _, ci := range fs.chosenIntercepts {
+			for _, cept := range cepts {
+				if ci.Id == cept.Id {
+					chosen = append(chosen, cept)
+					break
+				}
 			}
 		}
-
-		if myChoice == nil {
-			// Chosen intercept is not present in the snapshot
-			fs.chosenIntercept = nil
-		} else if myChoice.Disposition == manager.InterceptDispositionType_ACTIVE {
-			// The chosen intercept still exists and is active
-			activeIntercept = myChoice
-		}
-	} else {
-		// Attach to already ACTIVE intercept if there is one.
+		fs.chosenIntercepts = chosen
+	}
+	if len(fs.chosenIntercepts) == 0 {
+		// Attach to already ACTIVE intercepts if there are any.
 		for _, cept := range cepts {
-			if cept.Disposition == manager.InterceptDispositionType_ACTIVE {
-				myChoice = cept
-				fs.chosenIntercept = cept
-				activeIntercept = cept
-				break
+			if cept.Disposition == manager.InterceptDispositionType_ACTIVE && fs.conflictingIntercept(cept) == nil {
+				fs.chosenIntercepts = append(fs.chosenIntercepts, cept)
 			}
 		}
 	}
 
+	var activeIntercepts []*manager.InterceptInfo
+	for _, ci := range fs.chosenIntercepts {
+		if ci.Disposition == manager.InterceptDispositionType_ACTIVE {
+			activeIntercepts = append(activeIntercepts, ci)
+		}
+	}
+
 	if fs.sessionInfo != nil {
 		// Update forwarding.
 		fs.forwarder.SetStreamProvider(
 			&ProviderMux{
 				AgentProvider:   fs,
-				ManagerProvider: &tunnel.TrafficManagerStreamProvider{Manager: fs.ManagerClient(), AgentSessionID: fs.sessionInfo.SessionId},
+				ManagerProvider: &tunnel.TrafficManagerStreamProvider{Manager: fs.ManagerClient(), AgentSessionID: fs.sessionInfo.SessionId, Muxes: fs.muxes},
 			})
 	}
-	fs.forwarder.SetIntercepting(activeIntercept)
+	fs.forwarder.SetIntercepting(activeIntercepts)
 
 	// Review waiting intercepts
 	reviews := make([]*manager.ReviewInterceptRequest, 0, len(cepts))
 	for _, cept := range cepts {
+		desc, headers, disposition, err := fs.reviewMechanism(ctx, cept.Spec)
 		container := cept.Spec.ContainerName
 		if container == "" {
 			container = fs.container
@@ -125,64 +132,147 @@ func (fs *fwdState) HandleIntercepts(ctx context.Context, cepts []*manager.Inter
 				Id:                cept.Id,
 				Disposition:       manager.InterceptDispositionType_AGENT_ERROR,
 				Message:           fmt.Sprintf("No match for container %q", container),
-				MechanismArgsDesc: "all TCP connections",
+				MechanismArgsDesc: desc,
 			})
 			continue
 		}
 		if cept.Disposition == manager.InterceptDispositionType_WAITING {
+			if err != nil {
+				dlog.Infof(ctx, "Setting intercept %q as %s; %v", cept.Id, disposition, err)
+				reviews = append(reviews, &manager.ReviewInterceptRequest{
+					Id:                cept.Id,
+					Disposition:       disposition,
+					Message:           err.Error(),
+					MechanismArgsDesc: desc,
+				})
+				continue
+			}
 			// This intercept is ready to be active
-			switch {
-			case cept == myChoice:
+			if fs.isChosen(cept) {
 				// We've already chosen this one, but it's not active yet in this
 				// snapshot. Let's go ahead and tell the manager to mark it ACTIVE.
 				dlog.Infof(ctx, "Setting intercept %q as ACTIVE (again?)", cept.Id)
-				reviews = append(reviews, &manager.ReviewInterceptRequest{
-					Id:                cept.Id,
-					Disposition:       manager.InterceptDispositionType_ACTIVE,
-					PodIp:             fs.PodIP(),
-					FtpPort:           int32(fs.FtpPort()),
-					SftpPort:          int32(fs.SftpPort()),
-					MountPoint:        cs.MountPoint(),
-					MechanismArgsDesc: "all TCP connections",
-					Environment:       cs.Env(),
-				})
-			case fs.chosenIntercept == nil:
-				// We don't have an intercept in play, so choose this one. All
-				// agents will get intercepts in the same order every time, so
-				// this will yield a consistent result. Note that the intercept
-				// will not become active at this time. That will happen later,
-				// once the manager assigns a port.
-				dlog.Infof(ctx, "Setting intercept %q as ACTIVE", cept.Id)
-				fs.chosenIntercept = cept
-				myChoice = cept
-				reviews = append(reviews, &manager.ReviewInterceptRequest{
-					Id:                cept.Id,
-					Disposition:       manager.InterceptDispositionType_ACTIVE,
-					PodIp:             fs.PodIP(),
-					FtpPort:           int32(fs.FtpPort()),
-					SftpPort:          int32(fs.SftpPort()),
-					MountPoint:        cs.MountPoint(),
-					MechanismArgsDesc: "all TCP connections",
-					Environment:       cs.Env(),
-				})
-			default:
-				// We already have an intercept in play, so reject this one.
-				chosenID := fs.chosenIntercept.Id
-				dlog.Infof(ctx, "Setting intercept %q as AGENT_ERROR; as it conflicts with %q as the current chosen-to-be-ACTIVE intercept", cept.Id, chosenID)
+			} else if ci := fs.conflictingIntercept(cept); ci != nil {
+				// We already have an intercept in play that this one conflicts with, so reject this one.
+				dlog.Infof(ctx, "Setting intercept %q as CONFLICT; as it conflicts with %q as a chosen-to-be-ACTIVE intercept", cept.Id, ci.Id)
 				var msg string
-				if fs.chosenIntercept.Disposition == manager.InterceptDispositionType_ACTIVE {
-					msg = fmt.Sprintf("Conflicts with the currently-served intercept %q", chosenID)
+				if ci.Disposition == manager.InterceptDispositionType_ACTIVE {
+					msg = fmt.Sprintf("Conflicts with the currently-served intercept %q", ci.Id)
 				} else {
-					msg = fmt.Sprintf("Conflicts with the currently-waiting-to-be-served intercept %q", chosenID)
+					msg = fmt.Sprintf("Conflicts with the currently-waiting-to-be-served intercept %q", ci.Id)
 				}
 				reviews = append(reviews, &manager.ReviewInterceptRequest{
 					Id:                cept.Id,
-					Disposition:       manager.InterceptDispositionType_AGENT_ERROR,
+					Disposition:       manager.InterceptDispositionType_CONFLICT,
 					Message:           msg,
-					MechanismArgsDesc: "all TCP connections",
+					MechanismArgsDesc: desc,
 				})
+				continue
+			} else {
+				// This intercept doesn't conflict with the ones in play, so choose it. All
+				// agents will get intercepts in the same order every time, so this will
+				// yield a consistent result. Note that the intercept will not become active
+				// at this time. That will happen later, once the manager assigns a port.
+				dlog.Infof(ctx, "Setting intercept %q as ACTIVE", cept.Id)
+				fs.chosenIntercepts = append(fs.chosenIntercepts, cept)
 			}
+			reviews = append(reviews, &manager.ReviewInterceptRequest{
+				Id:                cept.Id,
+				Disposition:       manager.InterceptDispositionType_ACTIVE,
+				PodIp:             fs.PodIP(),
+				FtpPort:           int32(fs.FtpPort()),
+				SftpPort:          int32(fs.SftpPort()),
+				MountPoint:        cs.MountPoint(),
+				MechanismArgsDesc: desc,
+				Headers:           headers,
+				Environment:       cs.Env(),
+			})
 		}
 	}
 	return reviews
 }
+
+func (fs *fwdState) isChosen(cept *manager.InterceptInfo) bool {
+	for _, ci := range fs.chosenIntercepts {
+		if ci.Id == cept.Id {
+			return true
+		}
+	}
+	return false
+}
+
+// conflictingIntercept returns the first chosen intercept that cannot be served together with the given
+// intercept, or nil if there is no such intercept. Intercepts that use the "http" mechanism can share a
+// port unless their request matchers are identical, because the one with the lowest precedence would then
+// never receive any requests. All other intercepts require exclusive use of the port. Mirroring intercepts
+// never conflict with intercepts that aren't mirroring, but follow the same rules among themselves.
+func (fs *fwdState) conflictingIntercept(cept *manager.InterceptInfo) *manager.InterceptInfo {
+	for _, ci := range fs.chosenIntercepts {
+		if ci.Spec.Mirror != cept.Spec.Mirror {
+			continue
+		}
+		if ci.Spec.Mechanism != "http" || cept.Spec.Mechanism != "http" {
+			return ci
+		}
+		crm, err := matcher.NewRequestFromArgs(ci.Spec.MechanismArgs)
+		if err != nil {
+			return ci
+		}
+		rm, err := matcher.NewRequestFromArgs(cept.Spec.MechanismArgs)
+		if err != nil || maps.Equal(crm.Map(), rm.Map()) {
+			return ci
+		}
+	}
+	return nil
+}
+
+// reviewMechanism returns a human-friendly description of what the mechanism args of the given spec say,
+// and the headers that the workstation API-server will use when matching requests. A non-nil error, and
+// the disposition to use when rejecting the intercept, is returned when the mechanism isn't supported or
+// when its args are invalid.
+func (fs *fwdState) reviewMechanism(ctx context.Context, spec *manager.InterceptSpec) (string, map[string]string, manager.InterceptDispositionType, error) {
+	desc, headers, disposition, err := fs.reviewMechanismArgs(ctx, spec)
+	if err == nil && spec.Mirror {
+		if proto := fs.intercept.Protocol(); proto != core.ProtocolTCP {
+			return "", nil, manager.InterceptDispositionType_BAD_ARGS, fmt.Errorf("a %s port cannot be mirrored", proto)
+		}
+		desc = "copies of " + desc
+	}
+	return desc, headers, disposition, err
+}
+
+func (fs *fwdState) reviewMechanismArgs(ctx context.Context, spec *manager.InterceptSpec) (string, map[string]string, manager.InterceptDispositionType, error) {
+	switch spec.Mechanism {
+	case "", "tcp":
+		return "all TCP connections", nil, manager.InterceptDispositionType_ACTIVE, nil
+	case "http":
+		if proto := fs.intercept.Protocol(); proto != core.ProtocolTCP {
+			return "", nil, manager.InterceptDispositionType_BAD_ARGS, fmt.Errorf("mechanism %q cannot be used with a %s port", spec.Mechanism, proto)
+		}
+		rm, err := matcher.NewRequestFromArgs(spec.MechanismArgs)
+		if err != nil {
+			return "", nil, manager.InterceptDispositionType_BAD_ARGS, err
+		}
+		if p := rm.Path(); p != nil && p.Op() == "grpc" {
+			// Each gRPC call is an HTTP/2 stream, so the port must be known to carry cleartext HTTP/2.
+			if ap := fs.intercept.AppProtocol(ctx); !isH2CAppProtocol(ap) {
+				return "", nil, manager.InterceptDispositionType_BAD_ARGS, fmt.Errorf(
+					"gRPC methods can only be intercepted on a port with appProtocol grpc or h2c, but the appProtocol of this port is %q", ap)
+			}
+		}
+		return "HTTP " + rm.String(), rm.Map(), manager.InterceptDispositionType_ACTIVE, nil
+	default:
+		return "", nil, manager.InterceptDispositionType_NO_MECHANISM, fmt.Errorf("mechanism %q is not supported by this agent", spec.Mechanism)
+	}
+}
+
+// isH2CAppProtocol returns true if the given appProtocol means that the port carries cleartext HTTP/2. The
+// "http2" protocol is what a port named "h2c" is given by the agent config generator.
+func isH2CAppProtocol(ap string) bool {
+	switch ap {
+	case "grpc", "h2c", "http2", "kubernetes.io/h2c":
+		return true
+	default:
+		return false
+	}
+}
@@ -5,8 +5,6 @@ import (
 	"time"
 
 	"github.com/puzpuzpuz/xsync/v3"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/datawire/dlib/dlog"
@@ -26,11 +24,10 @@ func (s *state) Version(context.Context, *emptypb.Empty) (*rpc.VersionInfo2, err
 }
 
 func (s *state) Tunnel(server agent.Agent_TunnelServer) error {
-	ctx := server.Context()
-	stream, err := tunnel.NewServerStream(ctx, server)
-	if err != nil {
-		return status.Errorf(codes.FailedPrecondition, "failed to connect stream: %v", err)
-	}
+	return tunnel.ServeStreams(tunnel.WithResumeTimeout(server.Context(), tunnel.DefaultResumeTimeout), server, s.serveStream)
+}
+
+func (s *state) serveStream(ctx context.Context, stream tunnel.Stream) error {
 	if awc, ok := s.awaitingForwards.Load(stream.SessionID()); ok {
 		if awf, ok := awc.Load(stream.ID()); ok {
 			awf.streamCh <- stream
@@ -45,10 +42,13 @@ func (s *state) Tunnel(server agent.Agent_TunnelServer) error {
 	endPoint.Start(ctx)
 	<-endPoint.Done()
 
+	uncompressed, compressed := tunnel.GetCompressionStats(stream)
 	s.ReportMetrics(ctx, &rpc.TunnelMetrics{
-		ClientSessionId: stream.SessionID(),
-		IngressBytes:    ingressBytes.GetValue(),
-		EgressBytes:     egressBytes.GetValue(),
+		ClientSessionId:   stream.SessionID(),
+		IngressBytes:      ingressBytes.GetValue(),
+		EgressBytes:       egressBytes.GetValue(),
+		UncompressedBytes: uncompressed,
+		CompressedBytes:   compressed,
 	})
 	return nil
 }
@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/blang/semver/v4"
@@ -62,6 +63,9 @@ type state struct {
 	manager     manager.ManagerClient
 	mgrVer      semver.Version
 
+	// muxes multiplexes the tunnels to the traffic-manager.
+	muxes *tunnel.MuxPool
+
 	interceptStates []InterceptState
 	containerStates map[string]ContainerState
 	agent.UnimplementedAgentServer
@@ -123,9 +127,46 @@ func (s *state) HandleIntercepts(ctx context.Context, iis []*manager.InterceptIn
 		}
 		rs = append(rs, ist.HandleIntercepts(ctx, ms)...)
 	}
+	for _, ii := range iis {
+		if isPortLess(ii.Spec) && ii.Disposition == manager.InterceptDispositionType_WAITING {
+			rs = append(rs, s.reviewPortLess(ctx, ii))
+		}
+	}
 	return rs
 }
 
+// isPortLess returns true if the given spec is for an intercept that doesn't divert any traffic. Such
+// intercepts are used when replacing the container of a Job or CronJob with a local process.
+func isPortLess(spec *manager.InterceptSpec) bool {
+	return spec.ContainerPort == 0 && spec.PortIdentifier == ""
+}
+
+// reviewPortLess reviews a port-less intercept. There is no forwarder to configure, so the intercept
+// is made active as soon as its container is known.
+func (s *state) reviewPortLess(ctx context.Context, ii *manager.InterceptInfo) *manager.ReviewInterceptRequest {
+	const desc = "no traffic"
+	cs := s.containerStates[ii.Spec.ContainerName]
+	if cs == nil {
+		return &manager.ReviewInterceptRequest{
+			Id:                ii.Id,
+			Disposition:       manager.InterceptDispositionType_AGENT_ERROR,
+			Message:           fmt.Sprintf("No match for container %q", ii.Spec.ContainerName),
+			MechanismArgsDesc: desc,
+		}
+	}
+	dlog.Infof(ctx, "Setting port-less intercept %q as ACTIVE", ii.Id)
+	return &manager.ReviewInterceptRequest{
+		Id:                ii.Id,
+		Disposition:       manager.InterceptDispositionType_ACTIVE,
+		PodIp:             s.PodIP(),
+		FtpPort:           int32(s.FtpPort()),
+		SftpPort:          int32(s.SftpPort()),
+		MountPoint:        cs.MountPoint(),
+		MechanismArgsDesc: desc,
+		Environment:       cs.Env(),
+	}
+}
+
 func (s *state) InterceptInfo(ctx context.Context, callerID, path string, containerPort uint16, headers http.Header) (*restapi.InterceptInfo, error) {
 	if containerPort == 0 && len(s.interceptStates) == 1 {
 		containerPort = s.interceptStates[0].Target().ContainerPort()
@@ -140,10 +181,11 @@ func (s *state) InterceptInfo(ctx context.Context, callerID, path string, contai
 	return &restapi.InterceptInfo{}, nil
 }
 
-func (s *state) SetManager(_ context.Context, sessionInfo *manager.SessionInfo, manager manager.ManagerClient, version semver.Version) {
+func (s *state) SetManager(ctx context.Context, sessionInfo *manager.SessionInfo, manager manager.ManagerClient, version semver.Version) {
 	s.manager = manager
 	s.sessionInfo = sessionInfo
 	s.mgrVer = version
+	s.muxes = tunnel.NewMuxPool(tunnel.WithResumeTimeout(ctx, tunnel.DefaultResumeTimeout))
 }
 
 func (s *state) FtpPort() uint16 {
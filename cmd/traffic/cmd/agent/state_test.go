@@ -3,6 +3,7 @@ package agent_test
 import (
 	"context"
 	"net"
+	"net/http"
 	"path/filepath"
 	"testing"
 	"time"
@@ -70,7 +71,7 @@ func TestState_HandleIntercepts(t *testing.T) {
 
 	reviews = s.HandleIntercepts(ctx, cepts)
 	a.Len(reviews, 0)
-	a.Equal("", f.InterceptId())
+	a.Empty(f.InterceptIds())
 
 	// Prepare some intercepts..
 
@@ -110,7 +111,7 @@ func TestState_HandleIntercepts(t *testing.T) {
 
 	reviews = s.HandleIntercepts(ctx, cepts)
 	a.Len(reviews, 0)
-	a.Equal("", f.InterceptId())
+	a.Empty(f.InterceptIds())
 
 	// Handle reviews waiting intercepts
 
@@ -119,7 +120,7 @@ func TestState_HandleIntercepts(t *testing.T) {
 
 	reviews = s.HandleIntercepts(ctx, cepts)
 	a.Len(reviews, 2)
-	a.Equal("", f.InterceptId())
+	a.Empty(f.InterceptIds())
 
 	// Reviews are in the correct order
 
@@ -129,7 +130,7 @@ func TestState_HandleIntercepts(t *testing.T) {
 	// First cept was accepted, second was rejected
 
 	a.Equal(rpc.InterceptDispositionType_ACTIVE, reviews[0].Disposition)
-	a.Equal(rpc.InterceptDispositionType_AGENT_ERROR, reviews[1].Disposition)
+	a.Equal(rpc.InterceptDispositionType_CONFLICT, reviews[1].Disposition)
 	a.Equal("Conflicts with the currently-waiting-to-be-served intercept \"intercept-01\"", reviews[1].Message)
 
 	// Handle conflicts
@@ -140,13 +141,230 @@ func TestState_HandleIntercepts(t *testing.T) {
 	reviews = s.HandleIntercepts(ctx, cepts)
 	a.Len(reviews, 1)
 	a.Equal(cepts[1].Id, reviews[0].Id)
+	a.Equal([]string{cepts[0].Id}, f.InterceptIds())
 
-	a.Equal(rpc.InterceptDispositionType_AGENT_ERROR, reviews[0].Disposition)
+	a.Equal(rpc.InterceptDispositionType_CONFLICT, reviews[0].Disposition)
 	a.Equal("Conflicts with the currently-served intercept \"intercept-01\"", reviews[0].Message)
 
 	// Handle resets state on an empty intercept list again
 
 	reviews = s.HandleIntercepts(ctx, nil)
 	a.Len(reviews, 0)
-	a.Equal("", f.InterceptId())
+	a.Empty(f.InterceptIds())
+}
+
+func TestState_HandleHTTPIntercepts(t *testing.T) {
+	ctx := testContext(t, nil)
+	a := assert.New(t)
+	_, s := makeFS(t, ctx)
+
+	cepts := []*rpc.InterceptInfo{
+		{
+			Spec: &rpc.InterceptSpec{
+				Name:           "cept1Name",
+				Client:         "user@host1",
+				Agent:          "agentName",
+				Mechanism:      "http",
+				MechanismArgs:  []string{"--http-header=x-user=jane", "--http-path-prefix=/api/"},
+				Namespace:      namespace,
+				ServiceName:    serviceName,
+				PortIdentifier: "http",
+				TargetPort:     8080,
+			},
+			Id:          "intercept-01",
+			Disposition: rpc.InterceptDispositionType_WAITING,
+		},
+		{
+			Spec: &rpc.InterceptSpec{
+				Name:           "cept2Name",
+				Client:         "user@host2",
+				Agent:          "agentName",
+				Mechanism:      "http",
+				MechanismArgs:  []string{"--http-path-regex=/a(b"},
+				Namespace:      namespace,
+				ServiceName:    serviceName,
+				PortIdentifier: "http",
+				TargetPort:     8080,
+			},
+			Id:          "intercept-02",
+			Disposition: rpc.InterceptDispositionType_WAITING,
+		},
+		{
+			Spec: &rpc.InterceptSpec{
+				Name:           "cept3Name",
+				Client:         "user@host3",
+				Agent:          "agentName",
+				Mechanism:      "grpc",
+				Namespace:      namespace,
+				ServiceName:    serviceName,
+				PortIdentifier: "http",
+				TargetPort:     8080,
+			},
+			Id:          "intercept-03",
+			Disposition: rpc.InterceptDispositionType_WAITING,
+		},
+	}
+
+	reviews := s.HandleIntercepts(ctx, cepts)
+	a.Len(reviews, 3)
+
+	a.Equal(rpc.InterceptDispositionType_ACTIVE, reviews[0].Disposition)
+	a.Equal("HTTP requests with\n  path prefix /api/\n  headers\n    'X-User: jane'", reviews[0].MechanismArgsDesc)
+	a.Equal(map[string]string{":path-prefix:": "/api/", "X-User": "jane"}, reviews[0].Headers)
+
+	a.Equal(rpc.InterceptDispositionType_BAD_ARGS, reviews[1].Disposition)
+	a.Equal(rpc.InterceptDispositionType_NO_MECHANISM, reviews[2].Disposition)
+}
+
+func TestState_HandleGRPCIntercepts(t *testing.T) {
+	ctx := testContext(t, nil)
+	a := assert.New(t)
+	_, s := makeFS(t, ctx)
+
+	// The port of the test config has no appProtocol, so it isn't known to carry gRPC.
+	cepts := []*rpc.InterceptInfo{
+		{
+			Spec: &rpc.InterceptSpec{
+				Name:           "cept1Name",
+				Client:         "user@host1",
+				Agent:          "agentName",
+				Mechanism:      "http",
+				MechanismArgs:  []string{"--grpc-method=pkg.Svc/Get"},
+				Namespace:      namespace,
+				ServiceName:    serviceName,
+				PortIdentifier: "http",
+				TargetPort:     8080,
+			},
+			Id:          "intercept-01",
+			Disposition: rpc.InterceptDispositionType_WAITING,
+		},
+	}
+	reviews := s.HandleIntercepts(ctx, cepts)
+	a.Len(reviews, 1)
+	a.Equal(rpc.InterceptDispositionType_BAD_ARGS, reviews[0].Disposition)
+	a.Contains(reviews[0].Message, "appProtocol grpc or h2c")
+}
+
+func TestState_HandleConcurrentHTTPIntercepts(t *testing.T) {
+	ctx := testContext(t, nil)
+	a := assert.New(t)
+	f, s := makeFS(t, ctx)
+
+	httpCept := func(id string, priority int32, args ...string) *rpc.InterceptInfo {
+		return &rpc.InterceptInfo{
+			Spec: &rpc.InterceptSpec{
+				Name:           id + "Name",
+				Client:         "user@" + id,
+				Agent:          "agentName",
+				Mechanism:      "http",
+				MechanismArgs:  args,
+				Namespace:      namespace,
+				ServiceName:    serviceName,
+				PortIdentifier: "http",
+				TargetPort:     8080,
+				Priority:       priority,
+			},
+			Id:          id,
+			Disposition: rpc.InterceptDispositionType_WAITING,
+		}
+	}
+	cepts := []*rpc.InterceptInfo{
+		httpCept("intercept-01", 0, "--http-header=x-user=jane"),
+		httpCept("intercept-02", 0, "--http-header=x-user=john"),
+		httpCept("intercept-03", 0, "--http-header=X-User=jane"),
+		httpCept("intercept-04", 5, "--http-path-prefix=/api/"),
+	}
+
+	// Intercepts with different filters are all accepted, identical filters conflict.
+
+	reviews := s.HandleIntercepts(ctx, cepts)
+	a.Len(reviews, 4)
+	a.Equal(rpc.InterceptDispositionType_ACTIVE, reviews[0].Disposition)
+	a.Equal(rpc.InterceptDispositionType_ACTIVE, reviews[1].Disposition)
+	a.Equal(rpc.InterceptDispositionType_CONFLICT, reviews[2].Disposition)
+	a.Equal("Conflicts with the currently-waiting-to-be-served intercept \"intercept-01\"", reviews[2].Message)
+	a.Equal(rpc.InterceptDispositionType_ACTIVE, reviews[3].Disposition)
+	a.Empty(f.InterceptIds())
+
+	// Active intercepts are served in order of priority, number of conditions, and id.
+
+	cepts = []*rpc.InterceptInfo{cepts[0], cepts[1], cepts[3]}
+	for _, cept := range cepts {
+		cept.Disposition = rpc.InterceptDispositionType_ACTIVE
+	}
+	reviews = s.HandleIntercepts(ctx, cepts)
+	a.Len(reviews, 0)
+	a.Equal([]string{"intercept-04", "intercept-01", "intercept-02"}, f.InterceptIds())
+
+	ii := f.InterceptInfo("/api/v1", http.Header{"X-User": []string{"jane"}})
+	a.True(ii.Intercepted)
+	ii = f.InterceptInfo("/health", http.Header{"X-User": []string{"john"}})
+	a.True(ii.Intercepted)
+	ii = f.InterceptInfo("/health", http.Header{"X-User": []string{"joe"}})
+	a.False(ii.Intercepted)
+
+	// A tcp intercept conflicts with the served http intercepts.
+
+	tcpCept := httpCept("intercept-05", 0)
+	tcpCept.Spec.Mechanism = "tcp"
+	reviews = s.HandleIntercepts(ctx, append(cepts, tcpCept))
+	a.Len(reviews, 1)
+	a.Equal(rpc.InterceptDispositionType_CONFLICT, reviews[0].Disposition)
+	a.Equal("Conflicts with the currently-served intercept \"intercept-01\"", reviews[0].Message)
+
+	// Removing an intercept leaves the others in place.
+
+	reviews = s.HandleIntercepts(ctx, cepts[1:])
+	a.Len(reviews, 0)
+	a.Equal([]string{"intercept-04", "intercept-02"}, f.InterceptIds())
+}
+
+func TestState_HandleMirrorIntercepts(t *testing.T) {
+	ctx := testContext(t, nil)
+	a := assert.New(t)
+	f, s := makeFS(t, ctx)
+
+	mkCept := func(id, mechanism string, mirror bool) *rpc.InterceptInfo {
+		return &rpc.InterceptInfo{
+			Spec: &rpc.InterceptSpec{
+				Name:           id + "Name",
+				Client:         "user@" + id,
+				Agent:          "agentName",
+				Mechanism:      mechanism,
+				Namespace:      namespace,
+				ServiceName:    serviceName,
+				PortIdentifier: "http",
+				TargetPort:     8080,
+				Mirror:         mirror,
+			},
+			Id:          id,
+			Disposition: rpc.InterceptDispositionType_WAITING,
+		}
+	}
+	cepts := []*rpc.InterceptInfo{
+		mkCept("intercept-01", "tcp", false),
+		mkCept("intercept-02", "tcp", true),
+		mkCept("intercept-03", "tcp", true),
+	}
+
+	// A mirror doesn't conflict with an intercept, but with another mirror.
+
+	reviews := s.HandleIntercepts(ctx, cepts)
+	a.Len(reviews, 3)
+	a.Equal(rpc.InterceptDispositionType_ACTIVE, reviews[0].Disposition)
+	a.Equal("all TCP connections", reviews[0].MechanismArgsDesc)
+	a.Equal(rpc.InterceptDispositionType_ACTIVE, reviews[1].Disposition)
+	a.Equal("copies of all TCP connections", reviews[1].MechanismArgsDesc)
+	a.Equal(rpc.InterceptDispositionType_CONFLICT, reviews[2].Disposition)
+	a.Equal("Conflicts with the currently-waiting-to-be-served intercept \"intercept-02\"", reviews[2].Message)
+
+	cepts = cepts[:2]
+	for _, cept := range cepts {
+		cept.Disposition = rpc.InterceptDispositionType_ACTIVE
+	}
+	reviews = s.HandleIntercepts(ctx, cepts)
+	a.Len(reviews, 0)
+	a.Equal([]string{"intercept-01", "intercept-02"}, f.InterceptIds())
+	ii := f.InterceptInfo("/", nil)
+	a.True(ii.Intercepted)
 }
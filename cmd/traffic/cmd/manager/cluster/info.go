@@ -71,6 +71,51 @@ type info struct {
 
 const IDZero = "00000000-0000-0000-0000-000000000000"
 
+// probeServiceSubnet makes an attempt to create a service with a ClusterIP that is out of range and then
+// extracts the service subnet from the error message, as suggested in the second answer here:
+//
+//	https://stackoverflow.com/questions/44190607/how-do-you-find-the-cluster-service-cidr-of-a-kubernetes-cluster
+//
+// This requires an additional permission to create a service, which the traffic-manager should have. The
+// ipFamilies are used for the service when they are given, which makes it possible to probe the secondary
+// address family of a dual-stack cluster.
+func probeServiceSubnet(ctx context.Context, client v1.CoreV1Interface, namespace, dummyIP string, ipFamilies []corev1.IPFamily) (netip.Prefix, error) {
+	svc := corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      "t2-tst-dummy",
+		},
+		Spec: corev1.ServiceSpec{
+			Ports:     []corev1.ServicePort{{Port: 443}},
+			ClusterIP: dummyIP,
+		},
+	}
+	if len(ipFamilies) > 0 {
+		policy := corev1.IPFamilyPolicySingleStack
+		svc.Spec.IPFamilies = ipFamilies
+		svc.Spec.IPFamilyPolicy = &policy
+	}
+
+	_, err := client.Services(namespace).Create(ctx, &svc, metav1.CreateOptions{})
+	if err == nil {
+		_ = client.Services(namespace).Delete(ctx, svc.Name, metav1.DeleteOptions{})
+		return netip.Prefix{}, fmt.Errorf("unexpectedly created service with ClusterIP %s", dummyIP)
+	}
+	svcCIDRrx := regexp.MustCompile(`range of valid IPs is (.*)$`)
+	match := svcCIDRrx.FindStringSubmatch(err.Error())
+	if match == nil {
+		return netip.Prefix{}, fmt.Errorf("unable to extract service subnet from error message %q", err.Error())
+	}
+	cidr, err := netip.ParsePrefix(match[1])
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("unable to parse service CIDR %q", match[1])
+	}
+	return cidr, nil
+}
+
 func NewInfo(ctx context.Context) Info {
 	env := managerutil.GetEnv(ctx)
 	managedNamespaces := env.ManagedNamespaces
@@ -131,42 +176,26 @@ func NewInfo(ctx context.Context) Info {
 
 	dlog.Infof(ctx, "Enabled support for the following workload kinds: %v", env.EnabledWorkloadKinds)
 
-	// make an attempt to create a service with ClusterIP that is out of range and then
-	// check the error message for the correct range as suggested tin the second answer here:
-	//   https://stackoverflow.com/questions/44190607/how-do-you-find-the-cluster-service-cidr-of-a-kubernetes-cluster
-	// This requires an additional permission to create a service, which the traffic-manager
-	// should have.
-	svc := corev1.Service{
-		TypeMeta: metav1.TypeMeta{
-			Kind: "Service",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: env.ManagerNamespace,
-			Name:      "t2-tst-dummy",
-		},
-		Spec: corev1.ServiceSpec{
-			Ports:     []corev1.ServicePort{{Port: 443}},
-			ClusterIP: dummyIP,
-		},
-	}
-
-	if _, err = client.Services(env.ManagerNamespace).Create(ctx, &svc, metav1.CreateOptions{}); err != nil {
-		svcCIDRrx := regexp.MustCompile(`range of valid IPs is (.*)$`)
-		if match := svcCIDRrx.FindStringSubmatch(err.Error()); match != nil {
-			if cidr, err := netip.ParsePrefix(match[1]); err != nil {
-				dlog.Errorf(ctx, "unable to parse service CIDR %q", match[1])
-			} else {
-				dlog.Infof(ctx, "Extracting service subnet %v from create service error message", cidr)
-				oi.ServiceSubnet = iputil.PrefixToRPC(cidr)
-			}
+	if cidr, err := probeServiceSubnet(ctx, client, env.ManagerNamespace, dummyIP, nil); err != nil {
+		dlog.Warn(ctx, err)
+	} else {
+		dlog.Infof(ctx, "Extracting service subnet %v from create service error message", cidr)
+		oi.ServiceSubnet = iputil.PrefixToRPC(cidr)
+		oi.ServiceSubnets = append(oi.ServiceSubnets, oi.ServiceSubnet)
+
+		// A dual-stack cluster has a service subnet for each address family, so probe the other family too.
+		otherIP, otherFamily := "1:1::1", corev1.IPv6Protocol
+		if !cidr.Addr().Is4() {
+			otherIP, otherFamily = "1.1.1.1", corev1.IPv4Protocol
+		}
+		if cidr, err = probeServiceSubnet(ctx, client, env.ManagerNamespace, otherIP, []corev1.IPFamily{otherFamily}); err != nil {
+			dlog.Debugf(ctx, "No %s service subnet found: %v", otherFamily, err)
 		} else {
-			dlog.Errorf(ctx, "unable to extract service subnet from error message %q", err.Error())
+			dlog.Infof(ctx, "Extracting service subnet %v from create service error message", cidr)
+			oi.ServiceSubnets = append(oi.ServiceSubnets, iputil.PrefixToRPC(cidr))
 		}
 	}
 
-	if err != nil {
-		dlog.Warn(ctx, err)
-	}
 	if oi.ServiceSubnet == nil && len(oi.InjectorSvcIp) > 0 {
 		// Using a "kubectl cluster-info dump" or scanning all services generates a lot of unwanted traffic
 		// and would quite possibly also require elevated permissions, so instead, we derive the service subnet
@@ -178,6 +207,7 @@ func NewInfo(ctx context.Context) Info {
 		ones := bits / 2
 		mask := net.CIDRMask(ones, bits) // will yield a 16 bit mask on IPv4 and 64 bit mask on IPv6.
 		oi.ServiceSubnet = &rpc.IPNet{Ip: ip.Mask(mask), Mask: int32(ones)}
+		oi.ServiceSubnets = []*rpc.IPNet{oi.ServiceSubnet}
 	}
 
 	podCIDRStrategy := env.PodCIDRStrategy
@@ -436,6 +466,7 @@ func (oi *info) clusterInfo() *rpc.ClusterInfo {
 
 	ci := &rpc.ClusterInfo{
 		ServiceSubnet:   oi.ServiceSubnet,
+		ServiceSubnets:  slices.Clone(oi.ServiceSubnets),
 		PodSubnets:      make([]*rpc.IPNet, len(oi.PodSubnets)),
 		ManagerPodIp:    oi.ManagerPodIp,
 		ManagerPodPort:  oi.ManagerPodPort,
@@ -2,6 +2,8 @@ package cluster
 
 import (
 	"context"
+	"fmt"
+	"net/netip"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -10,6 +12,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
@@ -72,3 +75,44 @@ func TestNewInfo_GetClusterID(t *testing.T) {
 		require.Equal(t, info.ID(), testUID)
 	})
 }
+
+func TestProbeServiceSubnet(t *testing.T) {
+	// newClient returns a client that rejects the creation of services the same way that the API server of a
+	// cluster with the given service subnets does.
+	newClient := func(subnets ...string) *fake.Clientset {
+		cs := fake.NewSimpleClientset()
+		cs.PrependReactor("create", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			svc := action.(k8stesting.CreateAction).GetObject().(*v1.Service)
+			ip := netip.MustParseAddr(svc.Spec.ClusterIP)
+			for _, sn := range subnets {
+				if netip.MustParsePrefix(sn).Addr().Is4() == ip.Is4() {
+					return true, nil, fmt.Errorf(
+						`Service "t2-tst-dummy" is invalid: spec.clusterIPs: Invalid value: []string{"%s"}: failed to allocate IP %s: `+
+							`the provided IP (%s) is not in the valid range. The range of valid IPs is %s`, ip, ip, ip, sn)
+				}
+			}
+			return true, nil, fmt.Errorf(`Service "t2-tst-dummy" is invalid: spec.ipFamilies[0]: Invalid value: "%s": not configured on this cluster`,
+				svc.Spec.IPFamilies)
+		})
+		return cs
+	}
+
+	t.Run("single-stack", func(t *testing.T) {
+		client := newClient("10.96.0.0/12").CoreV1()
+		cidr, err := probeServiceSubnet(context.Background(), client, "test", "1.1.1.1", nil)
+		require.NoError(t, err)
+		require.Equal(t, netip.MustParsePrefix("10.96.0.0/12"), cidr)
+		_, err = probeServiceSubnet(context.Background(), client, "test", "1:1::1", []v1.IPFamily{v1.IPv6Protocol})
+		require.Error(t, err)
+	})
+
+	t.Run("dual-stack", func(t *testing.T) {
+		client := newClient("10.96.0.0/12", "fd00:10:96::/112").CoreV1()
+		cidr, err := probeServiceSubnet(context.Background(), client, "test", "1.1.1.1", nil)
+		require.NoError(t, err)
+		require.Equal(t, netip.MustParsePrefix("10.96.0.0/12"), cidr)
+		cidr, err = probeServiceSubnet(context.Background(), client, "test", "1:1::1", []v1.IPFamily{v1.IPv6Protocol})
+		require.NoError(t, err)
+		require.Equal(t, netip.MustParsePrefix("fd00:10:96::/112"), cidr)
+	})
+}
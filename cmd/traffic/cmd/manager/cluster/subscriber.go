@@ -96,7 +96,6 @@ func (ss *clusterInfoSubscribers) subscriberLoop(ctx context.Context, rec interf
 
 func clusterInfoEqual(a, b *rpc.ClusterInfo) bool {
 	if len(a.PodSubnets) != len(b.PodSubnets) ||
-		a.ServiceSubnet != b.ServiceSubnet ||
 		a.Dns.ClusterDomain != b.Dns.ClusterDomain ||
 		!net.IP(a.Dns.KubeIp).Equal(b.Dns.KubeIp) {
 		return false
@@ -104,6 +103,13 @@ func clusterInfoEqual(a, b *rpc.ClusterInfo) bool {
 	ipNetEQ := func(a, b *rpc.IPNet) bool {
 		return a.Mask == b.Mask && bytes.Equal(a.Ip, b.Ip)
 	}
+	if (a.ServiceSubnet == nil) != (b.ServiceSubnet == nil) ||
+		a.ServiceSubnet != nil && !ipNetEQ(a.ServiceSubnet, b.ServiceSubnet) {
+		return false
+	}
+	if !slices.EqualFunc(a.ServiceSubnets, b.ServiceSubnets, ipNetEQ) {
+		return false
+	}
 	if !slices.EqualFunc(a.PodSubnets, b.PodSubnets, ipNetEQ) {
 		return false
 	}
@@ -0,0 +1,154 @@
+package manager
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+)
+
+// clusterHostsDelay is how long WatchClusterHosts waits after a change before it sends a new snapshot,
+// so that a burst of changes, such as the ones caused by a rollout, results in one snapshot.
+const clusterHostsDelay = time.Second
+
+func (s *service) WatchClusterHosts(request *rpc.ClusterHostsRequest, stream rpc.Manager_WatchClusterHostsServer) error {
+	ctx := stream.Context()
+	if err := checkCompat(ctx, "WatchClusterHosts", "2.21.0"); err != nil {
+		return err
+	}
+	ctx = managerutil.WithSessionInfo(ctx, request.SessionInfo)
+	dlog.Debugf(ctx, "WatchClusterHosts %s called", request.Namespace)
+	defer dlog.Debugf(ctx, "WatchClusterHosts %s ended", request.Namespace)
+
+	if request.SessionInfo == nil {
+		return status.Error(codes.InvalidArgument, "SessionInfo is required")
+	}
+	sessionDone, err := s.state.SessionDone(request.SessionInfo.SessionId)
+	if err != nil {
+		return err
+	}
+	return watchClusterHosts(ctx, request.Namespace, sessionDone, stream.Send)
+}
+
+// watchClusterHosts sends the names of the services and pods of the given namespace, and then a new snapshot
+// each time those names change, until the context is cancelled or done is closed. The names are obtained from
+// the informers of the traffic-manager.
+func watchClusterHosts(ctx context.Context, ns string, done <-chan struct{}, send func(*rpc.ClusterHosts) error) error {
+	f := informer.GetK8sFactory(ctx, ns)
+	if ns == "" || f == nil {
+		return status.Errorf(codes.FailedPrecondition, "namespace %q is not managed by the traffic-manager", ns)
+	}
+	svcs := f.Core().V1().Services()
+	pods := f.Core().V1().Pods()
+
+	changed := make(chan struct{}, 1)
+	notify := func(any) {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	handler := cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj any) bool {
+			if dfsu, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = dfsu.Obj
+			}
+			o, ok := obj.(meta.Object)
+			return ok && o.GetNamespace() == ns
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    notify,
+			UpdateFunc: func(_, newObj any) { notify(newObj) },
+			DeleteFunc: notify,
+		},
+	}
+	for _, ix := range []cache.SharedIndexInformer{svcs.Informer(), pods.Informer()} {
+		reg, err := ix.AddEventHandler(handler)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		defer func() {
+			_ = ix.RemoveEventHandler(reg)
+		}()
+	}
+
+	var last []string
+	sendIfChanged := func(initial bool) error {
+		sl, err := svcs.Lister().Services(ns).List(labels.Everything())
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		pl, err := pods.Lister().Pods(ns).List(labels.Everything())
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		names := clusterHostNames(sl, pl)
+		if !initial && slices.Equal(names, last) {
+			return nil
+		}
+		last = names
+		return send(&rpc.ClusterHosts{Names: names})
+	}
+	if err := sendIfChanged(true); err != nil {
+		return err
+	}
+
+	var delay <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-done:
+			return nil
+		case <-changed:
+			if delay == nil {
+				delay = time.After(clusterHostsDelay)
+			}
+		case <-delay:
+			delay = nil
+			if err := sendIfChanged(false); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// clusterHostNames returns the sorted names that the cluster's DNS resolves for the given services and pods,
+// relative to the cluster domain. ExternalName services are omitted, because they have no addresses of their
+// own. A pod has a name only when it has a hostname and its subdomain is the name of a headless service, which
+// is the case for the pods of a StatefulSet.
+func clusterHostNames(svcs []*core.Service, pods []*core.Pod) []string {
+	names := make([]string, 0, len(svcs))
+	headless := make(map[string]struct{})
+	for _, svc := range svcs {
+		if svc.Spec.Type == core.ServiceTypeExternalName {
+			continue
+		}
+		names = append(names, svc.Name+"."+svc.Namespace+".svc")
+		if svc.Spec.ClusterIP == core.ClusterIPNone {
+			headless[svc.Name] = struct{}{}
+		}
+	}
+	for _, pod := range pods {
+		sp := &pod.Spec
+		if sp.Hostname == "" || pod.DeletionTimestamp != nil || pod.Status.PodIP == "" {
+			continue
+		}
+		if _, ok := headless[sp.Subdomain]; ok {
+			names = append(names, sp.Hostname+"."+sp.Subdomain+"."+pod.Namespace+".svc")
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
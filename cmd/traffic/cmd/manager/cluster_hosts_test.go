@@ -0,0 +1,51 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_clusterHostNames(t *testing.T) {
+	svc := func(name string, tp core.ServiceType, clusterIP string) *core.Service {
+		return &core.Service{
+			ObjectMeta: meta.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       core.ServiceSpec{Type: tp, ClusterIP: clusterIP},
+		}
+	}
+	pod := func(name, hostname, subdomain, ip string) *core.Pod {
+		return &core.Pod{
+			ObjectMeta: meta.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       core.PodSpec{Hostname: hostname, Subdomain: subdomain},
+			Status:     core.PodStatus{PodIP: ip},
+		}
+	}
+	deleted := pod("db-2", "db-2", "db", "10.1.0.3")
+	deleted.DeletionTimestamp = &meta.Time{}
+
+	svcs := []*core.Service{
+		svc("echo", core.ServiceTypeClusterIP, "10.0.0.1"),
+		svc("db", core.ServiceTypeClusterIP, core.ClusterIPNone),
+		svc("web", core.ServiceTypeLoadBalancer, "10.0.0.2"),
+		svc("ext", core.ServiceTypeExternalName, ""),
+	}
+	pods := []*core.Pod{
+		pod("db-1", "db-1", "db", "10.1.0.2"),
+		pod("db-0", "db-0", "db", "10.1.0.1"),
+		pod("echo-abc", "", "", "10.1.0.4"),
+		pod("orphan", "orphan", "nosvc", "10.1.0.5"),
+		pod("pending", "pending", "db", ""),
+		pod("plain", "plain", "echo", "10.1.0.6"),
+		deleted,
+	}
+	assert.Equal(t, []string{
+		"db-0.db.default.svc",
+		"db-1.db.default.svc",
+		"db.default.svc",
+		"echo.default.svc",
+		"web.default.svc",
+	}, clusterHostNames(svcs, pods))
+	assert.Empty(t, clusterHostNames(nil, nil))
+}
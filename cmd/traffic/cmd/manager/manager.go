@@ -117,6 +117,12 @@ func MainWithEnv(ctx context.Context) (err error) {
 		}
 	}
 
+	enabledKinds := make([]string, len(env.EnabledWorkloadKinds))
+	for i, wk := range env.EnabledWorkloadKinds {
+		enabledKinds[i] = string(wk)
+	}
+	ctx = agentmap.WithEnabledWorkloadKinds(ctx, enabledKinds)
+
 	var injectorCertGetter mutator.InjectorCertGetter
 	if managerutil.AgentInjectorEnabled(ctx) {
 		// The GetInjectorCertGetter and the mutator.Load both create SharedInformer instances
@@ -235,6 +241,8 @@ func (s *service) servePrometheus(ctx context.Context) error {
 	newGaugeFunc("tunnel_count", "Number of tunnels", s.state.CountTunnels)
 	newCounterFunc("tunnel_ingress_bytes", "Number of bytes tunneled from clients", s.state.CountTunnelIngress)
 	newCounterFunc("tunnel_egress_bytes", "Number bytes tunneled to clients", s.state.CountTunnelEgress)
+	newCounterFunc("tunnel_uncompressed_bytes", "Number of tunneled bytes that were compressed, before compression", s.state.CountTunnelUncompressed)
+	newCounterFunc("tunnel_compressed_bytes", "Number of tunneled bytes that were compressed, after compression", s.state.CountTunnelCompressed)
 
 	newGaugeFunc("active_http_request_count", "Number of currently served http requests", func() int {
 		return int(atomic.LoadInt32(&s.activeHttpRequests))
@@ -252,6 +260,8 @@ func (s *service) servePrometheus(ctx context.Context) error {
 		newGaugeVecFunc("intercept_active_status",
 			"Flag to indicate when an intercept is active. 1 for active, 0 for not active.", append(labels, "workload")),
 	)
+	s.state.SetClientLimitCounter(
+		newCounterVecFunc("client_limit_count", "The total number of times a per-client limit was enforced, by user and limit", append(labels, "limit")))
 
 	s.state.SetAllClientSessionsFinalizer(func(client *rpc.ClientInfo) {
 		SetGauge(s.state.GetConnectActiveStatus(), client.Name, client.InstallId, nil, 0)
@@ -42,10 +42,14 @@ type Env struct {
 	ManagedNamespaces   []string      `env:"MANAGED_NAMESPACES,       parser=split-trim,  default="`
 	APIPort             uint16        `env:"AGENT_REST_API_PORT,      parser=port-number, default=0"`
 	AgentArrivalTimeout time.Duration `env:"AGENT_ARRIVAL_TIMEOUT,    parser=time.ParseDuration, default=0"`
+	InterceptMaxTTL     time.Duration `env:"INTERCEPT_MAX_TTL,        parser=time.ParseDuration, default=0"`
 
 	TracingGrpcPort uint16            `env:"TRACING_GRPC_PORT,     parser=port-number,default=0"`
 	MaxReceiveSize  resource.Quantity `env:"GRPC_MAX_RECEIVE_SIZE, parser=quantity"`
 
+	TunnelCompression   bool          `env:"TUNNEL_COMPRESSION,    parser=bool,              default=true"`
+	TunnelResumeTimeout time.Duration `env:"TUNNEL_RESUME_TIMEOUT, parser=time.ParseDuration, default=30s"`
+
 	PodCIDRStrategy string         `env:"POD_CIDR_STRATEGY, parser=nonempty-string"`
 	PodCIDRs        []netip.Prefix `env:"POD_CIDRS,         parser=split-ipnet, default="`
 	PodIP           netip.Addr     `env:"POD_IP,            parser=ip"`
@@ -72,6 +76,10 @@ type Env struct {
 	ClientDnsIncludeSuffixes             []string       `env:"CLIENT_DNS_INCLUDE_SUFFIXES,       		parser=split-trim,  default="`
 	ClientConnectionTTL                  time.Duration  `env:"CLIENT_CONNECTION_TTL,              		parser=time.ParseDuration"`
 
+	ClientLimitBytesPerSecond       resource.Quantity `env:"CLIENT_LIMIT_BYTES_PER_SECOND,       parser=quantity,         default=0"`
+	ClientLimitMaxTunnels           int               `env:"CLIENT_LIMIT_MAX_TUNNELS,            parser=strconv.ParseInt, default=0"`
+	ClientLimitConnectionsPerSecond int               `env:"CLIENT_LIMIT_CONNECTIONS_PER_SECOND, parser=strconv.ParseInt, default=0"`
+
 	EnabledWorkloadKinds []workload.WorkloadKind `env:"ENABLED_WORKLOAD_KINDS, parser=split-trim, default=Deployment StatefulSet ReplicaSet"`
 
 	// For testing only
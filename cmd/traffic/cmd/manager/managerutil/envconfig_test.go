@@ -40,21 +40,24 @@ func TestEnvconfig(t *testing.T) {
 	}
 
 	defaults := managerutil.Env{
-		Registry:                 "ghcr.io/telepresenceio",
-		AgentAppProtocolStrategy: k8sapi.Http2Probe,
-		AgentLogLevel:            "info",
-		AgentPort:                9900,
-		AgentInjectorName:        "agent-injector",
-		AgentInjectorSecret:      "mutator-webhook-tls",
-		AgentArrivalTimeout:      45 * time.Second,
-		ClientConnectionTTL:      24 * time.Hour,
-		ClientDnsExcludeSuffixes: []string{".com", ".io", ".net", ".org", ".ru"},
-		LogLevel:                 "info",
-		MaxReceiveSize:           resource.MustParse("4Mi"),
-		PodCIDRStrategy:          "auto",
-		PodIP:                    netip.AddrFrom4([4]byte{203, 0, 113, 18}),
-		ServerPort:               8081,
-		EnabledWorkloadKinds:     []workload.WorkloadKind{workload.DeploymentWorkloadKind, workload.StatefulSetWorkloadKind, workload.ReplicaSetWorkloadKind},
+		Registry:                  "ghcr.io/telepresenceio",
+		AgentAppProtocolStrategy:  k8sapi.Http2Probe,
+		AgentLogLevel:             "info",
+		AgentPort:                 9900,
+		AgentInjectorName:         "agent-injector",
+		AgentInjectorSecret:       "mutator-webhook-tls",
+		AgentArrivalTimeout:       45 * time.Second,
+		ClientConnectionTTL:       24 * time.Hour,
+		ClientLimitBytesPerSecond: resource.MustParse("0"),
+		ClientDnsExcludeSuffixes:  []string{".com", ".io", ".net", ".org", ".ru"},
+		LogLevel:                  "info",
+		MaxReceiveSize:            resource.MustParse("4Mi"),
+		PodCIDRStrategy:           "auto",
+		PodIP:                     netip.AddrFrom4([4]byte{203, 0, 113, 18}),
+		ServerPort:                8081,
+		TunnelCompression:         true,
+		TunnelResumeTimeout:       30 * time.Second,
+		EnabledWorkloadKinds:      []workload.WorkloadKind{workload.DeploymentWorkloadKind, workload.StatefulSetWorkloadKind, workload.ReplicaSetWorkloadKind},
 	}
 
 	testcases := map[string]struct {
@@ -73,6 +76,42 @@ func TestEnvconfig(t *testing.T) {
 				e.AgentRegistry = "ghcr.io/telepresenceio"
 			},
 		},
+		"intercept max ttl": {
+			Input: map[string]string{
+				"INTERCEPT_MAX_TTL": "8h",
+			},
+			Output: func(e *managerutil.Env) {
+				e.InterceptMaxTTL = 8 * time.Hour
+			},
+		},
+		"client limits": {
+			Input: map[string]string{
+				"CLIENT_LIMIT_BYTES_PER_SECOND":       "10Mi",
+				"CLIENT_LIMIT_MAX_TUNNELS":            "200",
+				"CLIENT_LIMIT_CONNECTIONS_PER_SECOND": "50",
+			},
+			Output: func(e *managerutil.Env) {
+				e.ClientLimitBytesPerSecond = resource.MustParse("10Mi")
+				e.ClientLimitMaxTunnels = 200
+				e.ClientLimitConnectionsPerSecond = 50
+			},
+		},
+		"tunnel compression": {
+			Input: map[string]string{
+				"TUNNEL_COMPRESSION": "false",
+			},
+			Output: func(e *managerutil.Env) {
+				e.TunnelCompression = false
+			},
+		},
+		"tunnel resume timeout": {
+			Input: map[string]string{
+				"TUNNEL_RESUME_TIMEOUT": "0",
+			},
+			Output: func(e *managerutil.Env) {
+				e.TunnelResumeTimeout = 0
+			},
+		},
 		"complex": {
 			Input: map[string]string{
 				"CLIENT_ROUTING_NEVER_PROXY_SUBNETS": "10.20.30.0/24 10.20.40.0/24",
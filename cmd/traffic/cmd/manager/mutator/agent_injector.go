@@ -10,6 +10,7 @@ import (
 	"sync"
 	"sync/atomic"
 
+	"github.com/blang/semver/v4"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"go.opentelemetry.io/otel"
@@ -41,7 +42,8 @@ type AgentInjector interface {
 // NewAgentInjector creates a new agentInjector.
 func NewAgentInjector(ctx context.Context, agentConfigs Map) AgentInjector {
 	ai := &agentInjector{
-		agentConfigs: agentConfigs,
+		agentConfigs:   agentConfigs,
+		nativeSidecars: nativeSidecarsSupported(ctx),
 	}
 	return ai
 }
@@ -52,6 +54,25 @@ type agentInjector struct {
 	sync.Mutex
 	agentConfigs Map
 	terminating  int64
+
+	// nativeSidecars is true when the cluster supports init containers with restartPolicy Always.
+	nativeSidecars bool
+}
+
+// nativeSidecarsSupported returns true if the cluster runs Kubernetes 1.29 or later, where the SidecarContainers
+// feature is enabled by default.
+func nativeSidecarsSupported(ctx context.Context) bool {
+	info, err := k8sapi.GetK8sInterface(ctx).Discovery().ServerVersion()
+	if err != nil {
+		dlog.Errorf(ctx, "error getting server information: %s", err)
+		return false
+	}
+	ver, err := semver.Parse(strings.TrimPrefix(info.GitVersion, "v"))
+	if err != nil {
+		dlog.Errorf(ctx, "error converting version %s to semver: %s", info.GitVersion, err)
+		return false
+	}
+	return ver.Major > 1 || ver.Major == 1 && ver.Minor >= 29
 }
 
 func getPod(req *admission.AdmissionRequest, isDelete bool) (*core.Pod, error) {
@@ -156,6 +177,12 @@ func (a *agentInjector) Inject(ctx context.Context, req *admission.AdmissionRequ
 				supportedKinds[i] = "ReplicaSet"
 			case workload.StatefulSetWorkloadKind:
 				supportedKinds[i] = "StatefulSet"
+			case workload.DaemonSetWorkloadKind:
+				supportedKinds[i] = "DaemonSet"
+			case workload.JobWorkloadKind:
+				supportedKinds[i] = "Job"
+			case workload.CronJobWorkloadKind:
+				supportedKinds[i] = "CronJob"
 			case workload.RolloutWorkloadKind:
 				supportedKinds[i] = "Rollout"
 			}
@@ -200,7 +227,7 @@ func (a *agentInjector) Inject(ctx context.Context, req *admission.AdmissionRequ
 	config := scx.AgentConfig()
 	patches = disableAppContainer(ctx, pod, config, patches)
 	patches = addInitContainer(pod, config, patches)
-	patches = addAgentContainer(ctx, pod, config, patches)
+	patches = addAgentContainer(ctx, pod, config, a.nativeSidecars, patches)
 	patches = addPullSecrets(pod, config, patches)
 	patches = addAgentVolumes(pod, config, patches)
 	patches = hidePorts(pod, config, patches)
@@ -436,6 +463,7 @@ func addAgentContainer(
 	ctx context.Context,
 	pod *core.Pod,
 	config *agentconfig.Sidecar,
+	nativeSidecars bool,
 	patches PatchOps,
 ) PatchOps {
 	acn := agentconfig.AgentContainer(ctx, pod, config)
@@ -443,6 +471,21 @@ func addAgentContainer(
 		return patches
 	}
 
+	if agentconfig.IsBatchKind(config.WorkloadKind) {
+		batchAgentContainer(acn, nativeSidecars)
+		if nativeSidecars {
+			return addAgentInitContainer(ctx, pod, config, acn, patches)
+		}
+		if sp := pod.Spec.ShareProcessNamespace; sp == nil || !*sp {
+			// The agent must see the processes of the app containers to know when they have terminated.
+			patches = append(patches, PatchOperation{
+				Op:    "add",
+				Path:  "/spec/shareProcessNamespace",
+				Value: true,
+			})
+		}
+	}
+
 	refPodName := pod.Name + "." + pod.Namespace
 	for i := range pod.Spec.Containers {
 		pcn := &pod.Spec.Containers[i]
@@ -467,6 +510,76 @@ func addAgentContainer(
 	})
 }
 
+// batchAgentContainer modifies the traffic-agent container of a Job or CronJob pod so that it doesn't prevent
+// the pod from completing. A native sidecar is terminated by the kubelet when the app containers have
+// terminated. Otherwise, the agent watches the processes of the app containers and exits when they are gone.
+func batchAgentContainer(acn *core.Container, nativeSidecar bool) {
+	if nativeSidecar {
+		always := core.ContainerRestartPolicyAlways
+		acn.RestartPolicy = &always
+	} else {
+		acn.Env = append(acn.Env, core.EnvVar{
+			Name:  agentconfig.EnvExitWithApp,
+			Value: "true",
+		})
+	}
+}
+
+// addAgentInitContainer creates a patch operation to add the traffic-agent as a native sidecar, i.e. an
+// init-container with restartPolicy Always. It is added after the tel-agent-init container, so that the
+// init-container has completed before the agent starts.
+func addAgentInitContainer(
+	ctx context.Context,
+	pod *core.Pod,
+	config *agentconfig.Sidecar,
+	acn *core.Container,
+	patches PatchOps,
+) PatchOps {
+	refPodName := pod.Name + "." + pod.Namespace
+	pis := pod.Spec.InitContainers
+	removedInit := -1
+	if !needInitContainer(config) {
+		for i := range pis {
+			if pis[i].Name == agentconfig.InitContainerName {
+				removedInit = i
+				break
+			}
+		}
+	}
+	for i := range pis {
+		pcn := &pis[i]
+		if pcn.Name == agentconfig.ContainerName {
+			if containerEqual(pcn, acn) {
+				dlog.Infof(ctx, "Pod %s already has init-container %s and it isn't modified", refPodName, agentconfig.ContainerName)
+				return patches
+			}
+			dlog.Debugf(ctx, "Pod %s already has init-container %s but it is modified", refPodName, agentconfig.ContainerName)
+			if removedInit >= 0 && removedInit < i {
+				// The removal of the tel-agent-init container shifts the index.
+				i--
+			}
+			return append(patches, PatchOperation{
+				Op:    "replace",
+				Path:  "/spec/initContainers/" + strconv.Itoa(i),
+				Value: acn,
+			})
+		}
+	}
+
+	if len(pis) == 0 && !needInitContainer(config) {
+		return append(patches, PatchOperation{
+			Op:    "replace",
+			Path:  "/spec/initContainers",
+			Value: []core.Container{*acn},
+		})
+	}
+	return append(patches, PatchOperation{
+		Op:    "add",
+		Path:  "/spec/initContainers/-",
+		Value: acn,
+	})
+}
+
 // addAgentContainer creates a patch operation to add the traffic-agent container.
 func addPullSecrets(
 	pod *core.Pod,
@@ -1893,6 +1893,65 @@ func TestTrafficAgentInjector(t *testing.T) {
 	}
 }
 
+func TestAddAgentContainer_batch(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	for _, kind := range []string{"Job", "CronJob"} {
+		config := &agentconfig.Sidecar{
+			AgentImage:   "ghcr.io/telepresenceio/tel2:2.13.3",
+			AgentName:    "nightly",
+			Namespace:    "some-ns",
+			WorkloadName: "nightly",
+			WorkloadKind: kind,
+			Containers: []*agentconfig.Container{{
+				Name:       "app",
+				EnvPrefix:  "A_",
+				MountPoint: agentconfig.MountPrefixApp + "/app",
+			}},
+		}
+		pod := &core.Pod{
+			ObjectMeta: meta.ObjectMeta{Name: "nightly-28000000-abcde", Namespace: "some-ns"},
+			Spec: core.PodSpec{
+				Containers:    []core.Container{{Name: "app", Image: "busybox"}},
+				RestartPolicy: core.RestartPolicyOnFailure,
+			},
+		}
+
+		t.Run(kind+" native sidecar", func(t *testing.T) {
+			patches := addAgentContainer(ctx, pod, config, true, nil)
+			require.Len(t, patches, 1)
+			assert.Equal(t, "replace", patches[0].Op)
+			assert.Equal(t, "/spec/initContainers", patches[0].Path)
+			ics, ok := patches[0].Value.([]core.Container)
+			require.True(t, ok)
+			require.Len(t, ics, 1)
+			assert.Equal(t, agentconfig.ContainerName, ics[0].Name)
+			require.NotNil(t, ics[0].RestartPolicy)
+			assert.Equal(t, core.ContainerRestartPolicyAlways, *ics[0].RestartPolicy)
+			assert.NotContains(t, ics[0].Env, core.EnvVar{Name: agentconfig.EnvExitWithApp, Value: "true"})
+
+			// The agent is appended to the existing init-containers.
+			withInit := pod.DeepCopy()
+			withInit.Spec.InitContainers = []core.Container{{Name: "migrate", Image: "busybox"}}
+			patches = addAgentContainer(ctx, withInit, config, true, nil)
+			require.Len(t, patches, 1)
+			assert.Equal(t, "add", patches[0].Op)
+			assert.Equal(t, "/spec/initContainers/-", patches[0].Path)
+		})
+
+		t.Run(kind+" without native sidecars", func(t *testing.T) {
+			patches := addAgentContainer(ctx, pod, config, false, nil)
+			require.Len(t, patches, 2)
+			assert.Equal(t, PatchOperation{Op: "add", Path: "/spec/shareProcessNamespace", Value: true}, patches[0])
+			assert.Equal(t, "add", patches[1].Op)
+			assert.Equal(t, "/spec/containers/-", patches[1].Path)
+			ac, ok := patches[1].Value.(*core.Container)
+			require.True(t, ok)
+			assert.Nil(t, ac.RestartPolicy)
+			assert.Contains(t, ac.Env, core.EnvVar{Name: agentconfig.EnvExitWithApp, Value: "true"})
+		})
+	}
+}
+
 func requireContains(t *testing.T, err error, expected string) {
 	if expected == "" {
 		require.NoError(t, err)
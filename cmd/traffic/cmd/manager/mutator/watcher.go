@@ -32,6 +32,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
 	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sworkload"
 	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
 	"github.com/telepresenceio/telepresence/v2/pkg/workload"
 )
@@ -192,6 +193,9 @@ func isRolloutNeededForPod(ctx context.Context, ac *agentconfig.Sidecar, name, n
 			name, namespace, pod.GetName())
 	}
 	desiredAc := agentconfig.AgentContainer(ctx, pod, ac)
+	if desiredAc != nil && agentconfig.IsBatchKind(ac.WorkloadKind) {
+		batchAgentContainer(desiredAc, podAc.RestartPolicy != nil)
+	}
 	if !containerEqual(podAc, desiredAc) {
 		return fmt.Sprintf("Rollout of %s.%s is necessary. The desired agent is not equal to the existing agent in pod %s",
 			name, namespace, pod.GetName())
@@ -239,6 +243,10 @@ func isRolloutNeededForPod(ctx context.Context, ac *agentconfig.Sidecar, name, n
 }
 
 func (c *configWatcher) triggerRollout(ctx context.Context, wl k8sapi.Workload, ac *agentconfig.Sidecar) {
+	if wl.GetKind() == "CronJob" {
+		// A CronJob has no pods of its own. The pods of its next run are injected with the current agent config.
+		return
+	}
 	lck := c.getRolloutLock(wl)
 	if !lck.TryLock() {
 		// A rollout is already in progress, doing it again once it is complete wouldn't do any good.
@@ -258,6 +266,20 @@ func (c *configWatcher) triggerRollout(ctx context.Context, wl k8sapi.Workload,
 		triggerRolloutReplicaSet(ctx, wl, rs, span)
 		return
 	}
+	if ds, ok := k8sworkload.DaemonSetImpl(wl); ok && ds.Spec.UpdateStrategy.Type == appsv1.OnDeleteDaemonSetStrategyType {
+		// A DaemonSet with the OnDelete update strategy will not recreate its pods when the pod template
+		// changes, so the pods are deleted instead. The DaemonSet controller then creates new ones.
+		triggerRolloutByDeletingPods(ctx, wl, ds, ds.Spec.Selector, span)
+		return
+	}
+	if _, ok := k8sworkload.JobImpl(wl); ok {
+		// The pod template of a Job is immutable, and a deleted pod counts as a failure towards the Job's
+		// backoffLimit, so the running pods are left alone. Only the pods that the Job creates from now on
+		// will get a traffic-agent.
+		dlog.Infof(ctx, "The running pods of Job %s.%s are not restarted. New pods will get a traffic-agent", wl.GetName(), wl.GetNamespace())
+		span.AddEvent("tel2.noop-rollout")
+		return
+	}
 
 	restartAnnotation := generateRestartAnnotationPatch(wl.GetPodTemplate())
 	span.AddEvent("tel2.do-rollout")
@@ -350,6 +372,40 @@ func triggerRolloutReplicaSet(ctx context.Context, wl k8sapi.Workload, rs *appsv
 	}
 }
 
+// triggerRolloutByDeletingPods deletes the running pods that are controlled by the given owner, so that the
+// owner's controller replaces them with pods that are injected with the current agent config.
+func triggerRolloutByDeletingPods(ctx context.Context, wl k8sapi.Workload, owner meta.Object, selector *meta.LabelSelector, span trace.Span) {
+	dlog.Debugf(ctx, "Performing %s rollout of %s.%s by deleting its pods", wl.GetKind(), wl.GetName(), wl.GetNamespace())
+	sel, err := meta.LabelSelectorAsSelector(selector)
+	if err != nil {
+		err = fmt.Errorf("unable to parse selector of %s %s.%s: %w", wl.GetKind(), wl.GetName(), wl.GetNamespace(), err)
+		dlog.Error(ctx, err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	api := k8sapi.GetK8sInterface(ctx).CoreV1().Pods(wl.GetNamespace())
+	pods, err := api.List(ctx, meta.ListOptions{LabelSelector: sel.String()})
+	if err != nil {
+		err = fmt.Errorf("unable to list pods of %s %s.%s: %w", wl.GetKind(), wl.GetName(), wl.GetNamespace(), err)
+		dlog.Error(ctx, err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	span.AddEvent("tel2.do-rollout")
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !meta.IsControlledBy(pod, owner) || pod.Status.Phase == core.PodSucceeded || pod.Status.Phase == core.PodFailed {
+			continue
+		}
+		if err := api.Delete(ctx, pod.Name, meta.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			err = fmt.Errorf("unable to delete pod %s.%s: %w", pod.Name, pod.Namespace, err)
+			dlog.Error(ctx, err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	dlog.Infof(ctx, "Successfully rolled out %s.%s", wl.GetName(), wl.GetNamespace())
+}
+
 // RegenerateAgentMaps load the telepresence-agents config map, regenerates all entries in it,
 // and then, if any of the entries changed, it updates the map.
 func (c *configWatcher) RegenerateAgentMaps(ctx context.Context, agentImage string) error {
@@ -446,6 +502,9 @@ type configWatcher struct {
 	dps []cache.SharedIndexInformer
 	rss []cache.SharedIndexInformer
 	sss []cache.SharedIndexInformer
+	dss []cache.SharedIndexInformer
+	jbs []cache.SharedIndexInformer
+	cjs []cache.SharedIndexInformer
 	rls []cache.SharedIndexInformer
 
 	self Map // For extension
@@ -581,6 +640,27 @@ func (c *configWatcher) StartWatchers(ctx context.Context) error {
 			}
 		}
 	}
+	if c.dss != nil {
+		for _, si := range c.dss {
+			if err := c.watchWorkloads(ctx, si); err != nil {
+				return err
+			}
+		}
+	}
+	if c.jbs != nil {
+		for _, si := range c.jbs {
+			if err := c.watchWorkloads(ctx, si); err != nil {
+				return err
+			}
+		}
+	}
+	if c.cjs != nil {
+		for _, si := range c.cjs {
+			if err := c.watchWorkloads(ctx, si); err != nil {
+				return err
+			}
+		}
+	}
 	if c.rls != nil {
 		for _, si := range c.rls {
 			if err := c.watchWorkloads(ctx, si); err != nil {
@@ -848,6 +928,12 @@ func (c *configWatcher) Start(ctx context.Context) {
 			c.rss = make([]cache.SharedIndexInformer, len(nss))
 		case workload.StatefulSetWorkloadKind:
 			c.sss = make([]cache.SharedIndexInformer, len(nss))
+		case workload.DaemonSetWorkloadKind:
+			c.dss = make([]cache.SharedIndexInformer, len(nss))
+		case workload.JobWorkloadKind:
+			c.jbs = make([]cache.SharedIndexInformer, len(nss))
+		case workload.CronJobWorkloadKind:
+			c.cjs = make([]cache.SharedIndexInformer, len(nss))
 		case workload.RolloutWorkloadKind:
 			c.rls = make([]cache.SharedIndexInformer, len(nss))
 		}
@@ -864,6 +950,18 @@ func (c *configWatcher) Start(ctx context.Context) {
 		if c.sss != nil {
 			c.sss[i] = workload.StartStatefulSets(ctx, ns)
 		}
+		if c.dss != nil {
+			c.dss[i] = workload.StartDaemonSets(ctx, ns)
+		}
+		if c.jbs != nil {
+			c.jbs[i] = workload.StartJobs(ctx, ns)
+		} else if c.cjs != nil {
+			// The agent-injector must find the Jobs that CronJobs create, even when Jobs aren't enabled.
+			workload.StartJobs(ctx, ns)
+		}
+		if c.cjs != nil {
+			c.cjs[i] = workload.StartCronJobs(ctx, ns)
+		}
 		c.startPods(ctx, ns)
 		kf := informer.GetK8sFactory(ctx, ns)
 		kf.Start(ctx.Done())
@@ -28,6 +28,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/mutator"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/state"
 	"github.com/telepresenceio/telepresence/v2/pkg/dnsproxy"
+	"github.com/telepresenceio/telepresence/v2/pkg/informer"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
 	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
@@ -203,6 +204,9 @@ func (s *service) ArriveAsAgent(ctx context.Context, agent *rpc.AgentInfo) (*rpc
 	if val := validateAgent(agent); val != "" {
 		return nil, status.Error(codes.InvalidArgument, val)
 	}
+	if agent.NodeName == "" {
+		agent.NodeName = podNodeName(ctx, agent.PodName, agent.Namespace)
+	}
 
 	sessionID := s.state.AddAgent(agent, s.clock.Now())
 	mutator.GetMap(ctx).Whitelist(agent.PodName, agent.Namespace)
@@ -213,6 +217,22 @@ func (s *service) ArriveAsAgent(ctx context.Context, agent *rpc.AgentInfo) (*rpc
 	}, nil
 }
 
+// podNodeName returns the name of the node that the given pod runs on, or an empty string if
+// the pod can't be found.
+func podNodeName(ctx context.Context, podName, namespace string) string {
+	if f := informer.GetK8sFactory(ctx, namespace); f != nil {
+		if pod, err := f.Core().V1().Pods().Lister().Pods(namespace).Get(podName); err == nil {
+			return pod.Spec.NodeName
+		}
+	}
+	pod, err := k8sapi.GetK8sInterface(ctx).CoreV1().Pods(namespace).Get(ctx, podName, v1.GetOptions{})
+	if err != nil {
+		dlog.Debugf(ctx, "unable to get node name of pod %s.%s: %v", podName, namespace, err)
+		return ""
+	}
+	return pod.Spec.NodeName
+}
+
 func (s *service) ReportMetrics(ctx context.Context, metrics *rpc.TunnelMetrics) (*empty.Empty, error) {
 	s.state.AddSessionConsumptionMetrics(metrics)
 	return &empty.Empty{}, nil
@@ -506,11 +526,16 @@ func (s *service) WatchIntercepts(session *rpc.SessionInfo, stream rpc.Manager_W
 					// Don't return intercepts for different agents.
 					return false
 				}
+				if info.Spec.NodeName != "" && info.Spec.NodeName != agent.NodeName {
+					// The intercept is limited to agents on another node.
+					return false
+				}
 				// Don't return intercepts that aren't in a "agent-owned" state.
 				switch info.Disposition {
 				case rpc.InterceptDispositionType_WAITING,
 					rpc.InterceptDispositionType_ACTIVE,
-					rpc.InterceptDispositionType_AGENT_ERROR:
+					rpc.InterceptDispositionType_AGENT_ERROR,
+					rpc.InterceptDispositionType_CONFLICT:
 					// agent-owned state: include the intercept
 					dlog.Debugf(ctx, "Intercept %s.%s valid. Disposition: %s", info.Spec.Agent, info.Spec.Namespace, info.Disposition)
 					return true
@@ -594,6 +619,12 @@ func (s *service) GetKnownWorkloadKinds(ctx context.Context, request *rpc.Sessio
 			kinds[i] = rpc.WorkloadInfo_REPLICASET
 		case workload.StatefulSetWorkloadKind:
 			kinds[i] = rpc.WorkloadInfo_STATEFULSET
+		case workload.DaemonSetWorkloadKind:
+			kinds[i] = rpc.WorkloadInfo_DAEMONSET
+		case workload.JobWorkloadKind:
+			kinds[i] = rpc.WorkloadInfo_JOB
+		case workload.CronJobWorkloadKind:
+			kinds[i] = rpc.WorkloadInfo_CRONJOB
 		case workload.RolloutWorkloadKind:
 			kinds[i] = rpc.WorkloadInfo_ROLLOUT
 		}
@@ -652,6 +683,11 @@ func (s *service) CreateIntercept(ctx context.Context, ciReq *rpc.CreateIntercep
 			dlog.Errorf(ctx, "Failed to add finalizer for %s: %v", interceptInfo.Id, err)
 		}
 	}
+	if ciReq.InterceptSpec.TriggerJob {
+		if err := s.state.AddInterceptFinalizer(interceptInfo.Id, s.state.RemoveTriggeredJobs); err != nil {
+			dlog.Errorf(ctx, "Failed to add finalizer for %s: %v", interceptInfo.Id, err)
+		}
+	}
 
 	SetGauge(s.state.GetInterceptActiveStatus(), client.Name, client.InstallId, &spec.Name, 1)
 
@@ -674,7 +710,18 @@ func (s *service) MakeInterceptID(_ context.Context, sessionID string, name stri
 		if s.state.GetClient(sessionID) == nil {
 			return "", status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
 		}
-		return sessionID + ":" + name, nil
+		// An intercept that has been handed over from another client session retains its original id.
+		for id := range s.state.LoadMatchingIntercepts(func(_ string, ii *rpc.InterceptInfo) bool {
+			return ii.ClientSession.SessionId == sessionID && ii.Spec.Name == name
+		}) {
+			return id, nil
+		}
+		id := sessionID + ":" + name
+		if _, ok := s.state.GetIntercept(id); ok {
+			// The intercept has been handed over to another client session.
+			return "", status.Errorf(codes.NotFound, "Intercept named %q not found", name)
+		}
+		return id, nil
 	}
 }
 
@@ -695,12 +742,73 @@ func (s *service) RemoveIntercept(ctx context.Context, riReq *rpc.RemoveIntercep
 		return nil, status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
 	}
 
+	interceptID, err := s.MakeInterceptID(ctx, sessionID, name)
+	if err != nil {
+		return nil, err
+	}
+
 	SetGauge(s.state.GetInterceptActiveStatus(), client.Name, client.InstallId, &name, 0)
 
-	s.state.RemoveIntercept(ctx, sessionID+":"+name)
+	s.state.RemoveIntercept(ctx, interceptID)
 	return &empty.Empty{}, nil
 }
 
+// HandoverIntercept lets a client hand over one of its intercepts to another client session.
+func (s *service) HandoverIntercept(ctx context.Context, rq *rpc.HandoverInterceptRequest) (*rpc.InterceptInfo, error) {
+	ctx = managerutil.WithSessionInfo(ctx, rq.GetSession())
+	sessionID := rq.GetSession().GetSessionId()
+	name := rq.Name
+
+	dlog.Debugf(ctx, "HandoverIntercept called: %s to %s", name, rq.ToClient)
+
+	client := s.state.GetClient(sessionID)
+	if client == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
+	}
+	interceptID, err := s.MakeInterceptID(ctx, sessionID, name)
+	if err != nil {
+		return nil, err
+	}
+	toSessionID, toClient, err := s.findClientSession(rq.ToClient)
+	if err != nil {
+		return nil, err
+	}
+	if toSessionID == sessionID {
+		return nil, status.Errorf(codes.InvalidArgument, "Intercept named %q is already owned by %s", name, rq.ToClient)
+	}
+	ii, err := s.state.HandoverIntercept(ctx, interceptID, toSessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	SetGauge(s.state.GetInterceptActiveStatus(), client.Name, client.InstallId, &name, 0)
+	SetGauge(s.state.GetInterceptActiveStatus(), toClient.Name, toClient.InstallId, &name, 1)
+	return ii, nil
+}
+
+// findClientSession returns the session ID and the info of the client identified by the given string,
+// which is either a session ID, or the name of a client that has exactly one session.
+func (s *service) findClientSession(nameOrID string) (string, *rpc.ClientInfo, error) {
+	if client := s.state.GetClient(nameOrID); client != nil {
+		return nameOrID, client, nil
+	}
+	var foundID string
+	var found *rpc.ClientInfo
+	for id, client := range s.state.GetAllClients() {
+		if client.Name != nameOrID {
+			continue
+		}
+		if found != nil {
+			return "", nil, status.Errorf(codes.InvalidArgument, "Client %q has more than one session. Please use a session ID", nameOrID)
+		}
+		foundID, found = id, client
+	}
+	if found == nil {
+		return "", nil, status.Errorf(codes.NotFound, "Client %q not found", nameOrID)
+	}
+	return foundID, found, nil
+}
+
 // GetIntercept gets an intercept info from intercept name.
 func (s *service) GetIntercept(ctx context.Context, request *rpc.GetInterceptRequest) (*rpc.InterceptInfo, error) {
 	interceptID, err := s.MakeInterceptID(ctx, request.GetSession().GetSessionId(), request.GetName())
@@ -780,11 +888,10 @@ func (s *service) removeExcludedEnvVars(ctx context.Context, envVars map[string]
 
 func (s *service) Tunnel(server rpc.Manager_TunnelServer) error {
 	ctx := server.Context()
-	stream, err := tunnel.NewServerStream(ctx, server)
-	if err != nil {
-		return status.Errorf(codes.FailedPrecondition, "failed to connect stream: %v", err)
-	}
-	return s.state.Tunnel(ctx, stream)
+	env := managerutil.GetEnv(ctx)
+	ctx = tunnel.WithCompression(ctx, env.TunnelCompression)
+	ctx = tunnel.WithResumeTimeout(ctx, env.TunnelResumeTimeout)
+	return tunnel.ServeStreams(ctx, server, s.state.Tunnel)
 }
 
 func (s *service) WatchDial(session *rpc.SessionInfo, stream rpc.Manager_WatchDialServer) error {
@@ -998,8 +1105,13 @@ func (s *service) WatchWorkloads(request *rpc.WorkloadEventsRequest, stream rpc.
 
 const agentSessionTTL = 15 * time.Second
 
-// expire removes stale sessions.
+// expire removes stale sessions and expired intercepts.
 func (s *service) expire(ctx context.Context) {
 	now := s.clock.Now()
 	s.state.ExpireSessions(ctx, now.Add(-managerutil.GetEnv(ctx).ClientConnectionTTL), now.Add(-agentSessionTTL))
+	for _, ii := range s.state.ExpireIntercepts(ctx, now) {
+		if client := s.state.GetClient(ii.ClientSession.SessionId); client != nil {
+			SetGauge(s.state.GetInterceptActiveStatus(), client.Name, client.InstallId, &ii.Spec.Name, 0)
+		}
+	}
 }
@@ -22,6 +22,9 @@ func NewSessionConsumptionMetrics() *SessionConsumptionMetrics {
 	m := &SessionConsumptionMetrics{
 		FromClientBytes: tunnel.NewCounterProbe("FromClientBytes"),
 		ToClientBytes:   tunnel.NewCounterProbe("ToClientBytes"),
+
+		UncompressedBytes: tunnel.NewCounterProbe("UncompressedBytes"),
+		CompressedBytes:   tunnel.NewCounterProbe("CompressedBytes"),
 	}
 	m.lastUpdate.Store(time.Now().UnixNano())
 	return m
@@ -35,6 +38,10 @@ type SessionConsumptionMetrics struct {
 	FromClientBytes *tunnel.CounterProbe
 	// data from the traffic manager to the client.
 	ToClientBytes *tunnel.CounterProbe
+
+	// payload of compressed tunnel messages, before and after compression.
+	UncompressedBytes *tunnel.CounterProbe
+	CompressedBytes   *tunnel.CounterProbe
 }
 
 func (m *SessionConsumptionMetrics) ConnectDuration() time.Duration {
@@ -84,9 +91,18 @@ func (s *state) AddSessionConsumptionMetrics(metrics *manager.TunnelMetrics) {
 		cm := cs.consumptionMetrics
 		cm.FromClientBytes.Increment(metrics.IngressBytes)
 		cm.ToClientBytes.Increment(metrics.EgressBytes)
+		cm.UncompressedBytes.Increment(metrics.UncompressedBytes)
+		cm.CompressedBytes.Increment(metrics.CompressedBytes)
 	}
 }
 
+// addCompressionStats adds the compression stats of a tunnel stream that has ended.
+func (m *SessionConsumptionMetrics) addCompressionStats(stream tunnel.Stream) {
+	uncompressed, compressed := tunnel.GetCompressionStats(stream)
+	m.UncompressedBytes.Increment(uncompressed)
+	m.CompressedBytes.Increment(compressed)
+}
+
 // RefreshSessionConsumptionMetrics refreshes the metrics associated to a specific session.
 func (s *state) RefreshSessionConsumptionMetrics(sessionID string) {
 	css, ok := s.GetSession(sessionID).(*clientSessionState)
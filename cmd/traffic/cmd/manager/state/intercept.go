@@ -76,6 +76,11 @@ func (s *state) PrepareIntercept(
 		dlog.Error(ctx, err)
 		return interceptError(err)
 	}
+	if spec.TriggerJob && wl.GetKind() != "CronJob" {
+		// Reject this before the agent config is stored and the workload is modified.
+		return interceptError(errcat.User.Newf("--trigger-job can only be used with a CronJob, and %s.%s is a %s",
+			wl.GetName(), wl.GetNamespace(), wl.GetKind()))
+	}
 
 	ac, err := s.ensureAgent(ctx, wl, s.isExtended(spec), spec)
 	if err != nil {
@@ -139,12 +144,21 @@ func (s *state) ensureAgent(parentCtx context.Context, wl k8sapi.Workload, exten
 		return nil, err
 	}
 	ac = sce.AgentConfig()
-	if err = s.waitForAgent(ctx, ac.AgentName, ac.Namespace, failedCreateCh); err != nil {
-		// If no agent arrives, then drop its entry from the configmap. This ensures that there
-		// are no false positives the next time an intercept is attempted.
+	if spec != nil && spec.TriggerJob {
+		err = s.triggerJob(ctx, wl)
+	}
+	if err == nil {
+		err = s.waitForAgent(ctx, ac.AgentName, ac.Namespace, failedCreateCh)
+	}
+	if err != nil {
+		// If no Job could be triggered, or no agent arrives, then drop its entry from the configmap. This
+		// ensures that there are no false positives the next time an intercept is attempted.
 		if dropErr := s.dropAgentConfig(parentCtx, wl); dropErr != nil {
 			dlog.Errorf(ctx, "failed to remove configmap entry for %s.%s: %v", wl.GetName(), wl.GetNamespace(), dropErr)
 		}
+		if wl.GetKind() == "CronJob" && (spec == nil || !spec.TriggerJob) {
+			err = errcat.User.Newf("%v\nHint: a CronJob only gets a traffic-agent when it runs. Use --trigger-job to run it now", err)
+		}
 		return nil, err
 	}
 	return ac, nil
@@ -573,6 +587,9 @@ func findIntercept(ac *agentconfig.Sidecar, spec *managerrpc.InterceptSpec) (fou
 	if foundIC != nil {
 		return foundCN, foundIC, nil
 	}
+	if pi == "" && spec.ServiceName == "" && agentconfig.IsBatchKind(ac.WorkloadKind) {
+		return findBatchContainer(ac, spec.ContainerName)
+	}
 
 	ss := ""
 	if spec.ServiceName != "" {
@@ -587,6 +604,28 @@ func findIntercept(ac *agentconfig.Sidecar, spec *managerrpc.InterceptSpec) (fou
 	return nil, nil, errcat.User.Newf("%s %s.%s has no interceptable port%s", ac.WorkloadKind, ac.WorkloadName, ac.Namespace, ss)
 }
 
+// findBatchContainer returns the container of a Job or CronJob to intercept without ports, together with
+// an empty intercept. The container can be omitted when the workload has only one container.
+func findBatchContainer(ac *agentconfig.Sidecar, name string) (*agentconfig.Container, *agentconfig.Intercept, error) {
+	if name != "" {
+		for _, cn := range ac.Containers {
+			if cn.Name == name {
+				return cn, &agentconfig.Intercept{}, nil
+			}
+		}
+		return nil, nil, errcat.User.Newf("%s %s.%s has no container named %s", ac.WorkloadKind, ac.WorkloadName, ac.Namespace, name)
+	}
+	if len(ac.Containers) == 1 {
+		return ac.Containers[0], &agentconfig.Intercept{}, nil
+	}
+	names := make([]string, len(ac.Containers))
+	for i, cn := range ac.Containers {
+		names[i] = cn.Name
+	}
+	return nil, nil, errcat.User.Newf("%s %s.%s has more than one container. Please use --container to select one of %s",
+		ac.WorkloadKind, ac.WorkloadName, ac.Namespace, strings.Join(names, ", "))
+}
+
 type InterceptFinalizer func(ctx context.Context, interceptInfo *managerrpc.InterceptInfo) error
 
 type interceptState struct {
@@ -0,0 +1,77 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+	managerrpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/mutator"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sworkload"
+)
+
+// TriggeredByLabel is the label that identifies the Jobs that the traffic-manager creates from a CronJob.
+// Its value is the name of the CronJob.
+const TriggeredByLabel = "telepresence.io/triggered-by"
+
+// triggerJob creates a Job from the job template of the given CronJob, so that an agent arrives without
+// waiting for the next scheduled run. No Job is created when an agent of the CronJob is already present.
+func (s *state) triggerJob(ctx context.Context, wl k8sapi.Workload) error {
+	cj, ok := k8sworkload.CronJobImpl(wl)
+	if !ok {
+		// PrepareIntercept rejects --trigger-job for other kinds, so this is an internal error.
+		return fmt.Errorf("unable to trigger a Job from %s %s.%s: not a CronJob", wl.GetKind(), wl.GetName(), wl.GetNamespace())
+	}
+	if len(s.getAgentsByName(cj.Name, cj.Namespace)) > 0 {
+		return nil
+	}
+
+	// The name mimics the ones that the CronJob controller generates, but uses the time in seconds.
+	job := k8sworkload.NewJobFromCronJob(cj, fmt.Sprintf("%s-%d", cj.Name, time.Now().Unix()))
+	if job.Labels == nil {
+		job.Labels = make(map[string]string)
+	}
+	job.Labels[TriggeredByLabel] = cj.Name
+	job, err := k8sapi.GetK8sInterface(ctx).BatchV1().Jobs(cj.Namespace).Create(ctx, job, meta.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to create a Job from CronJob %s.%s: %w", cj.Name, cj.Namespace, err)
+	}
+	dlog.Infof(ctx, "Created Job %s.%s from CronJob %s", job.Name, job.Namespace, cj.Name)
+	return nil
+}
+
+// RemoveTriggeredJobs is an intercept finalizer that deletes the Jobs that were triggered for the intercepted
+// CronJob, and the CronJob's agent config, so that its scheduled runs no longer get a traffic-agent. Nothing
+// is removed while other intercepts of the CronJob remain.
+func (s *state) RemoveTriggeredJobs(ctx context.Context, ii *managerrpc.InterceptInfo) error {
+	spec := ii.Spec
+	others := s.intercepts.LoadAllMatching(func(_ string, oi *managerrpc.InterceptInfo) bool {
+		return oi.Id != ii.Id && oi.Spec.Agent == spec.Agent && oi.Spec.Namespace == spec.Namespace
+	})
+	if len(others) > 0 {
+		return nil
+	}
+
+	// The pods of the triggered Jobs would otherwise continue to run until the traffic-agent is gone.
+	sel := labels.SelectorFromSet(labels.Set{TriggeredByLabel: spec.Agent})
+	api := k8sapi.GetK8sInterface(ctx).BatchV1().Jobs(spec.Namespace)
+	jobs, err := api.List(ctx, meta.ListOptions{LabelSelector: sel.String()})
+	if err != nil {
+		return err
+	}
+	pp := meta.DeletePropagationBackground
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if err = api.Delete(ctx, job.Name, meta.DeleteOptions{PropagationPolicy: &pp}); err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+		dlog.Infof(ctx, "Deleted triggered Job %s.%s", job.Name, job.Namespace)
+	}
+	return mutator.GetMap(ctx).Delete(ctx, spec.Agent, spec.Namespace)
+}
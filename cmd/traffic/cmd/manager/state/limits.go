@@ -0,0 +1,157 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// Names of the per-client limits. Used as the "limit" label of the client_limit_count metric.
+const (
+	limitMaxTunnels           = "max_tunnels"
+	limitConnectionsPerSecond = "connections_per_second"
+	limitBytesPerSecond       = "bytes_per_second"
+)
+
+// clientLimits enforces the per-client limits that the traffic-manager is configured with, so that one
+// client cannot starve the traffic-manager for all others. A limit that is zero is not enforced.
+type clientLimits struct {
+	maxTunnels int32
+	tunnels    atomic.Int32
+	connRate   *rate.Limiter
+	ingress    *rate.Limiter
+	egress     *rate.Limiter
+}
+
+func newClientLimits(env *managerutil.Env) *clientLimits {
+	l := &clientLimits{maxTunnels: int32(env.ClientLimitMaxTunnels)}
+	if cps := env.ClientLimitConnectionsPerSecond; cps > 0 {
+		l.connRate = rate.NewLimiter(rate.Limit(cps), cps)
+	}
+	if bps, ok := env.ClientLimitBytesPerSecond.AsInt64(); ok && bps > 0 {
+		l.ingress = rate.NewLimiter(rate.Limit(bps), int(bps))
+		l.egress = rate.NewLimiter(rate.Limit(bps), int(bps))
+	}
+	return l
+}
+
+// tryOpenTunnel reserves a tunnel for the client, or returns a *limitError when the client isn't allowed
+// to open another tunnel. The reservation is atomic, so concurrent calls cannot exceed the max tunnels. The
+// returned function releases the tunnel and must be called when the tunnel closes.
+func (l *clientLimits) tryOpenTunnel() (func(), error) {
+	for {
+		n := l.tunnels.Load()
+		if l.maxTunnels > 0 && n >= l.maxTunnels {
+			return nil, &limitError{limit: limitMaxTunnels}
+		}
+		if l.tunnels.CompareAndSwap(n, n+1) {
+			break
+		}
+	}
+	if l.connRate != nil && !l.connRate.Allow() {
+		l.tunnels.Add(-1)
+		return nil, &limitError{limit: limitConnectionsPerSecond}
+	}
+	var once sync.Once
+	return func() { once.Do(func() { l.tunnels.Add(-1) }) }, nil
+}
+
+// limitStream returns a stream that limits the rate of the data that is sent and received on the given
+// stream to the bytes per second of the client. The onThrottle function is called each time a message
+// is delayed.
+func (l *clientLimits) limitStream(stream tunnel.Stream, onThrottle func()) tunnel.Stream {
+	if l.ingress == nil {
+		return stream
+	}
+	return &throttledStream{Stream: stream, ingress: l.ingress, egress: l.egress, onThrottle: onThrottle}
+}
+
+// limitError is returned when a tunnel is refused because a client limit was exceeded. It becomes a
+// ResourceExhausted status when returned from a gRPC call.
+type limitError struct {
+	limit string
+}
+
+func (e *limitError) Error() string {
+	return "client limit " + e.limit + " exceeded"
+}
+
+func (e *limitError) GRPCStatus() *status.Status {
+	return status.New(codes.ResourceExhausted, e.Error())
+}
+
+// throttledStream is a tunnel.Stream that shares a limited number of bytes per second in each direction
+// with all other streams of the same client. Only the payload of Normal messages is limited.
+type throttledStream struct {
+	tunnel.Stream
+	ingress    *rate.Limiter
+	egress     *rate.Limiter
+	onThrottle func()
+}
+
+func (s *throttledStream) Receive(ctx context.Context) (tunnel.Message, error) {
+	m, err := s.Stream.Receive(ctx)
+	if err == nil && m.Code() == tunnel.Normal {
+		err = s.wait(ctx, s.ingress, len(m.Payload()))
+	}
+	return m, err
+}
+
+func (s *throttledStream) Send(ctx context.Context, m tunnel.Message) error {
+	if m.Code() == tunnel.Normal {
+		if err := s.wait(ctx, s.egress, len(m.Payload())); err != nil {
+			return err
+		}
+	}
+	return s.Stream.Send(ctx, m)
+}
+
+// wait blocks until the given limiter permits n bytes. Messages that are larger than the burst of the
+// limiter are permitted in chunks.
+func (s *throttledStream) wait(ctx context.Context, l *rate.Limiter, n int) error {
+	throttled := false
+	for n > 0 {
+		c := min(n, l.Burst())
+		if !throttled && l.Tokens() < float64(c) {
+			throttled = true
+			s.onThrottle()
+		}
+		if err := l.WaitN(ctx, c); err != nil {
+			return err
+		}
+		n -= c
+	}
+	return nil
+}
+
+// countLimitError increments the client_limit_count metric for the client of the given session if
+// the given error is a *limitError.
+func (s *state) countLimitError(sessionID string, err error) {
+	var le *limitError
+	if errors.As(err, &le) {
+		s.countClientLimit(sessionID, le.limit)
+	}
+}
+
+// countClientLimit increments the client_limit_count metric for the client of the given session.
+func (s *state) countClientLimit(sessionID, limit string) {
+	if s.clientLimitCounter == nil {
+		return
+	}
+	if client, ok := s.clients.Load(sessionID); ok {
+		s.clientLimitCounter.With(prometheus.Labels{"client": client.Name, "install_id": client.InstallId, "limit": limit}).Inc()
+	}
+}
+
+func (s *state) SetClientLimitCounter(counterVec *prometheus.CounterVec) {
+	s.clientLimitCounter = counterVec
+}
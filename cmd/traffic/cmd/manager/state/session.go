@@ -3,6 +3,7 @@ package state
 import (
 	"context"
 	"fmt"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 )
 
@@ -164,12 +166,42 @@ type clientSessionState struct {
 	pool *tunnel.Pool
 
 	consumptionMetrics *SessionConsumptionMetrics
+
+	limitsOnce sync.Once
+	limits     *clientLimits
 }
 
 func (css *clientSessionState) ConsumptionMetrics() *SessionConsumptionMetrics {
 	return css.consumptionMetrics
 }
 
+// getLimits returns the limits of this client, creating them from the environment on first use.
+func (css *clientSessionState) getLimits(ctx context.Context) *clientLimits {
+	css.limitsOnce.Do(func() {
+		css.limits = newClientLimits(managerutil.GetEnv(ctx))
+	})
+	return css.limits
+}
+
+// EstablishBidiPipe asks the client to dial, unless that would exceed the limits of the client. The tunnel
+// that the client dials is counted until the bidi pipe ends.
+func (css *clientSessionState) EstablishBidiPipe(ctx context.Context, stream tunnel.Stream) (tunnel.Endpoint, error) {
+	release, err := css.getLimits(ctx).tryOpenTunnel()
+	if err != nil {
+		return nil, err
+	}
+	bidiPipe, err := css.sessionState.EstablishBidiPipe(ctx, stream)
+	if err != nil || bidiPipe == nil {
+		release()
+		return bidiPipe, err
+	}
+	go func() {
+		<-bidiPipe.Done()
+		release()
+	}()
+	return bidiPipe, nil
+}
+
 func newClientSessionState(ctx context.Context, ts time.Time) *clientSessionState {
 	return &clientSessionState{
 		sessionState: newSessionState(ctx, ts),
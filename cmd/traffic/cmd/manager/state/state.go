@@ -49,6 +49,9 @@ type State interface {
 	CountTunnels() int
 	CountTunnelIngress() uint64
 	CountTunnelEgress() uint64
+	CountTunnelUncompressed() uint64
+	CountTunnelCompressed() uint64
+	ExpireIntercepts(context.Context, time.Time) []*rpc.InterceptInfo
 	ExpireSessions(context.Context, time.Time, time.Time)
 	GetAgent(sessionID string) *rpc.AgentInfo
 	GetActiveAgent(sessionID string) *rpc.AgentInfo
@@ -58,6 +61,7 @@ type State interface {
 	GetSessionConsumptionMetrics(string) *SessionConsumptionMetrics
 	GetAllSessionConsumptionMetrics() map[string]*SessionConsumptionMetrics
 	GetIntercept(string) (*rpc.InterceptInfo, bool)
+	HandoverIntercept(context.Context, string, string) (*rpc.InterceptInfo, error)
 	GetConnectCounter() *prometheus.CounterVec
 	GetConnectActiveStatus() *prometheus.GaugeVec
 	GetInterceptCounter() *prometheus.CounterVec
@@ -71,6 +75,7 @@ type State interface {
 	RemoveIntercept(context.Context, string)
 	DropIntercept(string)
 	RestoreAppContainer(context.Context, *rpc.InterceptInfo) error
+	RemoveTriggeredJobs(context.Context, *rpc.InterceptInfo) error
 	FinalizeIntercept(ctx context.Context, intercept *rpc.InterceptInfo)
 	LoadMatchingIntercepts(filter func(string, *rpc.InterceptInfo) bool) map[string]*rpc.InterceptInfo
 	RemoveSession(context.Context, string)
@@ -82,6 +87,7 @@ type State interface {
 		connectStatusGaugeVec *prometheus.GaugeVec,
 		interceptCounterVec *prometheus.CounterVec,
 		interceptStatusGaugeVec *prometheus.GaugeVec)
+	SetClientLimitCounter(counterVec *prometheus.CounterVec)
 	Tunnel(context.Context, tunnel.Stream) error
 	UpdateIntercept(string, func(*rpc.InterceptInfo)) *rpc.InterceptInfo
 	UpdateClient(sessionID string, apply func(*rpc.ClientInfo)) *rpc.ClientInfo
@@ -140,10 +146,13 @@ type state struct {
 	tunnelCounter              int32
 	tunnelIngressCounter       uint64
 	tunnelEgressCounter        uint64
+	tunnelUncompressedCounter  uint64
+	tunnelCompressedCounter    uint64
 	connectCounter             *prometheus.CounterVec
 	connectActiveStatusGauge   *prometheus.GaugeVec
 	interceptCounter           *prometheus.CounterVec
 	interceptActiveStatusGauge *prometheus.GaugeVec
+	clientLimitCounter         *prometheus.CounterVec
 
 	// Possibly extended version of the state. Use when calling interface methods.
 	self State
@@ -197,6 +206,8 @@ func (s *state) checkAgentsForIntercept(intercept *rpc.InterceptInfo) (errCode r
 		return intercept.Disposition, intercept.Message
 	case rpc.InterceptDispositionType_AGENT_ERROR:
 		// Continue through; the error states of this function take precedence.
+	case rpc.InterceptDispositionType_CONFLICT:
+		// Continue through; the error states of this function take precedence.
 	case rpc.InterceptDispositionType_BAD_ARGS:
 		// Don't overwrite this error state.
 		return intercept.Disposition, intercept.Message
@@ -207,10 +218,12 @@ func (s *state) checkAgentsForIntercept(intercept *rpc.InterceptInfo) (errCode r
 
 	// main ////////////////////////////////////////////////////////////////
 
+	// An intercept that is limited to one node only relies on the agents on that node.
+	nodeName := intercept.Spec.NodeName
 	var agentList []*rpc.AgentInfo
 	if agentSet, ok := s.agentsByName.Load(intercept.Spec.Agent); ok {
 		agentSet.Range(func(_ string, agent *rpc.AgentInfo) bool {
-			if agent.Namespace == intercept.Spec.Namespace {
+			if agent.Namespace == intercept.Spec.Namespace && (nodeName == "" || agent.NodeName == nodeName) {
 				agentList = append(agentList, agent)
 			}
 			return true
@@ -218,6 +231,9 @@ func (s *state) checkAgentsForIntercept(intercept *rpc.InterceptInfo) (errCode r
 	}
 
 	switch {
+	case len(agentList) == 0 && nodeName != "":
+		errCode = rpc.InterceptDispositionType_NO_AGENT
+		errMsg = fmt.Sprintf("No agent found for %q on node %q", intercept.Spec.Agent, nodeName)
 	case len(agentList) == 0:
 		errCode = rpc.InterceptDispositionType_NO_AGENT
 		errMsg = fmt.Sprintf("No agent found for %q", intercept.Spec.Agent)
@@ -261,9 +277,10 @@ func (s *state) RemoveSession(ctx context.Context, sessionID string) {
 
 		// kill the session
 		defer sess.Cancel()
-		s.gcSessionIntercepts(ctx, sessionID)
 
-		agent, isAgent := s.agents.LoadAndDelete(sessionID)
+		// An agent is removed from the agentsByName index before the intercepts are checked, so that
+		// the intercepts that relied on it alone are moved to NO_AGENT.
+		agent, isAgent := s.agents.Load(sessionID)
 		if isAgent {
 			// remove it from the agentsByName index (if necessary)
 			dlog.Debugf(ctx, "Agent session %s. Explicit removal", agent.PodName)
@@ -274,10 +291,17 @@ func (s *state) RemoveSession(ctx context.Context, sessionID string) {
 				}
 				return nil, true
 			})
+		}
+		s.gcSessionIntercepts(ctx, sessionID)
+
+		if isAgent {
+			s.agents.Delete(sessionID)
 		} else if client, isClient := s.clients.LoadAndDelete(sessionID); isClient {
 			scm := sess.(*clientSessionState).consumptionMetrics
 			atomic.AddUint64(&s.tunnelIngressCounter, scm.FromClientBytes.GetValue())
 			atomic.AddUint64(&s.tunnelEgressCounter, scm.ToClientBytes.GetValue())
+			atomic.AddUint64(&s.tunnelUncompressedCounter, scm.UncompressedBytes.GetValue())
+			atomic.AddUint64(&s.tunnelCompressedCounter, scm.CompressedBytes.GetValue())
 			s.allClientSessionsFinalizerCall(client)
 		}
 		return nil, true
@@ -402,6 +426,14 @@ func (s *state) CountTunnelEgress() uint64 {
 	return atomic.LoadUint64(&s.tunnelEgressCounter)
 }
 
+func (s *state) CountTunnelUncompressed() uint64 {
+	return atomic.LoadUint64(&s.tunnelUncompressedCounter)
+}
+
+func (s *state) CountTunnelCompressed() uint64 {
+	return atomic.LoadUint64(&s.tunnelCompressedCounter)
+}
+
 // Sessions: Agents ////////////////////////////////////////////////////////////////////////////////
 
 func (s *state) AddAgent(agent *rpc.AgentInfo, now time.Time) string {
@@ -519,6 +551,10 @@ func (s *state) AddIntercept(ctx context.Context, sessionID, clusterID string, c
 
 	spec := cir.InterceptSpec
 	interceptID := fmt.Sprintf("%s:%s", sessionID, spec.Name)
+	if s.hasInterceptNamed(sessionID, spec.Name, interceptID) {
+		// An intercept that has been handed over to this session retains its original id.
+		return nil, nil, status.Errorf(codes.AlreadyExists, "Intercept named %q already exists", spec.Name)
+	}
 	installID := client.GetInstallId()
 	clientSession := rpc.SessionInfo{
 		SessionId: sessionID,
@@ -527,6 +563,9 @@ func (s *state) AddIntercept(ctx context.Context, sessionID, clusterID string, c
 	}
 
 	cept := s.self.NewInterceptInfo(interceptID, &clientSession, cir)
+	if ttl := interceptTTL(spec, managerutil.GetEnv(ctx).InterceptMaxTTL); ttl > 0 {
+		cept.ExpiresAt = timestamppb.New(cept.ModifiedAt.AsTime().Add(ttl))
+	}
 
 	// Wrap each potential-state-change in a
 	//
@@ -563,6 +602,32 @@ func (s *state) NewInterceptInfo(interceptID string, session *rpc.SessionInfo, c
 	}
 }
 
+// interceptTTL returns the time to live of an intercept with the given spec. It's the TTL of the
+// spec, limited by the given maximum. Zero means that the intercept never expires.
+func interceptTTL(spec *rpc.InterceptSpec, maxTTL time.Duration) time.Duration {
+	ttl := spec.Ttl.AsDuration()
+	if maxTTL > 0 && (ttl <= 0 || ttl > maxTTL) {
+		ttl = maxTTL
+	}
+	return ttl
+}
+
+// ExpireIntercepts removes the intercepts that expire before the given moment, and returns them.
+// The removal calls the finalizers of the intercepts, and the owning clients are notified by the
+// removal of the intercepts from their WatchIntercepts snapshots.
+func (s *state) ExpireIntercepts(ctx context.Context, moment time.Time) []*rpc.InterceptInfo {
+	expired := s.intercepts.LoadAllMatching(func(_ string, ii *rpc.InterceptInfo) bool {
+		return ii.ExpiresAt != nil && ii.Disposition != rpc.InterceptDispositionType_REMOVED && ii.ExpiresAt.AsTime().Before(moment)
+	})
+	iis := make([]*rpc.InterceptInfo, 0, len(expired))
+	for id, ii := range expired {
+		dlog.Infof(ctx, "Intercept %s expired at %s", id, ii.ExpiresAt.AsTime().Format(time.RFC3339))
+		s.self.RemoveIntercept(ctx, id)
+		iis = append(iis, ii)
+	}
+	return iis
+}
+
 func (s *state) AddInterceptFinalizer(interceptID string, finalizer InterceptFinalizer) error {
 	is, ok := s.interceptStates.Load(interceptID)
 	if !ok {
@@ -572,6 +637,57 @@ func (s *state) AddInterceptFinalizer(interceptID string, finalizer InterceptFin
 	return nil
 }
 
+// HandoverIntercept moves the intercept with the given id to the client session with the given id. The
+// intercept retains its id and disposition, so the traffic-agents continue to serve it without interruption.
+// The clients are notified by the change of their WatchIntercepts snapshots.
+func (s *state) HandoverIntercept(ctx context.Context, interceptID, toSessionID string) (*rpc.InterceptInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ii, ok := s.intercepts.Load(interceptID)
+	if !ok || ii.Disposition == rpc.InterceptDispositionType_REMOVED {
+		return nil, status.Errorf(codes.NotFound, "Intercept %q not found", interceptID)
+	}
+	name := ii.Spec.Name
+	if ii.Disposition != rpc.InterceptDispositionType_ACTIVE {
+		return nil, status.Errorf(codes.FailedPrecondition, "Intercept named %q is not active", name)
+	}
+	toClient := s.GetClient(toSessionID)
+	if toClient == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", toSessionID)
+	}
+	if toClient.Namespace != ii.Spec.Namespace {
+		return nil, status.Errorf(codes.FailedPrecondition, "Client %s is connected to namespace %s, but the intercept is in namespace %s",
+			toClient.Name, toClient.Namespace, ii.Spec.Namespace)
+	}
+	if s.hasInterceptNamed(toSessionID, name, interceptID) {
+		return nil, status.Errorf(codes.AlreadyExists, "Client %s already has an intercept named %q", toClient.Name, name)
+	}
+
+	installID := toClient.GetInstallId()
+	ii = s.UpdateIntercept(interceptID, func(ii *rpc.InterceptInfo) {
+		ii.ClientSession = &rpc.SessionInfo{
+			SessionId: toSessionID,
+			ClusterId: ii.ClientSession.GetClusterId(),
+			InstallId: &installID,
+		}
+		ii.Spec.Client = toClient.Name
+	})
+	if ii == nil {
+		return nil, status.Errorf(codes.NotFound, "Intercept %q not found", interceptID)
+	}
+	dlog.Infof(ctx, "Intercept %s handed over to %s", interceptID, toClient.Name)
+	return ii, nil
+}
+
+// hasInterceptNamed returns true if the given client session has an intercept with the given name, other than
+// the intercept with the given id.
+func (s *state) hasInterceptNamed(sessionID, name, exceptID string) bool {
+	return len(s.intercepts.LoadAllMatching(func(id string, ii *rpc.InterceptInfo) bool {
+		return id != exceptID && ii.ClientSession.SessionId == sessionID && ii.Spec.Name == name && ii.Disposition != rpc.InterceptDispositionType_REMOVED
+	})) > 0
+}
+
 // getAgentsInterceptedByClient returns the session IDs for each agent that are currently
 // intercepted by the client with the given client session ID.
 func (s *state) getAgentsInterceptedByClient(clientSessionID string) map[string]*rpc.AgentInfo {
@@ -712,6 +828,18 @@ func (s *state) Tunnel(ctx context.Context, stream tunnel.Stream) error {
 		}
 	case *clientSessionState:
 		scm = sst.ConsumptionMetrics()
+		defer scm.addCompressionStats(stream)
+		limits := sst.getLimits(ctx)
+		// A stream that a peer awaits was already counted when the client was asked to dial it.
+		if sst.AwaitingBidiMapOwnerSessionID(stream) == "" {
+			release, err := limits.tryOpenTunnel()
+			if err != nil {
+				s.countLimitError(sessionID, err)
+				return err
+			}
+			defer release()
+		}
+		stream = limits.limitStream(stream, func() { s.countClientLimit(sessionID, limitBytesPerSecond) })
 	default:
 	}
 
@@ -735,6 +863,7 @@ func (s *state) Tunnel(ctx context.Context, stream tunnel.Stream) error {
 	// A traffic-agent must always extend the tunnel to the client that it is currently intercepted
 	// by, and hence, start by sending the sessionID of that client on the tunnel.
 	var peerSession SessionState
+	var peerID string
 	if _, ok := ss.(*agentSessionState); ok {
 		span.SetAttributes(attribute.String("session-type", "traffic-agent"))
 		// traffic-agent, so obtain the desired client session
@@ -745,7 +874,7 @@ func (s *state) Tunnel(ctx context.Context, stream tunnel.Stream) error {
 		if m.Code() != tunnel.Session {
 			return status.Errorf(codes.FailedPrecondition, "unable to read ClientSession from agent %q", sessionID)
 		}
-		peerID := tunnel.GetSession(m)
+		peerID = tunnel.GetSession(m)
 		span.SetAttributes(attribute.String("peer-id", peerID))
 		peerSession, _ = s.sessions.Load(peerID)
 	} else {
@@ -760,6 +889,7 @@ func (s *state) Tunnel(ctx context.Context, stream tunnel.Stream) error {
 	if peerSession != nil {
 		var err error
 		if endPoint, err = peerSession.EstablishBidiPipe(ctx, stream); err != nil {
+			s.countLimitError(peerID, err)
 			return err
 		}
 	} else {
@@ -2,17 +2,31 @@ package state
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/puzpuzpuz/xsync/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	apps "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/k8sapi/pkg/k8sapi"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
 	testdata "github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/test"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
 	"github.com/telepresenceio/telepresence/v2/pkg/log"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 )
 
 type suiteState struct {
@@ -32,6 +46,7 @@ func (s *suiteState) SetupTest() {
 		timedLogLevel:   log.NewTimedLevel("debug", log.SetLevel),
 		llSubs:          newLoglevelSubscribers(),
 	}
+	s.state.self = s.state
 }
 
 type FakeClock struct {
@@ -185,6 +200,252 @@ func (s *suiteState) TestRemoveSession() {
 	assert.Equal(s.T(), s.state.sessions.Size(), 0)
 }
 
+func (s *suiteState) TestExpireIntercepts() {
+	// given
+	now := time.Now()
+	expiresAt := func(d time.Duration) *timestamppb.Timestamp { return timestamppb.New(now.Add(d)) }
+	s.state.intercepts.Store("session-1:expired", &manager.InterceptInfo{Id: "session-1:expired", ExpiresAt: expiresAt(-time.Second)})
+	s.state.intercepts.Store("session-1:alive", &manager.InterceptInfo{Id: "session-1:alive", ExpiresAt: expiresAt(time.Minute)})
+	s.state.intercepts.Store("session-2:forever", &manager.InterceptInfo{Id: "session-2:forever"})
+	s.state.interceptStates.Store("session-1:expired", newInterceptState("session-1:expired"))
+	finalized := false
+	s.Require().NoError(s.state.AddInterceptFinalizer("session-1:expired", func(context.Context, *manager.InterceptInfo) error {
+		finalized = true
+		return nil
+	}))
+
+	// when
+	expired := s.state.ExpireIntercepts(s.ctx, now)
+
+	// then
+	s.Require().Len(expired, 1)
+	s.Equal("session-1:expired", expired[0].Id)
+	s.True(finalized)
+	_, ok := s.state.GetIntercept("session-1:expired")
+	s.False(ok)
+	s.Equal(2, s.state.CountIntercepts())
+}
+
+func (s *suiteState) TestInterceptTTL() {
+	ttl := func(d time.Duration) *manager.InterceptSpec { return &manager.InterceptSpec{Ttl: durationpb.New(d)} }
+	s.Equal(time.Duration(0), interceptTTL(&manager.InterceptSpec{}, 0))
+	s.Equal(time.Hour, interceptTTL(ttl(time.Hour), 0))
+	s.Equal(time.Hour, interceptTTL(ttl(time.Hour), 2*time.Hour))
+	s.Equal(2*time.Hour, interceptTTL(ttl(3*time.Hour), 2*time.Hour))
+	s.Equal(2*time.Hour, interceptTTL(&manager.InterceptSpec{}, 2*time.Hour))
+}
+
+func (s *suiteState) TestHandoverIntercept() {
+	// given
+	now := time.Now()
+	alice := s.state.addClient("session-1", &manager.ClientInfo{Name: "alice@laptop", Namespace: "default"}, now)
+	bob := s.state.addClient("session-2", &manager.ClientInfo{Name: "bob@laptop", Namespace: "default"}, now)
+	carol := s.state.addClient("session-3", &manager.ClientInfo{Name: "carol@laptop", Namespace: "other"}, now)
+	id := alice + ":echo"
+	s.state.intercepts.Store(id, &manager.InterceptInfo{
+		Id:            id,
+		Spec:          &manager.InterceptSpec{Name: "echo", Namespace: "default", Client: "alice@laptop"},
+		ClientSession: &manager.SessionInfo{SessionId: alice},
+		Disposition:   manager.InterceptDispositionType_ACTIVE,
+	})
+
+	// when
+	_, err := s.state.HandoverIntercept(s.ctx, id, carol)
+
+	// then
+	s.Equal(codes.FailedPrecondition, status.Code(err), "client in another namespace")
+
+	// when
+	ii, err := s.state.HandoverIntercept(s.ctx, id, bob)
+
+	// then
+	s.Require().NoError(err)
+	s.Equal(id, ii.Id)
+	s.Equal(bob, ii.ClientSession.SessionId)
+	s.Equal("bob@laptop", ii.Spec.Client)
+	s.Equal(manager.InterceptDispositionType_ACTIVE, ii.Disposition)
+	s.True(s.state.hasInterceptNamed(bob, "echo", ""))
+	s.False(s.state.hasInterceptNamed(alice, "echo", ""))
+
+	// when
+	_, _, err = s.state.AddIntercept(s.ctx, bob, "cluster", &manager.CreateInterceptRequest{
+		InterceptSpec: &manager.InterceptSpec{Name: "echo", Namespace: "default"},
+	})
+
+	// then
+	s.Equal(codes.AlreadyExists, status.Code(err), "the handed over intercept has the same name")
+}
+
+func (s *suiteState) TestAddIntercept_nodeName() {
+	// given
+	ctx := managerutil.WithEnv(s.ctx, &managerutil.Env{})
+	now := time.Now()
+	alice := s.state.addClient("session-1", &manager.ClientInfo{Name: "alice@laptop", Namespace: "default"}, now)
+	s.state.AddAgent(&manager.AgentInfo{
+		Name:       "echo",
+		Namespace:  "default",
+		PodName:    "echo-1",
+		NodeName:   "node-a",
+		Mechanisms: []*manager.AgentInfo_Mechanism{{Name: "tcp"}},
+	}, now)
+	spec := func(name, nodeName string) *manager.CreateInterceptRequest {
+		return &manager.CreateInterceptRequest{InterceptSpec: &manager.InterceptSpec{
+			Name: name, Agent: "echo", Namespace: "default", Mechanism: "tcp", NodeName: nodeName,
+		}}
+	}
+
+	// when
+	_, ii, err := s.state.AddIntercept(ctx, alice, "cluster", spec("echo-a", "node-a"))
+
+	// then
+	s.Require().NoError(err)
+	s.Equal(manager.InterceptDispositionType_WAITING, ii.Disposition)
+
+	// when
+	_, ii, err = s.state.AddIntercept(ctx, alice, "cluster", spec("echo-b", "node-b"))
+
+	// then
+	s.Require().NoError(err)
+	s.Equal(manager.InterceptDispositionType_NO_AGENT, ii.Disposition, "no agent on node-b")
+	s.Contains(ii.Message, `node "node-b"`)
+}
+
+func (s *suiteState) TestRemoveSession_agentOnInterceptedNode() {
+	// given
+	ctx := managerutil.WithEnv(s.ctx, &managerutil.Env{})
+	now := time.Now()
+	alice := s.state.addClient("session-1", &manager.ClientInfo{Name: "alice@laptop", Namespace: "default"}, now)
+	agent := func(podName, nodeName string) *manager.AgentInfo {
+		return &manager.AgentInfo{
+			Name:       "echo",
+			Namespace:  "default",
+			PodName:    podName,
+			NodeName:   nodeName,
+			Mechanisms: []*manager.AgentInfo_Mechanism{{Name: "tcp"}},
+		}
+	}
+	agentA := s.state.AddAgent(agent("echo-1", "node-a"), now)
+	s.state.AddAgent(agent("echo-2", "node-b"), now)
+	_, ii, err := s.state.AddIntercept(ctx, alice, "cluster", &manager.CreateInterceptRequest{InterceptSpec: &manager.InterceptSpec{
+		Name: "echo", Agent: "echo", Namespace: "default", Mechanism: "tcp", NodeName: "node-a",
+	}})
+	s.Require().NoError(err)
+	s.Require().Equal(manager.InterceptDispositionType_WAITING, ii.Disposition)
+
+	// when the agent on the intercepted node goes away
+	s.state.RemoveSession(ctx, agentA)
+
+	// then the agent on the other node doesn't keep the intercept alive
+	ii, ok := s.state.GetIntercept(ii.Id)
+	s.Require().True(ok)
+	s.Equal(manager.InterceptDispositionType_NO_AGENT, ii.Disposition)
+	s.Contains(ii.Message, `node "node-a"`)
+}
+
+func (s *suiteState) TestPrepareIntercept_triggerJobRequiresCronJob() {
+	// given
+	ctx := k8sapi.WithK8sInterface(s.ctx, fake.NewSimpleClientset(&apps.Deployment{
+		ObjectMeta: meta.ObjectMeta{Name: "echo", Namespace: "default"},
+	}))
+
+	// when
+	pi, err := s.state.PrepareIntercept(ctx, &manager.CreateInterceptRequest{
+		InterceptSpec: &manager.InterceptSpec{Name: "echo", Agent: "echo", Namespace: "default", WorkloadKind: "Deployment", TriggerJob: true},
+	})
+
+	// then the intercept is rejected before an agent config is stored
+	s.Require().NoError(err)
+	s.Contains(pi.Error, "--trigger-job can only be used with a CronJob")
+	s.Equal(int32(errcat.User), pi.ErrorCategory)
+}
+
+func (s *suiteState) TestClientLimits() {
+	// given
+	ctx := managerutil.WithEnv(s.ctx, &managerutil.Env{ClientLimitMaxTunnels: 2, ClientLimitConnectionsPerSecond: 2})
+	css := newClientSessionState(s.ctx, time.Now())
+	limits := css.getLimits(ctx)
+
+	// when
+	release, err := limits.tryOpenTunnel()
+	s.Require().NoError(err)
+	_, err = limits.tryOpenTunnel()
+	s.Require().NoError(err)
+	_, err = css.EstablishBidiPipe(ctx, nil)
+
+	// then
+	s.Equal(codes.ResourceExhausted, status.Code(err), "max tunnels")
+
+	// when
+	release()
+	release()
+
+	// then
+	s.Equal(int32(1), limits.tunnels.Load(), "release is idempotent")
+	_, err = limits.tryOpenTunnel()
+	s.Equal(codes.ResourceExhausted, status.Code(err), "connections per second")
+	var le *limitError
+	s.Require().ErrorAs(err, &le)
+	s.Equal(limitConnectionsPerSecond, le.limit)
+	s.Equal(int32(1), limits.tunnels.Load(), "a refused tunnel isn't counted")
+}
+
+func (s *suiteState) TestClientLimits_concurrentTunnels() {
+	// given
+	ctx := managerutil.WithEnv(s.ctx, &managerutil.Env{ClientLimitMaxTunnels: 5})
+	limits := newClientSessionState(s.ctx, time.Now()).getLimits(ctx)
+
+	// when
+	var opened atomic.Int32
+	wg := sync.WaitGroup{}
+	start := make(chan struct{})
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if _, err := limits.tryOpenTunnel(); err == nil {
+				opened.Add(1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	// then
+	s.Equal(int32(5), opened.Load())
+	s.Equal(int32(5), limits.tunnels.Load())
+}
+
+type sinkStream struct {
+	tunnel.Stream
+	sent int
+}
+
+func (s *sinkStream) Send(_ context.Context, m tunnel.Message) error {
+	s.sent += len(m.Payload())
+	return nil
+}
+
+func (s *suiteState) TestClientLimits_bytesPerSecond() {
+	// given
+	limits := newClientLimits(&managerutil.Env{ClientLimitBytesPerSecond: resource.MustParse("100k")})
+	sink := &sinkStream{}
+	throttled := 0
+	stream := limits.limitStream(sink, func() { throttled++ })
+
+	// when
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		s.Require().NoError(stream.Send(s.ctx, tunnel.NewMessage(tunnel.Normal, make([]byte, 75_000))))
+	}
+	s.Require().NoError(stream.Send(s.ctx, tunnel.NewMessage(tunnel.KeepAlive, nil)))
+
+	// then
+	s.Equal(225_000, sink.sent)
+	s.Equal(2, throttled, "the first message is within the burst")
+	s.GreaterOrEqual(time.Since(start), time.Second, "125k bytes exceeded the burst")
+}
+
 func TestSuiteState(testing *testing.T) {
 	suite.Run(testing, new(suiteState))
 }
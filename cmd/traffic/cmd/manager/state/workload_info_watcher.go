@@ -163,6 +163,12 @@ func rpcKind(s string) rpc.WorkloadInfo_Kind {
 		return rpc.WorkloadInfo_REPLICASET
 	case "statefulset":
 		return rpc.WorkloadInfo_STATEFULSET
+	case "daemonset":
+		return rpc.WorkloadInfo_DAEMONSET
+	case "job":
+		return rpc.WorkloadInfo_JOB
+	case "cronjob":
+		return rpc.WorkloadInfo_CRONJOB
 	case "rollout":
 		return rpc.WorkloadInfo_ROLLOUT
 	default:
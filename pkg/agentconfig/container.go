@@ -31,7 +31,11 @@ func AgentContainer(
 		}
 	}
 	if len(ports) == 0 {
-		return nil
+		if !IsBatchKind(config.WorkloadKind) {
+			return nil
+		}
+		// The containers of a Job or CronJob can be replaced without intercepting any port.
+		ports = nil
 	}
 
 	evs := make([]core.EnvVar, 0, len(config.Containers)*5)
@@ -44,6 +44,10 @@ const (
 	// EnvAPIPort is the port number of the Telepresence API server, when it is enabled.
 	EnvAPIPort = "TELEPRESENCE_API_PORT"
 
+	// EnvExitWithApp tells the traffic-agent to exit when the app containers have terminated. It is set when the
+	// agent of a Job or CronJob can't be injected as a native sidecar.
+	EnvExitWithApp = EnvPrefixAgent + "EXIT_WITH_APP"
+
 	DomainPrefix                         = "telepresence.getambassador.io/"
 	InjectAnnotation                     = DomainPrefix + "inject-" + ContainerName
 	InjectIgnoreVolumeMounts             = DomainPrefix + "inject-ignore-volume-mounts"
@@ -201,6 +205,13 @@ func (s *Sidecar) AgentConfig() *Sidecar {
 	return s
 }
 
+// IsBatchKind returns true if the given workload kind is Job or CronJob. The containers of such workloads
+// can be intercepted without ports, and their pods run to completion, so the traffic-agent must not keep
+// them running.
+func IsBatchKind(kind string) bool {
+	return kind == "Job" || kind == "CronJob"
+}
+
 // Marshal returns YAML encoding of the Sidecar.
 func (s *Sidecar) Marshal() ([]byte, error) {
 	return yaml.Marshal(s)
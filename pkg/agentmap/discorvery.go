@@ -14,12 +14,14 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	apps "k8s.io/client-go/informers/apps/v1"
+	batchinformer "k8s.io/client-go/informers/batch/v1"
 
 	argorollouts "github.com/datawire/argo-rollouts-go-client/pkg/client/informers/externalversions/rollouts/v1alpha1"
 	"github.com/datawire/dlib/dlog"
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/informer"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sworkload"
 )
 
 var ReplicaSetNameRx = regexp.MustCompile(`\A(.+)-[a-f0-9]+\z`)
@@ -44,6 +46,15 @@ func FindOwnerWorkload(ctx context.Context, obj k8sapi.Object, supportedWorkload
 					}
 				}
 			}
+			if or.Kind == "Job" && slices.Contains(supportedWorkloadKinds, "CronJob") {
+				// The pods of a CronJob are owned by the Job that the CronJob created, and that
+				// Job must be resolved to its CronJob even when Jobs aren't supported.
+				if job, err := GetWorkload(ctx, or.Name, ns, "Job"); err == nil {
+					if wl, err := FindOwnerWorkload(ctx, job, supportedWorkloadKinds); err == nil && wl.GetKind() == "CronJob" {
+						return wl, nil
+					}
+				}
+			}
 			if slices.Contains(supportedWorkloadKinds, or.Kind) {
 				wl, err := GetWorkload(ctx, or.Name, ns, or.Kind)
 				if err != nil {
@@ -61,18 +72,55 @@ func FindOwnerWorkload(ctx context.Context, obj k8sapi.Object, supportedWorkload
 	return nil, fmt.Errorf("unable to find workload owner for %s.%s", obj.GetName(), obj.GetNamespace())
 }
 
+type enabledKindsKey struct{}
+
+// WithEnabledWorkloadKinds returns a context that makes GetWorkload include the given DaemonSet, Job,
+// and CronJob kinds when it searches for a workload of unspecified kind. Those kinds are otherwise
+// excluded from the search, because they must be explicitly enabled.
+func WithEnabledWorkloadKinds(ctx context.Context, kinds []string) context.Context {
+	return context.WithValue(ctx, enabledKindsKey{}, kinds)
+}
+
+func optionalWorkloadKinds(ctx context.Context) []string {
+	var oks []string
+	if kinds, ok := ctx.Value(enabledKindsKey{}).([]string); ok {
+		for _, k := range kinds {
+			switch k {
+			case "DaemonSet", "Job", "CronJob":
+				oks = append(oks, k)
+			}
+		}
+	}
+	return oks
+}
+
 func GetWorkload(ctx context.Context, name, namespace, workloadKind string) (obj k8sapi.Workload, err error) {
 	dlog.Debugf(ctx, "GetWorkload(%s,%s,%s)", name, namespace, workloadKind)
 	i := informer.GetFactory(ctx, namespace)
 	if i == nil {
 		dlog.Debugf(ctx, "fetching %s %s.%s using direct API call", workloadKind, name, namespace)
+		switch workloadKind {
+		case "DaemonSet":
+			return k8sworkload.GetDaemonSet(ctx, name, namespace)
+		case "Job":
+			return k8sworkload.GetJob(ctx, name, namespace)
+		case "CronJob":
+			return k8sworkload.GetCronJob(ctx, name, namespace)
+		}
 		return k8sapi.GetWorkload(ctx, name, namespace, workloadKind)
 	}
-	ai, ri := i.GetK8sInformerFactory().Apps().V1(), i.GetArgoRolloutsInformerFactory().Argoproj().V1alpha1().Rollouts()
-	return getWorkload(ai, ri, name, namespace, workloadKind)
+	kf := i.GetK8sInformerFactory()
+	ai, bi, ri := kf.Apps().V1(), kf.Batch().V1(), i.GetArgoRolloutsInformerFactory().Argoproj().V1alpha1().Rollouts()
+	return getWorkload(ai, bi, ri, name, namespace, workloadKind, optionalWorkloadKinds(ctx))
 }
 
-func getWorkload(ai apps.Interface, ri argorollouts.RolloutInformer, name, namespace, workloadKind string) (obj k8sapi.Workload, err error) {
+func getWorkload(
+	ai apps.Interface,
+	bi batchinformer.Interface,
+	ri argorollouts.RolloutInformer,
+	name, namespace, workloadKind string,
+	optionalKinds []string,
+) (obj k8sapi.Workload, err error) {
 	switch workloadKind {
 	case "Deployment":
 		return getDeployment(ai, name, namespace)
@@ -82,9 +130,16 @@ func getWorkload(ai apps.Interface, ri argorollouts.RolloutInformer, name, names
 		return getStatefulSet(ai, name, namespace)
 	case "Rollout":
 		return getRollout(ri, name, namespace)
+	case "DaemonSet":
+		return getDaemonSet(ai, name, namespace)
+	case "Job":
+		return getJob(bi, name, namespace)
+	case "CronJob":
+		return getCronJob(bi, name, namespace)
 	case "":
-		for _, wk := range []string{"Deployment", "ReplicaSet", "StatefulSet", "Rollout"} {
-			if obj, err = getWorkload(ai, ri, name, namespace, wk); err == nil {
+		// DaemonSets, Jobs, and CronJobs are only included in this search when they are enabled.
+		for _, wk := range append([]string{"Deployment", "ReplicaSet", "StatefulSet", "Rollout"}, optionalKinds...) {
+			if obj, err = getWorkload(ai, bi, ri, name, namespace, wk, nil); err == nil {
 				return obj, nil
 			}
 			if !k8sErrors.IsNotFound(err) {
@@ -132,6 +187,30 @@ func getStatefulSet(ai apps.Interface, name, namespace string) (k8sapi.Workload,
 	return k8sapi.StatefulSet(ss), nil
 }
 
+func getDaemonSet(ai apps.Interface, name, namespace string) (k8sapi.Workload, error) {
+	ds, err := ai.DaemonSets().Lister().DaemonSets(namespace).Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return k8sworkload.DaemonSet(ds), nil
+}
+
+func getJob(bi batchinformer.Interface, name, namespace string) (k8sapi.Workload, error) {
+	j, err := bi.Jobs().Lister().Jobs(namespace).Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return k8sworkload.Job(j), nil
+}
+
+func getCronJob(bi batchinformer.Interface, name, namespace string) (k8sapi.Workload, error) {
+	cj, err := bi.CronJobs().Lister().CronJobs(namespace).Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return k8sworkload.CronJob(cj), nil
+}
+
 func FindServicesForPod(ctx context.Context, pod *core.PodTemplateSpec, svcName string) ([]k8sapi.Object, error) {
 	switch {
 	case svcName != "":
@@ -296,8 +375,12 @@ func IsPodRunning(pod *core.Pod) bool {
 }
 
 // AgentContainer returns the pod's traffic-agent container, or nil if the pod doesn't have a traffic-agent.
+// The traffic-agent of a Job or CronJob pod might be a native sidecar, i.e. an init-container.
 func AgentContainer(pod *core.Pod) *core.Container {
-	return containerByName(agentconfig.ContainerName, pod.Spec.Containers)
+	if ac := containerByName(agentconfig.ContainerName, pod.Spec.Containers); ac != nil {
+		return ac
+	}
+	return containerByName(agentconfig.ContainerName, pod.Spec.InitContainers)
 }
 
 // InitContainer returns the pod's tel-agent-init init-container, or nil if the pod doesn't have a tel-agent-init.
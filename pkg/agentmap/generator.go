@@ -134,7 +134,8 @@ func (cfg *BasicGeneratorConfig) Generate(
 		return nil, err
 	}
 	if len(ports) == 0 {
-		if len(ccs) == 0 {
+		// The containers of a batch workload are intercepted without ports, so that they can be replaced.
+		if len(ccs) == 0 && !agentconfig.IsBatchKind(wl.GetKind()) {
 			return nil, fmt.Errorf("found no service with a port that matches a container in pod %s.%s", pod.Name, pod.Namespace)
 		}
 	} else {
@@ -0,0 +1,158 @@
+// Package capture contains the writer of the pcapng files that are produced by "telepresence capture". A
+// capture has two interfaces, one with the IP packets that pass the Telepresence virtual network interface
+// (VIF), and one with packets that are synthesized from the data of the intercepted connections that the
+// daemons dial on behalf of the traffic-agents.
+package capture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// The interfaces of a capture.
+const (
+	InterfaceVIF        = 0
+	InterfaceIntercepts = 1
+)
+
+// Names of the interfaces of a capture, in interface order.
+const (
+	vifName        = "telepresence-vif"
+	interceptsName = "telepresence-intercepts"
+)
+
+// pcapng block types, option codes, and constants. See https://www.ietf.org/archive/id/draft-ietf-opsawg-pcapng-01.html
+const (
+	blockSectionHeader        = 0x0a0d0d0a
+	blockInterfaceDescription = 0x00000001
+	blockEnhancedPacket       = 0x00000006
+	byteOrderMagic            = 0x1a2b3c4d
+	optEndOfOpt               = 0
+	optShbUserAppl            = 4
+	optIfName                 = 2
+	linkTypeRaw               = 101
+)
+
+// Writer writes the blocks of packets to a pcapng file, or to any other io.Writer.
+type Writer struct {
+	mu  sync.Mutex
+	out io.Writer
+	err error
+}
+
+// NewWriter returns a Writer that writes the blocks of packets to the given writer. Each block is written
+// using one single call to Write.
+func NewWriter(out io.Writer) *Writer {
+	return &Writer{out: out}
+}
+
+// Create creates or truncates the file with the given path and writes the section header and the
+// descriptions of the interfaces of a capture to it.
+func Create(path, application string) (*Writer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	shb := make([]byte, 16)
+	binary.LittleEndian.PutUint32(shb, byteOrderMagic)
+	binary.LittleEndian.PutUint16(shb[4:], 1) // major version
+	binary.LittleEndian.PutUint16(shb[6:], 0) // minor version
+	binary.LittleEndian.PutUint64(shb[8:], ^uint64(0))
+	writeBlock(&b, blockSectionHeader, shb, option(optShbUserAppl, application))
+	for _, name := range []string{vifName, interceptsName} {
+		idb := make([]byte, 8)
+		binary.LittleEndian.PutUint16(idb, linkTypeRaw)
+		writeBlock(&b, blockInterfaceDescription, idb, option(optIfName, name))
+	}
+	if _, err = f.Write(b.Bytes()); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return NewWriter(f), nil
+}
+
+// Close closes the writer, and the underlying writer if it's an io.Closer.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.out == nil {
+		return w.err
+	}
+	var err error
+	if c, ok := w.out.(io.Closer); ok {
+		err = c.Close()
+	}
+	w.out = nil
+	if w.err == nil {
+		w.err = err
+	}
+	return w.err
+}
+
+// Err returns the first error that occurred when writing a packet.
+func (w *Writer) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// WritePacket writes the given IP packet, captured on the given interface at the given time.
+func (w *Writer) WritePacket(iface uint32, t time.Time, data []byte) {
+	var b bytes.Buffer
+	epb := make([]byte, 20, 20+len(data)+3)
+	ts := uint64(t.UnixMicro())
+	binary.LittleEndian.PutUint32(epb, iface)
+	binary.LittleEndian.PutUint32(epb[4:], uint32(ts>>32))
+	binary.LittleEndian.PutUint32(epb[8:], uint32(ts))
+	binary.LittleEndian.PutUint32(epb[12:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(epb[16:], uint32(len(data)))
+	epb = append(epb, pad(data)...)
+	writeBlock(&b, blockEnhancedPacket, epb, nil)
+	w.WriteBlocks(b.Bytes())
+}
+
+// WriteBlocks writes blocks of packets that were written by another Writer.
+func (w *Writer) WriteBlocks(blocks []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.out == nil || w.err != nil {
+		return
+	}
+	_, w.err = w.out.Write(blocks)
+}
+
+// writeBlock writes a block with the given type, body, and options, and adds the end of options marker
+// when options are present.
+func writeBlock(b *bytes.Buffer, blockType uint32, body, options []byte) {
+	if len(options) > 0 {
+		options = binary.LittleEndian.AppendUint16(options, optEndOfOpt)
+		options = binary.LittleEndian.AppendUint16(options, 0)
+	}
+	total := uint32(12 + len(body) + len(options))
+	_ = binary.Write(b, binary.LittleEndian, blockType)
+	_ = binary.Write(b, binary.LittleEndian, total)
+	b.Write(body)
+	b.Write(options)
+	_ = binary.Write(b, binary.LittleEndian, total)
+}
+
+// option returns the given string option, padded to 32 bits.
+func option(code uint16, value string) []byte {
+	o := make([]byte, 4, 4+len(value)+3)
+	binary.LittleEndian.PutUint16(o, code)
+	binary.LittleEndian.PutUint16(o[2:], uint16(len(value)))
+	return append(o, pad([]byte(value))...)
+}
+
+// pad returns the given data, padded with zeroes to 32 bits.
+func pad(data []byte) []byte {
+	if r := len(data) % 4; r != 0 {
+		data = append(data[:len(data):len(data)], make([]byte, 4-r)...)
+	}
+	return data
+}
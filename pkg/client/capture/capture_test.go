@@ -0,0 +1,140 @@
+package capture
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/sendqueue"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+type block struct {
+	blockType uint32
+	body      []byte
+}
+
+func readBlocks(t *testing.T, path string) []block {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var blocks []block
+	for len(data) > 0 {
+		require.GreaterOrEqual(t, len(data), 12)
+		bt := binary.LittleEndian.Uint32(data)
+		total := binary.LittleEndian.Uint32(data[4:])
+		require.Zero(t, total%4)
+		require.GreaterOrEqual(t, len(data), int(total))
+		require.Equal(t, total, binary.LittleEndian.Uint32(data[total-4:]))
+		blocks = append(blocks, block{blockType: bt, body: data[8 : total-4]})
+		data = data[total:]
+	}
+	return blocks
+}
+
+// packet returns the interface and the data of an enhanced packet block.
+func (b block) packet() (uint32, []byte) {
+	n := binary.LittleEndian.Uint32(b.body[12:])
+	return binary.LittleEndian.Uint32(b.body), b.body[20 : 20+n]
+}
+
+func TestCapture(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.pcapng")
+	fw, err := Create(path, "test")
+	require.NoError(t, err)
+
+	// The packets are written by the daemons, and streamed as blocks to the process that writes the file.
+	q := sendqueue.New(16)
+	w := NewWriter(q)
+	vifPkt := []byte{0x45, 0, 0, 21, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17}
+	w.WritePacket(InterfaceVIF, time.Now(), vifPkt)
+
+	r := NewRecorder(w, &Filter{})
+	id := tunnel.NewConnID(ipproto.TCP, iputil.Parse("10.1.0.5"), iputil.Parse("127.0.0.1"), 43210, 8080)
+	r.RecordOpen(id)
+	r.RecordPeerData(id, []byte("GET / HTTP/1.1\r\n\r\n"))
+	r.RecordConnData(id, []byte("HTTP/1.1 200 OK\r\n\r\n"))
+	r.RecordClose(id)
+	require.NoError(t, w.Close())
+	assert.Zero(t, q.Dropped())
+	for len(q.Data()) > 0 {
+		fw.WriteBlocks(<-q.Data())
+	}
+	require.NoError(t, fw.Close())
+
+	blocks := readBlocks(t, path)
+	require.Len(t, blocks, 3+1+8)
+	assert.Equal(t, uint32(blockSectionHeader), blocks[0].blockType)
+	assert.Equal(t, uint32(byteOrderMagic), binary.LittleEndian.Uint32(blocks[0].body))
+	assert.Equal(t, uint32(blockInterfaceDescription), blocks[1].blockType)
+	assert.Equal(t, uint32(blockInterfaceDescription), blocks[2].blockType)
+	assert.Equal(t, uint16(linkTypeRaw), binary.LittleEndian.Uint16(blocks[1].body))
+
+	iface, data := blocks[3].packet()
+	assert.Equal(t, uint32(InterfaceVIF), iface)
+	assert.Equal(t, vifPkt, data)
+
+	flags := []header.TCPFlags{
+		header.TCPFlagSyn,
+		header.TCPFlagSyn | header.TCPFlagAck,
+		header.TCPFlagAck,
+		header.TCPFlagPsh | header.TCPFlagAck,
+		header.TCPFlagPsh | header.TCPFlagAck,
+		header.TCPFlagFin | header.TCPFlagAck,
+		header.TCPFlagFin | header.TCPFlagAck,
+		header.TCPFlagAck,
+	}
+	for i, b := range blocks[4:] {
+		iface, data = b.packet()
+		assert.Equal(t, uint32(InterfaceIntercepts), iface)
+		ip := header.IPv4(data)
+		require.True(t, ip.IsValid(len(data)))
+		assert.True(t, ip.IsChecksumValid())
+		tcp := header.TCP(ip.Payload())
+		assert.Equal(t, flags[i], tcp.Flags(), "packet %d", i)
+		switch i {
+		case 3:
+			assert.Equal(t, "10.1.0.5", ip.SourceAddress().String())
+			assert.Equal(t, uint16(8080), tcp.DestinationPort())
+			assert.Equal(t, "GET / HTTP/1.1\r\n\r\n", string(tcp.Payload()))
+		case 4:
+			assert.Equal(t, "127.0.0.1", ip.SourceAddress().String())
+			assert.Equal(t, uint16(8080), tcp.SourcePort())
+			assert.Equal(t, "HTTP/1.1 200 OK\r\n\r\n", string(tcp.Payload()))
+		}
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	tcp := tunnel.NewConnID(ipproto.TCP, iputil.Parse("10.1.0.5"), iputil.Parse("10.96.0.7"), 43210, 8080)
+	udp6 := tunnel.NewConnID(ipproto.UDP, iputil.Parse("fd00::5"), iputil.Parse("fd00:96::10"), 43210, 53)
+	tcpPkt := synthesize(tcp, false, header.TCPFlagAck, 1, 1, []byte("x"))
+	udp6Pkt := synthesize(udp6, true, 0, 0, 0, []byte("x"))
+
+	tests := []struct {
+		name   string
+		filter Filter
+		tcp    bool
+		udp6   bool
+	}{
+		{"empty", Filter{}, true, true},
+		{"subnet", Filter{Subnets: []netip.Prefix{netip.MustParsePrefix("10.96.0.0/12")}}, true, false},
+		{"IPv6 subnet", Filter{Subnets: []netip.Prefix{netip.MustParsePrefix("fd00:96::/64")}}, false, true},
+		{"port", Filter{Ports: []uint16{53}}, false, true},
+		{"subnet and port", Filter{Subnets: []netip.Prefix{netip.MustParsePrefix("10.1.0.5/32")}, Ports: []uint16{53}}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.tcp, tt.filter.Match(tcpPkt))
+			assert.Equal(t, tt.udp6, tt.filter.Match(udp6Pkt))
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package capture
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"slices"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+// Filter selects the packets that are captured. A packet is selected when its source or destination is
+// contained in one of the subnets, and its source or destination port is one of the ports. An empty list
+// of subnets or ports selects all packets. Only TCP and UDP packets have ports.
+type Filter struct {
+	Subnets []netip.Prefix
+	Ports   []uint16
+}
+
+// FilterFromRPC returns the Filter of the given request.
+func FilterFromRPC(req *daemon.CaptureRequest) *Filter {
+	f := &Filter{Subnets: iputil.RPCsToPrefixes(req.Subnets)}
+	for _, p := range req.Ports {
+		f.Ports = append(f.Ports, uint16(p))
+	}
+	return f
+}
+
+// Match returns true if the given IP packet is selected by this filter.
+func (f *Filter) Match(pkt []byte) bool {
+	if len(f.Subnets) == 0 && len(f.Ports) == 0 {
+		return true
+	}
+	if len(pkt) == 0 {
+		return false
+	}
+	var src, dst netip.Addr
+	var proto int
+	var transport []byte
+	switch pkt[0] >> 4 {
+	case 4:
+		hl := int(pkt[0]&0x0f) * 4
+		if len(pkt) < 20 || len(pkt) < hl {
+			return false
+		}
+		src = netip.AddrFrom4([4]byte(pkt[12:16]))
+		dst = netip.AddrFrom4([4]byte(pkt[16:20]))
+		proto = int(pkt[9])
+		if binary.BigEndian.Uint16(pkt[6:])&0x1fff == 0 {
+			// Only the first fragment contains the transport header.
+			transport = pkt[hl:]
+		}
+	case 6:
+		if len(pkt) < 40 {
+			return false
+		}
+		src = netip.AddrFrom16([16]byte(pkt[8:24]))
+		dst = netip.AddrFrom16([16]byte(pkt[24:40]))
+		proto = int(pkt[6])
+		transport = pkt[40:]
+	default:
+		return false
+	}
+	if len(f.Subnets) > 0 && !slices.ContainsFunc(f.Subnets, func(sn netip.Prefix) bool {
+		return sn.Contains(src) || sn.Contains(dst)
+	}) {
+		return false
+	}
+	if len(f.Ports) == 0 {
+		return true
+	}
+	if (proto != ipproto.TCP && proto != ipproto.UDP) || len(transport) < 4 {
+		return false
+	}
+	return slices.Contains(f.Ports, binary.BigEndian.Uint16(transport)) ||
+		slices.Contains(f.Ports, binary.BigEndian.Uint16(transport[2:]))
+}
@@ -0,0 +1,175 @@
+package capture
+
+import (
+	"sync"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/checksum"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// maxSegmentSize is the largest payload of a synthesized packet. Larger data is split into several packets.
+const maxSegmentSize = 0x8000
+
+// Recorder is a tunnel.Recorder that writes the connections that a dialer establishes to the intercepts
+// interface of a capture. Each TCP connection is written as a handshake, one packet per chunk of data in
+// each direction, and a FIN in each direction when it's closed. Each chunk of UDP data is written as one
+// datagram.
+type Recorder struct {
+	writer *Writer
+	filter *Filter
+	mu     sync.Mutex
+	conns  map[tunnel.ConnID]*synthConn
+}
+
+// synthConn keeps track of the next sequence numbers of a synthesized TCP connection.
+type synthConn struct {
+	peerSeq uint32
+	connSeq uint32
+}
+
+// NewRecorder returns a Recorder that writes the packets that are selected by the given filter to the
+// given writer.
+func NewRecorder(writer *Writer, filter *Filter) *Recorder {
+	return &Recorder{writer: writer, filter: filter, conns: make(map[tunnel.ConnID]*synthConn)}
+}
+
+func (r *Recorder) RecordOpen(id tunnel.ConnID) {
+	if id.Protocol() != ipproto.TCP {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := &synthConn{peerSeq: 1000, connSeq: 2000}
+	r.conns[id] = c
+	r.write(id, false, header.TCPFlagSyn, c.peerSeq, 0, nil)
+	r.write(id, true, header.TCPFlagSyn|header.TCPFlagAck, c.connSeq, c.peerSeq+1, nil)
+	c.peerSeq++
+	c.connSeq++
+	r.write(id, false, header.TCPFlagAck, c.peerSeq, c.connSeq, nil)
+}
+
+func (r *Recorder) RecordPeerData(id tunnel.ConnID, data []byte) {
+	r.recordData(id, false, data)
+}
+
+func (r *Recorder) RecordConnData(id tunnel.ConnID, data []byte) {
+	r.recordData(id, true, data)
+}
+
+func (r *Recorder) RecordClose(id tunnel.ConnID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.conns[id]
+	if !ok {
+		return
+	}
+	delete(r.conns, id)
+	r.write(id, false, header.TCPFlagFin|header.TCPFlagAck, c.peerSeq, c.connSeq, nil)
+	r.write(id, true, header.TCPFlagFin|header.TCPFlagAck, c.connSeq, c.peerSeq+1, nil)
+	r.write(id, false, header.TCPFlagAck, c.peerSeq+1, c.connSeq+1, nil)
+}
+
+func (r *Recorder) recordData(id tunnel.ConnID, fromConn bool, data []byte) {
+	if p := id.Protocol(); p != ipproto.TCP && p != ipproto.UDP {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := r.conns[id]
+	if id.Protocol() == ipproto.TCP && c == nil {
+		// The connection was established before the capture started.
+		return
+	}
+	for len(data) > 0 {
+		chunk := data[:min(len(data), maxSegmentSize)]
+		data = data[len(chunk):]
+		if c == nil {
+			r.write(id, fromConn, 0, 0, 0, chunk)
+			continue
+		}
+		if fromConn {
+			r.write(id, true, header.TCPFlagPsh|header.TCPFlagAck, c.connSeq, c.peerSeq, chunk)
+			c.connSeq += uint32(len(chunk))
+		} else {
+			r.write(id, false, header.TCPFlagPsh|header.TCPFlagAck, c.peerSeq, c.connSeq, chunk)
+			c.peerSeq += uint32(len(chunk))
+		}
+	}
+}
+
+// write writes a packet for the connection with the given id. The packet is sent from the source to the
+// destination of the id, unless fromConn is true. The flags, seq, and ack are only used for TCP.
+func (r *Recorder) write(id tunnel.ConnID, fromConn bool, flags header.TCPFlags, seq, ack uint32, payload []byte) {
+	pkt := synthesize(id, fromConn, flags, seq, ack, payload)
+	if r.filter.Match(pkt) {
+		r.writer.WritePacket(InterfaceIntercepts, time.Now(), pkt)
+	}
+}
+
+// synthesize creates an IP packet that carries the given TCP segment or UDP datagram.
+func synthesize(id tunnel.ConnID, fromConn bool, flags header.TCPFlags, seq, ack uint32, payload []byte) []byte {
+	src, dst := tcpip.AddrFromSlice(id.Source()), tcpip.AddrFromSlice(id.Destination())
+	srcPort, dstPort := id.SourcePort(), id.DestinationPort()
+	if fromConn {
+		src, dst, srcPort, dstPort = dst, src, dstPort, srcPort
+	}
+	proto := id.Protocol()
+	tl := header.UDPMinimumSize
+	if proto == ipproto.TCP {
+		tl = header.TCPMinimumSize
+	}
+
+	var pkt, transport []byte
+	if src.Len() == 4 {
+		pkt = make([]byte, header.IPv4MinimumSize+tl+len(payload))
+		ip := header.IPv4(pkt)
+		ip.Encode(&header.IPv4Fields{
+			TotalLength: uint16(len(pkt)),
+			TTL:         64,
+			Protocol:    uint8(proto),
+			SrcAddr:     src,
+			DstAddr:     dst,
+		})
+		ip.SetChecksum(^ip.CalculateChecksum())
+		transport = pkt[header.IPv4MinimumSize:]
+	} else {
+		pkt = make([]byte, header.IPv6MinimumSize+tl+len(payload))
+		header.IPv6(pkt).Encode(&header.IPv6Fields{
+			PayloadLength:     uint16(tl + len(payload)),
+			TransportProtocol: tcpip.TransportProtocolNumber(proto),
+			HopLimit:          64,
+			SrcAddr:           src,
+			DstAddr:           dst,
+		})
+		transport = pkt[header.IPv6MinimumSize:]
+	}
+	copy(transport[tl:], payload)
+	sum := header.PseudoHeaderChecksum(tcpip.TransportProtocolNumber(proto), src, dst, uint16(len(transport)))
+	if proto == ipproto.TCP {
+		t := header.TCP(transport)
+		t.Encode(&header.TCPFields{
+			SrcPort:    srcPort,
+			DstPort:    dstPort,
+			SeqNum:     seq,
+			AckNum:     ack,
+			DataOffset: header.TCPMinimumSize,
+			Flags:      flags,
+			WindowSize: 0xffff,
+		})
+		t.SetChecksum(^checksum.Checksum(transport, sum))
+	} else {
+		u := header.UDP(transport)
+		u.Encode(&header.UDPFields{
+			SrcPort: srcPort,
+			DstPort: dstPort,
+			Length:  uint16(len(transport)),
+		})
+		u.SetChecksum(^checksum.Checksum(transport, sum))
+	}
+	return pkt
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	daemonRpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/capture"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+type captureCommand struct {
+	file     string
+	filters  []string
+	duration time.Duration
+}
+
+func captureCmd() *cobra.Command {
+	cc := &captureCommand{}
+	cmd := &cobra.Command{
+		Use:  "capture --output <file>",
+		Args: cobra.NoArgs,
+
+		Short: "Capture the traffic of the virtual network interface and the intercepts in a pcapng file",
+		Long: `Capture the IP packets that pass the Telepresence virtual network interface (VIF) in a pcapng file, until
+interrupted or until the given duration has passed. The intercepted connections that are established after the
+capture starts are added as synthesized TCP and UDP packets on a second interface of the capture.
+
+Use --filter to only capture packets to or from a subnet, an IP address, or a TCP or UDP port. A packet is captured
+when it matches one of the given subnets (if any), and one of the given ports (if any).`,
+		Example: `  telepresence capture --output vif.pcapng
+  telepresence capture --output vif.pcapng --filter 10.96.0.0/12 --filter 8080 --duration 1m`,
+		RunE: cc.run,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVarP(&cc.file, "output", "o", "", "The pcapng file to write the capture to")
+	flags.StringSliceVar(&cc.filters, "filter", nil, "Only capture packets to or from the given subnet, IP address, or port")
+	flags.DurationVarP(&cc.duration, "duration", "d", 0, "Stop the capture after the given duration (0s means when interrupted)")
+	_ = cmd.MarkFlagRequired("output")
+	return cmd
+}
+
+// parseCaptureFilters returns the subnets and ports that are given as filters.
+func parseCaptureFilters(filters []string) (subnets []netip.Prefix, ports []int32, err error) {
+	for _, f := range filters {
+		for _, v := range strings.Split(f, ",") {
+			v = strings.TrimSpace(v)
+			if p, err := strconv.ParseUint(v, 10, 16); err == nil && p > 0 {
+				ports = append(ports, int32(p))
+			} else if sn, err := netip.ParsePrefix(v); err == nil {
+				subnets = append(subnets, sn.Masked())
+			} else if ip, err := netip.ParseAddr(v); err == nil {
+				subnets = append(subnets, netip.PrefixFrom(ip, ip.BitLen()))
+			} else {
+				return nil, nil, errcat.User.Newf("invalid --filter %q, must be a subnet, an IP address, or a port", v)
+			}
+		}
+	}
+	return subnets, ports, nil
+}
+
+func (cc *captureCommand) run(cmd *cobra.Command, _ []string) error {
+	subnets, ports, err := parseCaptureFilters(cc.filters)
+	if err != nil {
+		return err
+	}
+	if err = connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	file, err := filepath.Abs(cc.file)
+	if err != nil {
+		return errcat.User.New(err)
+	}
+
+	// The file is written by this process, so that it's owned by the user. The daemons stream the packets.
+	w, err := capture.Create(file, client.DisplayName+" "+client.Version())
+	if err != nil {
+		return errcat.User.New(err)
+	}
+	defer w.Close()
+
+	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer cancel()
+	if cc.duration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, cc.duration)
+		defer cancel()
+	}
+	stream, err := daemon.GetUserClient(ctx).Capture(ctx, &daemonRpc.CaptureRequest{
+		Subnets: iputil.PrefixesToRPC(subnets),
+		Ports:   ports,
+	})
+	if err == nil {
+		fmt.Fprintf(cmd.OutOrStdout(), "Capturing packets in %s. Press Ctrl-C to stop.\n", file)
+		var bs *daemonRpc.CaptureBlocks
+		for {
+			if bs, err = stream.Recv(); err != nil {
+				break
+			}
+			w.WriteBlocks(bs.Blocks)
+		}
+	}
+	if err != nil && !errors.Is(err, io.EOF) && ctx.Err() == nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return errcat.User.New(status.Convert(err).Message())
+		}
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return errcat.User.New(err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Capture written to %s\n", file)
+	return nil
+}
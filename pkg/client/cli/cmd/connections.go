@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	daemonRpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+// connectionsPollInterval is how often the connections are refreshed when watching them.
+const connectionsPollInterval = time.Second
+
+type connectionsCommand struct {
+	watch bool
+}
+
+// connectionJSON is the formatted output of one connection.
+type connectionJSON struct {
+	Protocol    string    `json:"protocol"`
+	Source      string    `json:"source"`
+	Destination string    `json:"destination"`
+	Pid         int32     `json:"pid,omitempty"`
+	Process     string    `json:"process,omitempty"`
+	Peer        string    `json:"peer"`
+	BytesIn     uint64    `json:"bytes_in"`
+	BytesOut    uint64    `json:"bytes_out"`
+	Opened      time.Time `json:"opened"`
+}
+
+func connections() *cobra.Command {
+	cc := &connectionsCommand{}
+	cmd := &cobra.Command{
+		Use:  "connections",
+		Args: cobra.NoArgs,
+
+		Short: "List the connections that are tunneled to the cluster",
+		Long: `List the connections that are currently tunneled to the cluster, together with the local process that
+owns them (Linux only), the traffic-manager or traffic-agent that they are tunneled to, the number of bytes
+received and sent, and their age.`,
+		RunE: cc.run,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+	}
+	cmd.Flags().BoolVarP(&cc.watch, "watch", "w", false, "refresh the list every second until interrupted")
+	return cmd
+}
+
+func (cc *connectionsCommand) run(cmd *cobra.Command, _ []string) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	stream := output.WantsStream(cmd)
+	if cc.watch && output.WantsFormatted(cmd) && !stream {
+		return errcat.User.New(`--watch cannot be combined with --output json or yaml, use "--output json-stream" instead`)
+	}
+	userD := daemon.GetUserClient(ctx)
+	if !(cc.watch || stream) {
+		conns, err := userD.GetConnections(ctx, &emptypb.Empty{})
+		if err != nil {
+			return err
+		}
+		cc.print(cmd, conns.Connections)
+		return nil
+	}
+
+	clearScreen := false
+	if f, ok := cmd.OutOrStdout().(*os.File); ok && !stream {
+		clearScreen = term.IsTerminal(int(f.Fd()))
+	}
+	ticker := time.NewTicker(connectionsPollInterval)
+	defer ticker.Stop()
+	for {
+		conns, err := userD.GetConnections(ctx, &emptypb.Empty{})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if clearScreen {
+			// Move the cursor home and clear the screen.
+			fmt.Fprint(cmd.OutOrStdout(), "\033[H\033[2J")
+		}
+		cc.print(cmd, conns.Connections)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if !(clearScreen || stream) {
+			fmt.Fprintln(cmd.OutOrStdout())
+		}
+	}
+}
+
+func (cc *connectionsCommand) print(cmd *cobra.Command, conns []*daemonRpc.Connection) {
+	ctx := cmd.Context()
+	if output.WantsFormatted(cmd) {
+		cjs := make([]*connectionJSON, len(conns))
+		for i, c := range conns {
+			cjs[i] = &connectionJSON{
+				Protocol:    c.Protocol,
+				Source:      connectionAddr(c.SourceIp, c.SourcePort),
+				Destination: connectionAddr(c.DestinationIp, c.DestinationPort),
+				Pid:         c.Pid,
+				Process:     c.Process,
+				Peer:        c.Peer,
+				BytesIn:     c.BytesIn,
+				BytesOut:    c.BytesOut,
+				Opened:      c.Opened.AsTime(),
+			}
+		}
+		output.Object(ctx, cjs, false)
+		return
+	}
+	printConnections(cmd.OutOrStdout(), conns, time.Now())
+}
+
+// printConnections prints the given connections as a table.
+func printConnections(out io.Writer, conns []*daemonRpc.Connection, now time.Time) {
+	if len(conns) == 0 {
+		fmt.Fprintln(out, "No connections")
+		return
+	}
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROTOCOL\tSOURCE\tDESTINATION\tPROCESS\tPEER\tIN\tOUT\tAGE")
+	for _, c := range conns {
+		process := c.Process
+		if c.Pid > 0 {
+			process += "(" + strconv.Itoa(int(c.Pid)) + ")"
+		}
+		if process == "" {
+			process = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\n",
+			c.Protocol,
+			connectionAddr(c.SourceIp, c.SourcePort),
+			connectionAddr(c.DestinationIp, c.DestinationPort),
+			process,
+			c.Peer,
+			c.BytesIn,
+			c.BytesOut,
+			now.Sub(c.Opened.AsTime()).Round(time.Second))
+	}
+	_ = tw.Flush()
+}
+
+// connectionAddr formats the given ip and port as an address. The port of an ICMP connection is
+// the identifier of its echo requests.
+func connectionAddr(ip []byte, port int32) string {
+	a, _ := netip.AddrFromSlice(ip)
+	return netip.AddrPortFrom(a.Unmap(), uint16(port)).String()
+}
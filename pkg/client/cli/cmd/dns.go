@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	daemonRpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+func dnsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dns",
+		Short: "Inspect and control the DNS server of the root daemon",
+	}
+	cmd.AddCommand(dnsLog(), dnsCache(), dnsFlush(), dnsExport())
+	return cmd
+}
+
+type dnsLogCommand struct {
+	follow bool
+}
+
+// dnsQueryJSON is the formatted output of one DNS query.
+type dnsQueryJSON struct {
+	Time     time.Time     `json:"time"`
+	Name     string        `json:"name"`
+	Type     string        `json:"type"`
+	Decision string        `json:"decision"`
+	Reason   string        `json:"reason,omitempty"`
+	Rcode    string        `json:"rcode"`
+	Answer   string        `json:"answer,omitempty"`
+	Latency  time.Duration `json:"latency"`
+	CacheHit bool          `json:"cache_hit"`
+}
+
+func dnsLog() *cobra.Command {
+	dc := &dnsLogCommand{}
+	cmd := &cobra.Command{
+		Use:  "log",
+		Args: cobra.NoArgs,
+
+		Short: "Show the queries that the DNS server has handled",
+		Long: `Show the most recent queries that the DNS server of the root daemon has handled, together with the
+decision that determined how each query was answered, and why. The decision is one of:
+
+  mapping   the name matched a DNS mapping
+  excluded  the name was excluded from cluster lookups
+  cluster   the name was looked up in the cluster
+  fallback  the query was sent to the DNS server that was configured before connecting
+  local     the DNS server answered the query itself
+
+The log also shows the response code, the answer, the time it took to answer, and whether the answer was found
+in the DNS server's cache.`,
+		RunE: dc.run,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+	}
+	cmd.Flags().BoolVarP(&dc.follow, "follow", "f", false, "keep showing new queries until interrupted")
+	return cmd
+}
+
+func (dc *dnsLogCommand) run(cmd *cobra.Command, _ []string) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	stream := output.WantsStream(cmd)
+	formatted := output.WantsFormatted(cmd) && !stream
+	if dc.follow && formatted {
+		return errcat.User.New(`--follow cannot be combined with --output json or yaml, use "--output json-stream" instead`)
+	}
+	qs, err := daemon.GetUserClient(ctx).WatchDNSQueries(ctx, &daemonRpc.WatchDNSQueriesRequest{Follow: dc.follow})
+	if err != nil {
+		return err
+	}
+	var qjs []*dnsQueryJSON
+	out := cmd.OutOrStdout()
+	for {
+		q, err := qs.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				break
+			}
+			return err
+		}
+		switch {
+		case stream:
+			output.Object(ctx, dnsQueryToJSON(q), false)
+		case formatted:
+			qjs = append(qjs, dnsQueryToJSON(q))
+		default:
+			printDNSQuery(out, q)
+		}
+	}
+	if formatted {
+		output.Object(ctx, qjs, false)
+	}
+	return nil
+}
+
+func dnsQueryToJSON(q *daemonRpc.DNSQuery) *dnsQueryJSON {
+	return &dnsQueryJSON{
+		Time:     q.Time.AsTime(),
+		Name:     q.Name,
+		Type:     q.Type,
+		Decision: q.Decision,
+		Reason:   q.Reason,
+		Rcode:    q.Rcode,
+		Answer:   q.Answer,
+		Latency:  q.Latency.AsDuration(),
+		CacheHit: q.CacheHit,
+	}
+}
+
+// printDNSQuery prints the given query on one line. The columns have fixed widths because the queries are
+// printed as they arrive.
+func printDNSQuery(out io.Writer, q *daemonRpc.DNSQuery) {
+	cache := "miss"
+	if q.CacheHit {
+		cache = "hit"
+	}
+	fmt.Fprintf(out, "%s %-5s %-40s %-8s %-8s %9s %-4s",
+		q.Time.AsTime().Local().Format("15:04:05.000"),
+		q.Type,
+		q.Name,
+		q.Decision,
+		q.Rcode,
+		q.Latency.AsDuration().Round(time.Microsecond),
+		cache)
+	if q.Answer != "" {
+		fmt.Fprintf(out, " %s", q.Answer)
+	}
+	if q.Reason != "" {
+		fmt.Fprintf(out, " (%s)", q.Reason)
+	}
+	fmt.Fprintln(out)
+}
+
+// dnsCacheEntryJSON is the formatted output of one DNS cache entry.
+type dnsCacheEntryJSON struct {
+	Name   string        `json:"name"`
+	Type   string        `json:"type"`
+	Rcode  string        `json:"rcode"`
+	Answer string        `json:"answer,omitempty"`
+	Age    time.Duration `json:"age"`
+	TTL    time.Duration `json:"ttl"`
+}
+
+func dnsCache() *cobra.Command {
+	return &cobra.Command{
+		Use:  "cache",
+		Args: cobra.NoArgs,
+
+		Short: "List the entries of the DNS cache",
+		Long: `List the answers that the DNS server of the root daemon keeps in its cache, together with their age and
+the time until they expire. An answer is kept until the TTL of its records expires, but no longer than the time
+configured using the client.dns.cacheTTL and client.dns.negativeCacheTTL settings.`,
+		RunE: runDNSCache,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+	}
+}
+
+func runDNSCache(cmd *cobra.Command, _ []string) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	dc, err := daemon.GetUserClient(ctx).GetDNSCache(ctx, &emptypb.Empty{})
+	if err != nil {
+		return err
+	}
+	if output.WantsFormatted(cmd) {
+		ejs := make([]*dnsCacheEntryJSON, len(dc.Entries))
+		for i, e := range dc.Entries {
+			ejs[i] = &dnsCacheEntryJSON{
+				Name:   e.Name,
+				Type:   e.Type,
+				Rcode:  e.Rcode,
+				Answer: e.Answer,
+				Age:    e.Age.AsDuration(),
+				TTL:    e.Ttl.AsDuration(),
+			}
+		}
+		output.Object(ctx, ejs, false)
+		return nil
+	}
+	printDNSCache(cmd.OutOrStdout(), dc)
+	return nil
+}
+
+// printDNSCache prints the entries of the given cache as a table, followed by the cache statistics.
+func printDNSCache(out io.Writer, dc *daemonRpc.DNSCache) {
+	if len(dc.Entries) == 0 {
+		fmt.Fprintln(out, "The DNS cache is empty")
+	} else {
+		tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tTYPE\tRCODE\tAGE\tTTL\tANSWER")
+		for _, e := range dc.Entries {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				e.Name,
+				e.Type,
+				e.Rcode,
+				e.Age.AsDuration().Round(time.Second),
+				e.Ttl.AsDuration().Round(time.Second),
+				e.Answer)
+		}
+		_ = tw.Flush()
+	}
+	if st := dc.Stats; st != nil {
+		fmt.Fprintf(out, "\n%d entries, %d hits, %d misses\n", st.Entries, st.Hits, st.Misses)
+	}
+}
+
+func dnsFlush() *cobra.Command {
+	return &cobra.Command{
+		Use:  "flush [NAME]",
+		Args: cobra.MaximumNArgs(1),
+
+		Short: "Remove all entries, or the entries of one name, from the DNS cache",
+		Long: `Remove all entries, or the entries of one name, from the cache of the DNS server of the root daemon. This is
+useful when a service in the cluster has been recreated, and the cache still contains stale answers.`,
+		RunE: runDNSFlush,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+	}
+}
+
+func runDNSFlush(cmd *cobra.Command, args []string) error {
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	req := &daemonRpc.FlushDNSCacheRequest{}
+	if len(args) > 0 {
+		req.Name = args[0]
+	}
+	rsp, err := daemon.GetUserClient(ctx).FlushDNSCache(ctx, req)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Flushed %d entries from the DNS cache\n", rsp.Flushed)
+	return nil
+}
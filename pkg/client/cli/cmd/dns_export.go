@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	daemonRpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+// dnsExportTTL is the TTL of the records in an exported zone file.
+const dnsExportTTL = 60
+
+type dnsExportCommand struct {
+	format    string
+	namespace string
+	file      string
+	watch     bool
+}
+
+// dnsHostJSON is the formatted output of one exported name.
+type dnsHostJSON struct {
+	Name string       `json:"name"`
+	IPs  []netip.Addr `json:"ips"`
+}
+
+func dnsExport() *cobra.Command {
+	dc := &dnsExportCommand{}
+	cmd := &cobra.Command{
+		Use:  "export",
+		Args: cobra.NoArgs,
+
+		Short: "Write the names of the services and pods in the cluster as a hosts file or a zone file",
+		Long: `Write the names of the services and pods in the cluster, together with their addresses, as a hosts file or
+a DNS zone file. The names are obtained from the traffic-manager, and the addresses are looked up the same way
+that the DNS server of the root daemon looks them up, so virtual IPs are used when the service subnet is
+translated. This is useful for programs that don't use the resolver of the operating system. Pods are included
+when they have a name of their own, such as the pods of a StatefulSet.
+
+The names of all mapped namespaces are exported unless a namespace is given. Use --watch together with --file
+to rewrite the file each time the names in the cluster change, until the command is interrupted.`,
+		RunE: dc.run,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&dc.format, "format", "hosts", `the format of the export, "hosts" or "zone"`)
+	flags.StringVarP(&dc.namespace, "namespace", "n", "", "the namespace to export, instead of all mapped namespaces")
+	flags.StringVarP(&dc.file, "file", "f", "", "write the export to this file instead of to stdout")
+	flags.BoolVarP(&dc.watch, "watch", "w", false, "keep the file up to date until interrupted")
+	return cmd
+}
+
+func (dc *dnsExportCommand) run(cmd *cobra.Command, _ []string) error {
+	if dc.format != "hosts" && dc.format != "zone" {
+		return errcat.User.Newf(`invalid --format %q, must be "hosts" or "zone"`, dc.format)
+	}
+	if dc.watch && dc.file == "" {
+		return errcat.User.New("--watch requires --file")
+	}
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+	stream, err := daemon.GetUserClient(ctx).ExportDNS(ctx, &connector.ExportDNSRequest{Namespace: dc.namespace})
+	if err != nil {
+		return err
+	}
+	if !dc.watch {
+		rsp, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if dc.file == "" && output.WantsFormatted(cmd) {
+			hjs := make([]*dnsHostJSON, len(rsp.Hosts))
+			for i, h := range rsp.Hosts {
+				hjs[i] = &dnsHostJSON{Name: h.Name, IPs: hostIPs(h)}
+			}
+			output.Object(ctx, hjs, false)
+			return nil
+		}
+		if dc.file == "" {
+			dc.write(cmd.OutOrStdout(), rsp.Hosts)
+			return nil
+		}
+		return dc.writeFile(cmd, rsp.Hosts)
+	}
+
+	// A new response arrives each time the names in the cluster change.
+	var last []byte
+	for {
+		rsp, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		var buf bytes.Buffer
+		dc.write(&buf, rsp.Hosts)
+		if !bytes.Equal(buf.Bytes(), last) {
+			if err = dc.writeFile(cmd, rsp.Hosts); err != nil {
+				return err
+			}
+			last = buf.Bytes()
+		}
+	}
+}
+
+func (dc *dnsExportCommand) write(out io.Writer, hosts []*daemonRpc.HostAddresses) {
+	if dc.format == "zone" {
+		printZoneFile(out, hosts)
+	} else {
+		printHostsFile(out, hosts)
+	}
+}
+
+// writeFile replaces the file with the export. The export is written to a temporary file in the same
+// directory first, so that readers never see a partially written file.
+func (dc *dnsExportCommand) writeFile(cmd *cobra.Command, hosts []*daemonRpc.HostAddresses) error {
+	tmp, err := os.CreateTemp(filepath.Dir(dc.file), filepath.Base(dc.file)+".*")
+	if err != nil {
+		return err
+	}
+	dc.write(tmp, hosts)
+	err = tmp.Chmod(0o644)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dc.file)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Wrote %d names to %s\n", len(hosts), dc.file)
+	return nil
+}
+
+func hostIPs(h *daemonRpc.HostAddresses) []netip.Addr {
+	ips := make([]netip.Addr, 0, len(h.Ips))
+	for _, ip := range h.Ips {
+		if addr, ok := netip.AddrFromSlice(ip); ok {
+			ips = append(ips, addr.Unmap())
+		}
+	}
+	return ips
+}
+
+// hostAliases returns the fully qualified name followed by its shorter forms, e.g. "echo.default.svc"
+// and "echo.default" for "echo.default.svc.cluster.local".
+func hostAliases(name string) []string {
+	aliases := []string{name}
+	if i := strings.Index(name, ".svc."); i > 0 {
+		aliases = append(aliases, name[:i+4], name[:i])
+	}
+	return aliases
+}
+
+// printHostsFile prints the given hosts in the format of /etc/hosts, one line per address.
+func printHostsFile(out io.Writer, hosts []*daemonRpc.HostAddresses) {
+	fmt.Fprintln(out, "# Generated by telepresence dns export")
+	for _, h := range hosts {
+		aliases := strings.Join(hostAliases(h.Name), " ")
+		for _, ip := range hostIPs(h) {
+			fmt.Fprintf(out, "%s\t%s\n", ip, aliases)
+		}
+	}
+}
+
+// printZoneFile prints the given hosts as the A and AAAA records of a DNS zone file.
+func printZoneFile(out io.Writer, hosts []*daemonRpc.HostAddresses) {
+	fmt.Fprintln(out, "; Generated by telepresence dns export")
+	fmt.Fprintf(out, "$TTL %d\n", dnsExportTTL)
+	for _, h := range hosts {
+		for _, ip := range hostIPs(h) {
+			rrType := "A"
+			if ip.Is6() {
+				rrType = "AAAA"
+			}
+			fmt.Fprintf(out, "%s.\tIN\t%s\t%s\n", h.Name, rrType, ip)
+		}
+	}
+}
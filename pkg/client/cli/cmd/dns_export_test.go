@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	daemonRpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+)
+
+func testExportHosts() []*daemonRpc.HostAddresses {
+	return []*daemonRpc.HostAddresses{
+		{Name: "echo.default.svc.cluster.local", Ips: [][]byte{{10, 0, 0, 1}}},
+		{Name: "dual.default.svc.cluster.local", Ips: [][]byte{{10, 0, 0, 2}, net.ParseIP("fd00::2")}},
+	}
+}
+
+func Test_hostAliases(t *testing.T) {
+	assert.Equal(t,
+		[]string{"echo.default.svc.cluster.local", "echo.default.svc", "echo.default"},
+		hostAliases("echo.default.svc.cluster.local"))
+	assert.Equal(t, []string{"echo.example.com"}, hostAliases("echo.example.com"))
+}
+
+func Test_printHostsFile(t *testing.T) {
+	var buf bytes.Buffer
+	printHostsFile(&buf, testExportHosts())
+	assert.Equal(t, `# Generated by telepresence dns export
+10.0.0.1	echo.default.svc.cluster.local echo.default.svc echo.default
+10.0.0.2	dual.default.svc.cluster.local dual.default.svc dual.default
+fd00::2	dual.default.svc.cluster.local dual.default.svc dual.default
+`, buf.String())
+}
+
+func Test_printZoneFile(t *testing.T) {
+	var buf bytes.Buffer
+	printZoneFile(&buf, testExportHosts())
+	assert.Equal(t, `; Generated by telepresence dns export
+$TTL 60
+echo.default.svc.cluster.local.	IN	A	10.0.0.1
+dual.default.svc.cluster.local.	IN	A	10.0.0.2
+dual.default.svc.cluster.local.	IN	AAAA	fd00::2
+`, buf.String())
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -25,6 +26,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/flags"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sworkload"
 	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
 )
 
@@ -145,7 +147,8 @@ func (i *genYAMLCommand) loadWorkload(ctx context.Context) (k8sapi.Workload, err
 	}
 
 	scheme := runtime.NewScheme()
-	scheme.AddKnownTypes(schema.GroupVersion{Group: apps.GroupName, Version: "v1"}, &apps.StatefulSet{}, &apps.Deployment{}, &apps.ReplicaSet{})
+	scheme.AddKnownTypes(schema.GroupVersion{Group: apps.GroupName, Version: "v1"}, &apps.StatefulSet{}, &apps.Deployment{}, &apps.ReplicaSet{}, &apps.DaemonSet{})
+	scheme.AddKnownTypes(schema.GroupVersion{Group: batch.GroupName, Version: "v1"}, &batch.Job{}, &batch.CronJob{})
 	codecFactory := serializer.NewCodecFactory(scheme)
 	deserializer := codecFactory.UniversalDeserializer()
 
@@ -153,9 +156,9 @@ func (i *genYAMLCommand) loadWorkload(ctx context.Context) (k8sapi.Workload, err
 	if err != nil {
 		return nil, errcat.User.Newf("unable to parse yaml in %s: %w", i.inputFile, err)
 	}
-	wl, err := k8sapi.WrapWorkload(obj)
+	wl, err := k8sworkload.Wrap(obj)
 	if err != nil {
-		return nil, errcat.User.Newf("unexpected object of kind %s; please pass in a Deployment, ReplicaSet, or StatefulSet", kind)
+		return nil, errcat.User.Newf("unexpected object of kind %s; please pass in a Deployment, ReplicaSet, StatefulSet, DaemonSet, Job, or CronJob", kind)
 	}
 	if wl.GetNamespace() == "" {
 		if d, ok := k8sapi.DeploymentImpl(wl); ok {
@@ -164,6 +167,12 @@ func (i *genYAMLCommand) loadWorkload(ctx context.Context) (k8sapi.Workload, err
 			r.Namespace = i.namespace
 		} else if s, ok := k8sapi.StatefulSetImpl(wl); ok {
 			s.Namespace = i.namespace
+		} else if ds, ok := k8sworkload.DaemonSetImpl(wl); ok {
+			ds.Namespace = i.namespace
+		} else if j, ok := k8sworkload.JobImpl(wl); ok {
+			j.Namespace = i.namespace
+		} else if cj, ok := k8sworkload.CronJobImpl(wl); ok {
+			cj.Namespace = i.namespace
 		}
 	}
 	return wl, nil
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/intercept"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+func interceptHandover() *cobra.Command {
+	var toClient string
+	cmd := &cobra.Command{
+		Use:  "handover [flags] <intercept_name> --to <client>",
+		Args: cobra.ExactArgs(1),
+
+		Short: "Hand over an active intercept to another client",
+		Long: `Hand over an active intercept to another client that is connected to the same namespace. The
+intercept remains active during the handover, so the intercepted traffic never returns to the
+intercepted container. The client is identified by its name (user@hostname), or by its session ID
+when the name isn't unique.`,
+		Annotations: map[string]string{
+			ann.Session: ann.Required,
+		},
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if toClient == "" {
+				return errcat.User.New("the client to hand over to must be given using --to")
+			}
+			if err := connect.InitCommand(cmd); err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			name := strings.TrimSpace(args[0])
+			ii, err := daemon.GetUserClient(ctx).HandoverIntercept(ctx, &manager.HandoverInterceptRequest{Name: name, ToClient: toClient})
+			if err != nil {
+				return intercept.RPCUserError(err)
+			}
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "Intercept %s handed over to %s\n", ii.Spec.Name, ii.Spec.Client)
+			return err
+		},
+	}
+	cmd.Flags().StringVar(&toClient, "to", "", "Name or session ID of the client to hand over the intercept to")
+	return cmd
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/ann"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/intercept"
+)
+
+func ingestCmd() *cobra.Command {
+	ic := &intercept.Command{}
+	cmd := &cobra.Command{
+		Use:   "ingest [flags] <workload> [-- <command with arguments...>]",
+		Args:  cobra.MinimumNArgs(1),
+		Short: "Ingest a container",
+		Long: `Ingest a container of a workload, making its environment and volume mounts available locally
+without intercepting any of its traffic.`,
+		Annotations: map[string]string{
+			ann.Session:           ann.Required,
+			ann.UpdateCheckFormat: ann.Tel2,
+		},
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		RunE:              ic.RunIngest,
+		ValidArgsFunction: ic.ValidArgs,
+	}
+	ic.AddIngestFlags(cmd)
+	return cmd
+}
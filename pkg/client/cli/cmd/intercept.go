@@ -23,5 +23,6 @@ func interceptCmd() *cobra.Command {
 		ValidArgsFunction: ic.ValidArgs,
 	}
 	ic.AddFlags(cmd)
+	cmd.AddCommand(interceptHandover())
 	return cmd
 }
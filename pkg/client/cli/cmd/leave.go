@@ -22,10 +22,10 @@ import (
 
 func leave() *cobra.Command {
 	return &cobra.Command{
-		Use:  "leave [flags] <intercept_name>",
+		Use:  "leave [flags] <intercept_name>|<workload>[/<container>]",
 		Args: cobra.ExactArgs(1),
 
-		Short: "Remove existing intercept",
+		Short: "Remove existing intercept or ingest",
 		Annotations: map[string]string{
 			ann.Session: ann.Required,
 		},
@@ -61,6 +61,12 @@ func leave() *cobra.Command {
 						completions = append(completions, name)
 					}
 				}
+				for _, ig := range intercept.IngestInfos {
+					name := ig.Workload + "/" + ig.Container
+					if strings.HasPrefix(name, toComplete) {
+						completions = append(completions, name)
+					}
+				}
 			}
 			return completions, shellCompDir
 		},
@@ -72,6 +78,9 @@ func removeIntercept(ctx context.Context, name string) error {
 	ic, err := userD.GetIntercept(ctx, &manager.GetInterceptRequest{Name: name})
 	if err != nil {
 		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+			if found, err := leaveIngest(ctx, name); found || err != nil {
+				return err
+			}
 			// User probably misspelled the name of the intercept
 			return errcat.User.Newf("Intercept named %q not found", name)
 		}
@@ -94,3 +103,17 @@ func removeIntercept(ctx context.Context, name string) error {
 	}
 	return err
 }
+
+// leaveIngest leaves the ingest with the given name, which is in the form <workload>[/<container>]. The
+// returned boolean is false if no such ingest exists.
+func leaveIngest(ctx context.Context, name string) (bool, error) {
+	workload, container, _ := strings.Cut(name, "/")
+	_, err := daemon.GetUserClient(ctx).LeaveIngest(ctx, &connector.IngestIdentifier{WorkloadName: workload, ContainerName: container})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return false, nil
+		}
+		return true, intercept.RPCUserError(err)
+	}
+	return true, nil
+}
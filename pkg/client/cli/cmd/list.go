@@ -41,14 +41,14 @@ func list() *cobra.Command {
 		Use:  "list",
 		Args: cobra.NoArgs,
 
-		Short: "List current intercepts",
+		Short: "List current intercepts and ingests",
 		RunE:  s.list,
 		Annotations: map[string]string{
 			ann.Session: ann.Required,
 		},
 	}
 	flags := cmd.Flags()
-	flags.BoolVarP(&s.onlyIntercepts, "intercepts", "i", false, "intercepts only")
+	flags.BoolVarP(&s.onlyIntercepts, "intercepts", "i", false, "intercepts and ingests only")
 	flags.BoolVarP(&s.onlyAgents, "agents", "a", false, "with installed agents only")
 	flags.BoolVarP(&s.onlyInterceptable, "only-interceptable", "o", true, "interceptable workloads only")
 	flags.BoolVar(&s.debug, "debug", false, "include debugging information")
@@ -172,7 +172,14 @@ func (s *listCommand) printList(ctx context.Context, workloads []*connector.Work
 
 	state := func(workload *connector.WorkloadInfo) string {
 		if iis := workload.InterceptInfos; len(iis) > 0 {
-			return intercept.DescribeIntercepts(ctx, iis, "", s.debug)
+			desc := intercept.DescribeIntercepts(ctx, iis, "", s.debug)
+			if gis := workload.IngestInfos; len(gis) > 0 {
+				desc += "\n" + intercept.DescribeIngests(ctx, gis, "")
+			}
+			return desc
+		}
+		if gis := workload.IngestInfos; len(gis) > 0 {
+			return intercept.DescribeIngests(ctx, gis, "")
 		}
 		ai := workload.Sidecar
 		if workload.NotInterceptableReason == "Progressing" {
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/recording"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+// replayResponseTimeout is how long a replayed connection waits for the rest of the response from
+// the service once all recorded client data has been sent.
+const replayResponseTimeout = 2 * time.Second
+
+type replayCommand struct {
+	address string
+	port    uint16
+	noDelay bool
+}
+
+func replay() *cobra.Command {
+	rc := &replayCommand{}
+	cmd := &cobra.Command{
+		Use:  "replay <recording>",
+		Args: cobra.ExactArgs(1),
+
+		Short: "Replay connections recorded using telepresence intercept --record",
+		Long: `Replay the connections of a recording, created using "telepresence intercept --record", against a local
+service. The data that the intercepted clients sent is sent again, using the recorded timing, and the responses
+from the service are discarded.`,
+		RunE: rc.run,
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&rc.address, "address", "127.0.0.1", "Address of the service that the connections are replayed against")
+	flags.Uint16VarP(&rc.port, "port", "p", 0, "Port of the service that the connections are replayed against. Defaults to the recorded port")
+	flags.BoolVar(&rc.noDelay, "no-delay", false, "Replay connections and data as fast as possible, instead of using the recorded timing")
+	return cmd
+}
+
+// replayResult is the outcome of replaying one connection.
+type replayResult struct {
+	conn     *recording.Conn
+	sent     int
+	received int
+	err      error
+}
+
+func (rc *replayCommand) run(cmd *cobra.Command, args []string) error {
+	rec, err := recording.ReadFile(args[0])
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return errcat.User.New(err)
+		}
+		return err
+	}
+	if iputil.Parse(rc.address) == nil {
+		return errcat.User.Newf("--address %s is not a valid IP address", rc.address)
+	}
+	if rec.Dropped > 0 {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %d events were dropped when intercept %s was recorded, so some connections may lack data or not be closed\n",
+			rec.Dropped, rec.Intercept)
+	}
+	if len(rec.Conns) == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "Recording of intercept %s contains no connections\n", rec.Intercept)
+		return nil
+	}
+
+	ctx := cmd.Context()
+	results := make([]replayResult, len(rec.Conns))
+	start := time.Now()
+	first := rec.Conns[0].Opened
+	wg := sync.WaitGroup{}
+	wg.Add(len(rec.Conns))
+	for i, c := range rec.Conns {
+		if !rc.noDelay {
+			if err := sleepUntil(ctx, start.Add(c.Opened.Sub(first))); err != nil {
+				return err
+			}
+		}
+		go func() {
+			defer wg.Done()
+			results[i] = rc.replayConn(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	out := cmd.OutOrStdout()
+	failed := 0
+	for _, r := range results {
+		if r.err != nil {
+			failed++
+			fmt.Fprintf(out, "%s: %v\n", r.conn.ID, r.err)
+			continue
+		}
+		fmt.Fprintf(out, "%s: sent %d bytes, received %d bytes\n", r.conn.ID, r.sent, r.received)
+	}
+	if failed > 0 {
+		return errcat.User.Newf("%d of %d connections failed", failed, len(results))
+	}
+	return nil
+}
+
+// replayConn dials the service and sends the recorded client data of the given connection to it.
+func (rc *replayCommand) replayConn(ctx context.Context, c *recording.Conn) (r replayResult) {
+	r.conn = c
+	port := rc.port
+	if port == 0 {
+		_, ps, err := net.SplitHostPort(c.Destination)
+		if err != nil {
+			r.err = err
+			return r
+		}
+		p, err := strconv.ParseUint(ps, 10, 16)
+		if err != nil {
+			r.err = err
+			return r
+		}
+		port = uint16(p)
+	}
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, c.Protocol, iputil.JoinHostPort(rc.address, port))
+	if err != nil {
+		r.err = err
+		return r
+	}
+	defer conn.Close()
+
+	received := 0
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		buf := make([]byte, 0x8000)
+		for {
+			n, err := conn.Read(buf)
+			received += n
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	start := time.Now()
+	for _, ev := range c.Events {
+		if !rc.noDelay {
+			if err = sleepUntil(ctx, start.Add(ev.Time.Sub(c.Opened))); err != nil {
+				r.err = err
+				return r
+			}
+		}
+		if ev.Type != recording.Client {
+			continue
+		}
+		n, err := conn.Write(ev.Data)
+		r.sent += n
+		if err != nil {
+			r.err = err
+			return r
+		}
+	}
+
+	// Let the service know that nothing more will be sent, and wait for its response.
+	if hc, ok := conn.(interface{ CloseWrite() error }); ok {
+		_ = hc.CloseWrite()
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(replayResponseTimeout))
+	<-readDone
+	r.received = received
+	return r
+}
+
+func sleepUntil(ctx context.Context, t time.Time) error {
+	d := time.Until(t)
+	if d <= 0 {
+		return nil
+	}
+	tm := time.NewTimer(d)
+	defer tm.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-tm.C:
+		return nil
+	}
+}
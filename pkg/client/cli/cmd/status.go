@@ -27,6 +27,7 @@ type StatusInfo struct {
 	RootDaemon     RootDaemonStatus     `json:"root_daemon"`
 	UserDaemon     UserDaemonStatus     `json:"user_daemon"`
 	TrafficManager TrafficManagerStatus `json:"traffic_manager"`
+	TunnelStats    TunnelStatsStatus    `json:"tunnel_stats,omitempty"`
 }
 
 type MultiConnectStatusInfo struct {
@@ -45,9 +46,17 @@ type RootDaemonStatus struct {
 	Version    string           `json:"version,omitempty"`
 	APIVersion int32            `json:"api_version,omitempty"`
 	DNS        *client.DNSSnake `json:"dns,omitempty"`
+	DNSCache   *DNSCacheStatus  `json:"dns_cache,omitempty"`
 	*client.RoutingSnake
 }
 
+// DNSCacheStatus is the status of the DNS cache of the root daemon.
+type DNSCacheStatus struct {
+	Entries int32  `json:"entries"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+}
+
 type UserDaemonStatus struct {
 	Running           bool                     `json:"running,omitempty"`
 	InDocker          bool                     `json:"in_docker,omitempty"`
@@ -66,13 +75,16 @@ type UserDaemonStatus struct {
 	Namespace         string                   `json:"namespace,omitempty"`
 	ManagerNamespace  string                   `json:"manager_namespace,omitempty"`
 	MappedNamespaces  []string                 `json:"mapped_namespaces,omitempty"`
+	ProxyAddress      string                   `json:"proxy_address,omitempty"`
 	Intercepts        []ConnectStatusIntercept `json:"intercepts,omitempty"`
+	Ingests           []ConnectStatusIngest    `json:"ingests,omitempty"`
 	versionName       string
 }
 
 type ContainerizedDaemonStatus struct {
 	*UserDaemonStatus
-	DNS *client.DNSSnake `json:"dns,omitempty"`
+	DNS      *client.DNSSnake `json:"dns,omitempty"`
+	DNSCache *DNSCacheStatus  `json:"dns_cache,omitempty"`
 	*client.RoutingSnake
 }
 
@@ -88,9 +100,15 @@ type ConnectStatusIntercept struct {
 	Client string `json:"client,omitempty"`
 }
 
+type ConnectStatusIngest struct {
+	Name       string `json:"name,omitempty"`
+	MountPoint string `json:"mount_point,omitempty"`
+}
+
 const (
 	multiDaemonFlag = "multi-daemon"
 	jsonFlag        = "json"
+	statsFlag       = "stats"
 )
 
 func statusCmd() *cobra.Command {
@@ -107,6 +125,7 @@ func statusCmd() *cobra.Command {
 	}
 	flags := cmd.Flags()
 	flags.Bool(multiDaemonFlag, false, "always use multi-daemon output format, even if there's only one daemon connected")
+	flags.Bool(statsFlag, false, "include the latency and throughput statistics of the tunneled connections, per destination")
 	flags.BoolP(jsonFlag, "j", false, "output as json object")
 	flags.Lookup(jsonFlag).Hidden = true
 	return cmd
@@ -137,6 +156,7 @@ func run(cmd *cobra.Command, _ []string) error {
 		}
 	}
 	ctx := cmd.Context()
+	withStats, _ := cmd.Flags().GetBool(statsFlag)
 
 	var sis []ioutil.WriterTos
 	if len(mdErr) > 0 {
@@ -146,14 +166,14 @@ func run(cmd *cobra.Command, _ []string) error {
 			if err != nil {
 				return err
 			}
-			sis[i], err = getStatusInfo(udCtx, info)
+			sis[i], err = getStatusInfo(udCtx, info, withStats)
 			_ = daemon.GetUserClient(udCtx).Close()
 			if err != nil {
 				return err
 			}
 		}
 	} else {
-		si, err := getStatusInfo(ctx, nil)
+		si, err := getStatusInfo(ctx, nil, withStats)
 		if err != nil {
 			return err
 		}
@@ -205,17 +225,24 @@ var GetTrafficManagerStatusExtras = func(context.Context, daemon.UserClient) iou
 }
 
 func (s *StatusInfo) WriterTos() []io.WriterTo {
+	var wts []io.WriterTo
 	if s.UserDaemon.InDocker {
-		return []io.WriterTo{
+		wts = []io.WriterTo{
 			&ContainerizedDaemonStatus{
 				UserDaemonStatus: &s.UserDaemon,
 				DNS:              s.RootDaemon.DNS,
+				DNSCache:         s.RootDaemon.DNSCache,
 				RoutingSnake:     s.RootDaemon.RoutingSnake,
 			},
 			&s.TrafficManager,
 		}
+	} else {
+		wts = []io.WriterTo{&s.UserDaemon, &s.RootDaemon, &s.TrafficManager}
 	}
-	return []io.WriterTo{&s.UserDaemon, &s.RootDaemon, &s.TrafficManager}
+	if s.TunnelStats != nil {
+		wts = append(wts, s.TunnelStats)
+	}
+	return wts
 }
 
 func (s *StatusInfo) MarshalJSON() ([]byte, error) {
@@ -223,24 +250,31 @@ func (s *StatusInfo) MarshalJSON() ([]byte, error) {
 }
 
 func (s *StatusInfo) toMap() map[string]any {
+	var m map[string]any
 	if s.UserDaemon.InDocker {
-		return map[string]any{
+		m = map[string]any{
 			"daemon": &ContainerizedDaemonStatus{
 				UserDaemonStatus: &s.UserDaemon,
 				DNS:              s.RootDaemon.DNS,
+				DNSCache:         s.RootDaemon.DNSCache,
 				RoutingSnake:     s.RootDaemon.RoutingSnake,
 			},
 			"traffic_manager": &s.TrafficManager,
 		}
+	} else {
+		m = map[string]any{
+			"user_daemon":     &s.UserDaemon,
+			"root_daemon":     &s.RootDaemon,
+			"traffic_manager": &s.TrafficManager,
+		}
 	}
-	return map[string]any{
-		"user_daemon":     &s.UserDaemon,
-		"root_daemon":     &s.RootDaemon,
-		"traffic_manager": &s.TrafficManager,
+	if s.TunnelStats != nil {
+		m["tunnel_stats"] = s.TunnelStats
 	}
+	return m
 }
 
-func getStatusInfo(ctx context.Context, di *daemon.Info) (*StatusInfo, error) {
+func getStatusInfo(ctx context.Context, di *daemon.Info, withStats bool) (*StatusInfo, error) {
 	wt := &StatusInfo{}
 	userD := daemon.GetUserClient(ctx)
 	if userD == nil {
@@ -289,9 +323,23 @@ func getStatusInfo(ctx context.Context, di *daemon.Info) (*StatusInfo, error) {
 				Client: icept.Spec.Client,
 			})
 		}
+		for _, ig := range status.GetIngests() {
+			us.Ingests = append(us.Ingests, ConnectStatusIngest{
+				Name:       ig.Workload + "/" + ig.Container,
+				MountPoint: ig.ClientMountPoint,
+			})
+		}
 		us.Namespace = status.Namespace
 		us.ManagerNamespace = status.ManagerNamespace
 		us.MappedNamespaces = status.MappedNamespaces
+		us.ProxyAddress = status.ProxyAddress
+		if withStats {
+			ts, err := userD.GetTunnelStats(ctx, &empty.Empty{})
+			if err != nil {
+				return nil, err
+			}
+			wt.TunnelStats = tunnelStatsStatus(ts)
+		}
 	case connector.ConnectInfo_UNAUTHORIZED:
 		us.Status = "Not authorized to connect"
 		us.Error = status.ErrorText
@@ -327,6 +375,9 @@ func getStatusInfo(ctx context.Context, di *daemon.Info) (*StatusInfo, error) {
 				rs.RoutingSnake = rootCfg.Routing().ToSnake()
 			}
 		}
+		if dc := rStatus.DnsCache; dc != nil {
+			rs.DNSCache = &DNSCacheStatus{Entries: dc.Entries, Hits: dc.Hits, Misses: dc.Misses}
+		}
 	}
 
 	if mv := status.ManagerVersion; mv != nil {
@@ -425,6 +476,9 @@ func (cs *ContainerizedDaemonStatus) WriteTo(out io.Writer) (int64, error) {
 		if cs.DNS != nil {
 			printDNS(kvf, cs.DNS)
 		}
+		if cs.DNSCache != nil {
+			printDNSCacheStatus(kvf, cs.DNSCache)
+		}
 		if cs.RoutingSnake != nil {
 			printRouting(kvf, cs.RoutingSnake)
 		}
@@ -446,6 +500,9 @@ func (ds *RootDaemonStatus) WriteTo(out io.Writer) (int64, error) {
 		if ds.DNS != nil {
 			printDNS(kvf, ds.DNS)
 		}
+		if ds.DNSCache != nil {
+			printDNSCacheStatus(kvf, ds.DNSCache)
+		}
 		if ds.RoutingSnake != nil {
 			printRouting(kvf, ds.RoutingSnake)
 		}
@@ -481,9 +538,30 @@ func printDNS(kvf *ioutil.KeyValueFormatter, d *client.DNSSnake) {
 		dnsKvf.Add("Mappings", "\n"+mappingsKvf.String())
 	}
 	dnsKvf.Add("Timeout", fmt.Sprintf("%v", d.LookupTimeout))
+	if d.CacheTTL != 0 {
+		dnsKvf.Add("Cache TTL", fmt.Sprintf("%v", d.CacheTTL))
+	}
+	if d.NegativeCacheTTL != 0 {
+		dnsKvf.Add("Negative cache TTL", fmt.Sprintf("%v", d.NegativeCacheTTL))
+	}
+	if len(d.Listeners) > 0 {
+		listenersKvf := ioutil.DefaultKeyValueFormatter()
+		for _, l := range d.Listeners {
+			allowed := "loopback"
+			if len(l.AllowedSubnets) > 0 {
+				allowed = fmt.Sprintf("%v", l.AllowedSubnets)
+			}
+			listenersKvf.Add(l.Address.String(), allowed)
+		}
+		dnsKvf.Add("Listeners", "\n"+listenersKvf.String())
+	}
 	kvf.Add("DNS", "\n"+dnsKvf.String())
 }
 
+func printDNSCacheStatus(kvf *ioutil.KeyValueFormatter, c *DNSCacheStatus) {
+	kvf.Add("DNS cache", fmt.Sprintf("%d entries, %d hits, %d misses", c.Entries, c.Hits, c.Misses))
+}
+
 func printRouting(kvf *ioutil.KeyValueFormatter, r *client.RoutingSnake) {
 	printSubnets := func(title string, subnets []netip.Prefix) {
 		if len(subnets) == 0 {
@@ -535,6 +613,9 @@ func (cs *UserDaemonStatus) print(kvf *ioutil.KeyValueFormatter) {
 	if len(cs.MappedNamespaces) > 0 {
 		kvf.Add("Mapped namespaces", fmt.Sprintf("%v", cs.MappedNamespaces))
 	}
+	if cs.ProxyAddress != "" {
+		kvf.Add("Proxy address", cs.ProxyAddress)
+	}
 	if cs.Hostname != "" {
 		kvf.Add("Hostname", cs.Hostname)
 	}
@@ -552,6 +633,18 @@ func (cs *UserDaemonStatus) print(kvf *ioutil.KeyValueFormatter) {
 		subKvf.Println(out)
 	}
 	kvf.Add("Intercepts", out.String())
+
+	out = &strings.Builder{}
+	fmt.Fprintf(out, "%d total\n", len(cs.Ingests))
+	if len(cs.Ingests) > 0 {
+		subKvf := ioutil.DefaultKeyValueFormatter()
+		subKvf.Indent = "  "
+		for _, ingest := range cs.Ingests {
+			subKvf.Add(ingest.Name, ingest.MountPoint)
+		}
+		subKvf.Println(out)
+	}
+	kvf.Add("Ingests", out.String())
 }
 
 func (ts *TrafficManagerStatus) MarshalJSON() ([]byte, error) {
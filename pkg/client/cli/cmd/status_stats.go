@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/tunnelstats"
+)
+
+// TunnelStatsSummary summarizes the statistics of a histogram using the count and some quantiles of its
+// observations.
+type TunnelStatsSummary struct {
+	Count uint64  `json:"count"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+}
+
+// DestinationStatus is the "telepresence status --stats" output for one destination.
+type DestinationStatus struct {
+	Daemon        string             `json:"daemon"`
+	Destination   string             `json:"destination"`
+	Connections   uint64             `json:"connections"`
+	BytesSent     uint64             `json:"bytes_sent"`
+	BytesReceived uint64             `json:"bytes_received"`
+	Roundtrip     TunnelStatsSummary `json:"roundtrip_seconds"`
+	Throughput    TunnelStatsSummary `json:"throughput_bytes_per_second"`
+}
+
+type TunnelStatsStatus []*DestinationStatus
+
+func summarize(h *daemon.Histogram) TunnelStatsSummary {
+	var count uint64
+	for _, c := range h.GetCounts() {
+		count += c
+	}
+	return TunnelStatsSummary{
+		Count: count,
+		P50:   tunnelstats.Quantile(h, 0.5),
+		P90:   tunnelstats.Quantile(h, 0.9),
+		P99:   tunnelstats.Quantile(h, 0.99),
+	}
+}
+
+func tunnelStatsStatus(ts *daemon.TunnelStats) TunnelStatsStatus {
+	dss := make(TunnelStatsStatus, len(ts.Destinations))
+	for i, ds := range ts.Destinations {
+		dss[i] = &DestinationStatus{
+			Daemon:        ds.Daemon,
+			Destination:   ds.Destination,
+			Connections:   ds.Connections,
+			BytesSent:     ds.BytesSent,
+			BytesReceived: ds.BytesReceived,
+			Roundtrip:     summarize(ds.Roundtrip),
+			Throughput:    summarize(ds.Throughput),
+		}
+	}
+	return dss
+}
+
+func (ts TunnelStatsStatus) WriteTo(out io.Writer) (int64, error) {
+	sb := &strings.Builder{}
+	sb.WriteString("Tunnel statistics:\n")
+	if len(ts) == 0 {
+		sb.WriteString("  No connections\n")
+	} else {
+		tw := tabwriter.NewWriter(sb, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "  DAEMON\tDESTINATION\tCONNECTIONS\tSENT\tRECEIVED\tROUNDTRIP P50/P99\tTHROUGHPUT P50/P99")
+		for _, ds := range ts {
+			fmt.Fprintf(tw, "  %s\t%s\t%d\t%d\t%d\t%s\t%s\n",
+				ds.Daemon,
+				ds.Destination,
+				ds.Connections,
+				ds.BytesSent,
+				ds.BytesReceived,
+				formatRoundtrips(ds.Roundtrip),
+				formatThroughputs(ds.Throughput))
+		}
+		_ = tw.Flush()
+	}
+	sb.WriteByte('\n')
+	n, err := io.WriteString(out, sb.String())
+	return int64(n), err
+}
+
+func formatRoundtrips(s TunnelStatsSummary) string {
+	if s.Count == 0 {
+		return "-"
+	}
+	d := func(v float64) time.Duration {
+		return time.Duration(v * float64(time.Second)).Round(10 * time.Microsecond)
+	}
+	return fmt.Sprintf("%s/%s", d(s.P50), d(s.P99))
+}
+
+func formatThroughputs(s TunnelStatsSummary) string {
+	if s.Count == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%s/%s", formatRate(s.P50), formatRate(s.P99))
+}
+
+// formatRate formats the given number of bytes per second using binary prefixes.
+func formatRate(v float64) string {
+	for _, unit := range []string{"B/s", "KiB/s", "MiB/s"} {
+		if v < 1024 {
+			return fmt.Sprintf("%.1f%s", v, unit)
+		}
+		v /= 1024
+	}
+	return fmt.Sprintf("%.1fGiB/s", v)
+}
@@ -134,8 +134,8 @@ func OnlySubcommands(cmd *cobra.Command, args []string) error {
 
 func WithSubCommands(ctx context.Context) context.Context {
 	return MergeSubCommands(ctx,
-		configCmd(), connectCmd(), currentClusterId(), gatherLogs(), gatherTraces(), genYAML(), helmCmd(),
-		interceptCmd(), kubeauthCmd(), leave(), list(), listContexts(), listNamespaces(), loglevel(), quit(), statusCmd(),
+		captureCmd(), configCmd(), connectCmd(), connections(), currentClusterId(), dnsCmd(), gatherLogs(), gatherTraces(), genYAML(), helmCmd(), ingestCmd(),
+		interceptCmd(), kubeauthCmd(), leave(), list(), listContexts(), listNamespaces(), loglevel(), quit(), replay(), statusCmd(),
 		testVPN(), uninstall(), uploadTraces(), version(), listNamespaces(), listContexts(),
 	)
 }
@@ -335,7 +335,7 @@ func EnsureUserDaemon(ctx context.Context, required bool) (rc context.Context, e
 	defer func() {
 		if err == nil && required && !daemon.GetUserClient(rc).Containerized() {
 			// The RootDaemon must be started if the UserDaemon was started
-			err = ensureRootDaemonRunning(ctx)
+			err = ensureRootDaemonRunning(rc)
 		}
 	}()
 
@@ -431,6 +431,9 @@ func connectSession(ctx context.Context, useLine string, userD daemon.UserClient
 		switch ci.Error {
 		case connector.ConnectInfo_UNSPECIFIED:
 			ioutil.Printf(output.Info(ctx), "Connected to context %s, namespace %s (%s)\n", ci.ClusterContext, ci.Namespace, ci.ClusterServer)
+			if ci.ProxyAddress != "" {
+				ioutil.Printf(output.Info(ctx), "SOCKS5 and HTTP CONNECT proxy listening on %s\n", ci.ProxyAddress)
+			}
 			err := warnMngrVersion(ci)
 			if err != nil {
 				dlog.Error(ctx, err)
@@ -56,8 +56,8 @@ func launchDaemon(ctx context.Context, cr *daemon.Request) error {
 // ensureRootDaemonRunning ensures that the daemon is running.
 func ensureRootDaemonRunning(ctx context.Context) error {
 	cr := daemon.GetRequest(ctx)
-	if cr != nil && cr.Docker {
-		// Never start root daemon when connecting using a docker container.
+	if cr != nil && (cr.Docker || cr.ProxyOnly) {
+		// Never start root daemon when connecting using a docker container, or when only a proxy is used.
 		return nil
 	}
 	if addr := client.GetEnv(ctx).UserDaemonAddress; addr != "" {
@@ -68,6 +68,12 @@ func ensureRootDaemonRunning(ctx context.Context) error {
 	if err != nil || running {
 		return err
 	}
+	if userD := daemon.GetUserClient(ctx); userD != nil {
+		// A session that was connected using --proxy-only doesn't need a root daemon.
+		if ci, err := userD.Status(ctx, &emptypb.Empty{}); err == nil && ci.ProxyAddress != "" {
+			return nil
+		}
+	}
 	if err = launchDaemon(ctx, cr); err != nil {
 		return fmt.Errorf("failed to launch the daemon service: %w", err)
 	}
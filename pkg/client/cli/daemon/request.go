@@ -23,7 +23,9 @@ import (
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/connector"
 	"github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/global"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/proxy"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
 	"github.com/telepresenceio/telepresence/v2/pkg/slice"
 )
@@ -52,6 +54,9 @@ type Request struct {
 
 	// proxyVia holds the string version for the --proxy-via flag values.
 	proxyVia []string
+
+	// dnsMappings holds the string version for the --dns-mapping flag values.
+	dnsMappings []string
 }
 
 type CobraRequest struct {
@@ -85,10 +90,23 @@ func InitRequest(cmd *cobra.Command) *CobraRequest {
 		"proxy-via", nil, ``+
 			`Locally translate cluster DNS responses matching CIDR to virtual IPs that are routed (with reverse `+
 			`translation) via WORKLOAD. Must be in the form CIDR=WORKLOAD. CIDR can be substituted for the symblic name "service", "pods", "also", or "all".`)
+	nwFlags.StringArrayVar(&cr.dnsMappings,
+		"dns-mapping", nil, ``+
+			`Resolve NAME as ALIAS, where ALIAS is a host name or an IP address. Must be in the form NAME=ALIAS. `+
+			`NAME can be a wildcard like "*.prod.internal", and "$1" in ALIAS is then replaced with the labels `+
+			`that the wildcard matched. Can be repeated`)
 	nwFlags.StringSliceVar(&cr.AllowConflictingSubnets,
 		"allow-conflicting-subnets", nil, ``+
 			`Comma separated list of CIDR that will be allowed to conflict with local subnets`)
 
+	nwFlags.BoolVar(&cr.ProxyOnly,
+		"proxy-only", false, ``+
+			`Don't start the root daemon or create a virtual network interface. Instead, serve a SOCKS5 and HTTP CONNECT `+
+			`proxy that tunnels connections to the cluster and resolves host names using the cluster DNS`)
+	nwFlags.StringVar(&cr.ProxyAddress,
+		"proxy-address", proxy.DefaultAddress, ``+
+			`The address that the proxy listens on when using --proxy-only`)
+
 	// Docker flags
 	nwFlags.Bool(global.FlagDocker, false, "Start, or connect to, daemon in a docker container")
 	nwFlags.StringArrayVar(&cr.ExposedPorts,
@@ -146,6 +164,16 @@ func (cr *CobraRequest) CommitFlags(cmd *cobra.Command) error {
 	if err != nil {
 		return errcat.User.New(err)
 	}
+	if cr.ProxyOnly {
+		if cr.Docker {
+			return errcat.User.New("--proxy-only cannot be combined with --docker")
+		}
+	} else {
+		if f := cmd.Flag("proxy-address"); f != nil && f.Changed {
+			return errcat.User.New("--proxy-address can only be used together with --proxy-only")
+		}
+		cr.ProxyAddress = ""
+	}
 	ctx, err := cr.Commit(cmd.Context())
 	if err != nil {
 		return err
@@ -161,6 +189,10 @@ func (cr *Request) Commit(ctx context.Context) (context.Context, error) {
 	if err != nil {
 		return ctx, errcat.User.New(err)
 	}
+	cr.DnsMappings, err = parseDNSMappings(cr.dnsMappings)
+	if err != nil {
+		return ctx, errcat.User.New(err)
+	}
 	if len(cr.KubeconfigData) > 0 {
 		kc, err := clientcmd.Load(cr.KubeconfigData)
 		if err != nil {
@@ -183,6 +215,21 @@ func (cr *Request) Commit(ctx context.Context) (context.Context, error) {
 	return context.WithValue(ctx, requestKey{}, cr), nil
 }
 
+func parseDNSMappings(dnsMappings []string) ([]*daemon.DNSMapping, error) {
+	if len(dnsMappings) == 0 {
+		return nil, nil
+	}
+	ms := make(client.DNSMappings, len(dnsMappings))
+	for i, dm := range dnsMappings {
+		m, err := client.ParseDNSMapping(dm)
+		if err != nil {
+			return nil, err
+		}
+		ms[i] = m
+	}
+	return ms.ToRPC(), nil
+}
+
 type prefixViaWL struct {
 	subnet   netip.Prefix
 	symbolic string
@@ -200,3 +200,69 @@ func Test_parseProxyVias(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseDNSMappings(t *testing.T) {
+	tests := []struct {
+		name    string
+		dms     []string
+		want    []*daemon.DNSMapping
+		wantErr bool
+	}{
+		{
+			"none",
+			nil,
+			nil,
+			false,
+		},
+		{
+			"exact",
+			[]string{"db.example.com=postgres.data"},
+			[]*daemon.DNSMapping{{Name: "db.example.com", AliasFor: "postgres.data"}},
+			false,
+		},
+		{
+			"wildcard",
+			[]string{"*.prod.internal=$1.staging.svc.cluster.local", "*.api.example.com=10.1.2.3"},
+			[]*daemon.DNSMapping{
+				{Name: "*.prod.internal", AliasFor: "$1.staging.svc.cluster.local"},
+				{Name: "*.api.example.com", AliasFor: "10.1.2.3"},
+			},
+			false,
+		},
+		{
+			"missing alias",
+			[]string{"db.example.com"},
+			nil,
+			true,
+		},
+		{
+			"wildcard not first",
+			[]string{"api.*.example.com=api.default"},
+			nil,
+			true,
+		},
+		{
+			"match in exact alias",
+			[]string{"db.example.com=$1.default"},
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDNSMappings(tt.dms)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseDNSMappings() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseDNSMappings() got = %v, want %v", got, tt.want)
+			}
+			for i, m := range got {
+				if m.Name != tt.want[i].Name || m.AliasFor != tt.want[i].AliasFor {
+					t.Errorf("parseDNSMappings() got = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -1,10 +1,13 @@
 package intercept
 
 import (
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/connector"
@@ -14,6 +17,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
 	"github.com/telepresenceio/telepresence/v2/pkg/dos"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
 )
 
 type Command struct {
@@ -24,8 +28,13 @@ type Command struct {
 	ContainerName  string // --container
 	Address        string // --address
 	LocalMountPort uint16 // --local-mount-port
+	Node           string // --node
 
-	Replace bool // whether --replace was passed
+	Replace    bool          // whether --replace was passed
+	Mirror     bool          // whether --mirror was passed
+	Record     string        // --record
+	TriggerJob bool          // whether --trigger-job was passed
+	TTL        time.Duration // --ttl
 
 	EnvFile   string // --env-file
 	EnvSyntax EnvironmentSyntax
@@ -43,6 +52,12 @@ type Command struct {
 
 	Mechanism       string // --mechanism tcp
 	MechanismArgs   []string
+	HTTPHeader      []string // --http-header NAME=VALUE
+	HTTPPathEqual   string   // --http-path-equal
+	HTTPPathPrefix  string   // --http-path-prefix
+	HTTPPathRegex   string   // --http-path-regex
+	GRPCMethod      []string // --grpc-method
+	Priority        int32    // --priority
 	ExtendedInfo    []byte
 	WaitMessage     string // Message printed when a containerized intercept handler is started and waiting for an interrupt
 	FormattedOutput bool
@@ -52,7 +67,7 @@ type Command struct {
 
 func (a *Command) AddFlags(cmd *cobra.Command) {
 	flagSet := cmd.Flags()
-	flagSet.StringVarP(&a.AgentName, "workload", "w", "", "Name of workload (Deployment, ReplicaSet) to intercept, if different from <name>")
+	flagSet.StringVarP(&a.AgentName, "workload", "w", "", "Name of workload (Deployment, ReplicaSet, StatefulSet, Rollout, DaemonSet, Job, CronJob) to intercept, if different from <name>")
 	flagSet.StringVarP(&a.Port, "port", "p", "", ``+
 		`Local port to forward to. If intercepting a service with multiple ports, `+
 		`use <local port>:<svcPortIdentifier>, where the identifier is the port name or port number. `+
@@ -70,22 +85,90 @@ func (a *Command) AddFlags(cmd *cobra.Command) {
 	flagSet.StringVar(&a.ContainerName, "container", "",
 		"Name of container that provides the environment and mounts for the intercept. Defaults to the container matching the targetPort")
 
+	flagSet.StringVar(&a.Node, "node", "", ``+
+		`Only intercept the pod that runs on this node. Pods on other nodes continue to serve all traffic. `+
+		`Useful when intercepting a DaemonSet`)
+
+	a.addEnvFlags(flagSet)
+	a.addMountFlags(flagSet)
+
+	flagSet.StringSliceVar(&a.ToPod, "to-pod", []string{}, ``+
+		`An additional port to forward from the intercepted pod, will be made available at localhost:PORT `+
+		`Use this to, for example, access proxy/helper sidecars in the intercepted pod. The default protocol is TCP. `+
+		`Use <port>/UDP for UDP ports`)
+
+	a.addDockerFlags(flagSet)
+	flagSet.StringP("namespace", "n", "", "If present, the namespace scope for this CLI request")
+
+	flagSet.StringVar(&a.Mechanism, "mechanism", "tcp", "Which extension `mechanism` to use")
+
+	flagSet.StringArrayVar(&a.HTTPHeader, "http-header", nil, ``+
+		`Only intercept HTTP requests with this header, in the form NAME=VALUE. The VALUE is a regular expression `+
+		`if it contains regexp meta characters. Can be repeated. Implies --mechanism http`)
+
+	flagSet.StringVar(&a.HTTPPathEqual, "http-path-equal", "",
+		`Only intercept HTTP requests with this exact path. Implies --mechanism http`)
+
+	flagSet.StringVar(&a.HTTPPathPrefix, "http-path-prefix", "",
+		`Only intercept HTTP requests with a path that starts with this prefix. Implies --mechanism http`)
+
+	flagSet.StringVar(&a.HTTPPathRegex, "http-path-regex", "",
+		`Only intercept HTTP requests with a path that matches this regular expression. Implies --mechanism http`)
+
+	flagSet.StringSliceVar(&a.GRPCMethod, "grpc-method", nil, ``+
+		`Only intercept gRPC calls to this fully qualified method, in the form <package>.<service>/<method>, or to all `+
+		`methods of a service when given as <package>.<service>. Can be repeated. The intercepted port must have `+
+		`appProtocol grpc or h2c. Cannot be combined with --http-path-XXX. Implies --mechanism http`)
+
+	flagSet.Int32Var(&a.Priority, "priority", 0, ``+
+		`Precedence of this intercept when several intercepts with an HTTP filter match the same request. `+
+		`The intercept with the highest priority receives the request`)
+
+	a.addOutputFlags(flagSet)
+
+	flagSet.BoolVarP(&a.Replace, "replace", "", false,
+		`Indicates if the traffic-agent should replace application containers in workload pods. `+
+			`The default behavior is for the agent sidecar to be installed alongside existing containers.`)
+
+	flagSet.BoolVar(&a.Mirror, "mirror", false, ``+
+		`Let the original container continue to serve all traffic, and send a copy of each TCP connection, or of each `+
+		`matching HTTP request when an --http-XXX flag is used, to the workstation. Responses from the workstation are discarded.`)
+
+	flagSet.StringVar(&a.Record, "record", "", ``+
+		`Record the payload of all intercepted connections in this file. The recording can be replayed `+
+		`using "telepresence replay"`)
+
+	flagSet.BoolVar(&a.TriggerJob, "trigger-job", false, ``+
+		`Create a Job from the CronJob's job template, so that the intercept doesn't have to wait for the next `+
+		`scheduled run. The Job is deleted when the intercept ends`)
+
+	flagSet.DurationVar(&a.TTL, "ttl", 0, ``+
+		`Time to live of the intercept. The traffic-manager removes the intercept when it expires. `+
+		`The traffic-manager may enforce a shorter maximum`)
+}
+
+// addEnvFlags adds the flags that control how the remote environment is emitted.
+func (a *Command) addEnvFlags(flagSet *pflag.FlagSet) {
 	flagSet.StringVarP(&a.EnvFile, "env-file", "e", "", ``+
 		`Also emit the remote environment to an file. The syntax used in the file can be determined using flag --env-syntax`)
 
 	flagSet.Var(&a.EnvSyntax, "env-syntax", `Syntax used for env-file. One of `+EnvSyntaxUsage())
 
 	flagSet.StringVarP(&a.EnvJSON, "env-json", "j", "", `Also emit the remote environment to a file as a JSON blob.`)
+}
 
+// addMountFlags adds the flags that control how the remote volumes are mounted.
+func (a *Command) addMountFlags(flagSet *pflag.FlagSet) {
 	flagSet.StringVar(&a.Mount, "mount", "true", ``+
 		`The absolute path for the root directory where volumes will be mounted, $TELEPRESENCE_ROOT. Use "true" to `+
 		`have Telepresence pick a random mount point (default). Use "false" to disable filesystem mounting entirely.`)
 
-	flagSet.StringSliceVar(&a.ToPod, "to-pod", []string{}, ``+
-		`An additional port to forward from the intercepted pod, will be made available at localhost:PORT `+
-		`Use this to, for example, access proxy/helper sidecars in the intercepted pod. The default protocol is TCP. `+
-		`Use <port>/UDP for UDP ports`)
+	flagSet.Uint16Var(&a.LocalMountPort, "local-mount-port", 0,
+		`Do not mount remote directories. Instead, expose this port on localhost to an external mounter`)
+}
 
+// addDockerFlags adds the flags that control how a command is run in a docker container.
+func (a *Command) addDockerFlags(flagSet *pflag.FlagSet) {
 	flagSet.BoolVar(&a.DockerRun, "docker-run", false, ``+
 		`Run a Docker container with intercepted environment, volume mount, by passing arguments after -- to 'docker run', `+
 		`e.g. '--docker-run -- -it --rm ubuntu:20.04 /bin/bash'`)
@@ -102,22 +185,14 @@ func (a *Command) AddFlags(cmd *cobra.Command) {
 
 	flagSet.StringVar(&a.DockerMount, "docker-mount", "", ``+
 		`The volume mount point in docker. Defaults to same as "--mount"`)
+}
 
-	flagSet.StringP("namespace", "n", "", "If present, the namespace scope for this CLI request")
-
-	flagSet.StringVar(&a.Mechanism, "mechanism", "tcp", "Which extension `mechanism` to use")
-
+// addOutputFlags adds the flags that control the output.
+func (a *Command) addOutputFlags(flagSet *pflag.FlagSet) {
 	flagSet.StringVar(&a.WaitMessage, "wait-message", "", "Message to print when intercept handler has started")
 
 	flagSet.BoolVar(&a.DetailedOutput, "detailed-output", false,
 		`Provide very detailed info about the intercept when used together with --output=json or --output=yaml'`)
-
-	flagSet.Uint16Var(&a.LocalMountPort, "local-mount-port", 0,
-		`Do not mount remote directories. Instead, expose this port on localhost to an external mounter`)
-
-	flagSet.BoolVarP(&a.Replace, "replace", "", false,
-		`Indicates if the traffic-agent should replace application containers in workload pods. `+
-			`The default behavior is for the agent sidecar to be installed alongside existing containers.`)
 }
 
 func (a *Command) Validate(cmd *cobra.Command, positional []string) error {
@@ -140,6 +215,39 @@ func (a *Command) Validate(cmd *cobra.Command, positional []string) error {
 		a.Port = strconv.Itoa(client.GetConfig(cmd.Context()).Intercept().DefaultPort)
 	}
 	a.MountSet = cmd.Flag("mount").Changed
+	if err := a.validateHTTPFilter(cmd); err != nil {
+		return err
+	}
+	if a.TTL < 0 {
+		return errcat.User.New("--ttl cannot be negative")
+	}
+	if a.Mirror && a.Replace {
+		return errcat.User.New("--mirror cannot be used with --replace, because a replaced container cannot serve the traffic")
+	}
+	if a.Record != "" {
+		// The file is created by the user daemon, which has a different working directory.
+		var err error
+		if a.Record, err = filepath.Abs(a.Record); err != nil {
+			return errcat.User.New(err)
+		}
+	}
+	return a.validateDocker()
+}
+
+func (a *Command) Run(cmd *cobra.Command, positional []string) error {
+	if err := a.Validate(cmd, positional); err != nil {
+		return err
+	}
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := dos.WithStdio(cmd.Context(), cmd)
+	_, err := NewState(a).Run(ctx)
+	return err
+}
+
+// validateDocker ensures that at most one of the --docker-XXX flags that run a container is used.
+func (a *Command) validateDocker() error {
 	drCount := 0
 	if a.DockerRun {
 		drCount++
@@ -162,16 +270,36 @@ func (a *Command) Validate(cmd *cobra.Command, positional []string) error {
 	return nil
 }
 
-func (a *Command) Run(cmd *cobra.Command, positional []string) error {
-	if err := a.Validate(cmd, positional); err != nil {
-		return err
+// validateHTTPFilter converts the --http-XXX and --grpc-method flags into mechanism args for the "http" mechanism.
+func (a *Command) validateHTTPFilter(cmd *cobra.Command) error {
+	var args []string
+	for _, h := range a.HTTPHeader {
+		args = append(args, matcher.HeaderArg+"="+h)
 	}
-	if err := connect.InitCommand(cmd); err != nil {
-		return err
+	if a.HTTPPathEqual != "" {
+		args = append(args, matcher.PathEqualArg+"="+a.HTTPPathEqual)
 	}
-	ctx := dos.WithStdio(cmd.Context(), cmd)
-	_, err := NewState(a).Run(ctx)
-	return err
+	if a.HTTPPathPrefix != "" {
+		args = append(args, matcher.PathPrefixArg+"="+a.HTTPPathPrefix)
+	}
+	if a.HTTPPathRegex != "" {
+		args = append(args, matcher.PathRegexArg+"="+a.HTTPPathRegex)
+	}
+	for _, m := range a.GRPCMethod {
+		args = append(args, matcher.GRPCMethodArg+"="+m)
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	if cmd.Flag("mechanism").Changed && a.Mechanism != "http" {
+		return errcat.User.Newf("--http-header, --http-path-XXX, and --grpc-method flags cannot be used with --mechanism %s", a.Mechanism)
+	}
+	if _, err := matcher.NewRequestFromArgs(args); err != nil {
+		return errcat.User.New(err)
+	}
+	a.Mechanism = "http"
+	a.MechanismArgs = args
+	return nil
 }
 
 func (a *Command) ValidateDockerArgs() error {
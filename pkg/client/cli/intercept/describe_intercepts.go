@@ -4,6 +4,7 @@ import (
 	"context"
 	"strings"
 
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 )
 
@@ -22,3 +23,13 @@ func describeIntercept(ctx context.Context, ii *manager.InterceptInfo, volumeMou
 	info.debug = debug
 	_, _ = info.WriteTo(sb)
 }
+
+func DescribeIngests(ctx context.Context, iis []*connector.IngestInfo, volumeMountsPrevented string) string {
+	sb := strings.Builder{}
+	sb.WriteString("ingested")
+	for _, ii := range iis {
+		sb.WriteByte('\n')
+		_, _ = NewIngestInfo(ctx, ii, volumeMountsPrevented).WriteTo(&sb)
+	}
+	return sb.String()
+}
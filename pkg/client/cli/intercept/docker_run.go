@@ -171,7 +171,9 @@ func (s *state) getContainerName(args []string) (string, []string, error) {
 		return "", args, err
 	}
 	if name == "" {
-		name = fmt.Sprintf("intercept-%s-%d", s.Name(), s.localPort)
+		if name = s.dockerName; name == "" {
+			name = fmt.Sprintf("intercept-%s-%d", s.Name(), s.localPort)
+		}
 		args = append([]string{"--name", name}, args...)
 	}
 	return name, args, nil
@@ -218,9 +220,8 @@ func (s *state) startInDocker(ctx context.Context, name, envFile string, args []
 		daemonName := ud.DaemonID().ContainerName()
 		ourArgs = append(ourArgs, "--network", "container:"+daemonName)
 
-		if !(s.mountDisabled || s.info == nil) {
-			m := s.info.Mount
-			if m != nil {
+		if !s.mountDisabled {
+			if m := s.mount; m != nil {
 				pluginName, err := docker.EnsureVolumePlugin(ctx)
 				if err != nil {
 					ioutil.Printf(output.Err(ctx), "Remote mount disabled: %s\n", err)
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
@@ -46,6 +48,10 @@ type Info struct {
 	FilterDesc    string            `json:"filter_desc,omitempty"     yaml:"filter_desc,omitempty"`
 	Metadata      map[string]string `json:"metadata,omitempty"        yaml:"metadata,omitempty"`
 	HttpFilter    []string          `json:"http_filter,omitempty"     yaml:"http_filter,omitempty"`
+	Priority      int32             `json:"priority,omitempty"        yaml:"priority,omitempty"`
+	Mirror        bool              `json:"mirror,omitempty"          yaml:"mirror,omitempty"`
+	NodeName      string            `json:"node_name,omitempty"       yaml:"node_name,omitempty"`
+	ExpiresAt     string            `json:"expires_at,omitempty"      yaml:"expires_at,omitempty"`
 	Global        bool              `json:"global,omitempty"          yaml:"global,omitempty"`
 	PreviewURL    string            `json:"preview_url,omitempty"     yaml:"preview_url,omitempty"`
 	Ingress       *Ingress          `json:"ingress,omitempty"         yaml:"ingress,omitempty"`
@@ -81,28 +87,32 @@ func NewMount(ctx context.Context, ii *manager.InterceptInfo, mountError string)
 		return &Mount{Error: mountError}
 	}
 	if ii.MountPoint != "" {
-		var port int32
-		if client.GetConfig(ctx).Intercept().UseFtp {
-			port = ii.FtpPort
-		} else {
-			port = ii.SftpPort
-		}
-		var mounts []string
-		if tpMounts := ii.Environment["TELEPRESENCE_MOUNTS"]; tpMounts != "" {
-			// This is a Unix path, so we cannot use filepath.SplitList
-			mounts = strings.Split(tpMounts, ":")
-		}
-		return &Mount{
-			LocalDir:  ii.ClientMountPoint,
-			RemoteDir: ii.MountPoint,
-			PodIP:     ii.PodIp,
-			Port:      port,
-			Mounts:    mounts,
-		}
+		return newMount(ctx, ii.ClientMountPoint, ii.MountPoint, ii.PodIp, ii.FtpPort, ii.SftpPort, ii.Environment)
 	}
 	return nil
 }
 
+func newMount(ctx context.Context, localDir, remoteDir, podIP string, ftpPort, sftpPort int32, env map[string]string) *Mount {
+	var port int32
+	if client.GetConfig(ctx).Intercept().UseFtp {
+		port = ftpPort
+	} else {
+		port = sftpPort
+	}
+	var mounts []string
+	if tpMounts := env["TELEPRESENCE_MOUNTS"]; tpMounts != "" {
+		// This is a Unix path, so we cannot use filepath.SplitList
+		mounts = strings.Split(tpMounts, ":")
+	}
+	return &Mount{
+		LocalDir:  localDir,
+		RemoteDir: remoteDir,
+		PodIP:     podIP,
+		Port:      port,
+		Mounts:    mounts,
+	}
+}
+
 func NewInfo(ctx context.Context, ii *manager.InterceptInfo, mountError string) *Info {
 	spec := ii.Spec
 	info := &Info{
@@ -122,10 +132,16 @@ func NewInfo(ctx context.Context, ii *manager.InterceptInfo, mountError string)
 		FilterDesc:    ii.MechanismArgsDesc,
 		Metadata:      ii.Metadata,
 		HttpFilter:    spec.MechanismArgs,
+		Priority:      spec.Priority,
+		Mirror:        spec.Mirror,
+		NodeName:      spec.NodeName,
 		Global:        spec.Mechanism == "tcp",
 		PreviewURL:    PreviewURL(ii.PreviewDomain),
 		Ingress:       NewIngress(ii.PreviewSpec),
 	}
+	if ii.ExpiresAt != nil {
+		info.ExpiresAt = ii.ExpiresAt.AsTime().Local().Format(time.RFC3339)
+	}
 	if spec.ServiceUid != "" {
 		// For backward compatibility in JSON output
 		info.ServicePortID = info.PortID
@@ -184,7 +200,11 @@ func (ii *Info) WriteTo(w io.Writer) (int64, error) {
 		}
 	}
 
-	kvf.Add("Intercepting", func() string {
+	label := "Intercepting"
+	if ii.Mirror {
+		label = "Mirroring"
+	}
+	kvf.Add(label, func() string {
 		if ii.FilterDesc != "" {
 			return ii.FilterDesc
 		}
@@ -193,7 +213,16 @@ func (ii *Info) WriteTo(w io.Writer) (int64, error) {
 		}
 		return fmt.Sprintf("using mechanism=%q with args=%q", "http", ii.HttpFilter)
 	}())
-	if ii.ServiceUID == "" {
+	if ii.Priority != 0 {
+		kvf.Add("Priority", strconv.Itoa(int(ii.Priority)))
+	}
+	if ii.NodeName != "" {
+		kvf.Add("Node", ii.NodeName)
+	}
+	if ii.ExpiresAt != "" {
+		kvf.Add("Expires at", ii.ExpiresAt)
+	}
+	if ii.ServiceUID == "" && ii.ContainerPort != 0 {
 		kvf.Add("Address", iputil.JoinHostPort(ii.PodIP, uint16(ii.ContainerPort)))
 	}
 
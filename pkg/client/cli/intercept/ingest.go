@@ -0,0 +1,243 @@
+package intercept
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	grpcCodes "google.golang.org/grpc/codes"
+	grpcStatus "google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/connect"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/docker"
+	"github.com/telepresenceio/telepresence/v2/pkg/dos"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/ioutil"
+)
+
+// IngestInfo is the CLI representation of an ingest.
+type IngestInfo struct {
+	Workload    string            `json:"workload,omitempty"    yaml:"workload,omitempty"`
+	Container   string            `json:"container,omitempty"   yaml:"container,omitempty"`
+	Namespace   string            `json:"namespace,omitempty"   yaml:"namespace,omitempty"`
+	PodIP       string            `json:"pod_ip,omitempty"      yaml:"pod_ip,omitempty"`
+	Environment map[string]string `json:"environment,omitempty" yaml:"environment,omitempty"`
+	Mount       *Mount            `json:"mount,omitempty"       yaml:"mount,omitempty"`
+}
+
+func NewIngestInfo(ctx context.Context, ii *connector.IngestInfo, mountError string) *IngestInfo {
+	info := &IngestInfo{
+		Workload:    ii.Workload,
+		Container:   ii.Container,
+		Namespace:   ii.Namespace,
+		PodIP:       ii.PodIp,
+		Environment: ii.Environment,
+	}
+	if mountError != "" {
+		info.Mount = &Mount{Error: mountError}
+	} else if ii.MountPoint != "" {
+		info.Mount = newMount(ctx, ii.ClientMountPoint, ii.MountPoint, ii.PodIp, ii.FtpPort, ii.SftpPort, ii.Environment)
+	}
+	return info
+}
+
+func (ii *IngestInfo) WriteTo(w io.Writer) (int64, error) {
+	kvf := ioutil.DefaultKeyValueFormatter()
+	kvf.Prefix = "   "
+	kvf.Add("Ingest name", ii.Workload+"/"+ii.Container)
+	kvf.Add("Namespace", ii.Namespace)
+	kvf.Add("Pod IP", ii.PodIP)
+	if m := ii.Mount; m != nil {
+		if m.LocalDir != "" {
+			kvf.Add("Volume Mount Point", m.LocalDir)
+		} else if m.Error != "" {
+			kvf.Add("Volume Mount Error", m.Error)
+		}
+	}
+	return kvf.WriteTo(w)
+}
+
+// AddIngestFlags adds the flags of the ingest command. They are a subset of the intercept flags.
+func (a *Command) AddIngestFlags(cmd *cobra.Command) {
+	flagSet := cmd.Flags()
+	flagSet.StringVar(&a.ContainerName, "container", "",
+		"Name of the container that provides the environment and mounts. Can be omitted when the workload has only one container")
+
+	a.addEnvFlags(flagSet)
+	a.addMountFlags(flagSet)
+	a.addDockerFlags(flagSet)
+	flagSet.StringP("namespace", "n", "", "If present, the namespace scope for this CLI request")
+	flagSet.StringVar(&a.WaitMessage, "wait-message", "", "Message to print when the ingest handler has started")
+}
+
+func (a *Command) ValidateIngest(cmd *cobra.Command, positional []string) error {
+	if len(positional) > 1 && cmd.Flags().ArgsLenAtDash() != 1 {
+		return errcat.User.New("commands to be run with ingest must come after options")
+	}
+	a.Name = positional[0]
+	a.AgentName = a.Name
+	a.Cmdline = positional[1:]
+	a.FormattedOutput = output.WantsFormatted(cmd)
+	if a.LocalMountPort > 0 && client.GetConfig(cmd.Context()).Intercept().UseFtp {
+		return errcat.User.New("only SFTP can be used with --local-mount-port. Client is configured to perform remote mounts using FTP")
+	}
+	a.MountSet = cmd.Flag("mount").Changed
+	return a.validateDocker()
+}
+
+func (a *Command) RunIngest(cmd *cobra.Command, positional []string) error {
+	if err := a.ValidateIngest(cmd, positional); err != nil {
+		return err
+	}
+	if err := connect.InitCommand(cmd); err != nil {
+		return err
+	}
+	ctx := dos.WithStdio(cmd.Context(), cmd)
+	_, err := newIngestState(a).Run(ctx)
+	return err
+}
+
+// ingestState reuses the environment, mount, and docker handling of the intercept state, but
+// creates an ingest instead of an intercept.
+type ingestState struct {
+	*state
+	ingestInfo *IngestInfo
+}
+
+func newIngestState(args *Command) *ingestState {
+	s := &ingestState{state: &state{Command: args}}
+	s.self = s.state
+	s.dockerName = "ingest-" + args.Name
+	return s
+}
+
+func (s *ingestState) identifier() *connector.IngestIdentifier {
+	return &connector.IngestIdentifier{WorkloadName: s.AgentName, ContainerName: s.ContainerName}
+}
+
+func (s *ingestState) Run(ctx context.Context) (*IngestInfo, error) {
+	if !s.RunAndLeave() {
+		if err := client.WithEnsuredState(ctx, s.create, nil, nil); err != nil {
+			return nil, err
+		}
+		return s.ingestInfo, nil
+	}
+
+	// start ingest, run command, then leave the ingest
+	if s.DockerRun {
+		if err := s.prepareDockerRun(docker.EnableClient(ctx)); err != nil {
+			return nil, err
+		}
+	}
+	if err := client.WithEnsuredState(ctx, s.create, s.runCommand, s.leave); err != nil {
+		return nil, err
+	}
+	return s.ingestInfo, nil
+}
+
+func (s *ingestState) create(ctx context.Context) (acquired bool, err error) {
+	rq := &connector.IngestRequest{Identifier: s.identifier()}
+	if rq.MountPoint, rq.LocalMountPort, err = s.prepareMount(ctx); err != nil {
+		return false, errcat.NoDaemonLogs.New(err)
+	}
+	if rq.MountPoint != "" {
+		defer func() {
+			if !acquired && runtime.GOOS != "windows" {
+				// remove if empty
+				_ = os.Remove(rq.MountPoint)
+			}
+		}()
+		s.mountPoint = rq.MountPoint
+	}
+	if s.DockerMount != "" {
+		if !s.DockerRun {
+			return false, errcat.User.New("--docker-mount must be used together with --docker-run")
+		}
+		if s.mountDisabled {
+			return false, errcat.User.New("--docker-mount cannot be used with --mount=false")
+		}
+	}
+
+	ii, err := daemon.GetUserClient(ctx).Ingest(ctx, rq)
+	if err != nil {
+		return false, RPCUserError(err)
+	}
+	// The daemon resolves the container name when it's omitted.
+	s.ContainerName = ii.Container
+
+	s.env = ii.Environment
+	if s.env == nil {
+		s.env = make(map[string]string)
+	}
+	s.env["TELEPRESENCE_ROOT"] = ii.ClientMountPoint
+	if s.EnvFile != "" {
+		if err = s.writeEnvFile(); err != nil {
+			return true, err
+		}
+	}
+	if s.EnvJSON != "" {
+		if err = s.writeEnvJSON(); err != nil {
+			return true, err
+		}
+	}
+
+	var volumeMountProblem error
+	if rq.LocalMountPort != 0 {
+		ii.PodIp = "127.0.0.1"
+		ii.SftpPort = rq.LocalMountPort
+	} else {
+		doMount, err := strconv.ParseBool(s.Mount)
+		if doMount || err != nil {
+			volumeMountProblem = s.checkMountCapability(ctx)
+		}
+	}
+	mountError := ""
+	if volumeMountProblem != nil {
+		mountError = volumeMountProblem.Error()
+	}
+	s.ingestInfo = NewIngestInfo(ctx, ii, mountError)
+	s.mount = s.ingestInfo.Mount
+	if !s.Silent {
+		if s.FormattedOutput {
+			output.Object(ctx, s.ingestInfo, true)
+		} else {
+			out := dos.Stdout(ctx)
+			_, _ = s.ingestInfo.WriteTo(out)
+			_, _ = fmt.Fprintln(out)
+		}
+	}
+	return true, nil
+}
+
+func (s *ingestState) leave(ctx context.Context) error {
+	dlog.Debugf(ctx, "Leaving ingest %s", s.AgentName)
+	_, err := daemon.GetUserClient(ctx).LeaveIngest(ctx, s.identifier())
+	if err != nil && grpcStatus.Code(err) == grpcCodes.Canceled {
+		// Deactivation was caused by a disconnect
+		err = nil
+	}
+	if err != nil {
+		dlog.Errorf(ctx, "Leaving ingest ended with error %v", err)
+	}
+	return RPCUserError(err)
+}
+
+// RPCUserError turns gRPC errors caused by the user's input into user errors.
+func RPCUserError(err error) error {
+	if st, ok := grpcStatus.FromError(err); ok {
+		switch st.Code() {
+		case grpcCodes.InvalidArgument, grpcCodes.AlreadyExists, grpcCodes.NotFound, grpcCodes.FailedPrecondition:
+			return errcat.User.New(st.Message())
+		}
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package intercept
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	grpcCodes "google.golang.org/grpc/codes"
+	grpcStatus "google.golang.org/grpc/status"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+func TestRPCUserError(t *testing.T) {
+	assert.NoError(t, RPCUserError(nil))
+
+	for _, code := range []grpcCodes.Code{grpcCodes.InvalidArgument, grpcCodes.AlreadyExists, grpcCodes.NotFound, grpcCodes.FailedPrecondition} {
+		t.Run(code.String(), func(t *testing.T) {
+			err := RPCUserError(grpcStatus.Error(code, "echo/echo is already ingested"))
+			require.Error(t, err)
+			assert.Equal(t, errcat.User, errcat.GetCategory(err))
+			assert.Equal(t, "echo/echo is already ingested", err.Error())
+		})
+	}
+
+	// Other errors are returned unchanged.
+	err := grpcStatus.Error(grpcCodes.Unavailable, "connection refused")
+	assert.Same(t, err, RPCUserError(err))
+	err = errors.New("boom")
+	assert.Same(t, err, RPCUserError(err))
+}
+
+func TestIngestState_identifier(t *testing.T) {
+	s := newIngestState(&Command{Name: "echo", AgentName: "echo", ContainerName: "sidecar"})
+	assert.Equal(t, "echo", s.identifier().WorkloadName)
+	assert.Equal(t, "sidecar", s.identifier().ContainerName)
+	assert.Equal(t, "ingest-echo", s.dockerName)
+}
+
+func TestNewIngestInfo(t *testing.T) {
+	ii := &connector.IngestInfo{
+		Workload:    "echo",
+		Container:   "echo",
+		Namespace:   "default",
+		PodIp:       "10.0.0.1",
+		Environment: map[string]string{"A": "B"},
+	}
+
+	info := NewIngestInfo(context.Background(), ii, "")
+	assert.Nil(t, info.Mount)
+	assert.Equal(t, map[string]string{"A": "B"}, info.Environment)
+	buf := bytes.Buffer{}
+	_, err := info.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "echo/echo")
+	assert.Contains(t, buf.String(), "default")
+	assert.Contains(t, buf.String(), "10.0.0.1")
+	assert.NotContains(t, buf.String(), "Volume Mount")
+
+	info = NewIngestInfo(context.Background(), ii, "sshfs is not installed")
+	require.NotNil(t, info.Mount)
+	assert.Equal(t, "sshfs is not installed", info.Mount.Error)
+	buf.Reset()
+	_, err = info.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "Volume Mount Error")
+	assert.Contains(t, buf.String(), "sshfs is not installed")
+}
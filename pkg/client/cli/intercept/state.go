@@ -16,6 +16,7 @@ import (
 	"github.com/go-json-experiment/json/jsontext"
 	grpcCodes "google.golang.org/grpc/codes"
 	grpcStatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	empty "google.golang.org/protobuf/types/known/emptypb"
 	core "k8s.io/api/core/v1"
 
@@ -52,7 +53,9 @@ type state struct {
 	localPort     uint16 // the parsed <local port>
 	dockerPort    uint16
 	status        *connector.ConnectInfo
-	info          *Info // Info from the created intercept
+	info          *Info  // Info from the created intercept
+	mount         *Mount // The remote mount, if any
+	dockerName    string // Default name of the docker container. Derived from the intercept when empty
 
 	// Possibly extended version of the state. Use when calling interface methods.
 	self State
@@ -92,6 +95,13 @@ func (s *state) CreateRequest(ctx context.Context) (*connector.CreateInterceptRe
 	spec.ContainerName = s.ContainerName
 	spec.Mechanism = s.Mechanism
 	spec.MechanismArgs = s.MechanismArgs
+	spec.Priority = s.Priority
+	spec.Mirror = s.Mirror
+	spec.NodeName = s.Node
+	spec.TriggerJob = s.TriggerJob
+	if s.TTL > 0 {
+		spec.Ttl = durationpb.New(s.TTL)
+	}
 	spec.Agent = s.AgentName
 	spec.TargetHost = "127.0.0.1"
 
@@ -109,43 +119,15 @@ func (s *state) CreateRequest(ctx context.Context) (*connector.CreateInterceptRe
 	}
 	spec.TargetHost = s.Address
 
-	mountEnabled, mountPoint := s.GetMountPoint()
-	if !mountEnabled {
-		s.mountDisabled = true
-	} else {
-		if ud.Containerized() && ir.LocalMountPort == 0 {
-			// No use having the remote container actually mount, so let's have it create a bridge
-			// to the remote sftp server instead.
-			lma, err := client.FreePortsTCP(1)
-			if err != nil {
-				return nil, err
-			}
-			s.LocalMountPort = uint16(lma[0].Port)
-			mountPoint = ""
-		}
-
-		if err = s.checkMountCapability(ctx); err != nil {
-			err = fmt.Errorf("remote volume mounts are disabled: %w", err)
-			if mountPoint != "" {
-				return nil, err
-			}
-			// Log a warning and disable, but continue
-			s.mountDisabled = true
-			dlog.Warning(ctx, err)
+	if s.Record != "" {
+		if ud.Containerized() {
+			return nil, errcat.User.New("--record cannot be used when the daemon runs in a container")
 		}
+		ir.RecordFile = s.Record
+	}
 
-		if !s.mountDisabled {
-			ir.LocalMountPort = int32(s.LocalMountPort)
-			if ir.LocalMountPort == 0 {
-				var cwd string
-				if cwd, err = os.Getwd(); err != nil {
-					return nil, err
-				}
-				if ir.MountPoint, err = PrepareMount(cwd, mountPoint); err != nil {
-					return nil, err
-				}
-			}
-		}
+	if ir.MountPoint, ir.LocalMountPort, err = s.prepareMount(ctx); err != nil {
+		return nil, err
 	}
 
 	for _, toPod := range s.ToPod {
@@ -171,6 +153,49 @@ func (s *state) CreateRequest(ctx context.Context) (*connector.CreateInterceptRe
 	return ir, nil
 }
 
+// prepareMount returns the client mount point, or the local mount port, to use for the remote volumes. The
+// mountDisabled flag is set when no mount will take place.
+func (s *state) prepareMount(ctx context.Context) (string, int32, error) {
+	mountEnabled, mountPoint := s.GetMountPoint()
+	if !mountEnabled {
+		s.mountDisabled = true
+		return "", 0, nil
+	}
+	if daemon.GetUserClient(ctx).Containerized() {
+		// No use having the remote container actually mount, so let's have it create a bridge
+		// to the remote sftp server instead.
+		lma, err := client.FreePortsTCP(1)
+		if err != nil {
+			return "", 0, err
+		}
+		s.LocalMountPort = uint16(lma[0].Port)
+		mountPoint = ""
+	}
+
+	if err := s.checkMountCapability(ctx); err != nil {
+		err = fmt.Errorf("remote volume mounts are disabled: %w", err)
+		if mountPoint != "" {
+			return "", 0, err
+		}
+		// Log a warning and disable, but continue
+		s.mountDisabled = true
+		dlog.Warning(ctx, err)
+		return "", 0, nil
+	}
+
+	if s.LocalMountPort != 0 {
+		return "", int32(s.LocalMountPort), nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", 0, err
+	}
+	if mountPoint, err = PrepareMount(cwd, mountPoint); err != nil {
+		return "", 0, err
+	}
+	return mountPoint, 0, nil
+}
+
 func (s *state) Name() string {
 	return s.Command.Name
 }
@@ -302,6 +327,7 @@ func (s *state) create(ctx context.Context) (acquired bool, err error) {
 		mountError = volumeMountProblem.Error()
 	}
 	s.info = NewInfo(ctx, intercept, mountError)
+	s.mount = s.info.Mount
 	if !s.Silent {
 		if detailedOutput {
 			output.Object(ctx, s.info, true)
@@ -109,6 +109,7 @@ type Config interface {
 	Cluster() *Cluster
 	DNS() *DNS
 	Routing() *Routing
+	Metrics() *Metrics
 	DestructiveMerge(Config)
 	Merge(priority Config) Config
 }
@@ -125,6 +126,7 @@ type BaseConfig struct {
 	ClusterV         Cluster         `json:"cluster,omitzero"`
 	DNSV             DNS             `json:"dns,omitzero"`
 	RoutingV         Routing         `json:"routing,omitzero"`
+	MetricsV         Metrics         `json:"metrics,omitzero"`
 }
 
 func (c *BaseConfig) OSSpecific() *OSSpecificConfig {
@@ -171,6 +173,10 @@ func (c *BaseConfig) Routing() *Routing {
 	return &c.RoutingV
 }
 
+func (c *BaseConfig) Metrics() *Metrics {
+	return &c.MetricsV
+}
+
 func (c *BaseConfig) MarshalYAML() ([]byte, error) {
 	data, err := MarshalJSON(c)
 	if err == nil {
@@ -251,6 +257,7 @@ func (c *BaseConfig) DestructiveMerge(lc Config) {
 	c.ClusterV.merge(lc.Cluster())
 	c.DNSV.merge(lc.DNS())
 	c.RoutingV.merge(lc.Routing())
+	c.MetricsV.merge(lc.Metrics())
 }
 
 func (c *BaseConfig) Merge(lc Config) Config {
@@ -339,6 +346,8 @@ type Timeouts struct {
 	PrivateFtpReadWrite time.Duration `json:"ftpReadWrite"`
 	// PrivateFtpShutdown max time to wait for the fuseftp client to complete pending operations before forcing termination.
 	PrivateFtpShutdown time.Duration `json:"ftpShutdown"`
+	// PrivateTunnelResume is how long to attempt to resume a lost tunnel before its connections are dropped. Zero disables resumption.
+	PrivateTunnelResume time.Duration `json:"tunnelResume"`
 }
 
 type TimeoutID int
@@ -355,6 +364,7 @@ const (
 	TimeoutTrafficManagerConnect
 	TimeoutFtpReadWrite
 	TimeoutFtpShutdown
+	TimeoutTunnelResume
 )
 
 type timeoutContext struct {
@@ -401,6 +411,8 @@ func (t *Timeouts) Get(timeoutID TimeoutID) time.Duration {
 		timeoutVal = t.PrivateFtpReadWrite
 	case TimeoutFtpShutdown:
 		timeoutVal = t.PrivateFtpShutdown
+	case TimeoutTunnelResume:
+		timeoutVal = t.PrivateTunnelResume
 	default:
 		panic("should not happen")
 	}
@@ -461,6 +473,9 @@ func (e timeoutError) Error() string {
 	case TimeoutFtpShutdown:
 		yamlName = "ftpShutdown"
 		humanName = "FTP client shutdown grace period"
+	case TimeoutTunnelResume:
+		yamlName = "tunnelResume"
+		humanName = "resumption of a lost tunnel"
 	default:
 		panic("should not happen")
 	}
@@ -491,6 +506,7 @@ const (
 	defaultTimeoutsTrafficManagerConnect = 60 * time.Second
 	defaultTimeoutsFtpReadWrite          = 1 * time.Minute
 	defaultTimeoutsFtpShutdown           = 2 * time.Minute
+	defaultTimeoutsTunnelResume          = 30 * time.Second
 )
 
 var defaultTimeouts = Timeouts{ //nolint:gochecknoglobals // constant
@@ -505,6 +521,7 @@ var defaultTimeouts = Timeouts{ //nolint:gochecknoglobals // constant
 	PrivateTrafficManagerConnect: defaultTimeoutsTrafficManagerConnect,
 	PrivateFtpReadWrite:          defaultTimeoutsFtpReadWrite,
 	PrivateFtpShutdown:           defaultTimeoutsFtpShutdown,
+	PrivateTunnelResume:          defaultTimeoutsTunnelResume,
 }
 
 func (t *Timeouts) defaults() DefaultsAware {
@@ -650,6 +667,9 @@ type Grpc struct {
 	// MaxReceiveSize is the maximum message size in bytes the client can receive in a gRPC call or stream message.
 	// Overrides the gRPC default of 4MB.
 	MaxReceiveSizeV resource.Quantity `json:"maxReceiveSize"`
+
+	// CompressTunnels asks the peers of the client's tunnels to compress the traffic of the tunnels.
+	CompressTunnels bool `json:"compressTunnels"`
 }
 
 func (g *Grpc) MaxReceiveSize() int64 {
@@ -665,11 +685,14 @@ func (g *Grpc) merge(o *Grpc) {
 	if !o.MaxReceiveSizeV.IsZero() {
 		g.MaxReceiveSizeV = o.MaxReceiveSizeV
 	}
+	if o.CompressTunnels {
+		g.CompressTunnels = true
+	}
 }
 
 // IsZero controls whether this element will be included in marshalled output.
 func (g *Grpc) IsZero() bool {
-	return g == nil || g.MaxReceiveSizeV.IsZero()
+	return g == nil || g.MaxReceiveSizeV.IsZero() && !g.CompressTunnels
 }
 
 type TelepresenceAPI struct {
@@ -682,6 +705,18 @@ func (g *TelepresenceAPI) merge(o *TelepresenceAPI) {
 	}
 }
 
+type Metrics struct {
+	// PrometheusPort is the local port where the user daemon serves the tunnel statistics as Prometheus
+	// metrics. No metrics are served when the port is zero.
+	PrometheusPort int `json:"prometheusPort"`
+}
+
+func (m *Metrics) merge(o *Metrics) {
+	if o.PrometheusPort != 0 {
+		m.PrometheusPort = o.PrometheusPort
+	}
+}
+
 var defaultTelemount = DockerImage{ //nolint:gochecknoglobals // constant
 	RegistryAPI: "ghcr.io/v2",
 	Registry:    "ghcr.io",
@@ -748,17 +783,22 @@ type Cluster struct {
 	ForceSPDY               bool     `json:"forceSPDY"`
 	AgentPortForward        bool     `json:"agentPortForward"`
 	VirtualIPSubnet         string   `json:"virtualIPSubnet"`
+	VirtualIPv6Subnet       string   `json:"virtualIPv6Subnet"`
 }
 
 // This is used by a different config -- the k8s_config, which needs to be able to tell if it's overridden at a cluster or environment variable level.
 // Hence, we don't default to "ambassador" but to empty, so that it can check that no default has been given.
 const defaultDefaultManagerNamespace = ""
 
+// defaultVirtualIPv6Subnet is a randomly chosen subnet in the unique local address range.
+const defaultVirtualIPv6Subnet = "fd6e:7e1e:a5c6::/64"
+
 var defaultCluster = Cluster{ //nolint:gochecknoglobals // constant
 	DefaultManagerNamespace: defaultDefaultManagerNamespace,
 	ConnectFromRootDaemon:   true,
 	AgentPortForward:        true,
 	VirtualIPSubnet:         defaultVirtualIPSubnet,
+	VirtualIPv6Subnet:       defaultVirtualIPv6Subnet,
 }
 
 func (cc *Cluster) defaults() DefaultsAware {
@@ -810,6 +850,9 @@ func (d *DNS) Equal(o *DNS) bool {
 	return o.LocalIP == d.LocalIP &&
 		o.RemoteIP == d.RemoteIP &&
 		o.LookupTimeout == d.LookupTimeout &&
+		o.CacheTTL == d.CacheTTL &&
+		o.NegativeCacheTTL == d.NegativeCacheTTL &&
+		slices.EqualFunc(o.Listeners, d.Listeners, (*DNSListener).Equal) &&
 		slices.Equal(o.IncludeSuffixes, d.IncludeSuffixes) &&
 		slices.Equal(o.ExcludeSuffixes, d.ExcludeSuffixes) &&
 		slices.Equal(o.Excludes, d.Excludes) &&
@@ -908,6 +951,7 @@ var defaultConfig = BaseConfig{ //nolint:gochecknoglobals // constant
 	ClusterV:         defaultCluster,
 	DNSV:             defaultDNS,
 	RoutingV:         Routing{},
+	MetricsV:         Metrics{},
 }
 
 // GetDefaultBaseConfig returns the default configuration settings.
@@ -996,27 +1040,45 @@ type RoutingSnake struct {
 	AllowConflicting []netip.Prefix `json:"allow_conflicting_subnets"`
 }
 
+// DNSListener is an additional address that the DNS server listens to, using both UDP and TCP. Only clients
+// with an address in one of the allowed subnets can query it. Only loopback clients can query it when no
+// subnets are allowed.
+type DNSListener struct {
+	Address        netip.AddrPort `json:"address"`
+	AllowedSubnets []netip.Prefix `json:"allowedSubnets,omitempty"`
+}
+
+func (l *DNSListener) Equal(o *DNSListener) bool {
+	return l.Address == o.Address && slices.Equal(l.AllowedSubnets, o.AllowedSubnets)
+}
+
 type DNS struct {
-	Error           string        `json:"error"`
-	LocalIP         netip.Addr    `json:"localIP"`
-	RemoteIP        netip.Addr    `json:"remoteIP"`
-	IncludeSuffixes []string      `json:"includeSuffixes"`
-	ExcludeSuffixes []string      `json:"excludeSuffixes"`
-	Excludes        []string      `json:"excludes"`
-	Mappings        DNSMappings   `json:"mappings"`
-	LookupTimeout   time.Duration `json:"lookupTimeout"`
+	Error            string         `json:"error"`
+	LocalIP          netip.Addr     `json:"localIP"`
+	RemoteIP         netip.Addr     `json:"remoteIP"`
+	IncludeSuffixes  []string       `json:"includeSuffixes"`
+	ExcludeSuffixes  []string       `json:"excludeSuffixes"`
+	Excludes         []string       `json:"excludes"`
+	Mappings         DNSMappings    `json:"mappings"`
+	LookupTimeout    time.Duration  `json:"lookupTimeout"`
+	CacheTTL         time.Duration  `json:"cacheTTL"`
+	NegativeCacheTTL time.Duration  `json:"negativeCacheTTL"`
+	Listeners        []*DNSListener `json:"listeners"`
 }
 
 // DNSSnake is the same as DNS but with snake_case json/yaml names.
 type DNSSnake struct {
-	Error           string        `json:"error"`
-	LocalIP         netip.Addr    `json:"local_ip"`
-	RemoteIP        netip.Addr    `json:"remote_ip"`
-	IncludeSuffixes []string      `json:"include_suffixes"`
-	ExcludeSuffixes []string      `json:"exclude_suffixes"`
-	Excludes        []string      `json:"excludes"`
-	Mappings        DNSMappings   `json:"mappings"`
-	LookupTimeout   time.Duration `json:"lookup_timeout"`
+	Error            string         `json:"error"`
+	LocalIP          netip.Addr     `json:"local_ip"`
+	RemoteIP         netip.Addr     `json:"remote_ip"`
+	IncludeSuffixes  []string       `json:"include_suffixes"`
+	ExcludeSuffixes  []string       `json:"exclude_suffixes"`
+	Excludes         []string       `json:"excludes"`
+	Mappings         DNSMappings    `json:"mappings"`
+	LookupTimeout    time.Duration  `json:"lookup_timeout"`
+	CacheTTL         time.Duration  `json:"cache_ttl"`
+	NegativeCacheTTL time.Duration  `json:"negative_cache_ttl"`
+	Listeners        []*DNSListener `json:"listeners"`
 }
 
 func (d *DNS) ToRPC() *daemon.DNSConfig {
@@ -1029,6 +1091,21 @@ func (d *DNS) ToRPC() *daemon.DNSConfig {
 		LookupTimeout:   durationpb.New(d.LookupTimeout),
 		Error:           d.Error,
 	}
+	if d.CacheTTL != 0 {
+		rd.CacheTtl = durationpb.New(d.CacheTTL)
+	}
+	if d.NegativeCacheTTL != 0 {
+		rd.NegativeCacheTtl = durationpb.New(d.NegativeCacheTTL)
+	}
+	if len(d.Listeners) > 0 {
+		rd.Listeners = make([]*daemon.DNSListener, len(d.Listeners))
+		for i, l := range d.Listeners {
+			rd.Listeners[i] = &daemon.DNSListener{
+				Address:        l.Address.String(),
+				AllowedSubnets: iputil.PrefixesToRPC(l.AllowedSubnets),
+			}
+		}
+	}
 	if len(d.Mappings) > 0 {
 		rd.Mappings = make([]*daemon.DNSMapping, len(d.Mappings))
 		for i, n := range d.Mappings {
@@ -1043,14 +1120,17 @@ func (d *DNS) ToRPC() *daemon.DNSConfig {
 
 func (d *DNS) ToSnake() *DNSSnake {
 	return &DNSSnake{
-		LocalIP:         d.LocalIP,
-		RemoteIP:        d.RemoteIP,
-		ExcludeSuffixes: d.ExcludeSuffixes,
-		IncludeSuffixes: d.IncludeSuffixes,
-		Excludes:        d.Excludes,
-		Mappings:        d.Mappings,
-		LookupTimeout:   d.LookupTimeout,
-		Error:           d.Error,
+		LocalIP:          d.LocalIP,
+		RemoteIP:         d.RemoteIP,
+		ExcludeSuffixes:  d.ExcludeSuffixes,
+		IncludeSuffixes:  d.IncludeSuffixes,
+		Excludes:         d.Excludes,
+		Mappings:         d.Mappings,
+		LookupTimeout:    d.LookupTimeout,
+		CacheTTL:         d.CacheTTL,
+		NegativeCacheTTL: d.NegativeCacheTTL,
+		Listeners:        d.Listeners,
+		Error:            d.Error,
 	}
 }
 
@@ -1085,6 +1165,17 @@ func DNSFromRPC(s *daemon.DNSConfig) *DNS {
 	if s.LookupTimeout != nil {
 		c.LookupTimeout = s.LookupTimeout.AsDuration()
 	}
+	if s.CacheTtl != nil {
+		c.CacheTTL = s.CacheTtl.AsDuration()
+	}
+	if s.NegativeCacheTtl != nil {
+		c.NegativeCacheTTL = s.NegativeCacheTtl.AsDuration()
+	}
+	for _, l := range s.Listeners {
+		if ap, err := netip.ParseAddrPort(l.Address); err == nil {
+			c.Listeners = append(c.Listeners, &DNSListener{Address: ap, AllowedSubnets: iputil.RPCsToPrefixes(l.AllowedSubnets)})
+		}
+	}
 	return &c
 }
 
@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"net/netip"
 	"os"
 	"path/filepath"
 	"testing"
@@ -179,3 +180,29 @@ logLevels:
 	require.NoError(t, err)
 	require.Equal(t, cfg.LogLevels().UserDaemon, logrus.DebugLevel)
 }
+
+func Test_ConfigUnmarshalYAMLDNSListeners(t *testing.T) {
+	cfg, err := ParseConfigYAML(dlog.NewTestContext(t, true), "", []byte(`
+dns:
+  negativeCacheTTL: 5s
+  listeners:
+    - address: 172.17.0.1:53
+      allowedSubnets:
+        - 172.17.0.0/16
+    - address: 127.0.0.53:5353
+`))
+	require.NoError(t, err)
+	dns := cfg.DNS()
+	assert.Equal(t, 5*time.Second, dns.NegativeCacheTTL)
+	assert.Equal(t, []*DNSListener{
+		{Address: netip.MustParseAddrPort("172.17.0.1:53"), AllowedSubnets: []netip.Prefix{netip.MustParsePrefix("172.17.0.0/16")}},
+		{Address: netip.MustParseAddrPort("127.0.0.53:5353")},
+	}, dns.Listeners)
+
+	// The listeners survive a marshal/unmarshal round-trip.
+	cfgBytes, err := cfg.MarshalYAML()
+	require.NoError(t, err)
+	cfg2, err := ParseConfigYAML(dlog.NewTestContext(t, true), "", cfgBytes)
+	require.NoError(t, err)
+	assert.True(t, cfg2.DNS().Equal(dns))
+}
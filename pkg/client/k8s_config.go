@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"encoding/csv"
+	"fmt"
 	"net"
 	"net/netip"
 	"net/url"
@@ -33,13 +34,67 @@ import (
 
 // DNSMapping contains a hostname and its associated alias. When requesting the name, the intended behavior is
 // to resolve the alias instead.
+//
+// A name that starts with "*." is a wildcard that matches all names that end with the rest of the name and
+// have at least one label in front of it. The "$1" in the alias of a wildcard mapping is replaced with those
+// labels, so that "*.prod.internal" with the alias "$1.staging.svc.cluster.local" maps "orders.prod.internal"
+// to "orders.staging.svc.cluster.local".
 type DNSMapping struct {
 	Name     string `json:"name,omitempty" yaml:"name,omitempty"`
 	AliasFor string `json:"aliasFor,omitempty" yaml:"aliasFor,omitempty"`
 }
 
+// DNSWildcardPrefix is the prefix of the name of a wildcard DNSMapping.
+const DNSWildcardPrefix = "*."
+
+// DNSWildcardMatch is replaced with the labels that a wildcard matched in the alias of a wildcard DNSMapping.
+const DNSWildcardMatch = "$1"
+
+// IsWildcard returns true if this is a wildcard mapping.
+func (m *DNSMapping) IsWildcard() bool {
+	return strings.HasPrefix(m.Name, DNSWildcardPrefix)
+}
+
+// Validate checks that the name and the alias of this mapping are well-formed.
+func (m *DNSMapping) Validate() error {
+	if m.Name == "" || m.AliasFor == "" {
+		return fmt.Errorf("DNS mapping %q=%q must have both a name and an alias", m.Name, m.AliasFor)
+	}
+	name := strings.TrimPrefix(m.Name, DNSWildcardPrefix)
+	if name == "" || strings.Contains(name, "*") {
+		return fmt.Errorf("DNS mapping %q: a wildcard is only allowed as the first label of a name", m.Name)
+	}
+	if !m.IsWildcard() && strings.Contains(m.AliasFor, DNSWildcardMatch) {
+		return fmt.Errorf("DNS mapping %q: %s is only allowed in the alias of a wildcard name", m.Name, DNSWildcardMatch)
+	}
+	return nil
+}
+
 type DNSMappings []*DNSMapping
 
+// Validate checks that all mappings are well-formed.
+func (d DNSMappings) Validate() error {
+	for _, m := range d {
+		if err := m.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseDNSMapping parses a mapping in the form NAME=ALIAS.
+func ParseDNSMapping(s string) (*DNSMapping, error) {
+	name, alias, ok := strings.Cut(s, "=")
+	if !ok {
+		return nil, fmt.Errorf("DNS mapping %q must be in the form NAME=ALIAS", s)
+	}
+	m := &DNSMapping{Name: strings.TrimSpace(name), AliasFor: strings.TrimSpace(alias)}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (d *DNSMappings) FromRPC(rpcMappings []*rpc.DNSMapping) {
 	*d = make(DNSMappings, 0, len(rpcMappings))
 	for i := range rpcMappings {
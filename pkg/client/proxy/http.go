@@ -0,0 +1,58 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+)
+
+// serveHTTP reads an HTTP CONNECT request from the given connection and tunnels the connection to the
+// requested host and port. Other HTTP methods are answered with 405 Method Not Allowed.
+func (s *Server) serveHTTP(ctx context.Context, conn *bufferedConn) error {
+	req, err := http.ReadRequest(conn.rd)
+	if err != nil {
+		httpReply(conn, http.StatusBadRequest)
+		return err
+	}
+	if req.Method != http.MethodConnect {
+		httpReply(conn, http.StatusMethodNotAllowed)
+		return fmt.Errorf("unsupported HTTP method %s", req.Method)
+	}
+	host, portStr, err := net.SplitHostPort(req.Host)
+	if err != nil {
+		httpReply(conn, http.StatusBadRequest)
+		return err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		httpReply(conn, http.StatusBadRequest)
+		return fmt.Errorf("invalid port in %q", req.Host)
+	}
+	ip, err := s.resolveHost(ctx, host)
+	if err != nil {
+		httpReply(conn, http.StatusBadGateway)
+		return err
+	}
+	start, err := s.dial(ctx, conn, netip.AddrPortFrom(ip, uint16(port)))
+	if err != nil {
+		if errors.Is(err, errRejected) {
+			httpReply(conn, http.StatusBadGateway)
+		} else {
+			httpReply(conn, http.StatusServiceUnavailable)
+		}
+		return fmt.Errorf("unable to connect to %s: %w", req.Host, err)
+	}
+	if _, err = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+		return err
+	}
+	start()
+	return nil
+}
+
+func httpReply(conn net.Conn, code int) {
+	_, _ = fmt.Fprintf(conn, "HTTP/1.1 %d %s\r\nContent-Length: 0\r\nConnection: close\r\n\r\n", code, http.StatusText(code))
+}
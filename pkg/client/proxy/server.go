@@ -0,0 +1,146 @@
+// Package proxy contains the SOCKS5 and HTTP CONNECT proxy that is served by "telepresence connect --proxy-only".
+// Both protocols are served on the same listener. The connections are tunneled to the cluster using the same
+// streams as the connections that pass the virtual network interface, and host names are resolved by the
+// cluster.
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// DefaultAddress is the address that the proxy listens on unless another address is given.
+const DefaultAddress = "127.0.0.1:1080"
+
+// handshakeTimeout is the max time that a client may spend on the SOCKS5 or HTTP CONNECT handshake.
+const handshakeTimeout = 10 * time.Second
+
+// Resolver resolves a host name to an IP address.
+type Resolver func(ctx context.Context, name string) (netip.Addr, error)
+
+var (
+	// errUnresolved is returned when the host name of a request cannot be resolved.
+	errUnresolved = errors.New("host is unreachable")
+
+	// errRejected is returned when the cluster rejects the dial.
+	errRejected = errors.New("connection refused")
+)
+
+// Server is a proxy that accepts SOCKS5 and HTTP CONNECT requests and dials the requested destinations
+// using a tunnel.StreamCreator.
+type Server struct {
+	streamCreator tunnel.StreamCreator
+	resolve       Resolver
+}
+
+// NewServer returns a Server that creates streams using the given creator and resolves host names
+// using the given resolver.
+func NewServer(streamCreator tunnel.StreamCreator, resolve Resolver) *Server {
+	return &Server{streamCreator: streamCreator, resolve: resolve}
+}
+
+// Serve serves the proxy on the given listener until the context is cancelled. The listener is closed
+// when Serve returns.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	stop := context.AfterFunc(ctx, func() { _ = l.Close() })
+	defer stop()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			_ = l.Close()
+			return err
+		}
+		go s.handle(ctx, conn)
+	}
+}
+
+// bufferedConn is a net.Conn that reads what remains in the reader that was used during the handshake
+// before it reads from the connection.
+type bufferedConn struct {
+	net.Conn
+	rd *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.rd.Read(b)
+}
+
+func (s *Server) handle(ctx context.Context, conn net.Conn) {
+	_ = conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	rd := bufio.NewReader(conn)
+	first, err := rd.Peek(1)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	bc := &bufferedConn{Conn: conn, rd: rd}
+	if first[0] == socksVersion {
+		err = s.serveSOCKS(ctx, bc)
+	} else {
+		err = s.serveHTTP(ctx, bc)
+	}
+	if err != nil {
+		dlog.Debugf(ctx, "proxy request from %s failed: %v", conn.RemoteAddr(), err)
+		_ = conn.Close()
+	}
+}
+
+// resolveHost returns the IP address of the given host, which is either an IP address or a name.
+func (s *Server) resolveHost(ctx context.Context, host string) (netip.Addr, error) {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return ip.Unmap(), nil
+	}
+	ip, err := s.resolve(ctx, host)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("%w: %v", errUnresolved, err)
+	}
+	return ip, nil
+}
+
+// dial creates a stream to the given destination and waits for the cluster to answer the dial. It
+// returns a function that must be called once the client has been told that the dial succeeded.
+func (s *Server) dial(ctx context.Context, conn net.Conn, dst netip.AddrPort) (func(), error) {
+	src, ok := conn.RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		return nil, fmt.Errorf("unexpected client address %s", conn.RemoteAddr())
+	}
+	id := tunnel.NewConnID(ipproto.TCP, src.IP, dst.Addr().AsSlice(), uint16(src.Port), dst.Port())
+
+	// The handshake is complete, and the dial has its own timeout.
+	_ = conn.SetDeadline(time.Time{})
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := s.streamCreator(ctx, id)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The first message from the peer tells if the dial succeeded.
+	dc, dCancel := context.WithTimeout(ctx, stream.DialTimeout()+time.Second)
+	m, err := stream.Receive(dc)
+	dCancel()
+	if err == nil && m.Code() != tunnel.DialOK {
+		err = errRejected
+	}
+	if err != nil {
+		_ = stream.CloseSend(ctx)
+		cancel()
+		return nil, err
+	}
+	dlog.Debugf(ctx, "proxy connection %s established", id)
+	return func() {
+		tunnel.NewConnEndpoint(stream, conn, cancel, nil, nil).Start(ctx)
+	}, nil
+}
@@ -0,0 +1,148 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/proxy"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// startEcho starts a TCP server that echoes everything it reads and returns its port.
+func startEcho(t *testing.T) uint16 {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _ = io.Copy(conn, conn)
+				_ = conn.Close()
+			}()
+		}
+	}()
+	return uint16(l.Addr().(*net.TCPAddr).Port)
+}
+
+// closedPort returns a port that nothing listens on.
+func closedPort(t *testing.T) uint16 {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := uint16(l.Addr().(*net.TCPAddr).Port)
+	require.NoError(t, l.Close())
+	return port
+}
+
+// startProxy starts a proxy that dials its destinations locally and resolves "echo.test" to the loopback address.
+func startProxy(t *testing.T) string {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	t.Cleanup(cancel)
+	streamCreator := func(ctx context.Context, id tunnel.ConnID) (tunnel.Stream, error) {
+		from, to := tunnel.NewPipe(id, "session")
+		tunnel.NewDialer(to, func() {}, nil, nil).Start(ctx)
+		return from, nil
+	}
+	resolve := func(_ context.Context, name string) (netip.Addr, error) {
+		if name == "echo.test" {
+			return netip.MustParseAddr("127.0.0.1"), nil
+		}
+		return netip.Addr{}, fmt.Errorf("no such host %s", name)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = NewServer(streamCreator, resolve).Serve(ctx, l)
+	}()
+	return l.Addr().String()
+}
+
+func assertEcho(t *testing.T, conn net.Conn) {
+	msg := []byte("hello through the proxy")
+	_, err := conn.Write(msg)
+	require.NoError(t, err)
+	buf := make([]byte, len(msg))
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, msg, buf)
+}
+
+func TestSOCKS5(t *testing.T) {
+	echoPort := startEcho(t)
+	d, err := proxy.SOCKS5("tcp", startProxy(t), nil, proxy.Direct)
+	require.NoError(t, err)
+
+	t.Run("domain name", func(t *testing.T) {
+		conn, err := d.Dial("tcp", fmt.Sprintf("echo.test:%d", echoPort))
+		require.NoError(t, err)
+		defer conn.Close()
+		assertEcho(t, conn)
+	})
+
+	t.Run("IP address", func(t *testing.T) {
+		conn, err := d.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", echoPort))
+		require.NoError(t, err)
+		defer conn.Close()
+		assertEcho(t, conn)
+	})
+
+	t.Run("unknown host", func(t *testing.T) {
+		_, err := d.Dial("tcp", fmt.Sprintf("unknown.test:%d", echoPort))
+		assert.ErrorContains(t, err, "host unreachable")
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		_, err := d.Dial("tcp", fmt.Sprintf("echo.test:%d", closedPort(t)))
+		assert.ErrorContains(t, err, "connection refused")
+	})
+}
+
+func TestHTTPConnect(t *testing.T) {
+	echoPort := startEcho(t)
+	proxyAddr := startProxy(t)
+
+	connect := func(t *testing.T, method, host string) (net.Conn, *http.Response) {
+		conn, err := net.Dial("tcp", proxyAddr)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+		_, err = fmt.Fprintf(conn, "%s %s HTTP/1.1\r\nHost: %s\r\n\r\n", method, host, host)
+		require.NoError(t, err)
+		rsp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		require.NoError(t, err)
+		return conn, rsp
+	}
+
+	t.Run("connect", func(t *testing.T) {
+		conn, rsp := connect(t, http.MethodConnect, fmt.Sprintf("echo.test:%d", echoPort))
+		require.Equal(t, http.StatusOK, rsp.StatusCode)
+		assertEcho(t, conn)
+	})
+
+	t.Run("unknown host", func(t *testing.T) {
+		_, rsp := connect(t, http.MethodConnect, fmt.Sprintf("unknown.test:%d", echoPort))
+		assert.Equal(t, http.StatusBadGateway, rsp.StatusCode)
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		_, rsp := connect(t, http.MethodConnect, fmt.Sprintf("echo.test:%d", closedPort(t)))
+		assert.Equal(t, http.StatusBadGateway, rsp.StatusCode)
+	})
+
+	t.Run("not connect", func(t *testing.T) {
+		_, rsp := connect(t, http.MethodGet, fmt.Sprintf("echo.test:%d", echoPort))
+		assert.Equal(t, http.StatusMethodNotAllowed, rsp.StatusCode)
+	})
+}
@@ -0,0 +1,133 @@
+package proxy
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+)
+
+// SOCKS5 constants. See https://www.rfc-editor.org/rfc/rfc1928
+const (
+	socksVersion = 0x05
+
+	socksNoAuth       = 0x00
+	socksNoAcceptable = 0xff
+
+	socksConnect = 0x01
+
+	socksIPv4   = 0x01
+	socksDomain = 0x03
+	socksIPv6   = 0x04
+
+	socksSucceeded           = 0x00
+	socksGeneralFailure      = 0x01
+	socksHostUnreachable     = 0x04
+	socksConnectionRefused   = 0x05
+	socksCommandNotSupported = 0x07
+	socksAddrNotSupported    = 0x08
+)
+
+// serveSOCKS performs the SOCKS5 handshake and request on the given connection. Only the CONNECT command
+// is supported, and only without authentication. Domain names are resolved by the cluster.
+func (s *Server) serveSOCKS(ctx context.Context, conn net.Conn) error {
+	var hdr [2]byte
+	if _, err := io.ReadFull(conn, hdr[:]); err != nil {
+		return err
+	}
+	methods := make([]byte, hdr[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return err
+	}
+	if !slices.Contains(methods, socksNoAuth) {
+		_, _ = conn.Write([]byte{socksVersion, socksNoAcceptable})
+		return errors.New("SOCKS5 client does not support the no authentication method")
+	}
+	if _, err := conn.Write([]byte{socksVersion, socksNoAuth}); err != nil {
+		return err
+	}
+
+	var req [4]byte
+	if _, err := io.ReadFull(conn, req[:]); err != nil {
+		return err
+	}
+	if req[0] != socksVersion {
+		return fmt.Errorf("invalid SOCKS version %d", req[0])
+	}
+	host, err := readSOCKSAddr(conn, req[3])
+	if err != nil {
+		if errors.Is(err, errAddrNotSupported) {
+			_ = socksReply(conn, socksAddrNotSupported)
+		}
+		return err
+	}
+	var pb [2]byte
+	if _, err = io.ReadFull(conn, pb[:]); err != nil {
+		return err
+	}
+	if req[1] != socksConnect {
+		_ = socksReply(conn, socksCommandNotSupported)
+		return fmt.Errorf("unsupported SOCKS command %d", req[1])
+	}
+
+	ip, err := s.resolveHost(ctx, host)
+	if err != nil {
+		_ = socksReply(conn, socksHostUnreachable)
+		return err
+	}
+	start, err := s.dial(ctx, conn, netip.AddrPortFrom(ip, binary.BigEndian.Uint16(pb[:])))
+	if err != nil {
+		rep := byte(socksGeneralFailure)
+		if errors.Is(err, errRejected) {
+			rep = socksConnectionRefused
+		}
+		_ = socksReply(conn, rep)
+		return fmt.Errorf("unable to connect to %s: %w", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(pb[:])))), err)
+	}
+	if err = socksReply(conn, socksSucceeded); err != nil {
+		return err
+	}
+	start()
+	return nil
+}
+
+var errAddrNotSupported = errors.New("unsupported SOCKS address type")
+
+// readSOCKSAddr reads an address of the given type and returns it in string form.
+func readSOCKSAddr(rd io.Reader, atyp byte) (string, error) {
+	var addr []byte
+	switch atyp {
+	case socksIPv4:
+		addr = make([]byte, 4)
+	case socksIPv6:
+		addr = make([]byte, 16)
+	case socksDomain:
+		var l [1]byte
+		if _, err := io.ReadFull(rd, l[:]); err != nil {
+			return "", err
+		}
+		addr = make([]byte, l[0])
+	default:
+		return "", fmt.Errorf("%w %d", errAddrNotSupported, atyp)
+	}
+	if _, err := io.ReadFull(rd, addr); err != nil {
+		return "", err
+	}
+	if atyp == socksDomain {
+		return string(addr), nil
+	}
+	ip, _ := netip.AddrFromSlice(addr)
+	return ip.String(), nil
+}
+
+// socksReply writes a SOCKS5 reply with the given code. The bound address is always reported as 0.0.0.0:0
+// because it's an address in the cluster that the client has no use for.
+func socksReply(w io.Writer, rep byte) error {
+	_, err := w.Write([]byte{socksVersion, rep, 0, socksIPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
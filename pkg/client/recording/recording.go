@@ -0,0 +1,271 @@
+// Package recording contains the writer and reader of the files that are produced when intercepted
+// connections are recorded using "telepresence intercept --record". A recording is a JSON stream. The
+// first value is a Header, and it is followed by one Event for each thing that happened on a recorded
+// connection.
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+const (
+	// Format identifies a recording file.
+	Format = "telepresence-recording"
+
+	// Version is the version of the recording file format.
+	Version = 1
+)
+
+// EventType is the type of recorded event.
+type EventType string
+
+const (
+	// Open is recorded when a connection is established.
+	Open EventType = "open"
+
+	// Client is recorded when data arrives from the client of the intercepted service.
+	Client EventType = "client"
+
+	// Server is recorded when data arrives from the intercept handler on the workstation.
+	Server EventType = "server"
+
+	// Close is recorded when a connection is closed.
+	Close EventType = "close"
+
+	// Dropped is recorded when events were dropped because the recording couldn't keep up with the
+	// connections. It doesn't belong to a connection.
+	Dropped EventType = "dropped"
+)
+
+// Header is the first value of a recording.
+type Header struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	Intercept string    `json:"intercept"`
+	Started   time.Time `json:"started"`
+}
+
+// Event is something that happened on a recorded connection.
+type Event struct {
+	Time time.Time `json:"time"`
+	Type EventType `json:"type"`
+
+	// Conn is the tunnel.ConnID of the connection, formatted as "<proto> <source> -> <destination>".
+	Conn string `json:"conn"`
+
+	// Protocol, Source, and Destination are only present in Open events.
+	Protocol    string `json:"protocol,omitempty"`
+	Source      string `json:"source,omitempty"`
+	Destination string `json:"destination,omitempty"`
+
+	Data []byte `json:"data,omitempty"`
+
+	// Count is the number of dropped events. It is only present in Dropped events.
+	Count uint64 `json:"count,omitempty"`
+}
+
+// Writer writes a recording to a file, or to any other io.Writer. It implements the tunnel.Recorder interface.
+type Writer struct {
+	mu  sync.Mutex
+	out io.Writer
+	enc *json.Encoder
+	err error
+}
+
+// Create creates or truncates the file with the given path and writes a Header for the given intercept to it.
+func Create(path, intercept string) (*Writer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	w := NewWriter(f)
+	if err = w.enc.Encode(&Header{Format: Format, Version: Version, Intercept: intercept, Started: time.Now()}); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// NewWriter returns a Writer that writes the events of a recording to the given writer. The encoder writes
+// each event using one single call to Write, so the events can be sent to another Writer.
+func NewWriter(out io.Writer) *Writer {
+	return &Writer{out: out, enc: json.NewEncoder(out)}
+}
+
+// Close closes the writer, and the underlying writer if it's an io.Closer.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.out == nil {
+		return w.err
+	}
+	var err error
+	if c, ok := w.out.(io.Closer); ok {
+		err = c.Close()
+	}
+	w.out = nil
+	if w.err == nil {
+		w.err = err
+	}
+	return w.err
+}
+
+// Err returns the first error that occurred when writing an event.
+func (w *Writer) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+func (w *Writer) write(ev *Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.out == nil || w.err != nil {
+		return
+	}
+	w.err = w.enc.Encode(ev)
+}
+
+// WriteEvents writes events that were written by another Writer.
+func (w *Writer) WriteEvents(events []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.out == nil || w.err != nil {
+		return
+	}
+	_, w.err = w.out.Write(events)
+}
+
+// RecordDropped records that the given number of events were dropped.
+func (w *Writer) RecordDropped(count uint64) {
+	w.write(&Event{Time: time.Now(), Type: Dropped, Count: count})
+}
+
+func (w *Writer) RecordOpen(id tunnel.ConnID) {
+	w.write(&Event{
+		Time:        time.Now(),
+		Type:        Open,
+		Conn:        id.String(),
+		Protocol:    ipproto.String(id.Protocol()),
+		Source:      iputil.JoinIpPort(id.Source(), id.SourcePort()),
+		Destination: iputil.JoinIpPort(id.Destination(), id.DestinationPort()),
+	})
+}
+
+func (w *Writer) RecordPeerData(id tunnel.ConnID, data []byte) {
+	w.write(&Event{Time: time.Now(), Type: Client, Conn: id.String(), Data: data})
+}
+
+func (w *Writer) RecordConnData(id tunnel.ConnID, data []byte) {
+	w.write(&Event{Time: time.Now(), Type: Server, Conn: id.String(), Data: data})
+}
+
+func (w *Writer) RecordClose(id tunnel.ConnID) {
+	w.write(&Event{Time: time.Now(), Type: Close, Conn: id.String()})
+}
+
+// Conn is a recorded connection.
+type Conn struct {
+	ID          string
+	Protocol    string
+	Source      string
+	Destination string
+	Opened      time.Time
+
+	// Events are the Client, Server, and Close events of the connection, in the order they were recorded.
+	Events []*Event
+}
+
+// Recording is the content of a recording file.
+type Recording struct {
+	Header
+
+	// Conns are the recorded connections, in the order they were opened.
+	Conns []*Conn
+
+	// Dropped is the number of events that were dropped when the recording was made. The connections
+	// of a recording with dropped events may lack data, or never be closed.
+	Dropped uint64
+}
+
+// Read reads a recording from the given reader.
+func Read(r io.Reader) (*Recording, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	rec := &Recording{}
+	if err := dec.Decode(&rec.Header); err != nil {
+		return nil, fmt.Errorf("unable to read recording header: %w", err)
+	}
+	if rec.Format != Format {
+		return nil, errors.New("not a telepresence recording")
+	}
+	if rec.Version > Version {
+		return nil, fmt.Errorf("unsupported recording version %d", rec.Version)
+	}
+
+	// A connection ID is reused when a caller reuses its source port, so connections are
+	// tracked by their ID only while they are open.
+	open := make(map[string]*Conn)
+	for {
+		ev := &Event{}
+		if err := dec.Decode(ev); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				// The last event was only partially written, which happens if the recording process is killed.
+				break
+			}
+			return nil, fmt.Errorf("unable to read recording event: %w", err)
+		}
+		switch ev.Type {
+		case Dropped:
+			rec.Dropped += ev.Count
+			continue
+		case Open:
+			c := &Conn{
+				ID:          ev.Conn,
+				Protocol:    ev.Protocol,
+				Source:      ev.Source,
+				Destination: ev.Destination,
+				Opened:      ev.Time,
+			}
+			open[ev.Conn] = c
+			rec.Conns = append(rec.Conns, c)
+			continue
+		}
+		c, ok := open[ev.Conn]
+		if !ok {
+			continue
+		}
+		c.Events = append(c.Events, ev)
+		if ev.Type == Close {
+			delete(open, ev.Conn)
+		}
+	}
+
+	// Events from several writers may be slightly out of order.
+	sort.SliceStable(rec.Conns, func(i, j int) bool { return rec.Conns[i].Opened.Before(rec.Conns[j].Opened) })
+	return rec, nil
+}
+
+// ReadFile reads a recording from the file with the given path.
+func ReadFile(path string) (*Recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
@@ -0,0 +1,117 @@
+package recording
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// eventsWriter writes the events that it receives to a Writer.
+type eventsWriter struct {
+	w *Writer
+}
+
+func (ew eventsWriter) Write(events []byte) (int, error) {
+	ew.w.WriteEvents(events)
+	return len(events), nil
+}
+
+func TestRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rec.json")
+	w, err := Create(path, "echo-easy")
+	require.NoError(t, err)
+
+	// A second writer, like the one in the root daemon, whose events are streamed to the first writer.
+	w2 := NewWriter(eventsWriter{w})
+
+	dst := net.IP{127, 0, 0, 1}
+	id1 := tunnel.NewConnID(ipproto.TCP, net.IP{10, 1, 2, 3}, dst, 34567, 8080)
+	id2 := tunnel.NewConnID(ipproto.TCP, net.IP{10, 1, 2, 4}, dst, 45678, 8080)
+
+	w.RecordOpen(id1)
+	w.RecordPeerData(id1, []byte("GET / HTTP/1.1\r\n\r\n"))
+	w2.RecordOpen(id2)
+	w.RecordConnData(id1, []byte("HTTP/1.1 200 OK\r\n\r\n"))
+	w2.RecordPeerData(id2, []byte("ping"))
+	w.RecordClose(id1)
+	w2.RecordClose(id2)
+
+	// The same connection ID is reused.
+	w.RecordOpen(id1)
+	w.RecordPeerData(id1, []byte("pong"))
+	require.NoError(t, w2.Close())
+	require.NoError(t, w.Close())
+
+	rec, err := ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "echo-easy", rec.Intercept)
+	require.Len(t, rec.Conns, 3)
+
+	c := rec.Conns[0]
+	assert.Equal(t, id1.String(), c.ID)
+	assert.Equal(t, "tcp", c.Protocol)
+	assert.Equal(t, "10.1.2.3:34567", c.Source)
+	assert.Equal(t, "127.0.0.1:8080", c.Destination)
+	require.Len(t, c.Events, 3)
+	assert.Equal(t, Client, c.Events[0].Type)
+	assert.Equal(t, []byte("GET / HTTP/1.1\r\n\r\n"), c.Events[0].Data)
+	assert.Equal(t, Server, c.Events[1].Type)
+	assert.Equal(t, Close, c.Events[2].Type)
+
+	c = rec.Conns[1]
+	assert.Equal(t, id2.String(), c.ID)
+	require.Len(t, c.Events, 2)
+	assert.Equal(t, []byte("ping"), c.Events[0].Data)
+
+	c = rec.Conns[2]
+	assert.Equal(t, id1.String(), c.ID)
+	require.Len(t, c.Events, 1)
+	assert.Equal(t, []byte("pong"), c.Events[0].Data)
+}
+
+func TestRead_truncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rec.json")
+	w, err := Create(path, "echo-easy")
+	require.NoError(t, err)
+	id := tunnel.NewConnID(ipproto.TCP, net.IP{10, 1, 2, 3}, net.IP{127, 0, 0, 1}, 34567, 8080)
+	w.RecordOpen(id)
+	w.RecordPeerData(id, []byte("hello"))
+	require.NoError(t, w.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	rec, err := Read(bytes.NewReader(data[:len(data)-5]))
+	require.NoError(t, err)
+	require.Len(t, rec.Conns, 1)
+	assert.Empty(t, rec.Conns[0].Events)
+
+	_, err = Read(bytes.NewReader([]byte(`{"format":"something else"}`)))
+	assert.Error(t, err)
+}
+
+func TestRead_dropped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rec.json")
+	w, err := Create(path, "echo-easy")
+	require.NoError(t, err)
+	id := tunnel.NewConnID(ipproto.TCP, net.IP{10, 1, 2, 3}, net.IP{127, 0, 0, 1}, 34567, 8080)
+	w.RecordOpen(id)
+	w.RecordDropped(3)
+	w.RecordPeerData(id, []byte("hello"))
+	w.RecordDropped(2)
+	require.NoError(t, w.Close())
+
+	rec, err := ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), rec.Dropped)
+	require.Len(t, rec.Conns, 1)
+	require.Len(t, rec.Conns[0].Events, 1)
+	assert.Equal(t, Client, rec.Conns[0].Events[0].Type)
+}
@@ -0,0 +1,64 @@
+package rootd
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/capture"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/sendqueue"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// Capture sends the packets that pass the VIF, and packets that represent the intercepted connections that
+// are dialed in this process, as pcapng blocks to the given send function until the given context is
+// cancelled or the session ends. The caller writes the capture file, because this daemon runs as root.
+func (s *Session) Capture(ctx context.Context, req *rpc.CaptureRequest, send func(*rpc.CaptureBlocks) error) error {
+	if !s.capturing.CompareAndSwap(false, true) {
+		return status.Error(codes.AlreadyExists, "a capture is already in progress")
+	}
+	defer s.capturing.Store(false)
+
+	q := sendqueue.New(sendqueue.Size)
+	w := capture.NewWriter(q)
+	filter := capture.FilterFromRPC(req)
+	dlog.Info(ctx, "Capturing packets")
+	if s.tunVif != nil {
+		s.tunVif.Device.SetTap(func(data []byte) {
+			if filter.Match(data) {
+				w.WritePacket(capture.InterfaceVIF, time.Now(), data)
+			}
+		})
+	}
+	r := capture.NewRecorder(w, filter)
+	tunnel.AddTap(r)
+	defer func() {
+		tunnel.RemoveTap(r)
+		if s.tunVif != nil {
+			s.tunVif.Device.SetTap(nil)
+		}
+		_ = w.Close()
+		if n := q.Dropped(); n > 0 {
+			dlog.Warnf(ctx, "Capture ended. %d packets were dropped", n)
+		} else {
+			dlog.Info(ctx, "Capture ended")
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.done:
+			return nil
+		case blocks := <-q.Data():
+			if err := send(&rpc.CaptureBlocks{Blocks: blocks}); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package rootd
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// trackedConn is a connection that is tunneled to the cluster. It is listed by "telepresence connections".
+type trackedConn struct {
+	id       tunnel.ConnID
+	peer     string
+	opened   time.Time
+	bytesIn  *tunnel.CounterProbe
+	bytesOut *tunnel.CounterProbe
+}
+
+// trackedStream counts the payload bytes that are received and sent on a tunnel.Stream. The bytes are
+// counted the same way that tunnel.ReadLoop and tunnel.WriteLoop count them for the TunnelMetrics.
+type trackedStream struct {
+	tunnel.Stream
+	conn *trackedConn
+}
+
+func (s *trackedStream) Receive(ctx context.Context) (tunnel.Message, error) {
+	m, err := s.Stream.Receive(ctx)
+	if m != nil {
+		s.conn.bytesIn.Increment(uint64(len(m.Payload())))
+	}
+	return m, err
+}
+
+func (s *trackedStream) Send(ctx context.Context, m tunnel.Message) error {
+	err := s.Stream.Send(ctx, m)
+	if m != nil {
+		s.conn.bytesOut.Increment(uint64(len(m.Payload())))
+	}
+	return err
+}
+
+// trackConnection registers the connection with the given id as open until the given context is cancelled,
+// and returns a stream that counts the bytes that are transferred on it.
+func (s *Session) trackConnection(ctx context.Context, id tunnel.ConnID, peer string, stream tunnel.Stream) tunnel.Stream {
+	tc := &trackedConn{
+		id:       id,
+		peer:     peer,
+		opened:   time.Now(),
+		bytesIn:  tunnel.NewCounterProbe("IngressBytes"),
+		bytesOut: tunnel.NewCounterProbe("EgressBytes"),
+	}
+	s.connections.Store(id, tc)
+	context.AfterFunc(ctx, func() {
+		s.connections.Compute(id, func(old *trackedConn, loaded bool) (*trackedConn, bool) {
+			// A new connection with the same id might have replaced this one.
+			return old, !loaded || old == tc
+		})
+	})
+	return &trackedStream{Stream: stream, conn: tc}
+}
+
+// Connections returns the connections that are currently tunneled to the cluster, oldest first.
+func (s *Session) Connections(ctx context.Context) []*rpc.Connection {
+	var tcs []*trackedConn
+	s.connections.Range(func(_ tunnel.ConnID, tc *trackedConn) bool {
+		tcs = append(tcs, tc)
+		return true
+	})
+	slices.SortFunc(tcs, func(a, b *trackedConn) int {
+		return a.opened.Compare(b.opened)
+	})
+	conns := make([]*rpc.Connection, len(tcs))
+	for i, tc := range tcs {
+		id := tc.id
+		conns[i] = &rpc.Connection{
+			Protocol:        ipproto.String(id.Protocol()),
+			SourceIp:        id.Source(),
+			SourcePort:      int32(id.SourcePort()),
+			DestinationIp:   id.Destination(),
+			DestinationPort: int32(id.DestinationPort()),
+			Peer:            tc.peer,
+			BytesIn:         tc.bytesIn.GetValue(),
+			BytesOut:        tc.bytesOut.GetValue(),
+			Opened:          timestamppb.New(tc.opened),
+		}
+	}
+	lookupProcesses(ctx, conns)
+	return conns
+}
+
+// TunnelStats returns the latency and throughput statistics of the destinations in the cluster.
+func (s *Session) TunnelStats() *rpc.TunnelStats {
+	return &rpc.TunnelStats{Destinations: s.tunnelStats.Snapshot("root")}
+}
@@ -0,0 +1,129 @@
+package dns
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/dnsproxy"
+)
+
+// cacheTTL returns the time that an answer with the given rCode is kept in the cache, or zero when
+// it must not be cached at all. The configured TTLs are caps. An answer is never kept longer than the
+// smallest TTL of its records.
+func (s *Server) cacheTTL(answer dnsproxy.RRs, rCode int, err error) time.Duration {
+	var ttl time.Duration
+	switch {
+	case err != nil:
+		return 0
+	case rCode == dns.RcodeSuccess:
+		ttl = defaultCacheTTL
+		if s.CacheTTL > 0 {
+			ttl = s.CacheTTL
+		}
+	case rCode == dns.RcodeNameError:
+		ttl = s.NegativeCacheTTL
+	default:
+		return 0
+	}
+	if rt, ok := recordsTTL(answer, rCode); ok {
+		ttl = min(ttl, rt)
+	}
+	return ttl
+}
+
+// recordsTTL returns the smallest TTL of the given records. Only SOA records are considered for an
+// NXDOMAIN answer, and their minimum TTL applies when it's smaller than the record's own TTL. The
+// returned flag is false when no record was considered.
+func recordsTTL(answer dnsproxy.RRs, rCode int) (time.Duration, bool) {
+	var ttl uint32
+	found := false
+	for _, rr := range answer {
+		t := rr.Header().Ttl
+		if rCode == dns.RcodeNameError {
+			soa, ok := rr.(*dns.SOA)
+			if !ok {
+				continue
+			}
+			t = min(t, soa.Minttl)
+		}
+		if !found || t < ttl {
+			ttl = t
+			found = true
+		}
+	}
+	return time.Duration(ttl) * time.Second, found
+}
+
+// rangeCache calls the given function for each entry in the cache that is resolved and hasn't expired.
+func (s *Server) rangeCache(f func(cacheKey, *cacheEntry)) {
+	s.cache.Range(func(key cacheKey, ce *cacheEntry) bool {
+		select {
+		case <-ce.wait:
+			if !ce.expired() {
+				f(key, ce)
+			}
+		default:
+			// Still resolving.
+		}
+		return true
+	})
+}
+
+// CacheEntries returns the entries of the cache that haven't expired, sorted by name and type.
+func (s *Server) CacheEntries() []*rpc.DNSCacheEntry {
+	var es []*rpc.DNSCacheEntry
+	now := time.Now()
+	s.rangeCache(func(key cacheKey, ce *cacheEntry) {
+		age := now.Sub(ce.created)
+		es = append(es, &rpc.DNSCacheEntry{
+			Name:   key.name,
+			Type:   dns.TypeToString[key.qType],
+			Rcode:  dns.RcodeToString[ce.rCode],
+			Answer: ce.answer.String(),
+			Age:    durationpb.New(age),
+			Ttl:    durationpb.New(max(ce.ttl-age, 0)),
+		})
+	})
+	slices.SortFunc(es, func(a, b *rpc.DNSCacheEntry) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.Type, b.Type))
+	})
+	return es
+}
+
+// CacheStats returns the number of entries in the cache, and the number of cache hits and misses.
+func (s *Server) CacheStats() *rpc.DNSCacheStats {
+	var n int32
+	s.rangeCache(func(cacheKey, *cacheEntry) {
+		n++
+	})
+	return &rpc.DNSCacheStats{
+		Entries: n,
+		Hits:    s.cacheHits.Load(),
+		Misses:  s.cacheMisses.Load(),
+	}
+}
+
+// FlushCache removes the entries of the given name from the cache, or all entries when the name is
+// empty, and returns the number of entries that were removed.
+func (s *Server) FlushCache(name string) int {
+	if name != "" {
+		name = strings.ToLower(strings.TrimSuffix(name, ".")) + "."
+	}
+	n := 0
+	s.cache.Range(func(key cacheKey, _ *cacheEntry) bool {
+		if name == "" || key.name == name {
+			if old, ok := s.cache.LoadAndDelete(key); ok {
+				old.close()
+				n++
+			}
+		}
+		return true
+	})
+	return n
+}
@@ -0,0 +1,132 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/puzpuzpuz/xsync/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/dnsproxy"
+)
+
+// newCacheTestServer returns a server that resolves "echo." and "short." in the cluster, and counts the
+// cluster lookups. The record of "short." has a TTL of one second, and so has the SOA record of the NXDOMAIN
+// answer for "gone.".
+func newCacheTestServer(config client.DNS) (*Server, *atomic.Int32) {
+	var lookups atomic.Int32
+	s := &Server{
+		DNS:           config,
+		cache:         xsync.NewMapOf[cacheKey, *cacheEntry](),
+		clusterDomain: defaultClusterDomain,
+		clusterLookup: func(_ context.Context, q *dns.Question) (dnsproxy.RRs, int, error) {
+			lookups.Add(1)
+			switch q.Name {
+			case "echo.", "short.":
+				hdr := dnsproxy.NewHeader(q.Name, q.Qtype)
+				if q.Name == "short." {
+					hdr.Ttl = 1
+				}
+				return dnsproxy.RRs{&dns.A{Hdr: hdr, A: net.IP{10, 0, 0, 1}}}, dns.RcodeSuccess, nil
+			case "gone.":
+				return dnsproxy.RRs{&dns.SOA{
+					Hdr:    dns.RR_Header{Name: q.Name, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 30},
+					Minttl: 1,
+				}}, dns.RcodeNameError, nil
+			default:
+				return nil, dns.RcodeNameError, nil
+			}
+		},
+		ctx: context.Background(),
+	}
+	s.resolve = s.resolveInCluster
+	s.LookupTimeout = time.Second
+	return s, &lookups
+}
+
+func lookupA(t *testing.T, s *Server, name string) int {
+	_, rCode, err := s.resolveThruCache(context.Background(), &dns.Question{Name: name, Qtype: dns.TypeA, Qclass: dns.ClassINET})
+	require.NoError(t, err)
+	return rCode
+}
+
+func TestCache(t *testing.T) {
+	s, lookups := newCacheTestServer(client.DNS{})
+	assert.Equal(t, dns.RcodeSuccess, lookupA(t, s, "echo."))
+	assert.Equal(t, dns.RcodeSuccess, lookupA(t, s, "echo."))
+	assert.Equal(t, int32(1), lookups.Load())
+
+	// NXDOMAIN answers are not cached by default.
+	assert.Equal(t, dns.RcodeNameError, lookupA(t, s, "nope."))
+	assert.Equal(t, dns.RcodeNameError, lookupA(t, s, "nope."))
+	assert.Equal(t, int32(3), lookups.Load())
+
+	st := s.CacheStats()
+	assert.Equal(t, int32(1), st.Entries)
+	assert.Equal(t, uint64(1), st.Hits)
+	assert.Equal(t, uint64(3), st.Misses)
+
+	es := s.CacheEntries()
+	require.Len(t, es, 1)
+	assert.Equal(t, "echo.", es[0].Name)
+	assert.Equal(t, "A", es[0].Type)
+	assert.Equal(t, "NOERROR", es[0].Rcode)
+	assert.LessOrEqual(t, es[0].Ttl.AsDuration(), defaultCacheTTL)
+
+	assert.Equal(t, 0, s.FlushCache("other"))
+	assert.Equal(t, 1, s.FlushCache("ECHO"))
+	assert.Empty(t, s.CacheEntries())
+	assert.Equal(t, dns.RcodeSuccess, lookupA(t, s, "echo."))
+	assert.Equal(t, int32(4), lookups.Load())
+}
+
+func TestCache_ttls(t *testing.T) {
+	s, lookups := newCacheTestServer(client.DNS{CacheTTL: 50 * time.Millisecond, NegativeCacheTTL: time.Minute})
+	assert.Equal(t, dns.RcodeNameError, lookupA(t, s, "nope."))
+	assert.Equal(t, dns.RcodeNameError, lookupA(t, s, "nope."))
+	assert.Equal(t, int32(1), lookups.Load())
+
+	assert.Equal(t, dns.RcodeSuccess, lookupA(t, s, "echo."))
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, dns.RcodeSuccess, lookupA(t, s, "echo."))
+	assert.Equal(t, int32(3), lookups.Load())
+
+	assert.Equal(t, 2, s.FlushCache(""))
+}
+
+func TestCache_recordTTLs(t *testing.T) {
+	s, lookups := newCacheTestServer(client.DNS{NegativeCacheTTL: time.Minute})
+	assert.Equal(t, dns.RcodeSuccess, lookupA(t, s, "short."))
+	assert.Equal(t, dns.RcodeNameError, lookupA(t, s, "gone."))
+	assert.Equal(t, dns.RcodeNameError, lookupA(t, s, "nope."))
+	for _, e := range s.CacheEntries() {
+		if e.Name == "nope." {
+			assert.Greater(t, e.Ttl.AsDuration(), time.Second, "no records, so the cap applies")
+		} else {
+			assert.LessOrEqual(t, e.Ttl.AsDuration(), time.Second, "the record's TTL is smaller than the cap")
+		}
+	}
+
+	// The answers must still be cached until their records expire.
+	assert.Equal(t, dns.RcodeSuccess, lookupA(t, s, "short."))
+	assert.Equal(t, dns.RcodeNameError, lookupA(t, s, "gone."))
+	assert.Equal(t, int32(3), lookups.Load())
+
+	time.Sleep(1100 * time.Millisecond)
+	assert.Equal(t, dns.RcodeSuccess, lookupA(t, s, "short."))
+	assert.Equal(t, dns.RcodeNameError, lookupA(t, s, "gone."))
+	assert.Equal(t, dns.RcodeNameError, lookupA(t, s, "nope."))
+	assert.Equal(t, int32(5), lookups.Load())
+
+	// The TTLs that are returned to the caller are kept low.
+	answer, _, err := s.resolveThruCache(context.Background(), &dns.Question{Name: "echo.", Qtype: dns.TypeA, Qclass: dns.ClassINET})
+	require.NoError(t, err)
+	require.Len(t, answer, 1)
+	assert.Equal(t, uint32(dnsTTL), answer[0].Header().Ttl)
+}
@@ -0,0 +1,73 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+)
+
+// maxConcurrentHostLookups limits the number of names that LookupHosts looks up concurrently.
+const maxConcurrentHostLookups = 16
+
+// LookupHosts looks up the A and AAAA records of the given names, which are relative to the cluster domain.
+// The lookups bypass the cache but are otherwise made the same way as the lookups of the queries that the
+// server receives, so the addresses are virtual IPs when the names resolve to translated subnets. Names that
+// don't resolve to any address are omitted.
+func (s *Server) LookupHosts(ctx context.Context, names []string) []*rpc.HostAddresses {
+	s.RLock()
+	cd := s.clusterDomain
+	s.RUnlock()
+
+	hosts := make([]*rpc.HostAddresses, len(names))
+	sem := make(chan struct{}, maxConcurrentHostLookups)
+	wg := sync.WaitGroup{}
+	for i, name := range names {
+		fqn := strings.ToLower(strings.TrimSuffix(name, ".")) + "." + cd
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			var ips [][]byte
+			for _, qType := range []uint16{dns.TypeA, dns.TypeAAAA} {
+				rrs, _, err := s.resolveInCluster(ctx, &dns.Question{Name: fqn, Qtype: qType, Qclass: dns.ClassINET})
+				if err != nil {
+					dlog.Errorf(ctx, "unable to look up %s %s: %v", dns.TypeToString[qType], fqn, err)
+					continue
+				}
+				for _, rr := range rrs {
+					var ip net.IP
+					switch rr := rr.(type) {
+					case *dns.A:
+						ip = rr.A.To4()
+					case *dns.AAAA:
+						ip = rr.AAAA
+					default:
+						continue
+					}
+					ips = append(ips, ip)
+				}
+			}
+			if len(ips) > 0 {
+				hosts[i] = &rpc.HostAddresses{Name: strings.TrimSuffix(fqn, "."), Ips: ips}
+			}
+		}()
+	}
+	wg.Wait()
+
+	found := hosts[:0]
+	for _, h := range hosts {
+		if h != nil {
+			found = append(found, h)
+		}
+	}
+	return found
+}
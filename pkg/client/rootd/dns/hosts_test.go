@@ -0,0 +1,49 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/puzpuzpuz/xsync/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/dnsproxy"
+)
+
+func TestLookupHosts(t *testing.T) {
+	s := &Server{
+		cache:         xsync.NewMapOf[cacheKey, *cacheEntry](),
+		clusterDomain: defaultClusterDomain,
+		clusterLookup: func(_ context.Context, q *dns.Question) (dnsproxy.RRs, int, error) {
+			hdr := dnsproxy.NewHeader(q.Name, q.Qtype)
+			switch {
+			case q.Name == "echo.default.svc.cluster.local." && q.Qtype == dns.TypeA:
+				return dnsproxy.RRs{&dns.A{Hdr: hdr, A: net.IP{10, 0, 0, 1}}}, dns.RcodeSuccess, nil
+			case q.Name == "dual.default.svc.cluster.local." && q.Qtype == dns.TypeA:
+				return dnsproxy.RRs{&dns.A{Hdr: hdr, A: net.IP{10, 0, 0, 2}}}, dns.RcodeSuccess, nil
+			case q.Name == "dual.default.svc.cluster.local." && q.Qtype == dns.TypeAAAA:
+				return dnsproxy.RRs{&dns.AAAA{Hdr: hdr, AAAA: net.ParseIP("fd00::2")}}, dns.RcodeSuccess, nil
+			case q.Name == "echo.default.svc.cluster.local.", q.Name == "dual.default.svc.cluster.local.":
+				return nil, dns.RcodeSuccess, nil
+			default:
+				return nil, dns.RcodeNameError, nil
+			}
+		},
+		ctx: context.Background(),
+	}
+	s.LookupTimeout = time.Second
+
+	hosts := s.LookupHosts(context.Background(), []string{"Echo.default.svc", "nope.default.svc", "dual.default.svc"})
+	require.Len(t, hosts, 2)
+	assert.Equal(t, "echo.default.svc.cluster.local", hosts[0].Name)
+	assert.Equal(t, [][]byte{{10, 0, 0, 1}}, hosts[0].Ips)
+	assert.Equal(t, "dual.default.svc.cluster.local", hosts[1].Name)
+	assert.Equal(t, [][]byte{{10, 0, 0, 2}, net.ParseIP("fd00::2")}, hosts[1].Ips)
+
+	// The lookups bypass the cache.
+	assert.Empty(t, s.CacheEntries())
+}
@@ -0,0 +1,84 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+// loopbackSubnets are the subnets of the clients that can query a listener that has no allowed subnets.
+var loopbackSubnets = []netip.Prefix{ //nolint:gochecknoglobals // constant
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("::1/128"),
+}
+
+// restrictedHandler is a dns.Handler that refuses queries from clients that don't have an address in one of
+// the allowed subnets.
+type restrictedHandler struct {
+	dns.Handler
+	allowed []netip.Prefix
+}
+
+func (h *restrictedHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	if !h.isAllowed(w.RemoteAddr()) {
+		msg := new(dns.Msg)
+		msg.SetRcode(r, dns.RcodeRefused)
+		_ = w.WriteMsg(msg)
+		return
+	}
+	h.Handler.ServeDNS(w, r)
+}
+
+func (h *restrictedHandler) isAllowed(addr net.Addr) bool {
+	var ap netip.AddrPort
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		ap = a.AddrPort()
+	case *net.TCPAddr:
+		ap = a.AddrPort()
+	default:
+		return false
+	}
+	ip := ap.Addr().Unmap()
+	for _, sn := range h.allowed {
+		if sn.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// listenerServers returns servers for the additional listeners of the DNS configuration. A listener that
+// cannot be opened is logged and skipped, so that it doesn't prevent the DNS server from serving the
+// operating system.
+func (s *Server) listenerServers(ctx context.Context) []*dns.Server {
+	var srvs []*dns.Server
+	for _, l := range s.Listeners {
+		allowed := l.AllowedSubnets
+		if len(allowed) == 0 {
+			allowed = loopbackSubnets
+		}
+		h := &restrictedHandler{Handler: s, allowed: allowed}
+		addr := l.Address.String()
+		n := len(srvs)
+		if pc, err := net.ListenPacket("udp", addr); err != nil {
+			dlog.Errorf(ctx, "unable to open DNS listener: %v", err)
+		} else {
+			srvs = append(srvs, &dns.Server{PacketConn: pc, Handler: h, ReadTimeout: time.Second})
+		}
+		if tl, err := net.Listen("tcp", addr); err != nil {
+			dlog.Errorf(ctx, "unable to open DNS listener: %v", err)
+		} else {
+			srvs = append(srvs, &dns.Server{Listener: tl, Handler: h, ReadTimeout: time.Second})
+		}
+		if len(srvs) > n {
+			dlog.Infof(ctx, "DNS server listening on %s for clients in %v", addr, allowed)
+		}
+	}
+	return srvs
+}
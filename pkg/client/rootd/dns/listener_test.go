@@ -0,0 +1,84 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+)
+
+// freeAddr returns a loopback address with a port that is free for both UDP and TCP.
+func freeAddr(t *testing.T) netip.AddrPort {
+	for {
+		tl, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		ap := tl.Addr().(*net.TCPAddr).AddrPort()
+		pc, err := net.ListenPacket("udp", ap.String())
+		_ = tl.Close()
+		if err == nil {
+			_ = pc.Close()
+			return ap
+		}
+	}
+}
+
+func TestRun_listeners(t *testing.T) {
+	ctx, cancel := context.WithTimeout(dlog.NewTestContext(t, false), 10*time.Second)
+	defer cancel()
+
+	open := freeAddr(t)
+	restricted := freeAddr(t)
+	s := NewServer(&client.DNS{
+		Listeners: []*client.DNSListener{
+			{Address: open},
+			{Address: restricted, AllowedSubnets: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}},
+		},
+	}, nil)
+	s.SetMappings([]*rpc.DNSMapping{{Name: "db.example.com", AliasFor: "10.1.2.3"}})
+
+	initDone := make(chan struct{})
+	runDone := make(chan error, 1)
+	go func() {
+		runDone <- s.Run(ctx, initDone, nil, nil, s.resolveInCluster)
+	}()
+	<-initDone
+
+	m := new(dns.Msg)
+	m.SetQuestion("db.example.com.", dns.TypeA)
+	for _, network := range []string{"udp", "tcp"} {
+		t.Run(network, func(t *testing.T) {
+			dc := &dns.Client{Net: network, Timeout: 2 * time.Second}
+			r, _, err := dc.ExchangeContext(ctx, m, open.String())
+			require.NoError(t, err)
+			assert.Equal(t, dns.RcodeSuccess, r.Rcode)
+			if assert.Len(t, r.Answer, 1) {
+				assert.Equal(t, "10.1.2.3", r.Answer[0].(*dns.A).A.String())
+			}
+
+			// The client isn't in an allowed subnet.
+			r, _, err = dc.ExchangeContext(ctx, m, restricted.String())
+			require.NoError(t, err)
+			assert.Equal(t, dns.RcodeRefused, r.Rcode)
+		})
+	}
+	cancel()
+	<-runDone
+}
+
+func TestRestrictedHandler_isAllowed(t *testing.T) {
+	h := &restrictedHandler{allowed: []netip.Prefix{netip.MustParsePrefix("172.17.0.0/16"), netip.MustParsePrefix("fd00::/8")}}
+	assert.True(t, h.isAllowed(&net.UDPAddr{IP: net.ParseIP("172.17.0.2"), Port: 4711}))
+	assert.True(t, h.isAllowed(&net.TCPAddr{IP: net.ParseIP("::ffff:172.17.0.2"), Port: 4711}))
+	assert.True(t, h.isAllowed(&net.UDPAddr{IP: net.ParseIP("fd00::1"), Port: 4711}))
+	assert.False(t, h.isAllowed(&net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 4711}))
+	assert.False(t, h.isAllowed(&net.UnixAddr{Name: "/tmp/sock"}))
+}
@@ -0,0 +1,174 @@
+package dns
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+)
+
+// The decisions that determine how a query is answered.
+const (
+	decisionMapping  = "mapping"
+	decisionExcluded = "excluded"
+	decisionCluster  = "cluster"
+	decisionFallback = "fallback"
+	decisionLocal    = "local"
+)
+
+const (
+	// queryLogSize is the number of recent queries that are kept in the query log.
+	queryLogSize = 500
+
+	// queryWatcherBuffer is the number of queries that can be buffered for a watcher. Queries are
+	// dropped for watchers that don't keep up.
+	queryWatcherBuffer = 100
+)
+
+// queryTrace collects the decisions taken while a query is resolved.
+type queryTrace struct {
+	sync.Mutex
+	decision string
+	reason   string
+	cacheHit bool
+}
+
+type queryTraceKey struct{}
+
+func withQueryTrace(ctx context.Context, qt *queryTrace) context.Context {
+	return context.WithValue(ctx, queryTraceKey{}, qt)
+}
+
+// getQueryTrace returns the queryTrace of the given context, or nil if there is none. All queryTrace
+// methods can be called on nil.
+func getQueryTrace(ctx context.Context) *queryTrace {
+	qt, _ := ctx.Value(queryTraceKey{}).(*queryTrace)
+	return qt
+}
+
+// decide records the decision and its reason. A reason that is empty doesn't replace the current reason.
+func (qt *queryTrace) decide(decision, reason string) {
+	if qt == nil {
+		return
+	}
+	qt.Lock()
+	qt.decision = decision
+	if reason != "" {
+		qt.reason = reason
+	}
+	qt.Unlock()
+}
+
+func (qt *queryTrace) setCacheHit() {
+	if qt == nil {
+		return
+	}
+	qt.Lock()
+	qt.cacheHit = true
+	qt.Unlock()
+}
+
+// get returns the decision and its reason.
+func (qt *queryTrace) get() (string, string) {
+	if qt == nil {
+		return "", ""
+	}
+	qt.Lock()
+	defer qt.Unlock()
+	return qt.decision, qt.reason
+}
+
+func (qt *queryTrace) toRPC(start time.Time, name, qType, rCode, answer string) *rpc.DNSQuery {
+	qt.Lock()
+	defer qt.Unlock()
+	return &rpc.DNSQuery{
+		Time:     timestamppb.New(start),
+		Name:     name,
+		Type:     qType,
+		Decision: qt.decision,
+		Reason:   qt.reason,
+		Rcode:    rCode,
+		Answer:   answer,
+		Latency:  durationpb.New(time.Since(start)),
+		CacheHit: qt.cacheHit,
+	}
+}
+
+// queryLog keeps the most recent queries and distributes new queries to its watchers.
+type queryLog struct {
+	sync.Mutex
+	recent   []*rpc.DNSQuery
+	next     int
+	watchers map[chan *rpc.DNSQuery]struct{}
+}
+
+func newQueryLog() *queryLog {
+	return &queryLog{
+		recent:   make([]*rpc.DNSQuery, 0, queryLogSize),
+		watchers: make(map[chan *rpc.DNSQuery]struct{}),
+	}
+}
+
+func (l *queryLog) add(q *rpc.DNSQuery) {
+	l.Lock()
+	defer l.Unlock()
+	if len(l.recent) < queryLogSize {
+		l.recent = append(l.recent, q)
+	} else {
+		l.recent[l.next] = q
+		l.next = (l.next + 1) % queryLogSize
+	}
+	for ch := range l.watchers {
+		select {
+		case ch <- q:
+		default:
+		}
+	}
+}
+
+// watch returns the recent queries, oldest first, and, when follow is true, a channel that receives the
+// queries that are added after that. The channel is closed when the given context is cancelled.
+func (l *queryLog) watch(ctx context.Context, follow bool) ([]*rpc.DNSQuery, <-chan *rpc.DNSQuery) {
+	l.Lock()
+	defer l.Unlock()
+	recent := make([]*rpc.DNSQuery, 0, len(l.recent))
+	recent = append(recent, l.recent[l.next:]...)
+	recent = append(recent, l.recent[:l.next]...)
+	if !follow {
+		return recent, nil
+	}
+	ch := make(chan *rpc.DNSQuery, queryWatcherBuffer)
+	l.watchers[ch] = struct{}{}
+	context.AfterFunc(ctx, func() {
+		l.Lock()
+		delete(l.watchers, ch)
+		close(ch)
+		l.Unlock()
+	})
+	return recent, ch
+}
+
+// WatchQueries calls the given function with the most recent queries that the server has handled and,
+// when follow is true, with each new query until the context is cancelled or the function returns an
+// error. Queries are dropped if the function doesn't keep up.
+func (s *Server) WatchQueries(ctx context.Context, follow bool, f func(*rpc.DNSQuery) error) error {
+	recent, ch := s.queryLog.watch(ctx, follow)
+	for _, q := range recent {
+		if err := f(q); err != nil {
+			return err
+		}
+	}
+	if ch == nil {
+		return nil
+	}
+	for q := range ch {
+		if err := f(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
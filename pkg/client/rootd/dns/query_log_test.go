@@ -0,0 +1,94 @@
+package dns
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/puzpuzpuz/xsync/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/dnsproxy"
+)
+
+func names(qs []*rpc.DNSQuery) []string {
+	ns := make([]string, len(qs))
+	for i, q := range qs {
+		ns[i] = q.Name
+	}
+	return ns
+}
+
+func TestQueryLog(t *testing.T) {
+	l := newQueryLog()
+	for i := 0; i < queryLogSize+2; i++ {
+		l.add(&rpc.DNSQuery{Name: strconv.Itoa(i)})
+	}
+
+	// The oldest queries are dropped, and the rest are returned oldest first.
+	recent, ch := l.watch(context.Background(), false)
+	assert.Nil(t, ch)
+	require.Len(t, recent, queryLogSize)
+	assert.Equal(t, []string{"2", "3"}, names(recent[:2]))
+	assert.Equal(t, strconv.Itoa(queryLogSize+1), recent[queryLogSize-1].Name)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, ch = l.watch(ctx, true)
+	l.add(&rpc.DNSQuery{Name: "new"})
+	select {
+	case q := <-ch:
+		assert.Equal(t, "new", q.Name)
+	case <-time.After(time.Second):
+		t.Fatal("no query received")
+	}
+	cancel()
+	for range ch {
+	}
+	l.Lock()
+	assert.Empty(t, l.watchers)
+	l.Unlock()
+}
+
+func TestQueryTrace(t *testing.T) {
+	s := &Server{
+		cache:         xsync.NewMapOf[cacheKey, *cacheEntry](),
+		clusterDomain: defaultClusterDomain,
+		clusterLookup: func(context.Context, *dns.Question) (dnsproxy.RRs, int, error) {
+			return nil, dns.RcodeNameError, nil
+		},
+		DNS: client.DNS{ExcludeSuffixes: []string{".com"}, LookupTimeout: time.Second},
+	}
+	s.ctx = context.Background()
+	s.resolve = s.resolveInCluster
+	s.SetMappings([]*rpc.DNSMapping{{Name: "*.prod.internal", AliasFor: "10.1.2.3"}})
+
+	tests := []struct {
+		name     string
+		decision string
+		reason   string
+	}{
+		{"orders.prod.internal.", decisionMapping, "mapped to 10.1.2.3"},
+		{"example.com.", decisionExcluded, `excluded by exclude-suffix ".com"`},
+		{"echo.", decisionCluster, "included for single label name"},
+		{"localhost.", decisionLocal, "localhost"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qt := &queryTrace{}
+			c := withQueryTrace(context.Background(), qt)
+			q := &dns.Question{Name: tt.name, Qtype: dns.TypeA, Qclass: dns.ClassINET}
+			_, _, err := s.resolveMapping(c, q)
+			if err == errNoMapping {
+				_, _, err = s.resolveWithRecursionCheck(c, q)
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.decision, qt.decision)
+			assert.Equal(t, tt.reason, qt.reason)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package dns
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
@@ -75,6 +76,8 @@ type Server struct {
 	resolve      Resolver
 	requestCount int64
 	cache        *xsync.MapOf[cacheKey, *cacheEntry]
+	cacheHits    atomic.Uint64
+	cacheMisses  atomic.Uint64
 	recursive    int32 // one of the recursionXXX constants declared above (unique type avoided because it just gets messy with the atomic calls)
 
 	// Suffixes to immediately drop from the query before processing. This list will always contain the tel2Search domain.
@@ -103,9 +106,16 @@ type Server struct {
 	// Function that sends a lookup request to the traffic-manager
 	clusterLookup Resolver
 
-	// mappingsMap is contains the same mappings as DNS.Mappings but as a map (for performance).
+	// mappingsMap is contains the same mappings as DNS.Mappings but as a map (for performance). Wildcard
+	// mappings are not included.
 	mappingsMap map[string]string
 
+	// wildcards contains the wildcard mappings of DNS.Mappings, most specific first.
+	wildcards []wildcardMapping
+
+	// queryLog keeps the most recent queries, and how they were answered, for WatchQueries.
+	queryLog *queryLog
+
 	error string
 
 	// ready is closed when the DNS server is fully configured
@@ -114,17 +124,21 @@ type Server struct {
 
 type cacheEntry struct {
 	created      time.Time
+	ttl          time.Duration
 	currentQType int32 // will be set to the current qType during call to cluster
 	answer       dnsproxy.RRs
 	rCode        int
+	decision     string // the decision of the query that resolved the entry
+	reason       string
 	wait         chan struct{}
 }
 
-// cacheTTL is the time to live for an entry in the local DNS cache.
-const cacheTTL = 60 * time.Second
+// defaultCacheTTL is the time to live for a successful answer in the local DNS cache, unless
+// configured otherwise.
+const defaultCacheTTL = 60 * time.Second
 
 func (dv *cacheEntry) expired() bool {
-	return time.Since(dv.created) > cacheTTL
+	return time.Since(dv.created) > dv.ttl
 }
 
 func (dv *cacheEntry) close() {
@@ -156,6 +170,7 @@ func NewServer(config *client.DNS, clusterLookup Resolver) *Server {
 	return &Server{
 		DNS:            *config,
 		mappingsMap:    mappingsMap(config.Mappings),
+		wildcards:      wildcardMappings(config.Mappings),
 		cache:          xsync.NewMapOf[cacheKey, *cacheEntry](),
 		routes:         make(map[string]struct{}),
 		domains:        make(map[string]struct{}),
@@ -164,6 +179,7 @@ func NewServer(config *client.DNS, clusterLookup Resolver) *Server {
 		nsAndDomainsCh: make(chan nsAndDomains, 5),
 		clusterDomain:  defaultClusterDomain,
 		clusterLookup:  clusterLookup,
+		queryLog:       newQueryLog(),
 		ready:          make(chan struct{}),
 	}
 }
@@ -195,24 +211,23 @@ var (
 	localhostIPv6 = net.IP{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1} //nolint:gochecknoglobals // constant
 )
 
-func (s *Server) shouldDoClusterLookup(query string) bool {
+// shouldDoClusterLookup returns true if the given query should be resolved in the cluster, along with the
+// reason why.
+func (s *Server) shouldDoClusterLookup(query string) (bool, string) {
 	name := query[:len(query)-1] // skip last dot
 	if strings.HasPrefix(query, wpadDot) {
 		// Reject "wpad.*"
-		dlog.Debugf(s.ctx, `Cluster DNS excluded by exclude-prefix "wpad." for name %q`, name)
-		return false
+		return false, `excluded by exclude-prefix "wpad."`
 	}
 
 	if s.isExcluded(name) {
 		// Reject any host explicitly added to the exclude list.
-		dlog.Debugf(s.ctx, "Cluster DNS explicitly excluded for name %q", name)
-		return false
+		return false, "explicitly excluded"
 	}
 
 	if !strings.ContainsRune(name, '.') {
 		// Single label names are always included.
-		dlog.Debugf(s.ctx, "Cluster DNS included for single label name %q", name)
-		return true
+		return true, "included for single label name"
 	}
 
 	// Skip configured exclude-suffixes unless also matched by an include-suffix
@@ -222,50 +237,41 @@ func (s *Server) shouldDoClusterLookup(query string) bool {
 			// Exclude unless more specific include.
 			for _, is := range s.IncludeSuffixes {
 				if len(is) >= len(es) && strings.HasSuffix(name, is) {
-					dlog.Debugf(s.ctx,
-						"Cluster DNS included by include-suffix %q (overriding exclude-suffix %q) for name %q", is, es, name)
-					return true
+					return true, fmt.Sprintf("included by include-suffix %q (overriding exclude-suffix %q)", is, es)
 				}
 			}
-			dlog.Debugf(s.ctx, "Cluster DNS excluded by exclude-suffix %q for name %q", es, name)
-			return false
+			return false, fmt.Sprintf("excluded by exclude-suffix %q", es)
 		}
 	}
 
 	// Always include configured search paths
 	for _, sfx := range s.search {
 		if strings.HasSuffix(name, sfx) {
-			dlog.Debugf(s.ctx, "Cluster DNS included by search %q of name %q", sfx, name)
-			return true
+			return true, fmt.Sprintf("included by search %q", sfx)
 		}
 	}
 
 	// Always include configured routes
 	for sfx := range s.routes {
 		if strings.HasSuffix(name, sfx) {
-			dlog.Debugf(s.ctx, "Cluster DNS included by namespace %q of name %q", sfx, name)
-			return true
+			return true, fmt.Sprintf("included by namespace %q", sfx)
 		}
 	}
 
 	// Always include queries for the cluster domain.
 	if strings.HasSuffix(query, "."+s.clusterDomain) {
-		dlog.Debugf(s.ctx, "Cluster DNS included by cluster domain %q of name %q", s.clusterDomain, name)
-		return true
+		return true, fmt.Sprintf("included by cluster domain %q", s.clusterDomain)
 	}
 
 	// Always include configured includeSuffixes
 	for _, sfx := range s.IncludeSuffixes {
 		if strings.HasSuffix(name, sfx) {
-			dlog.Debugf(s.ctx,
-				"Cluster DNS included by include-suffix %q for name %q", sfx, name)
-			return true
+			return true, fmt.Sprintf("included by include-suffix %q", sfx)
 		}
 	}
 
 	// Pass any queries for the cluster domain.
-	dlog.Debugf(s.ctx, "Cluster DNS excluded for name %q. No inclusion rule was matched", name)
-	return false
+	return false, "excluded. No inclusion rule was matched"
 }
 
 func (s *Server) isExcluded(name string) bool {
@@ -303,6 +309,7 @@ func (s *Server) resolveInCluster(c context.Context, q *dns.Question) (result dn
 			Rrtype: q.Qtype,
 			Class:  q.Qclass,
 		}
+		getQueryTrace(c).decide(decisionLocal, "localhost")
 		switch q.Qtype {
 		case dns.TypeA:
 			return dnsproxy.RRs{&dns.A{
@@ -319,9 +326,13 @@ func (s *Server) resolveInCluster(c context.Context, q *dns.Question) (result dn
 		}
 	}
 
-	if !s.shouldDoClusterLookup(query) {
+	ok, reason := s.shouldDoClusterLookup(query)
+	dlog.Debugf(c, "Cluster DNS %s for name %q", reason, query[:len(query)-1])
+	if !ok {
+		getQueryTrace(c).decide(decisionExcluded, reason)
 		return nil, dns.RcodeNameError, nil
 	}
+	getQueryTrace(c).decide(decisionCluster, reason)
 
 	// Give the cluster lookup a reasonable timeout.
 	c, cancel := context.WithTimeout(c, s.LookupTimeout)
@@ -331,15 +342,6 @@ func (s *Server) resolveInCluster(c context.Context, q *dns.Question) (result dn
 	if err != nil {
 		return nil, rCode, client.CheckTimeout(c, err)
 	}
-
-	// Keep the TTLs of requests resolved in the cluster low. We
-	// cache them locally anyway, but our cache is flushed when things are
-	// intercepted or the namespaces change.
-	for _, rr := range result {
-		if h := rr.Header(); h != nil {
-			h.Ttl = dnsTTL
-		}
-	}
 	return result, rCode, nil
 }
 
@@ -422,6 +424,9 @@ func mappingsMap(mappings []*client.DNSMapping) map[string]string {
 	if l := len(mappings); l > 0 {
 		mm := make(map[string]string, l)
 		for _, m := range mappings {
+			if m.IsWildcard() {
+				continue
+			}
 			al := m.AliasFor
 			if ip := iputil.Parse(al); ip == nil {
 				al += "."
@@ -437,15 +442,30 @@ func mappingsMap(mappings []*client.DNSMapping) map[string]string {
 func (s *Server) SetMappings(mappings []*rpc.DNSMapping) {
 	ml := client.MappingsFromRPC(mappings)
 	mm := mappingsMap(ml)
+	wms := wildcardMappings(ml)
 	s.Lock()
 	s.Mappings = ml
 	s.mappingsMap = mm
+	s.wildcards = wms
 	s.Unlock()
 
 	// Flush the mappings.
 	for n := range mm {
 		s.purgeRecordsFromCache(n)
 	}
+	if len(wms) > 0 {
+		s.cache.Range(func(key cacheKey, _ *cacheEntry) bool {
+			for i := range wms {
+				if _, ok := wms[i].match(key.name); ok {
+					if old, ok := s.cache.LoadAndDelete(key); ok {
+						old.close()
+					}
+					break
+				}
+			}
+			return true
+		})
+	}
 }
 
 func newLocalUDPListener(c context.Context) (net.PacketConn, error) {
@@ -502,12 +522,7 @@ func (s *Server) processSearchPaths(g *dgroup.Group, processor func(context.Cont
 }
 
 func (s *Server) flushDNS() {
-	s.cache.Range(func(key cacheKey, _ *cacheEntry) bool {
-		if old, ok := s.cache.LoadAndDelete(key); ok {
-			old.close()
-		}
-		return true
-	})
+	s.FlushCache("")
 }
 
 // splitToUDPAddr splits the given address into an UDPAddr. It's
@@ -538,6 +553,18 @@ func copyRRs(rrs dnsproxy.RRs, qTypes []uint16) dnsproxy.RRs {
 	return cp
 }
 
+// lowerTTLs sets the TTLs of the given records to dnsTTL. The cache uses the TTLs that the records have in
+// the cluster, but the TTLs that are sent to the caller are kept low. We cache them locally anyway, but our
+// cache is flushed when things are intercepted or the namespaces change.
+func lowerTTLs(rrs dnsproxy.RRs) dnsproxy.RRs {
+	for _, rr := range rrs {
+		if h := rr.Header(); h != nil {
+			h.Ttl = dnsTTL
+		}
+	}
+	return rrs
+}
+
 type cacheKey struct {
 	name  string
 	qType uint16
@@ -564,8 +591,9 @@ const (
 	recursionCheck2 = "tel2-recursion-check.kube-system."
 )
 
-func (s *Server) resolveWithRecursionCheck(q *dns.Question) (dnsproxy.RRs, int, error) {
+func (s *Server) resolveWithRecursionCheck(c context.Context, q *dns.Question) (dnsproxy.RRs, int, error) {
 	if strings.HasPrefix(q.Name, recursionCheck) {
+		getQueryTrace(c).decide(decisionLocal, "recursion check")
 		if strings.HasPrefix(q.Name, recursionCheck2) {
 			if atomic.CompareAndSwapInt32(&s.recursive, recursionQueryReceived, recursionDetected) {
 				dlog.Debug(s.ctx, "DNS resolver is recursive")
@@ -591,7 +619,7 @@ func (s *Server) resolveWithRecursionCheck(q *dns.Question) (dnsproxy.RRs, int,
 		return localHostReply(q), dns.RcodeSuccess, nil
 	}
 
-	answer, rCode, err := s.resolveThruCache(q)
+	answer, rCode, err := s.resolveThruCache(c, q)
 	if err != nil || rCode != dns.RcodeSuccess {
 		// For A and AAAA queries, we check if we have a successful counterpart in the cache. If we
 		// do, then this query must return NOERROR EMPTY
@@ -607,6 +635,7 @@ func (s *Server) resolveWithRecursionCheck(q *dns.Question) (dnsproxy.RRs, int,
 				<-ce.wait
 				if !ce.expired() && ce.rCode == dns.RcodeSuccess && atomic.LoadInt32(&ce.currentQType) == int32(ck.qType) {
 					dlog.Debugf(s.ctx, "found counterpart for %s %s", dns.TypeToString[uint16(ce.currentQType)], ce.answer)
+					getQueryTrace(c).setCacheHit()
 					err = nil
 					rCode = dns.RcodeSuccess
 				}
@@ -619,7 +648,7 @@ func (s *Server) resolveWithRecursionCheck(q *dns.Question) (dnsproxy.RRs, int,
 // resolveThruCache resolves the given query by first performing a cache lookup. If a cached
 // entry is found that hasn't expired, it's returned. If not, this function will call
 // resolveQuery() to resolve and store in the case.
-func (s *Server) resolveThruCache(q *dns.Question) (answer dnsproxy.RRs, rCode int, err error) {
+func (s *Server) resolveThruCache(c context.Context, q *dns.Question) (answer dnsproxy.RRs, rCode int, err error) {
 	dv := &cacheEntry{wait: make(chan struct{}), created: time.Now()}
 	key := cacheKey{name: q.Name, qType: q.Qtype}
 	if oldDv, loaded := s.cache.LoadOrStore(key, dv); loaded {
@@ -630,6 +659,10 @@ func (s *Server) resolveThruCache(q *dns.Question) (answer dnsproxy.RRs, rCode i
 		}
 		<-oldDv.wait
 		if !oldDv.expired() {
+			s.cacheHits.Add(1)
+			qt := getQueryTrace(c)
+			qt.decide(cmp.Or(oldDv.decision, decisionCluster), oldDv.reason)
+			qt.setCacheHit()
 			qTypes := []uint16{q.Qtype}
 			if q.Qtype != dns.TypeCNAME {
 				// Allow additional CNAME records if they are present.
@@ -640,28 +673,32 @@ func (s *Server) resolveThruCache(q *dns.Question) (answer dnsproxy.RRs, rCode i
 					}
 				}
 			}
-			return copyRRs(oldDv.answer, qTypes), oldDv.rCode, nil
+			return lowerTTLs(copyRRs(oldDv.answer, qTypes)), oldDv.rCode, nil
 		}
 		s.cache.Store(key, dv)
 	}
+	s.cacheMisses.Add(1)
 
 	atomic.StoreInt32(&dv.currentQType, int32(q.Qtype))
 	defer func() {
-		if rCode != dns.RcodeSuccess {
-			s.cache.Delete(key) // Don't cache unless the lookup succeeded.
+		if ttl := s.cacheTTL(answer, rCode, err); ttl <= 0 {
+			s.cache.Delete(key) // Don't cache unless the lookup succeeded, or negative caching is enabled.
 		} else {
+			dv.ttl = ttl
 			dv.answer = answer
 			dv.rCode = rCode
+			dv.decision, dv.reason = getQueryTrace(c).get()
 
 			// Return a result for the correct query type. The result will be nil (nxdomain) if nothing was found. It might
 			// also be empty if no RRs were found for the given query type and that is OK.
 			// See https://datatracker.ietf.org/doc/html/rfc4074#section-3
 			answer = copyRRs(answer, []uint16{q.Qtype})
 		}
+		answer = lowerTTLs(answer)
 		atomic.StoreInt32(&dv.currentQType, int32(dns.TypeNone))
 		dv.close()
 	}()
-	return s.resolve(s.ctx, q)
+	return s.resolve(c, q)
 }
 
 // dfs is a func that implements the fmt.Stringer interface. Used in log statements to ensure
@@ -746,7 +783,9 @@ func localHostReply(q *dns.Question) dnsproxy.RRs {
 
 // ServeDNS is an implementation of github.com/miekg/dns Handler.ServeDNS.
 func (s *Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
-	c := s.ctx
+	start := time.Now()
+	qt := &queryTrace{}
+	c := withQueryTrace(s.ctx, qt)
 	atomic.AddInt64(&s.requestCount, 1)
 
 	q := &r.Question[0]
@@ -761,6 +800,7 @@ func (s *Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 	defer func() {
 		dlog.Debugf(c, "%s%5d %-6s %s -> %s %s", pfx, r.Id, qts, q.Name, rct, txt)
 		_ = w.WriteMsg(msg)
+		s.queryLog.add(qt.toRPC(start, q.Name, qts, rct.String(), dnsproxy.RRs(msg.Answer).String()))
 
 		// Closing the response tells the DNS service to terminate
 		if c.Err() != nil {
@@ -774,6 +814,7 @@ func (s *Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 	// NOTE! The sanity-check will always use the tel2-search subdomain, so the check made here
 	//       must be made before the tel2-search is removed.
 	if q.Name == santiyCheckDot {
+		qt.decide(decisionLocal, "sanity check")
 		answer := localHostReply(q)
 		if answer == nil {
 			msg.SetRcode(r, dns.RcodeNotImplemented)
@@ -788,6 +829,7 @@ func (s *Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 	}
 
 	if !dnsproxy.SupportedType(q.Qtype) {
+		qt.decide(decisionLocal, "unsupported query type")
 		msg.SetRcode(r, dns.RcodeNotImplemented)
 		return
 	}
@@ -845,19 +887,21 @@ func (s *Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 			// the tel2-search domain. Should normally never happen, but
 			// will happen if someone queries for the tel2-search domain
 			// as a single label name.
+			qt.decide(decisionLocal, "name contains the "+tel2SubDomain+" domain")
 			msg.SetRcode(r, dns.RcodeNameError)
 			return
 		}
 
 		// try and resolve any mappings before consulting the cache, so that mapping hits don't
 		// end up in the cache.
-		answer, rCode, err = s.resolveMapping(q)
+		answer, rCode, err = s.resolveMapping(c, q)
 		if err == errNoMapping {
-			answer, rCode, err = s.resolveWithRecursionCheck(q)
+			answer, rCode, err = s.resolveWithRecursionCheck(c, q)
 		}
 	case dns.TypePTR:
 		// Respond with cluster domain if the queried IP is the IP of this DNS server.
 		if ip, err := dnsproxy.PtrAddress(q.Name); err == nil && ip == s.RemoteIP {
+			qt.decide(decisionLocal, "address of this DNS server")
 			answer = dnsproxy.RRs{
 				&dns.PTR{
 					Hdr: dnsproxy.NewHeader(q.Name, q.Qtype),
@@ -869,7 +913,7 @@ func (s *Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 		}
 		fallthrough
 	default:
-		answer, rCode, err = s.resolveWithRecursionCheck(q)
+		answer, rCode, err = s.resolveWithRecursionCheck(c, q)
 	}
 
 	if err == nil && rCode == dns.RcodeSuccess {
@@ -902,6 +946,7 @@ func (s *Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 	} else {
 		// Use the original query name when sending things to the fallback resolver.
 		q.Name = origName
+		qt.decide(decisionFallback, "")
 		pfx = func() string { return fmt.Sprintf("(%s) ", s.fallbackPool.RemoteAddr()) }
 		msg, txt = s.fallbackExchange(c, msg, r)
 	}
@@ -934,7 +979,7 @@ func (s *Server) fallbackExchange(c context.Context, msg, r *dns.Msg) (*dns.Msg,
 
 var errNoMapping = errors.New("no mapping") //nolint:gochecknoglobals // constant
 
-func (s *Server) resolveMapping(q *dns.Question) (dnsproxy.RRs, int, error) {
+func (s *Server) resolveMapping(c context.Context, q *dns.Question) (dnsproxy.RRs, int, error) {
 	switch q.Qtype {
 	case dns.TypeA, dns.TypeAAAA, dns.TypeCNAME:
 	default:
@@ -943,11 +988,17 @@ func (s *Server) resolveMapping(q *dns.Question) (dnsproxy.RRs, int, error) {
 
 	s.RLock()
 	mappingAlias, ok := s.mappingsMap[q.Name]
+	if !ok {
+		mappingAlias, ok = matchWildcards(s.wildcards, q.Name)
+	}
 	s.RUnlock()
 
 	if !ok {
 		return nil, dns.RcodeNameError, errNoMapping
 	}
+	qt := getQueryTrace(c)
+	reason := "mapped to " + mappingAlias
+	qt.decide(decisionMapping, reason)
 	if ip := iputil.Parse(mappingAlias); ip != nil {
 		// The name resolves to an A or AAAA record known by this DNS server.
 		var rrs dnsproxy.RRs
@@ -977,11 +1028,12 @@ func (s *Server) resolveMapping(q *dns.Question) (dnsproxy.RRs, int, error) {
 
 	// A query for an A or AAAA must resolve the CNAME and then return both the result and the
 	// CNAME that resolved to it.
-	answer, rCode, err := s.resolveWithRecursionCheck(&dns.Question{
+	answer, rCode, err := s.resolveWithRecursionCheck(c, &dns.Question{
 		Name:   mappingAlias,
 		Qtype:  q.Qtype,
 		Qclass: q.Qclass,
 	})
+	qt.decide(decisionMapping, reason)
 	if err == nil {
 		answer = append(cnameRRs, answer...)
 	}
@@ -995,9 +1047,8 @@ func (s *Server) Run(c context.Context, initDone chan<- struct{}, listeners []ne
 	s.resolve = resolve
 
 	g := dgroup.NewGroup(c, dgroup.GroupConfig{})
-	for _, listener := range listeners {
-		srv := &dns.Server{PacketConn: listener, Handler: s, ReadTimeout: time.Second}
-		g.Go(listener.LocalAddr().String(), func(c context.Context) error {
+	serve := func(name string, srv *dns.Server) {
+		g.Go(name, func(c context.Context) error {
 			go func() {
 				<-c.Done()
 				dlog.Debugf(c, "Shutting down DNS server")
@@ -1006,6 +1057,16 @@ func (s *Server) Run(c context.Context, initDone chan<- struct{}, listeners []ne
 			return srv.ActivateAndServe()
 		})
 	}
+	for _, listener := range listeners {
+		serve(listener.LocalAddr().String(), &dns.Server{PacketConn: listener, Handler: s, ReadTimeout: time.Second})
+	}
+	for _, srv := range s.listenerServers(c) {
+		if srv.PacketConn != nil {
+			serve("udp:"+srv.PacketConn.LocalAddr().String(), srv)
+		} else {
+			serve("tcp:"+srv.Listener.Addr().String(), srv)
+		}
+	}
 	close(initDone)
 	return g.Wait()
 }
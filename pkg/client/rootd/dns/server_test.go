@@ -1,6 +1,7 @@
 package dns
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -130,3 +131,48 @@ func (s *suiteServer) TestIsExcluded() {
 func TestServerTestSuite(t *testing.T) {
 	suite.Run(t, new(suiteServer))
 }
+
+func TestResolveMapping_wildcards(t *testing.T) {
+	s := &Server{cache: xsync.NewMapOf[cacheKey, *cacheEntry]()}
+	s.SetMappings([]*rpc.DNSMapping{
+		{Name: "*.prod.internal", AliasFor: "$1.staging.svc.cluster.local"},
+		{Name: "*.api.prod.internal", AliasFor: "10.1.2.3"},
+		{Name: "db.prod.internal", AliasFor: "10.1.2.4"},
+		{Name: "*.Example.com", AliasFor: "$1.default"},
+	})
+
+	tests := []struct {
+		name  string
+		qType uint16
+		want  string
+	}{
+		{"orders.prod.internal.", dns.TypeCNAME, "orders.staging.svc.cluster.local."},
+		{"a.b.prod.internal.", dns.TypeCNAME, "a.b.staging.svc.cluster.local."},
+		{"v1.api.prod.internal.", dns.TypeA, "10.1.2.3"},     // the longest suffix wins
+		{"db.prod.internal.", dns.TypeA, "10.1.2.4"},         // an exact name wins over a wildcard
+		{"www.example.com.", dns.TypeCNAME, "www.default."},  // names are case-insensitive
+		{"prod.internal.", dns.TypeCNAME, ""},                // a wildcard requires at least one label
+		{"orders.prod.internal.example.", dns.TypeCNAME, ""}, // not a suffix
+		{"orders.prod.internal.", dns.TypeTXT, ""},           // only A, AAAA, and CNAME are mapped
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer, _, err := s.resolveMapping(context.Background(), &dns.Question{Name: tt.name, Qtype: tt.qType, Qclass: dns.ClassINET})
+			if tt.want == "" {
+				assert.ErrorIs(t, err, errNoMapping)
+				return
+			}
+			assert.NoError(t, err)
+			if assert.Len(t, answer, 1) {
+				switch rr := answer[0].(type) {
+				case *dns.CNAME:
+					assert.Equal(t, tt.want, rr.Target)
+				case *dns.A:
+					assert.Equal(t, tt.want, rr.A.String())
+				default:
+					t.Fatalf("unexpected answer %s", rr)
+				}
+			}
+		})
+	}
+}
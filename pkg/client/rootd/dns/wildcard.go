@@ -0,0 +1,68 @@
+package dns
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+// wildcardMapping maps all names that end with its suffix, and that have at least one label in front of it,
+// to its alias. The labels in front of the suffix replace the client.DNSWildcardMatch in the alias.
+type wildcardMapping struct {
+	// suffix is the name of the mapping without its "*", e.g. ".prod.internal."
+	suffix string
+
+	// alias is either an IP address or a fully qualified name, e.g. "$1.staging.svc.cluster.local."
+	alias string
+}
+
+// match returns the alias for the given fully qualified, lower case, name, and true if the name matches.
+func (w *wildcardMapping) match(name string) (string, bool) {
+	if len(name) > len(w.suffix) && strings.HasSuffix(name, w.suffix) {
+		return strings.ReplaceAll(w.alias, client.DNSWildcardMatch, name[:len(name)-len(w.suffix)]), true
+	}
+	return "", false
+}
+
+// wildcardMappings returns the wildcard mappings of the given mappings, ordered so that a mapping with a
+// longer suffix comes before a mapping with a shorter suffix. The last of several mappings with the same
+// name wins, just like it does for names that are mapped exactly.
+func wildcardMappings(mappings []*client.DNSMapping) []wildcardMapping {
+	var wms []wildcardMapping
+	seen := make(map[string]int)
+	for _, m := range mappings {
+		if !m.IsWildcard() {
+			continue
+		}
+		al := strings.ToLower(m.AliasFor)
+		if ip := iputil.Parse(al); ip == nil {
+			al = strings.TrimSuffix(al, ".") + "."
+		}
+		wm := wildcardMapping{
+			suffix: strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(m.Name, "*"), ".") + "."),
+			alias:  al,
+		}
+		if i, ok := seen[wm.suffix]; ok {
+			wms[i] = wm
+			continue
+		}
+		seen[wm.suffix] = len(wms)
+		wms = append(wms, wm)
+	}
+	sort.SliceStable(wms, func(i, j int) bool {
+		return len(wms[i].suffix) > len(wms[j].suffix)
+	})
+	return wms
+}
+
+// matchWildcards returns the alias of the first of the given wildcard mappings that matches the given name.
+func matchWildcards(wms []wildcardMapping, name string) (string, bool) {
+	for i := range wms {
+		if alias, ok := wms[i].match(name); ok {
+			return alias, true
+		}
+	}
+	return "", false
+}
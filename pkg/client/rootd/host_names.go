@@ -0,0 +1,66 @@
+package rootd
+
+import (
+	"net/netip"
+	"sync/atomic"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v3"
+)
+
+const (
+	// hostNameMinTTL is the minimum time that a name is kept for an address. Answers are often kept longer
+	// than their TTL by the DNS cache and by the clients, so connections can be made after the TTL has passed.
+	hostNameMinTTL = time.Minute
+
+	// hostNamePruneInterval is how often expired names are removed.
+	hostNamePruneInterval = time.Minute
+)
+
+type hostName struct {
+	name    string
+	expires time.Time
+}
+
+// hostNames maps the addresses of cluster DNS lookups to the names that were looked up. The names expire
+// with the TTL of the answers, so that the map doesn't grow without bounds, and so that a name isn't used
+// for an address that has been reused.
+type hostNames struct {
+	names     *xsync.MapOf[netip.Addr, hostName]
+	lastPrune atomic.Int64
+}
+
+func newHostNames() *hostNames {
+	hn := &hostNames{names: xsync.NewMapOf[netip.Addr, hostName]()}
+	hn.lastPrune.Store(time.Now().UnixNano())
+	return hn
+}
+
+// store records that the given name was looked up and resolved to the given address.
+func (hn *hostNames) store(addr netip.Addr, name string, ttl time.Duration) {
+	now := time.Now()
+	hn.names.Store(addr, hostName{name: name, expires: now.Add(max(ttl, hostNameMinTTL))})
+	if last := hn.lastPrune.Load(); now.UnixNano()-last >= int64(hostNamePruneInterval) && hn.lastPrune.CompareAndSwap(last, now.UnixNano()) {
+		hn.prune(now)
+	}
+}
+
+// load returns the name that resolved to the given address, unless it has expired.
+func (hn *hostNames) load(addr netip.Addr) (string, bool) {
+	if h, ok := hn.names.Load(addr); ok && time.Now().Before(h.expires) {
+		return h.name, true
+	}
+	return "", false
+}
+
+func (hn *hostNames) prune(now time.Time) {
+	hn.names.Range(func(addr netip.Addr, h hostName) bool {
+		if !now.Before(h.expires) {
+			hn.names.Compute(addr, func(old hostName, loaded bool) (hostName, bool) {
+				// The name might have been refreshed since the Range call.
+				return old, !loaded || !now.Before(old.expires)
+			})
+		}
+		return true
+	})
+}
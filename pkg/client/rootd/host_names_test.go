@@ -0,0 +1,37 @@
+package rootd
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostNames(t *testing.T) {
+	hn := newHostNames()
+	a1 := netip.MustParseAddr("10.0.0.1")
+	a2 := netip.MustParseAddr("10.0.0.2")
+	hn.store(a1, "echo.default", 0)
+	name, ok := hn.load(a1)
+	assert.True(t, ok)
+	assert.Equal(t, "echo.default", name)
+
+	// A new lookup replaces the name of a reused address.
+	hn.store(a1, "other.default", 0)
+	name, _ = hn.load(a1)
+	assert.Equal(t, "other.default", name)
+
+	// Expired names are ignored, and removed when pruning.
+	hn.names.Store(a2, hostName{name: "gone.default", expires: time.Now().Add(-time.Second)})
+	_, ok = hn.load(a2)
+	assert.False(t, ok)
+	hn.prune(time.Now())
+	assert.Equal(t, 1, hn.names.Size())
+
+	// Storing prunes once the prune interval has passed.
+	hn.names.Store(a2, hostName{name: "gone.default", expires: time.Now().Add(-time.Second)})
+	hn.lastPrune.Store(time.Now().Add(-hostNamePruneInterval).UnixNano())
+	hn.store(a1, "echo.default", time.Hour)
+	assert.Equal(t, 1, hn.names.Size())
+}
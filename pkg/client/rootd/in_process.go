@@ -2,10 +2,12 @@ package rootd
 
 import (
 	"context"
+	"io"
 
 	"github.com/blang/semver/v4"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	empty "google.golang.org/protobuf/types/known/emptypb"
 
@@ -60,6 +62,7 @@ func (rd *InProcSession) Status(ctx context.Context, _ *empty.Empty, _ ...grpc.C
 			Name:       client.DisplayName,
 		},
 		OutboundConfig: rd.getNetworkConfig(ctx),
+		DnsCache:       rd.dnsServer.CacheStats(),
 	}, nil
 }
 
@@ -92,10 +95,115 @@ func (rd *InProcSession) SetDNSExcludes(ctx context.Context, in *rpc.SetDNSExclu
 }
 
 func (rd *InProcSession) SetDNSMappings(ctx context.Context, in *rpc.SetDNSMappingsRequest, _ ...grpc.CallOption) (*empty.Empty, error) {
+	if err := client.MappingsFromRPC(in.Mappings).Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 	rd.SetMappings(ctx, in.Mappings)
 	return &empty.Empty{}, nil
 }
 
+func (rd *InProcSession) Record(ctx context.Context, _ *rpc.Recording, _ ...grpc.CallOption) (rpc.Daemon_RecordClient, error) {
+	// The user daemon records the connections that are dialed in this process, so the stream ends at once.
+	return newInProcStream(ctx, func(context.Context, func(*rpc.RecordedEvents) error) error {
+		return nil
+	}), nil
+}
+
+func (rd *InProcSession) GetConnections(ctx context.Context, _ *empty.Empty, _ ...grpc.CallOption) (*rpc.Connections, error) {
+	return &rpc.Connections{Connections: rd.Connections(ctx)}, nil
+}
+
+func (rd *InProcSession) GetTunnelStats(context.Context, *empty.Empty, ...grpc.CallOption) (*rpc.TunnelStats, error) {
+	return rd.TunnelStats(), nil
+}
+
+func (rd *InProcSession) Capture(ctx context.Context, req *rpc.CaptureRequest, _ ...grpc.CallOption) (rpc.Daemon_CaptureClient, error) {
+	return newInProcStream(ctx, func(ctx context.Context, send func(*rpc.CaptureBlocks) error) error {
+		return rd.Session.Capture(ctx, req, send)
+	}), nil
+}
+
+func (rd *InProcSession) GetDNSCache(context.Context, *empty.Empty, ...grpc.CallOption) (*rpc.DNSCache, error) {
+	return rd.DNSCache(), nil
+}
+
+func (rd *InProcSession) FlushDNSCache(ctx context.Context, req *rpc.FlushDNSCacheRequest, _ ...grpc.CallOption) (*rpc.FlushDNSCacheResponse, error) {
+	return rd.Session.FlushDNSCache(ctx, req.Name), nil
+}
+
+func (rd *InProcSession) LookupHosts(ctx context.Context, req *rpc.LookupHostsRequest, _ ...grpc.CallOption) (*rpc.LookupHostsResponse, error) {
+	return rd.Session.LookupHosts(ctx, req.Names), nil
+}
+
+func (rd *InProcSession) WatchDNSQueries(ctx context.Context, req *rpc.WatchDNSQueriesRequest, _ ...grpc.CallOption) (rpc.Daemon_WatchDNSQueriesClient, error) {
+	return newInProcStream(ctx, func(ctx context.Context, send func(*rpc.DNSQuery) error) error {
+		return rd.Session.WatchDNSQueries(ctx, req.Follow, send)
+	}), nil
+}
+
+// inProcStream is a client stream that receives the messages directly from the session.
+type inProcStream[T any] struct {
+	ctx  context.Context
+	msgs <-chan *T
+	err  error // set before msgs is closed
+}
+
+// newInProcStream returns a stream that receives the messages that the given function sends. The function
+// runs until it returns or the stream's context is cancelled.
+func newInProcStream[T any](ctx context.Context, f func(context.Context, func(*T) error) error) *inProcStream[T] {
+	ctx, cancel := context.WithCancel(ctx)
+	msgs := make(chan *T)
+	st := &inProcStream[T]{ctx: ctx, msgs: msgs}
+	go func() {
+		defer cancel()
+		defer close(msgs)
+		st.err = f(ctx, func(m *T) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case msgs <- m:
+				return nil
+			}
+		})
+	}()
+	return st
+}
+
+func (st *inProcStream[T]) Recv() (*T, error) {
+	m, ok := <-st.msgs
+	if !ok {
+		if st.err != nil {
+			return nil, st.err
+		}
+		return nil, io.EOF
+	}
+	return m, nil
+}
+
+func (st *inProcStream[T]) Header() (metadata.MD, error) {
+	return nil, nil
+}
+
+func (st *inProcStream[T]) Trailer() metadata.MD {
+	return nil
+}
+
+func (st *inProcStream[T]) CloseSend() error {
+	return nil
+}
+
+func (st *inProcStream[T]) Context() context.Context {
+	return st.ctx
+}
+
+func (st *inProcStream[T]) SendMsg(any) error {
+	return status.Error(codes.Unimplemented, "SendMsg")
+}
+
+func (st *inProcStream[T]) RecvMsg(any) error {
+	return status.Error(codes.Unimplemented, "RecvMsg")
+}
+
 func (rd *InProcSession) SetLogLevel(context.Context, *manager.LogLevelRequest, ...grpc.CallOption) (*empty.Empty, error) {
 	// No loglevel when session runs in the same process as the user daemon.
 	return &empty.Empty{}, nil
@@ -113,13 +221,16 @@ func (rd *InProcSession) WaitForAgentIP(ctx context.Context, request *rpc.WaitFo
 }
 
 // NewInProcSession returns a root daemon session suitable to use in-process (from the user daemon) and is primarily intended for
-// when the user daemon runs in a docker container with NET_ADMIN capabilities.
+// when the user daemon runs in a docker container with NET_ADMIN capabilities. A session with a non-empty proxyAddress
+// doesn't need any privileges, because it serves a SOCKS5 and HTTP CONNECT proxy on that address instead of creating a
+// virtual network interface.
 func NewInProcSession(
 	ctx context.Context,
 	mi *rpc.NetworkConfig,
 	mc manager.ManagerClient,
 	ver semver.Version,
 	isPodDaemon bool,
+	proxyAddress string,
 ) (context.Context, *InProcSession, error) {
 	ctx, cancel := context.WithCancel(ctx)
 	ctx, session, err := newSession(ctx, mi, &userdToManagerShortcut{mc}, ver, isPodDaemon)
@@ -127,5 +238,6 @@ func NewInProcSession(
 		cancel()
 		return ctx, nil, err
 	}
+	session.proxyAddress = proxyAddress
 	return ctx, &InProcSession{Session: session, cancel: cancel}, nil
 }
@@ -0,0 +1,142 @@
+package rootd
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+)
+
+// socketKey identifies a local socket by its protocol and local address.
+type socketKey struct {
+	protocol string
+	addr     netip.AddrPort
+}
+
+// procNetFiles maps the protocol of a connection to the files in /proc/net that list its sockets.
+var procNetFiles = map[string][]string{ //nolint:gochecknoglobals // constant
+	"tcp":    {"tcp", "tcp6"},
+	"udp":    {"udp", "udp6"},
+	"icmp":   {"icmp"},
+	"icmpv6": {"icmp6"},
+}
+
+// lookupProcesses assigns the pid and name of the process that owns the local socket of each of the given
+// connections. The socket inodes are found in /proc/net, and the processes that own them in /proc/<pid>/fd.
+func lookupProcesses(ctx context.Context, conns []*rpc.Connection) {
+	if len(conns) == 0 {
+		return
+	}
+	wanted := make(map[string]struct{}, len(procNetFiles))
+	for _, c := range conns {
+		wanted[c.Protocol] = struct{}{}
+	}
+	inodes := make(map[socketKey]string)
+	for proto := range wanted {
+		for _, f := range procNetFiles[proto] {
+			if err := readProcNet(filepath.Join("/proc/net", f), proto, inodes); err != nil {
+				dlog.Debugf(ctx, "unable to read socket table: %v", err)
+			}
+		}
+	}
+
+	owners := make(map[string][]*rpc.Connection)
+	for _, c := range conns {
+		src, _ := netip.AddrFromSlice(c.SourceIp)
+		key := socketKey{protocol: c.Protocol, addr: netip.AddrPortFrom(src.Unmap(), uint16(c.SourcePort))}
+		inode, ok := inodes[key]
+		for _, ua := range []netip.Addr{netip.IPv4Unspecified(), netip.IPv6Unspecified()} {
+			if ok {
+				break
+			}
+			// A socket that is bound to the unspecified address.
+			inode, ok = inodes[socketKey{protocol: c.Protocol, addr: netip.AddrPortFrom(ua, key.addr.Port())}]
+		}
+		if ok {
+			owners[inode] = append(owners[inode], c)
+		}
+	}
+	if len(owners) > 0 {
+		findSocketOwners(owners)
+	}
+}
+
+// readProcNet adds the local address and inode of each socket that is listed in the given /proc/net file
+// to the inodes map.
+func readProcNet(path, proto string, inodes map[socketKey]string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Scan() // skip the header
+	for sc.Scan() {
+		if addr, inode, ok := parseProcNetLine(sc.Text()); ok && inode != "0" {
+			inodes[socketKey{protocol: proto, addr: addr}] = inode
+		}
+	}
+	return sc.Err()
+}
+
+// parseProcNetLine returns the local address and the inode of a line in /proc/net/{tcp,udp,icmp}[6].
+func parseProcNetLine(line string) (netip.AddrPort, string, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 10 {
+		return netip.AddrPort{}, "", false
+	}
+	host, port, ok := strings.Cut(fields[1], ":")
+	if !ok {
+		return netip.AddrPort{}, "", false
+	}
+	p, err := strconv.ParseUint(port, 16, 16)
+	if err != nil {
+		return netip.AddrPort{}, "", false
+	}
+	b, err := hex.DecodeString(host)
+	if err != nil || (len(b) != 4 && len(b) != 16) {
+		return netip.AddrPort{}, "", false
+	}
+	// The address is printed as a sequence of 32-bit words in host byte order.
+	for i := 0; i < len(b); i += 4 {
+		binary.BigEndian.PutUint32(b[i:], binary.NativeEndian.Uint32(b[i:]))
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return netip.AddrPortFrom(addr.Unmap(), uint16(p)), fields[9], true
+}
+
+// findSocketOwners scans the file descriptors of all processes and assigns the pid and name of the process
+// that owns each of the given socket inodes to its connections.
+func findSocketOwners(owners map[string][]*rpc.Connection) {
+	fds, _ := filepath.Glob("/proc/[0-9]*/fd/*")
+	for _, fd := range fds {
+		link, err := os.Readlink(fd)
+		if err != nil || !strings.HasPrefix(link, "socket:[") {
+			continue
+		}
+		inode := strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")
+		conns, ok := owners[inode]
+		if !ok {
+			continue
+		}
+		delete(owners, inode)
+		pidDir := filepath.Dir(filepath.Dir(fd))
+		pid, _ := strconv.Atoi(filepath.Base(pidDir))
+		name, _ := os.ReadFile(filepath.Join(pidDir, "comm"))
+		for _, c := range conns {
+			c.Pid = int32(pid)
+			c.Process = strings.TrimSpace(string(name))
+		}
+		if len(owners) == 0 {
+			return
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package rootd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The addresses in the test lines are in the byte order of a little-endian host.
+func TestParseProcNetLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		addr  string
+		inode string
+		ok    bool
+	}{
+		{
+			name:  "tcp",
+			line:  "   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 4711 1 0000000000000000 100 0 0 10 0",
+			addr:  "127.0.0.1:8080",
+			inode: "4711",
+			ok:    true,
+		},
+		{
+			name:  "tcp6 v4-mapped",
+			line:  "   1: 0000000000000000FFFF00000A00000A:D431 0A6000A0000000000000000000000000:0050 01 00000000:00000000 00:00000000 00000000  1000        0 815 1 0000000000000000 20 4 30 10 -1",
+			addr:  "10.0.0.10:54321",
+			inode: "815",
+			ok:    true,
+		},
+		{
+			name:  "tcp6",
+			line:  "   2: 000080FE000000000000000001000000:0016 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 42 1 0000000000000000 100 0 0 10 0",
+			addr:  "[fe80::1]:22",
+			inode: "42",
+			ok:    true,
+		},
+		{
+			name: "header",
+			line: "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, inode, ok := parseProcNetLine(tt.line)
+			assert.Equal(t, tt.ok, ok)
+			if ok {
+				assert.Equal(t, tt.addr, addr.String())
+				assert.Equal(t, tt.inode, inode)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package rootd
+
+import (
+	"context"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+)
+
+// lookupProcesses is a no-op on platforms where the owner of a socket cannot be found without
+// spawning external commands.
+func lookupProcesses(context.Context, []*rpc.Connection) {}
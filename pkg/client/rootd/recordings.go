@@ -0,0 +1,55 @@
+package rootd
+
+import (
+	"context"
+	"net"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/recording"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/sendqueue"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// Record sends the events of the connections that this daemon dials to the given intercept destination to
+// the given send function until the given context is cancelled or the session ends. The caller writes the
+// recording file, because this daemon runs as root.
+func (s *Session) Record(ctx context.Context, ip net.IP, port uint16, send func(*rpc.RecordedEvents) error) error {
+	key := iputil.JoinIpPort(ip, port)
+	q := sendqueue.New(sendqueue.Size)
+	w := recording.NewWriter(q)
+	dlog.Debugf(ctx, "Recording connections to %s", key)
+	tunnel.SetRecorder(ip, port, w)
+	defer func() {
+		tunnel.RemoveRecorder(ip, port, w)
+		_ = w.Close()
+		if n := q.Dropped(); n > 0 {
+			dlog.Warnf(ctx, "Stop recording connections to %s. %d events were dropped", key, n)
+		} else {
+			dlog.Debugf(ctx, "Stop recording connections to %s", key)
+		}
+	}()
+
+	// The events are dropped when the queue is full, so that the tunnel never waits for the caller. The
+	// number of dropped events is sent along with the next events, so that the caller can mark the
+	// recording as incomplete.
+	var reported uint64
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.done:
+			return nil
+		case events := <-q.Data():
+			re := &rpc.RecordedEvents{Events: events}
+			if n := q.Dropped(); n > reported {
+				re.Dropped = n - reported
+				reported = n
+			}
+			if err := send(re); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -143,6 +143,7 @@ func (s *Service) Status(context.Context, *emptypb.Empty) (*rpc.DaemonStatus, er
 	}
 	if s.session != nil {
 		r.OutboundConfig = s.session.getNetworkConfig(s.sessionContext)
+		r.DnsCache = s.session.dnsServer.CacheStats()
 	}
 	return r, nil
 }
@@ -180,6 +181,9 @@ func (s *Service) SetDNSExcludes(ctx context.Context, req *rpc.SetDNSExcludesReq
 }
 
 func (s *Service) SetDNSMappings(ctx context.Context, req *rpc.SetDNSMappingsRequest) (*emptypb.Empty, error) {
+	if err := client.MappingsFromRPC(req.Mappings).Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 	err := s.WithSession(func(c context.Context, session *Session) error {
 		session.SetMappings(c, req.Mappings)
 		return nil
@@ -187,6 +191,85 @@ func (s *Service) SetDNSMappings(ctx context.Context, req *rpc.SetDNSMappingsReq
 	return &emptypb.Empty{}, err
 }
 
+func (s *Service) GetConnections(ctx context.Context, _ *emptypb.Empty) (result *rpc.Connections, err error) {
+	err = s.WithSession(func(c context.Context, session *Session) error {
+		result = &rpc.Connections{Connections: session.Connections(c)}
+		return nil
+	})
+	return result, err
+}
+
+func (s *Service) Capture(req *rpc.CaptureRequest, stream rpc.Daemon_CaptureServer) error {
+	// The capture runs until the call is cancelled, so it must not hold on to the session lock.
+	var session *Session
+	err := s.WithSession(func(_ context.Context, ss *Session) error {
+		session = ss
+		return nil
+	})
+	if err == nil {
+		err = session.Capture(stream.Context(), req, stream.Send)
+	}
+	return err
+}
+
+func (s *Service) GetTunnelStats(ctx context.Context, _ *emptypb.Empty) (result *rpc.TunnelStats, err error) {
+	err = s.WithSession(func(c context.Context, session *Session) error {
+		result = session.TunnelStats()
+		return nil
+	})
+	return result, err
+}
+
+func (s *Service) GetDNSCache(ctx context.Context, _ *emptypb.Empty) (result *rpc.DNSCache, err error) {
+	err = s.WithSession(func(c context.Context, session *Session) error {
+		result = session.DNSCache()
+		return nil
+	})
+	return result, err
+}
+
+func (s *Service) FlushDNSCache(ctx context.Context, req *rpc.FlushDNSCacheRequest) (result *rpc.FlushDNSCacheResponse, err error) {
+	err = s.WithSession(func(c context.Context, session *Session) error {
+		result = session.FlushDNSCache(c, req.Name)
+		return nil
+	})
+	return result, err
+}
+
+func (s *Service) LookupHosts(ctx context.Context, req *rpc.LookupHostsRequest) (result *rpc.LookupHostsResponse, err error) {
+	err = s.WithSession(func(c context.Context, session *Session) error {
+		result = session.LookupHosts(ctx, req.Names)
+		return nil
+	})
+	return result, err
+}
+
+func (s *Service) WatchDNSQueries(req *rpc.WatchDNSQueriesRequest, stream rpc.Daemon_WatchDNSQueriesServer) error {
+	// The watch runs until the call is cancelled when following, so it must not hold on to the session lock.
+	var session *Session
+	err := s.WithSession(func(_ context.Context, ss *Session) error {
+		session = ss
+		return nil
+	})
+	if err == nil {
+		err = session.WatchDNSQueries(stream.Context(), req.Follow, stream.Send)
+	}
+	return err
+}
+
+func (s *Service) Record(req *rpc.Recording, stream rpc.Daemon_RecordServer) error {
+	// The recording runs until the call is cancelled, so it must not hold on to the session lock.
+	var session *Session
+	err := s.WithSession(func(_ context.Context, ss *Session) error {
+		session = ss
+		return nil
+	})
+	if err == nil {
+		err = session.Record(stream.Context(), req.Ip, uint16(req.Port), stream.Send)
+	}
+	return err
+}
+
 func (s *Service) Connect(ctx context.Context, info *rpc.NetworkConfig) (*rpc.DaemonStatus, error) {
 	dlog.Debug(ctx, "Received gRPC Connect")
 	select {
@@ -45,10 +45,12 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/client/agentpf"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/k8sclient"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/portforward"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/proxy"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/rootd/dns"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/rootd/vip"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/scout"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/socket"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/tunnelstats"
 	"github.com/telepresenceio/telepresence/v2/pkg/dnsproxy"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
@@ -121,8 +123,8 @@ type Session struct {
 	// dnsLocalAddr is the address of the local DNS Service.
 	dnsLocalAddr *net.UDPAddr
 
-	// serviceSubnet reported by the traffic-manager
-	serviceSubnet netip.Prefix
+	// serviceSubnets reported by the traffic-manager, one for each address family of the cluster
+	serviceSubnets []netip.Prefix
 
 	// podSubnets reported by the traffic-manager
 	podSubnets []netip.Prefix
@@ -146,8 +148,8 @@ type Session struct {
 	// virtualIPs maps a virtual IP to an agent tunnel.
 	virtualIPs *xsync.MapOf[netip.Addr, agentVIP]
 
-	// vipGenerator generates virtual IPs for a given range.
-	vipGenerator vip.Generator
+	// vipGenerators generate virtual IPs for a given range. There's at most one generator per address family.
+	vipGenerators []vip.Generator
 
 	// closing is set during shutdown and can have the values:
 	//   0 = running
@@ -195,6 +197,22 @@ type Session struct {
 
 	// daemon runs as part of a pod-daemon setup.
 	podDaemon bool
+
+	// connections that are currently tunneled to the cluster.
+	connections *xsync.MapOf[tunnel.ConnID, *trackedConn]
+
+	// tunnelStats contains the latency and throughput statistics of the destinations in the cluster.
+	tunnelStats *tunnelstats.Registry
+
+	// hostNames maps the addresses of cluster DNS lookups to the names that were looked up.
+	hostNames *hostNames
+
+	// capturing is true while a capture is in progress.
+	capturing atomic.Bool
+
+	// proxyAddress is set when the session runs without a VIF. Connections are then made using a SOCKS5 and
+	// HTTP CONNECT proxy that listens on this address.
+	proxyAddress string
 }
 
 type NewSessionFunc func(context.Context, *rpc.NetworkConfig) (context.Context, *Session, error)
@@ -366,8 +384,13 @@ func newSession(c context.Context, mi *rpc.NetworkConfig, mc connector.ManagerPr
 		vifReady:           make(chan error, 2),
 		done:               make(chan struct{}),
 		podDaemon:          isPodDaemon,
+		connections:        xsync.NewMapOf[tunnel.ConnID, *trackedConn](),
+		tunnelStats:        tunnelstats.NewRegistry(),
+		hostNames:          newHostNames(),
 	}
 	cfg := client.GetConfig(c)
+	c = tunnel.WithCompression(c, cfg.Grpc().CompressTunnels)
+	c = tunnel.WithResumeTimeout(c, cfg.Timeouts().Get(client.TimeoutTunnelResume))
 	rt := cfg.Routing()
 	var err error
 	s.alsoProxySubnets, err = validateSubnets("also-proxy", rt.AlsoProxy, s.alsoProxyVia)
@@ -393,6 +416,30 @@ func newSession(c context.Context, mi *rpc.NetworkConfig, mc connector.ManagerPr
 	return c, s, nil
 }
 
+// resolveProxyHost resolves a host name that is used in a proxy request, using the same lookup as the DNS server.
+func (s *Session) resolveProxyHost(ctx context.Context, name string) (netip.Addr, error) {
+	q := dns2.Question{Name: dns2.Fqdn(name), Qclass: dns2.ClassINET}
+	for _, qType := range []uint16{dns2.TypeA, dns2.TypeAAAA} {
+		q.Qtype = qType
+		answer, rCode, err := s.clusterLookup(ctx, &q)
+		if err != nil {
+			return netip.Addr{}, err
+		}
+		if rCode != dns2.RcodeSuccess {
+			return netip.Addr{}, fmt.Errorf("lookup of %s failed: %s", name, dns2.RcodeToString[rCode])
+		}
+		for _, rr := range answer {
+			switch rr := rr.(type) {
+			case *dns2.A:
+				return netip.AddrFrom4([4]byte(rr.A.To4())), nil
+			case *dns2.AAAA:
+				return netip.AddrFrom16([16]byte(rr.AAAA.To16())), nil
+			}
+		}
+	}
+	return netip.Addr{}, fmt.Errorf("no such host %s", name)
+}
+
 // clusterLookup sends a LookupDNS request to the traffic-manager and returns the result.
 func (s *Session) clusterLookup(ctx context.Context, q *dns2.Question) (dnsproxy.RRs, int, error) {
 	dlog.Debugf(ctx, "Lookup %s %q", dns2.TypeToString[q.Qtype], q.Name)
@@ -440,6 +487,18 @@ func (s *Session) clusterLookup(ctx context.Context, q *dns2.Question) (dnsproxy
 			}
 		}
 	}
+	if err == nil {
+		name := strings.TrimSuffix(q.Name, ".")
+		for _, rr := range answer {
+			ttl := time.Duration(rr.Header().Ttl) * time.Second
+			switch rr := rr.(type) {
+			case *dns2.A:
+				s.hostNames.store(netip.AddrFrom4([4]byte(rr.A.To4())), name, ttl)
+			case *dns2.AAAA:
+				s.hostNames.store(netip.AddrFrom16([16]byte(rr.AAAA)), name, ttl)
+			}
+		}
+	}
 	return answer, rCode, err
 }
 
@@ -465,8 +524,23 @@ func (s *Session) maybeGetVirtualIP(ctx context.Context, destinationIP netip.Add
 	return destinationIP, err
 }
 
+// vipGeneratorFor returns the virtual IP generator for the address family of the given IP, or nil
+// if no such generator exists.
+func (s *Session) vipGeneratorFor(ip netip.Addr) vip.Generator {
+	for _, g := range s.vipGenerators {
+		if g.Subnet().Addr().Is4() == ip.Is4() {
+			return g
+		}
+	}
+	return nil
+}
+
 func (s *Session) nextVirtualIP(workload string, destinationIP netip.Addr) (netip.Addr, error) {
-	va, err := s.vipGenerator.Next()
+	g := s.vipGeneratorFor(destinationIP)
+	if g == nil {
+		return netip.Addr{}, fmt.Errorf("no virtual IP subnet is configured for the address family of %s", destinationIP)
+	}
+	va, err := g.Next()
 	if err != nil {
 		return va, err
 	}
@@ -558,7 +632,7 @@ func (s *Session) networkReady(ctx context.Context) <-chan error {
 		case err, ok := <-s.vifReady:
 			if ok {
 				rdy <- err
-			} else {
+			} else if s.proxyAddress == "" {
 				select {
 				case <-ctx.Done():
 				case <-s.dnsServer.Ready():
@@ -629,6 +703,18 @@ func (s *Session) watchClusterInfo(ctx context.Context) error {
 	return nil
 }
 
+// serviceSubnets returns the service subnets of the given ClusterInfo. Older traffic-managers only report
+// the service subnet of the primary address family.
+func serviceSubnets(mgrInfo *manager.ClusterInfo) []netip.Prefix {
+	if len(mgrInfo.ServiceSubnets) > 0 {
+		return iputil.RPCsToPrefixes(mgrInfo.ServiceSubnets)
+	}
+	if mgrInfo.ServiceSubnet != nil {
+		return []netip.Prefix{iputil.RPCToPrefix(mgrInfo.ServiceSubnet)}
+	}
+	return nil
+}
+
 // createSubnetForDNSOnly will find a random IPv4 subnet that isn't currently routed and
 // attach the DNS server to that subnet.
 func (s *Session) createSubnetForDNSOnly(ctx context.Context, mgrInfo *manager.ClusterInfo) {
@@ -637,11 +723,9 @@ func (s *Session) createSubnetForDNSOnly(ctx context.Context, mgrInfo *manager.C
 	avoid = append(avoid, s.alsoProxySubnets...)
 	avoid = append(avoid, s.neverProxySubnets...)
 
-	// Avoid the service subnet. It might be mapped with iptables (if running bare-metal) and
+	// Avoid the service subnets. They might be mapped with iptables (if running bare-metal) and
 	// hence invisible when listing known routes.
-	if mgrInfo.ServiceSubnet != nil {
-		avoid = append(avoid, iputil.RPCToPrefix(mgrInfo.ServiceSubnet))
-	}
+	avoid = append(avoid, serviceSubnets(mgrInfo)...)
 
 	// Avoid the pod subnets. They are probably visible as known routes, but we add them to
 	// the avoid table to be sure.
@@ -691,18 +775,17 @@ func (s *Session) onClusterInfo(ctx context.Context, mgrInfo *manager.ClusterInf
 		mgrInfo.Routing = &manager.Routing{}
 	}
 
-	s.serviceSubnet = netip.Prefix{}
+	s.serviceSubnets = nil
 	s.podSubnets = nil
 
 	var subnets []netip.Prefix
 	if s.proxyClusterSvcs {
-		if mgrInfo.ServiceSubnet != nil {
-			cidr := iputil.RPCToPrefix(mgrInfo.ServiceSubnet)
+		for _, cidr := range serviceSubnets(mgrInfo) {
 			if s.shouldProxySubnet(ctx, "service", cidr) {
 				dlog.Infof(ctx, "Adding service subnet %s", cidr)
 				subnets = append(subnets, cidr)
 			}
-			s.serviceSubnet = cidr
+			s.serviceSubnets = append(s.serviceSubnets, cidr)
 		}
 	}
 
@@ -717,11 +800,11 @@ func (s *Session) onClusterInfo(ctx context.Context, mgrInfo *manager.ClusterInf
 		}
 	}
 
-	if s.vipGenerator != nil {
-		subnets = append(subnets, s.vipGenerator.Subnet())
-		dlog.Debugf(ctx, "Adding VIP subnet %q to TUN-device", s.vipGenerator.Subnet().String())
-		s.consolidateProxyViaWorkloads(ctx)
+	for _, g := range s.vipGenerators {
+		subnets = append(subnets, g.Subnet())
+		dlog.Debugf(ctx, "Adding VIP subnet %q to TUN-device", g.Subnet().String())
 	}
+	s.consolidateProxyViaWorkloads(ctx)
 
 	if !s.alsoProxyVia() {
 		subnets = append(subnets, s.alsoProxySubnets...)
@@ -746,9 +829,9 @@ func (s *Session) onClusterInfo(ctx context.Context, mgrInfo *manager.ClusterInf
 		// We'll need to synthesize a subnet where we can attach the DNS service when the VIF isn't configured
 		// from cluster subnets. But not on darwin systems, because there the DNS is controlled by /etc/resolver
 		// entries appointing the DNS service directly via localhost:<port>.
-		if s.vipGenerator != nil {
+		if len(s.vipGenerators) > 0 {
 			var err error
-			dnsAddr, err = s.vipGenerator.Next()
+			dnsAddr, err = s.vipGenerators[0].Next()
 			if err != nil {
 				return nil
 			}
@@ -765,9 +848,9 @@ func (s *Session) onClusterInfo(ctx context.Context, mgrInfo *manager.ClusterInf
 		dnsRouted = true
 	}
 
-	if len(subnets) > 0 && s.tunVif == nil {
+	if len(subnets) > 0 && s.tunVif == nil && s.proxyAddress == "" {
 		var err error
-		if s.tunVif, err = vif.NewTunnelingDevice(ctx, s.streamCreator()); err != nil {
+		if s.tunVif, err = vif.NewTunnelingDevice(ctx, s.streamCreator(ctx)); err != nil {
 			return fmt.Errorf("NewTunnelVIF: %w", err)
 		}
 	}
@@ -1070,6 +1153,21 @@ func (s *Session) Start(c context.Context, g *dgroup.Group) error {
 		}
 	}
 
+	if s.proxyAddress != "" {
+		// No VIF and no DNS configuration. The proxy is the only way to reach the cluster.
+		l, err := net.Listen("tcp", s.proxyAddress)
+		if err != nil {
+			s.dnsServer.Stop()
+			return fmt.Errorf("unable to listen on proxy address %s: %w", s.proxyAddress, err)
+		}
+		dlog.Infof(c, "SOCKS5 and HTTP CONNECT proxy listening on %s", l.Addr())
+		g.Go("proxy", func(ctx context.Context) error {
+			defer s.stop(c) // using group parent context
+			return proxy.NewServer(s.streamCreator(ctx), s.resolveProxyHost).Serve(ctx, l)
+		})
+		return s.waitForProxyViaWorkloads(c)
+	}
+
 	// Start the router and the DNS Service and wait for the context
 	// to be done. Then shut things down in order. The following happens:
 	// 1. The DNS worker terminates (it needs the TUN device to be alive while doing that)
@@ -1128,17 +1226,34 @@ func (s *Session) activateProxyViaWorkloads(ctx context.Context) error {
 	if sl == 0 {
 		return nil
 	}
-	vipSubnet, err := netip.ParsePrefix(client.GetConfig(ctx).Cluster().VirtualIPSubnet)
-	if err != nil {
-		return fmt.Errorf("unable to parse configuration value cluster.virtualIPSubnet: %w", err)
+	cc := client.GetConfig(ctx).Cluster()
+	s.vipGenerators = nil
+	for _, cv := range []struct {
+		name  string
+		value string
+	}{
+		{"virtualIPSubnet", cc.VirtualIPSubnet},
+		{"virtualIPv6Subnet", cc.VirtualIPv6Subnet},
+	} {
+		if cv.value == "" {
+			continue
+		}
+		vipSubnet, err := netip.ParsePrefix(cv.value)
+		if err != nil {
+			return fmt.Errorf("unable to parse configuration value cluster.%s: %w", cv.name, err)
+		}
+		// The first subnet of each address family wins, so an IPv6 virtualIPSubnet takes precedence over
+		// the virtualIPv6Subnet.
+		if s.vipGeneratorFor(vipSubnet.Addr()) == nil {
+			s.vipGenerators = append(s.vipGenerators, vip.NewGenerator(vipSubnet))
+		}
 	}
-	s.vipGenerator = vip.NewGenerator(vipSubnet)
 	s.localTranslationTable = xsync.NewMapOf[netip.Addr, netip.Addr]()
 	s.virtualIPs = xsync.NewMapOf[netip.Addr, agentVIP]()
 	s.localTranslationSubnets = make([]agentSubnet, sl)
 	for _, wlName := range s.consolidateProxyViaWorkloads(ctx) {
 		dlog.Debugf(ctx, "Ensuring proxy-via agent in %s", wlName)
-		_, err = s.managerClient.EnsureAgent(ctx, &manager.EnsureAgentRequest{
+		_, err := s.managerClient.EnsureAgent(ctx, &manager.EnsureAgentRequest{
 			Session: s.session,
 			Name:    wlName,
 		})
@@ -1161,10 +1276,8 @@ func (s *Session) consolidateProxyViaWorkloads(ctx context.Context) []string {
 			desiredVips[pvx.Workload] = append(desiredVips[pvx.Workload], s.podSubnets...)
 			snCount += len(s.podSubnets)
 		case "service":
-			if s.serviceSubnet.IsValid() {
-				desiredVips[pvx.Workload] = append(desiredVips[pvx.Workload], s.serviceSubnet)
-				snCount++
-			}
+			desiredVips[pvx.Workload] = append(desiredVips[pvx.Workload], s.serviceSubnets...)
+			snCount += len(s.serviceSubnets)
 		default:
 			sn, err := netip.ParsePrefix(pvx.Subnet)
 			if err != nil {
@@ -1236,6 +1349,28 @@ func (s *Session) SetMappings(ctx context.Context, mappings []*rpc.DNSMapping) {
 	s.dnsServer.SetMappings(mappings)
 }
 
+// WatchDNSQueries calls the given function with the queries that the DNS server handles. See dns.Server.WatchQueries.
+func (s *Session) WatchDNSQueries(ctx context.Context, follow bool, f func(*rpc.DNSQuery) error) error {
+	return s.dnsServer.WatchQueries(ctx, follow, f)
+}
+
+// DNSCache returns the entries of the DNS server's cache.
+func (s *Session) DNSCache() *rpc.DNSCache {
+	return &rpc.DNSCache{Entries: s.dnsServer.CacheEntries(), Stats: s.dnsServer.CacheStats()}
+}
+
+// FlushDNSCache removes the entries of the given name, or all entries, from the DNS server's cache.
+func (s *Session) FlushDNSCache(ctx context.Context, name string) *rpc.FlushDNSCacheResponse {
+	n := s.dnsServer.FlushCache(name)
+	dlog.Debugf(ctx, "Flushed %d entries from the DNS cache", n)
+	return &rpc.FlushDNSCacheResponse{Flushed: int32(n)}
+}
+
+// LookupHosts looks up the addresses of the given cluster names. See dns.Server.LookupHosts.
+func (s *Session) LookupHosts(ctx context.Context, names []string) *rpc.LookupHostsResponse {
+	return &rpc.LookupHostsResponse{Hosts: s.dnsServer.LookupHosts(ctx, names)}
+}
+
 func (s *Session) waitForAgentIP(ctx context.Context, request *rpc.WaitForAgentIPRequest) (*empty.Empty, error) {
 	if s.agentClients == nil {
 		return nil, status.Error(codes.Unavailable, "")
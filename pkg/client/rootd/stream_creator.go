@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"net/netip"
+	"strings"
 	"time"
 
 	"github.com/datawire/dlib/dlog"
@@ -30,7 +31,10 @@ func checkRecursion(p int, ip net.IP, sn netip.Prefix) (err error) {
 	return err
 }
 
-func (s *Session) streamCreator() tunnel.StreamCreator {
+// streamCreator returns a tunnel.StreamCreator that multiplexes the streams to each traffic-agent and to the
+// traffic-manager over one tunnel. The tunnels are closed when the given context is cancelled.
+func (s *Session) streamCreator(ctx context.Context) tunnel.StreamCreator {
+	muxes := tunnel.NewMuxPool(ctx)
 	return func(c context.Context, id tunnel.ConnID) (tunnel.Stream, error) {
 		p := id.Protocol()
 		srcIp := id.Source()
@@ -49,8 +53,9 @@ func (s *Session) streamCreator() tunnel.StreamCreator {
 			}
 		}
 
-		var err error
+		origID := id
 		var tp tunnel.Provider
+		var peer string
 		if a, ok := s.getAgentVIP(id); ok {
 			// s.agentClients is never nil when agentVIPs are used.
 			tp = s.agentClients.GetWorkloadClient(a.workload)
@@ -60,26 +65,49 @@ func (s *Session) streamCreator() tunnel.StreamCreator {
 			// Replace the virtual IP with the original destination IP. This will ensure that the agent
 			// dials the original destination when the tunnel is established.
 			id = tunnel.NewConnID(id.Protocol(), id.Source(), a.destinationIP.AsSlice(), id.SourcePort(), id.DestinationPort())
+			peer = "workload " + a.workload
 			dlog.Debugf(c, "Opening proxy-via %s tunnel for id %s", a.workload, id)
 		} else {
 			if a, ok := netip.AddrFromSlice(id.Destination()); ok {
-				tp = s.getAgentClient(a)
+				if tp = s.getAgentClient(a); tp != nil {
+					peer = "agent " + a.String()
+				}
 			}
 			if tp != nil {
 				dlog.Debugf(c, "Opening traffic-agent tunnel for id %s", id)
 			} else {
 				tp = tunnel.ManagerProxyProvider(s.managerClient)
+				peer = "traffic-manager"
 				dlog.Debugf(c, "Opening traffic-manager tunnel for id %s", id)
 			}
 		}
-		ct, err := tp.Tunnel(c)
+
+		tc := client.GetConfig(c).Timeouts()
+		stream, err := muxes.CreateStream(c, peer, id, s.session.SessionId, tc.Get(client.TimeoutRoundtripLatency), tc.Get(client.TimeoutEndpointDial),
+			func(ctx context.Context) (tunnel.GRPCClientStream, error) {
+				return tp.Tunnel(ctx)
+			})
 		if err != nil {
 			return nil, err
 		}
+		stream = s.tunnelStats.Track(c, s.statsDestination(origID, peer), stream)
+		return s.trackConnection(c, origID, peer, stream), nil
+	}
+}
 
-		tc := client.GetConfig(c).Timeouts()
-		return tunnel.NewClientStream(c, ct, id, s.session.SessionId, tc.Get(client.TimeoutRoundtripLatency), tc.Get(client.TimeoutEndpointDial))
+// statsDestination returns the destination that the tunnel statistics of the connection with the given id
+// are recorded for. That's the workload of a proxy-via connection, or else the host name that the destination
+// address was looked up with, or the address itself when no such lookup has been made.
+func (s *Session) statsDestination(id tunnel.ConnID, peer string) string {
+	if strings.HasPrefix(peer, "workload ") {
+		return peer
+	}
+	a, _ := netip.AddrFromSlice(id.Destination())
+	a = a.Unmap()
+	if name, ok := s.hostNames.load(a); ok {
+		return name
 	}
+	return a.String()
 }
 
 func (s *Session) getAgentVIP(id tunnel.ConnID) (a agentVIP, ok bool) {
@@ -0,0 +1,43 @@
+// Package sendqueue contains a queue for data that the daemons produce in code that must not be delayed,
+// such as the code that handles packets and tunneled connections, and that another goroutine sends to a
+// client.
+package sendqueue
+
+import (
+	"bytes"
+	"sync/atomic"
+)
+
+// Size is the number of writes that the queues of the daemons hold.
+const Size = 1024
+
+// Queue is an io.Writer that queues the data of each write, so that another goroutine can send it to a
+// client. The data is dropped when the queue is full, so that a slow client never delays the writer.
+type Queue struct {
+	data    chan []byte
+	dropped atomic.Uint64
+}
+
+// New returns a Queue that holds at most size writes.
+func New(size int) *Queue {
+	return &Queue{data: make(chan []byte, size)}
+}
+
+func (q *Queue) Write(data []byte) (int, error) {
+	select {
+	case q.data <- bytes.Clone(data):
+	default:
+		q.dropped.Add(1)
+	}
+	return len(data), nil
+}
+
+// Data returns the channel that the data of the queued writes is received from.
+func (q *Queue) Data() <-chan []byte {
+	return q.data
+}
+
+// Dropped returns the number of writes that were dropped because the queue was full.
+func (q *Queue) Dropped() uint64 {
+	return q.dropped.Load()
+}
@@ -0,0 +1,17 @@
+package sendqueue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueue(t *testing.T) {
+	q := New(1)
+	data := []byte{1, 2, 3, 4}
+	_, _ = q.Write(data)
+	data[0] = 5
+	_, _ = q.Write(data)
+	assert.Equal(t, uint64(1), q.Dropped())
+	assert.Equal(t, []byte{1, 2, 3, 4}, <-q.Data())
+}
@@ -0,0 +1,100 @@
+package tunnelstats
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/datawire/dlib/dhttp"
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+// StatsFunc returns the tunnel statistics to expose.
+type StatsFunc func(context.Context) (*daemon.TunnelStats, error)
+
+var labels = []string{"daemon", "destination"} //nolint:gochecknoglobals // constant
+
+// collector is a prometheus.Collector that collects the tunnel statistics when it is scraped.
+type collector struct {
+	ctx           context.Context
+	stats         StatsFunc
+	connections   *prometheus.Desc
+	bytesSent     *prometheus.Desc
+	bytesReceived *prometheus.Desc
+	roundtrip     *prometheus.Desc
+	throughput    *prometheus.Desc
+}
+
+func newCollector(ctx context.Context, stats StatsFunc) *collector {
+	return &collector{
+		ctx:   ctx,
+		stats: stats,
+		connections: prometheus.NewDesc("telepresence_tunnel_connections_total",
+			"The number of connections that have been opened to the destination", labels, nil),
+		bytesSent: prometheus.NewDesc("telepresence_tunnel_sent_bytes_total",
+			"The number of bytes sent to the destination by connections that have been closed", labels, nil),
+		bytesReceived: prometheus.NewDesc("telepresence_tunnel_received_bytes_total",
+			"The number of bytes received from the destination by connections that have been closed", labels, nil),
+		roundtrip: prometheus.NewDesc("telepresence_tunnel_roundtrip_seconds",
+			"The time from when data is sent to the destination until the first data is received from it", labels, nil),
+		throughput: prometheus.NewDesc("telepresence_tunnel_throughput_bytes_per_second",
+			"The average throughput of each closed connection to the destination", labels, nil),
+	}
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.connections
+	ch <- c.bytesSent
+	ch <- c.bytesReceived
+	ch <- c.roundtrip
+	ch <- c.throughput
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	ts, err := c.stats(c.ctx)
+	if err != nil {
+		dlog.Warnf(c.ctx, "unable to collect tunnel statistics: %v", err)
+		return
+	}
+	for _, ds := range ts.Destinations {
+		lvs := []string{ds.Daemon, ds.Destination}
+		ch <- prometheus.MustNewConstMetric(c.connections, prometheus.CounterValue, float64(ds.Connections), lvs...)
+		ch <- prometheus.MustNewConstMetric(c.bytesSent, prometheus.CounterValue, float64(ds.BytesSent), lvs...)
+		ch <- prometheus.MustNewConstMetric(c.bytesReceived, prometheus.CounterValue, float64(ds.BytesReceived), lvs...)
+		ch <- constHistogram(c.roundtrip, ds.Roundtrip, lvs)
+		ch <- constHistogram(c.throughput, ds.Throughput, lvs)
+	}
+}
+
+// constHistogram converts the given histogram into a Prometheus histogram with cumulative bucket counts.
+func constHistogram(desc *prometheus.Desc, h *daemon.Histogram, lvs []string) prometheus.Metric {
+	buckets := make(map[float64]uint64, len(h.Bounds))
+	var count uint64
+	for i, b := range h.Bounds {
+		count += h.Counts[i]
+		buckets[b] = count
+	}
+	count += h.Counts[len(h.Bounds)]
+	return prometheus.MustNewConstHistogram(desc, count, h.Sum, buckets, lvs...)
+}
+
+// ServePrometheus serves the tunnel statistics that the given function returns as Prometheus metrics on
+// the given port of the loopback interface, until the given context is cancelled.
+func ServePrometheus(ctx context.Context, port int, stats StatsFunc) error {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(newCollector(ctx, stats))
+
+	lg := dlog.StdLogger(ctx, dlog.MaxLogLevel(ctx))
+	lg.SetPrefix(fmt.Sprintf("prometheus:%d", port))
+	sc := &dhttp.ServerConfig{
+		Handler:  promhttp.HandlerFor(reg, promhttp.HandlerOpts{}),
+		ErrorLog: lg,
+	}
+	dlog.Infof(ctx, "Prometheus tunnel statistics served on port: %d", port)
+	defer dlog.Info(ctx, "Prometheus tunnel statistics server stopped")
+	return sc.ListenAndServe(ctx, iputil.JoinHostPort("127.0.0.1", uint16(port)))
+}
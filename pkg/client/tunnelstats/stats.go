@@ -0,0 +1,247 @@
+// Package tunnelstats records per-destination latency and throughput histograms for the connections that a
+// client daemon tunnels to, or from, the cluster.
+package tunnelstats
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v3"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// RoundtripBuckets are the upper bounds, in seconds, of the buckets of the round-trip histograms.
+var RoundtripBuckets = []float64{ //nolint:gochecknoglobals // constant
+	0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10,
+}
+
+// ThroughputBuckets are the upper bounds, in bytes per second, of the buckets of the throughput histograms.
+var ThroughputBuckets = []float64{ //nolint:gochecknoglobals // constant
+	1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20, 64 << 20, 256 << 20,
+}
+
+// histogram counts observations in buckets with fixed upper bounds. The last count is for the observations
+// that are larger than the largest bound.
+type histogram struct {
+	bounds []float64
+	counts []uint64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) histogram {
+	return histogram{bounds: bounds, counts: make([]uint64, len(bounds)+1)}
+}
+
+func (h *histogram) observe(v float64) {
+	i, _ := slices.BinarySearch(h.bounds, v)
+	h.counts[i]++
+	h.sum += v
+}
+
+func (h *histogram) toRPC() *daemon.Histogram {
+	return &daemon.Histogram{Bounds: h.bounds, Counts: slices.Clone(h.counts), Sum: h.sum}
+}
+
+// destination holds the statistics of all connections to one destination.
+type destination struct {
+	sync.Mutex
+	connections   uint64
+	bytesSent     uint64
+	bytesReceived uint64
+	roundtrip     histogram
+	throughput    histogram
+}
+
+// Registry holds the statistics of the destinations of a client daemon.
+type Registry struct {
+	destinations *xsync.MapOf[string, *destination]
+}
+
+// NewRegistry returns a new, empty, Registry.
+func NewRegistry() *Registry {
+	return &Registry{destinations: xsync.NewMapOf[string, *destination]()}
+}
+
+// Conn records the statistics of one connection. A round-trip is the time from when data is sent to the
+// destination until the first data is received from it. The throughput of a connection is the number of
+// bytes that it transferred in both directions, divided by its lifetime, and is recorded when it is closed.
+type Conn struct {
+	dest     *destination
+	opened   time.Time
+	pending  atomic.Int64
+	sent     atomic.Uint64
+	received atomic.Uint64
+	closed   atomic.Bool
+}
+
+// Open registers a new connection to the given destination.
+func (r *Registry) Open(dest string) *Conn {
+	d, _ := r.destinations.LoadOrCompute(dest, func() *destination {
+		return &destination{
+			roundtrip:  newHistogram(RoundtripBuckets),
+			throughput: newHistogram(ThroughputBuckets),
+		}
+	})
+	d.Lock()
+	d.connections++
+	d.Unlock()
+	return &Conn{dest: d, opened: time.Now()}
+}
+
+// Sent records that n bytes were sent to the destination.
+func (c *Conn) Sent(n int) {
+	c.sent.Add(uint64(n))
+	c.pending.CompareAndSwap(0, time.Now().UnixNano())
+}
+
+// Received records that n bytes were received from the destination.
+func (c *Conn) Received(n int) {
+	c.received.Add(uint64(n))
+	if sent := c.pending.Swap(0); sent != 0 {
+		rt := time.Duration(time.Now().UnixNano() - sent)
+		d := c.dest
+		d.Lock()
+		d.roundtrip.observe(rt.Seconds())
+		d.Unlock()
+	}
+}
+
+// Close records the bytes and the throughput of the connection. Only the first call has an effect.
+func (c *Conn) Close() {
+	if !c.closed.CompareAndSwap(false, true) {
+		return
+	}
+	sent := c.sent.Load()
+	received := c.received.Load()
+	d := c.dest
+	d.Lock()
+	d.bytesSent += sent
+	d.bytesReceived += received
+	if lifetime := time.Since(c.opened); sent+received > 0 && lifetime > 0 {
+		d.throughput.observe(float64(sent+received) / lifetime.Seconds())
+	}
+	d.Unlock()
+}
+
+// Snapshot returns the current statistics of all destinations, sorted by destination. The given daemon
+// name is included in each entry.
+func (r *Registry) Snapshot(daemonName string) []*daemon.DestinationStats {
+	var dss []*daemon.DestinationStats
+	r.destinations.Range(func(name string, d *destination) bool {
+		d.Lock()
+		dss = append(dss, &daemon.DestinationStats{
+			Daemon:        daemonName,
+			Destination:   name,
+			Connections:   d.connections,
+			BytesSent:     d.bytesSent,
+			BytesReceived: d.bytesReceived,
+			Roundtrip:     d.roundtrip.toRPC(),
+			Throughput:    d.throughput.toRPC(),
+		})
+		d.Unlock()
+		return true
+	})
+	slices.SortFunc(dss, func(a, b *daemon.DestinationStats) int {
+		return cmp.Compare(a.Destination, b.Destination)
+	})
+	return dss
+}
+
+// stream is a tunnel.Stream that records the Normal messages that it sends and receives.
+type stream struct {
+	tunnel.Stream
+	conn *Conn
+}
+
+func (s *stream) Receive(ctx context.Context) (tunnel.Message, error) {
+	m, err := s.Stream.Receive(ctx)
+	if err == nil && m.Code() == tunnel.Normal {
+		s.conn.Received(len(m.Payload()))
+	}
+	return m, err
+}
+
+func (s *stream) Send(ctx context.Context, m tunnel.Message) error {
+	err := s.Stream.Send(ctx, m)
+	if err == nil && m.Code() == tunnel.Normal {
+		s.conn.Sent(len(m.Payload()))
+	}
+	return err
+}
+
+// Track returns a stream that records its traffic as a connection to the given destination. The connection
+// is closed when the given context is cancelled.
+func (r *Registry) Track(ctx context.Context, dest string, s tunnel.Stream) tunnel.Stream {
+	c := r.Open(dest)
+	context.AfterFunc(ctx, c.Close)
+	return &stream{Stream: s, conn: c}
+}
+
+// recorder is a tunnel.Recorder that records the connections that dialers establish.
+type recorder struct {
+	registry    *Registry
+	destination func(tunnel.ConnID) string
+	conns       *xsync.MapOf[tunnel.ConnID, *Conn]
+}
+
+// Recorder returns a tunnel.Recorder that records the connections of the dialers that it is added to. The
+// given function returns the destination of a connection. The data that the peer sends is sent to the
+// destination, and the data that is read from the dialed connection is received from it.
+func (r *Registry) Recorder(destination func(tunnel.ConnID) string) tunnel.Recorder {
+	return &recorder{registry: r, destination: destination, conns: xsync.NewMapOf[tunnel.ConnID, *Conn]()}
+}
+
+func (r *recorder) RecordOpen(id tunnel.ConnID) {
+	r.conns.Store(id, r.registry.Open(r.destination(id)))
+}
+
+func (r *recorder) RecordPeerData(id tunnel.ConnID, data []byte) {
+	if c, ok := r.conns.Load(id); ok {
+		c.Sent(len(data))
+	}
+}
+
+func (r *recorder) RecordConnData(id tunnel.ConnID, data []byte) {
+	if c, ok := r.conns.Load(id); ok {
+		c.Received(len(data))
+	}
+}
+
+func (r *recorder) RecordClose(id tunnel.ConnID) {
+	if c, ok := r.conns.LoadAndDelete(id); ok {
+		c.Close()
+	}
+}
+
+// Quantile estimates the q-quantile of the observations of the given histogram by linear interpolation
+// within the bucket that contains it, which is how Prometheus estimates quantiles. Quantiles that fall in
+// the last bucket are capped at the largest bound. The result is zero when there are no observations.
+func Quantile(h *daemon.Histogram, q float64) float64 {
+	var total uint64
+	for _, c := range h.GetCounts() {
+		total += c
+	}
+	if total == 0 || len(h.Bounds) == 0 {
+		return 0
+	}
+	rank := q * float64(total)
+	var count uint64
+	for i, b := range h.Bounds {
+		c := h.Counts[i]
+		if float64(count+c) >= rank && c > 0 {
+			lower := 0.0
+			if i > 0 {
+				lower = h.Bounds[i-1]
+			}
+			return lower + (b-lower)*(rank-float64(count))/float64(c)
+		}
+		count += c
+	}
+	return h.Bounds[len(h.Bounds)-1]
+}
@@ -0,0 +1,95 @@
+package tunnelstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+func TestHistogram_observe(t *testing.T) {
+	h := newHistogram([]float64{1, 2, 4})
+	for _, v := range []float64{0.5, 1, 1.5, 3, 4, 5, 100} {
+		h.observe(v)
+	}
+	// A value that is equal to a bound is counted in that bound's bucket.
+	assert.Equal(t, []uint64{2, 1, 2, 2}, h.counts)
+	assert.Equal(t, 115.0, h.sum)
+}
+
+func TestQuantile(t *testing.T) {
+	h := &daemon.Histogram{Bounds: []float64{1, 2, 4}, Counts: []uint64{0, 10, 10, 0}}
+	assert.Equal(t, 1.0, Quantile(h, 0))
+	assert.Equal(t, 2.0, Quantile(h, 0.5))
+	assert.Equal(t, 3.0, Quantile(h, 0.75))
+	assert.Equal(t, 4.0, Quantile(h, 1))
+
+	// Quantiles in the last bucket are capped at the largest bound.
+	h.Counts = []uint64{0, 0, 0, 5}
+	assert.Equal(t, 4.0, Quantile(h, 0.5))
+
+	assert.Zero(t, Quantile(&daemon.Histogram{Bounds: []float64{1}, Counts: []uint64{0, 0}}, 0.5))
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	c := r.Open("echo")
+	c.Sent(100)
+	c.Sent(20) // Doesn't restart the pending round-trip.
+	time.Sleep(20 * time.Millisecond)
+	c.Received(300)
+	c.Received(50) // Doesn't end a round-trip.
+	c.Close()
+	c.Close()
+	r.Open("echo").Close()
+	r.Open("another")
+
+	dss := r.Snapshot("root")
+	require.Len(t, dss, 2)
+	assert.Equal(t, "another", dss[0].Destination)
+	assert.Equal(t, uint64(1), dss[0].Connections)
+
+	ds := dss[1]
+	assert.Equal(t, "root", ds.Daemon)
+	assert.Equal(t, "echo", ds.Destination)
+	assert.Equal(t, uint64(2), ds.Connections)
+	assert.Equal(t, uint64(120), ds.BytesSent)
+	assert.Equal(t, uint64(350), ds.BytesReceived)
+	assert.Equal(t, uint64(1), total(ds.Roundtrip))
+	assert.GreaterOrEqual(t, ds.Roundtrip.Sum, 0.02)
+
+	// The connection that transferred nothing has no throughput.
+	assert.Equal(t, uint64(1), total(ds.Throughput))
+}
+
+func TestRegistry_recorder(t *testing.T) {
+	r := NewRegistry()
+	id := tunnel.NewConnID(ipproto.TCP, iputil.Parse("10.0.0.1"), iputil.Parse("127.0.0.1"), 1001, 8080)
+	rec := r.Recorder(func(tunnel.ConnID) string { return "intercept echo" })
+	rec.RecordOpen(id)
+	rec.RecordPeerData(id, make([]byte, 10))
+	rec.RecordConnData(id, make([]byte, 30))
+	rec.RecordClose(id)
+	rec.RecordConnData(id, make([]byte, 30))
+
+	dss := r.Snapshot("user")
+	require.Len(t, dss, 1)
+	ds := dss[0]
+	assert.Equal(t, "intercept echo", ds.Destination)
+	assert.Equal(t, uint64(10), ds.BytesSent)
+	assert.Equal(t, uint64(30), ds.BytesReceived)
+	assert.Equal(t, uint64(1), total(ds.Roundtrip))
+}
+
+func total(h *daemon.Histogram) (n uint64) {
+	for _, c := range h.Counts {
+		n += c
+	}
+	return n
+}
@@ -27,13 +27,17 @@ import (
 	"github.com/telepresenceio/telepresence/rpc/v2/daemon"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/capture"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/logging"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/rootd"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/scout"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/sendqueue"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/socket"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/userd"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
 	"github.com/telepresenceio/telepresence/v2/pkg/proc"
 	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 )
 
 func callRecovery(c context.Context, r any, err error) error {
@@ -321,6 +325,14 @@ func (s *service) UpdateIntercept(c context.Context, rr *manager.UpdateIntercept
 	return
 }
 
+func (s *service) HandoverIntercept(c context.Context, rq *manager.HandoverInterceptRequest) (result *manager.InterceptInfo, err error) {
+	err = s.WithSession(c, "HandoverIntercept", func(c context.Context, session userd.Session) error {
+		result, err = session.HandoverIntercept(c, rq)
+		return err
+	})
+	return
+}
+
 func (s *service) AddInterceptor(ctx context.Context, interceptor *rpc.Interceptor) (*empty.Empty, error) {
 	return &empty.Empty{}, s.WithSession(ctx, "AddInterceptor", func(_ context.Context, session userd.Session) error {
 		return session.AddInterceptor(interceptor.InterceptId, interceptor)
@@ -333,6 +345,22 @@ func (s *service) RemoveInterceptor(ctx context.Context, interceptor *rpc.Interc
 	})
 }
 
+func (s *service) Ingest(c context.Context, rq *rpc.IngestRequest) (result *rpc.IngestInfo, err error) {
+	err = s.WithSession(c, "Ingest", func(c context.Context, session userd.Session) error {
+		result, err = session.Ingest(c, rq)
+		return err
+	})
+	return
+}
+
+func (s *service) LeaveIngest(c context.Context, id *rpc.IngestIdentifier) (result *rpc.IngestInfo, err error) {
+	err = s.WithSession(c, "LeaveIngest", func(c context.Context, session userd.Session) error {
+		result, err = session.LeaveIngest(c, id)
+		return err
+	})
+	return
+}
+
 func (s *service) List(c context.Context, lr *rpc.ListRequest) (result *rpc.WorkloadInfoSnapshot, err error) {
 	err = s.WithSession(c, "List", func(c context.Context, session userd.Session) error {
 		result, err = session.WorkloadInfoSnapshot(c, []string{lr.Namespace}, lr.Filter)
@@ -572,7 +600,9 @@ func (s *service) GetClusterSubnets(ctx context.Context, _ *empty.Empty) (cs *rp
 				}
 				return err
 			}
-			if mgrInfo.ServiceSubnet != nil {
+			if len(mgrInfo.ServiceSubnets) > 0 {
+				svcSubnets = append(svcSubnets, mgrInfo.ServiceSubnets...)
+			} else if mgrInfo.ServiceSubnet != nil {
 				svcSubnets = append(svcSubnets, mgrInfo.ServiceSubnet)
 			}
 			podSubnets = append(podSubnets, mgrInfo.PodSubnets...)
@@ -611,6 +641,142 @@ func (s *service) SetDNSMappings(ctx context.Context, req *daemon.SetDNSMappings
 	return &empty.Empty{}, err
 }
 
+func (s *service) GetConnections(ctx context.Context, _ *empty.Empty) (result *daemon.Connections, err error) {
+	err = s.WithSession(ctx, "GetConnections", func(ctx context.Context, session userd.Session) error {
+		result, err = session.RootDaemon().GetConnections(ctx, &empty.Empty{})
+		return err
+	})
+	return result, err
+}
+
+func (s *service) Capture(req *daemon.CaptureRequest, stream rpc.Connector_CaptureServer) error {
+	// The capture runs until the call is cancelled, so it must not hold on to the session lock.
+	var rootDaemon daemon.DaemonClient
+	ctx := stream.Context()
+	err := s.WithSession(ctx, "Capture", func(_ context.Context, session userd.Session) error {
+		rootDaemon = session.RootDaemon()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	rs, err := rootDaemon.Capture(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	// The blocks from the root daemon are queued together with the blocks of the intercepted connections
+	// that are dialed by this process, so that they are sent to the caller by this goroutine only.
+	q := sendqueue.New(sendqueue.Size)
+	if _, inProc := rootDaemon.(*rootd.InProcSession); !inProc {
+		w := capture.NewWriter(q)
+		r := capture.NewRecorder(w, capture.FilterFromRPC(req))
+		tunnel.AddTap(r)
+		defer func() {
+			tunnel.RemoveTap(r)
+			_ = w.Close()
+		}()
+	}
+	rootErr := make(chan error, 1)
+	go func() {
+		for {
+			bs, err := rs.Recv()
+			if err != nil {
+				rootErr <- err
+				return
+			}
+			_, _ = q.Write(bs.Blocks)
+		}
+	}()
+	defer func() {
+		if n := q.Dropped(); n > 0 {
+			dlog.Warnf(ctx, "%d captured packets were dropped", n)
+		}
+	}()
+	for {
+		select {
+		case err = <-rootErr:
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		case blocks := <-q.Data():
+			if err = stream.Send(&daemon.CaptureBlocks{Blocks: blocks}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *service) GetTunnelStats(ctx context.Context, _ *empty.Empty) (result *daemon.TunnelStats, err error) {
+	err = s.WithSession(ctx, "GetTunnelStats", func(ctx context.Context, session userd.Session) error {
+		result, err = session.TunnelStats(ctx)
+		return err
+	})
+	return result, err
+}
+
+func (s *service) GetDNSCache(ctx context.Context, _ *empty.Empty) (result *daemon.DNSCache, err error) {
+	err = s.WithSession(ctx, "GetDNSCache", func(ctx context.Context, session userd.Session) error {
+		result, err = session.RootDaemon().GetDNSCache(ctx, &empty.Empty{})
+		return err
+	})
+	return result, err
+}
+
+func (s *service) FlushDNSCache(ctx context.Context, req *daemon.FlushDNSCacheRequest) (result *daemon.FlushDNSCacheResponse, err error) {
+	err = s.WithSession(ctx, "FlushDNSCache", func(ctx context.Context, session userd.Session) error {
+		result, err = session.RootDaemon().FlushDNSCache(ctx, req)
+		return err
+	})
+	return result, err
+}
+
+func (s *service) ExportDNS(req *rpc.ExportDNSRequest, stream rpc.Connector_ExportDNSServer) error {
+	// The export runs until the call is cancelled, so it must not hold on to the session lock.
+	var session userd.Session
+	ctx := stream.Context()
+	err := s.WithSession(ctx, "ExportDNS", func(_ context.Context, sess userd.Session) error {
+		session = sess
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return session.ExportDNS(ctx, req.Namespace, stream.Send)
+}
+
+func (s *service) WatchDNSQueries(req *daemon.WatchDNSQueriesRequest, stream rpc.Connector_WatchDNSQueriesServer) error {
+	// The watch runs until the call is cancelled when following, so it must not hold on to the session lock.
+	var rootDaemon daemon.DaemonClient
+	ctx := stream.Context()
+	err := s.WithSession(ctx, "WatchDNSQueries", func(_ context.Context, session userd.Session) error {
+		rootDaemon = session.RootDaemon()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	qs, err := rootDaemon.WatchDNSQueries(ctx, req)
+	if err != nil {
+		return err
+	}
+	for {
+		q, err := qs.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err = stream.Send(q); err != nil {
+			return err
+		}
+	}
+}
+
 func (s *service) withRootDaemon(ctx context.Context, f func(ctx context.Context, daemonClient daemon.DaemonClient) error) error {
 	if s.rootSessionInProc {
 		return status.Error(codes.Unavailable, "root daemon is embedded")
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/netip"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -221,12 +222,18 @@ func ConnectCluster(c context.Context, cr *rpc.ConnectRequest, config *client.Ku
 	if err != nil {
 		return c, nil, fmt.Errorf("failed to parse extra allow conflicting subnets: %w", err)
 	}
-	if len(extraAlsoProxy)+len(extraNeverProxy)+len(extraAllow) > 0 {
+	extraMappings := client.MappingsFromRPC(cr.GetDnsMappings())
+	if err = extraMappings.Validate(); err != nil {
+		return c, nil, fmt.Errorf("failed to parse extra DNS mappings: %w", err)
+	}
+	if len(extraAlsoProxy)+len(extraNeverProxy)+len(extraAllow)+len(extraMappings) > 0 {
 		cfg := client.GetConfig(c).Merge(client.GetDefaultConfig())
 		rt := cfg.Routing()
 		rt.AllowConflicting = append(rt.AllowConflicting, extraAllow...)
 		rt.AlsoProxy = append(rt.AlsoProxy, extraAlsoProxy...)
 		rt.NeverProxy = append(rt.NeverProxy, extraNeverProxy...)
+		dns := cfg.DNS()
+		dns.Mappings = append(slices.Clone(dns.Mappings), extraMappings...)
 		c = client.WithConfig(c, cfg)
 	}
 
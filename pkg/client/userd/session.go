@@ -61,6 +61,11 @@ type Session interface {
 	GetInterceptSpec(string) *manager.InterceptSpec
 	InterceptsForWorkload(string, string) []*manager.InterceptSpec
 
+	HandoverIntercept(context.Context, *manager.HandoverInterceptRequest) (*manager.InterceptInfo, error)
+
+	Ingest(context.Context, *rpc.IngestRequest) (*rpc.IngestInfo, error)
+	LeaveIngest(context.Context, *rpc.IngestIdentifier) (*rpc.IngestInfo, error)
+
 	ManagerClient() manager.ManagerClient
 	ManagerConn() *grpc.ClientConn
 	ManagerName() string
@@ -87,6 +92,8 @@ type Session interface {
 
 	SessionInfo() *manager.SessionInfo
 	RootDaemon() rootdRpc.DaemonClient
+	TunnelStats(context.Context) (*rootdRpc.TunnelStats, error)
+	ExportDNS(context.Context, string, func(*rootdRpc.LookupHostsResponse) error) error
 
 	ApplyConfig(context.Context) error
 	GetConfig(context.Context) (*client.SessionConfig, error)
@@ -0,0 +1,109 @@
+package trafficmgr
+
+import (
+	"context"
+	"slices"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	rootdRpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
+)
+
+// ExportDNS sends the addresses of the services and pods in the given namespace, or in all mapped namespaces
+// when the namespace is empty, and then new addresses each time the names change. The names are watched by
+// the traffic-manager, so the user needs no permission to list them. The lookups are made by the root daemon,
+// so that virtual IPs are used when the service subnets are translated.
+func (s *session) ExportDNS(ctx context.Context, namespace string, send func(*rootdRpc.LookupHostsResponse) error) error {
+	var nss []string
+	if namespace != "" {
+		ns := s.ActualNamespace(namespace)
+		if ns == "" {
+			return errcat.User.Newf("namespace %s is not mapped", namespace)
+		}
+		nss = []string{ns}
+	} else {
+		nss = s.GetCurrentNamespaces(false)
+		slices.Sort(nss)
+	}
+	if len(nss) == 0 {
+		return send(&rootdRpc.LookupHostsResponse{})
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type nsHosts struct {
+		index int
+		names []string
+	}
+	hostsCh := make(chan nsHosts)
+	errCh := make(chan error, len(nss))
+	for i, ns := range nss {
+		stream, err := s.managerClient.WatchClusterHosts(ctx, &manager.ClusterHostsRequest{SessionInfo: s.sessionInfo, Namespace: ns})
+		if err != nil {
+			return err
+		}
+		go func() {
+			for {
+				chs, err := stream.Recv()
+				if err != nil {
+					errCh <- err
+					return
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case hostsCh <- nsHosts{index: i, names: chs.Names}:
+				}
+			}
+		}()
+	}
+
+	// The first response is sent when all namespaces have reported their names.
+	names := make([][]string, len(nss))
+	pending := len(nss)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errCh:
+			switch {
+			case ctx.Err() != nil:
+				return nil
+			case status.Code(err) == codes.Unimplemented:
+				return errcat.User.Newf("traffic-manager %s doesn't support dns export", s.managerVersion)
+			default:
+				return err
+			}
+		case nh := <-hostsCh:
+			if names[nh.index] == nil {
+				pending--
+			}
+			names[nh.index] = nh.names
+			if names[nh.index] == nil {
+				names[nh.index] = []string{}
+			}
+			if pending > 0 {
+				continue
+			}
+			all := slices.Concat(names...)
+			dlog.Debugf(ctx, "exporting %d cluster names", len(all))
+			rsp := &rootdRpc.LookupHostsResponse{}
+			if len(all) > 0 {
+				var err error
+				if rsp, err = s.rootDaemon.LookupHosts(ctx, &rootdRpc.LookupHostsRequest{Names: all}); err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+					return err
+				}
+			}
+			if err := send(rsp); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package trafficmgr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	grpcCodes "google.golang.org/grpc/codes"
+	grpcStatus "google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/remotefs"
+)
+
+// ingestKey identifies an ingest. A workload can have one ingest per container.
+type ingestKey struct {
+	workload  string
+	container string
+}
+
+func (k ingestKey) String() string {
+	return k.workload + "/" + k.container
+}
+
+// ingest tracks the life-cycle of an ingest. An ingest provides the environment and the volumes of
+// a container in a workload with a traffic-agent, but unlike an intercept, it doesn't divert any traffic.
+type ingest struct {
+	sync.Mutex
+	ingestKey
+	remoteMount
+
+	// The mounter of the remote file system.
+	remotefs.Mounter
+
+	namespace string
+	env       map[string]string
+
+	// ctx is a context cancelled by the cancel attribute. It must be used by
+	// services that should be cancelled when the ingest ends
+	ctx context.Context
+
+	// cancel is called when the ingest ends
+	cancel context.CancelFunc
+
+	// wg is the group to wait for after a call to cancel
+	wg sync.WaitGroup
+}
+
+func (ig *ingest) info() *rpc.IngestInfo {
+	ig.Lock()
+	defer ig.Unlock()
+	return &rpc.IngestInfo{
+		Workload:         ig.workload,
+		Container:        ig.container,
+		Namespace:        ig.namespace,
+		PodIp:            ig.podIP,
+		SftpPort:         ig.sftpPort,
+		FtpPort:          ig.ftpPort,
+		MountPoint:       ig.mountPoint,
+		ClientMountPoint: ig.clientMountPoint,
+		Environment:      ig.env,
+	}
+}
+
+// Ingest ensures that the given workload has a traffic-agent, and then makes the environment and the
+// volumes of the given container available without creating an intercept.
+func (s *session) Ingest(ctx context.Context, rq *rpc.IngestRequest) (*rpc.IngestInfo, error) {
+	id := rq.Identifier
+	if id == nil || id.WorkloadName == "" {
+		return nil, grpcStatus.Error(grpcCodes.InvalidArgument, "no workload name given")
+	}
+	if ig := s.getIngest(id); ig != nil {
+		return nil, grpcStatus.Errorf(grpcCodes.AlreadyExists, "%s is already ingested", ig.ingestKey)
+	}
+
+	tos := client.GetConfig(ctx).Timeouts()
+	tc, cancel := tos.TimeoutContext(ctx, client.TimeoutIntercept)
+	defer cancel()
+	if _, err := s.managerClient.EnsureAgent(tc, &manager.EnsureAgentRequest{Session: s.SessionInfo(), Name: id.WorkloadName}); err != nil {
+		return nil, err
+	}
+	ai, container, err := s.waitForIngestAgent(tc, id)
+	if err != nil {
+		return nil, err
+	}
+	ci := ai.Containers[container]
+
+	ig := &ingest{
+		ingestKey: ingestKey{workload: id.WorkloadName, container: container},
+		remoteMount: remoteMount{
+			podIP:            ai.PodIp,
+			ftpPort:          ai.FtpPort,
+			sftpPort:         ai.SftpPort,
+			localMountPort:   rq.LocalMountPort,
+			mountPoint:       ci.MountPoint,
+			clientMountPoint: rq.MountPoint,
+		},
+		namespace: ai.Namespace,
+		env:       ci.Environment,
+	}
+	ig.id = ig.ingestKey.String()
+	ig.ctx, ig.cancel = context.WithCancel(ctx)
+
+	s.currentIngestsLock.Lock()
+	if _, ok := s.currentIngests[ig.ingestKey]; ok {
+		s.currentIngestsLock.Unlock()
+		ig.cancel()
+		return nil, grpcStatus.Errorf(grpcCodes.AlreadyExists, "%s is already ingested", ig.ingestKey)
+	}
+	s.currentIngests[ig.ingestKey] = ig
+	s.currentIngestsLock.Unlock()
+
+	dlog.Infof(ctx, "Ingesting %s in namespace %s using pod %s", ig.ingestKey, ig.namespace, ai.PodName)
+	if ig.shouldMount() {
+		ig.wg.Add(1)
+		go s.ingestMountLoop(ig)
+	}
+	return ig.info(), nil
+}
+
+// LeaveIngest ends the given ingest, and returns its info.
+func (s *session) LeaveIngest(ctx context.Context, id *rpc.IngestIdentifier) (*rpc.IngestInfo, error) {
+	ig := s.getIngest(id)
+	if ig == nil {
+		name := id.WorkloadName
+		if id.ContainerName != "" {
+			name += "/" + id.ContainerName
+		}
+		return nil, grpcStatus.Errorf(grpcCodes.NotFound, "ingest %s not found", name)
+	}
+	s.removeIngest(ctx, ig)
+	return ig.info(), nil
+}
+
+func (s *session) removeIngest(ctx context.Context, ig *ingest) {
+	dlog.Debugf(ctx, "Leaving ingest %s", ig.ingestKey)
+	s.currentIngestsLock.Lock()
+	delete(s.currentIngests, ig.ingestKey)
+	s.currentIngestsLock.Unlock()
+	ig.cancel()
+	ig.wg.Wait()
+}
+
+// clearIngests ends all ingests.
+func (s *session) clearIngests(ctx context.Context) {
+	for _, ig := range s.getCurrentIngests() {
+		s.removeIngest(ctx, ig)
+	}
+}
+
+// getIngest returns the ingest with the given identifier. The container name of the identifier
+// can be omitted if the workload only has one ingest.
+func (s *session) getIngest(id *rpc.IngestIdentifier) (found *ingest) {
+	s.currentIngestsLock.Lock()
+	defer s.currentIngestsLock.Unlock()
+	if id.ContainerName != "" {
+		return s.currentIngests[ingestKey{workload: id.WorkloadName, container: id.ContainerName}]
+	}
+	for k, ig := range s.currentIngests {
+		if k.workload == id.WorkloadName {
+			if found != nil {
+				return nil
+			}
+			found = ig
+		}
+	}
+	return found
+}
+
+func (s *session) getCurrentIngests() []*ingest {
+	s.currentIngestsLock.Lock()
+	igs := make([]*ingest, 0, len(s.currentIngests))
+	for _, ig := range s.currentIngests {
+		igs = append(igs, ig)
+	}
+	s.currentIngestsLock.Unlock()
+	sort.Slice(igs, func(i, j int) bool { return igs[i].ingestKey.String() < igs[j].ingestKey.String() })
+	return igs
+}
+
+// getCurrentIngestInfos returns the IngestInfos of the current ingests.
+func (s *session) getCurrentIngestInfos() []*rpc.IngestInfo {
+	igs := s.getCurrentIngests()
+	infos := make([]*rpc.IngestInfo, len(igs))
+	for i, ig := range igs {
+		infos[i] = ig.info()
+	}
+	return infos
+}
+
+// waitForIngestAgent waits for a traffic-agent of the given workload to arrive, and returns its info
+// together with the name of the container to ingest.
+func (s *session) waitForIngestAgent(ctx context.Context, id *rpc.IngestIdentifier) (*manager.AgentInfo, string, error) {
+	stream, err := s.managerClient.WatchAgents(ctx, s.SessionInfo())
+	if err != nil {
+		return nil, "", err
+	}
+	outdated := false
+	for {
+		snapshot, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				if outdated {
+					return nil, "", grpcStatus.Errorf(grpcCodes.FailedPrecondition,
+						"the traffic-agent of %s doesn't provide container information. It must be upgraded to support ingest", id.WorkloadName)
+				}
+				err = client.CheckTimeout(ctx, ctx.Err())
+			}
+			return nil, "", err
+		}
+		var ai *manager.AgentInfo
+		ai, outdated = s.chooseIngestAgent(snapshot.Agents, id.WorkloadName, "")
+		if ai != nil {
+			container, err := ingestContainer(ai, id.ContainerName)
+			return ai, container, err
+		}
+	}
+}
+
+// chooseIngestAgent returns the agent of the given workload that runs in a pod with the preferred
+// pod IP, or the first agent of the workload sorted by pod name. The returned boolean is true when
+// agents of the workload exist, but none of them provide container information.
+func (s *session) chooseIngestAgent(agents []*manager.AgentInfo, workload, preferredIP string) (*manager.AgentInfo, bool) {
+	var candidates []*manager.AgentInfo
+	outdated := false
+	for _, ai := range agents {
+		if ai.Name != workload || ai.Namespace != s.Namespace {
+			continue
+		}
+		if len(ai.Containers) == 0 {
+			outdated = true
+			continue
+		}
+		if ai.PodIp == preferredIP {
+			return ai, false
+		}
+		candidates = append(candidates, ai)
+	}
+	if len(candidates) == 0 {
+		return nil, outdated
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].PodName < candidates[j].PodName })
+	return candidates[0], false
+}
+
+// ingestContainer returns the given container name if the agent provides that container, or the name of
+// the agent's only container when no name is given.
+func ingestContainer(ai *manager.AgentInfo, name string) (string, error) {
+	if name != "" {
+		if _, ok := ai.Containers[name]; !ok {
+			return "", grpcStatus.Errorf(grpcCodes.InvalidArgument, "workload %s has no container named %s that can be ingested", ai.Name, name)
+		}
+		return name, nil
+	}
+	if len(ai.Containers) == 1 {
+		for cn := range ai.Containers {
+			return cn, nil
+		}
+	}
+	names := make([]string, 0, len(ai.Containers))
+	for cn := range ai.Containers {
+		names = append(names, cn)
+	}
+	sort.Strings(names)
+	return "", grpcStatus.Errorf(grpcCodes.InvalidArgument, "workload %s has more than one container that can be ingested. Please use --container to select one of %s",
+		ai.Name, strings.Join(names, ", "))
+}
+
+// ingestMountLoop mounts the volumes of the ingested container, and moves the mount to another pod of the
+// workload when the current pod goes away. It runs until the ingest ends.
+func (s *session) ingestMountLoop(ig *ingest) {
+	defer ig.wg.Done()
+	_ = runWithRetry(ig.ctx, func(ctx context.Context) error {
+		stream, err := s.managerClient.WatchAgents(ctx, s.SessionInfo())
+		if err != nil {
+			return fmt.Errorf("manager.WatchAgents dial: %w", err)
+		}
+		var podCancel context.CancelFunc
+		podWG := sync.WaitGroup{}
+		stopPod := func() {
+			if podCancel != nil {
+				podCancel()
+				podWG.Wait()
+				podCancel = nil
+			}
+		}
+		defer stopPod()
+
+		mountedIP := ""
+		for ctx.Err() == nil {
+			snapshot, err := stream.Recv()
+			if err != nil {
+				if ctx.Err() != nil || errors.Is(err, io.EOF) {
+					return nil
+				}
+				return fmt.Errorf("manager.WatchAgents recv: %w", err)
+			}
+			ig.Lock()
+			ai, _ := s.chooseIngestAgent(snapshot.Agents, ig.workload, ig.podIP)
+			ig.Unlock()
+			if ai == nil || ai.PodIp == mountedIP {
+				continue
+			}
+			ci, ok := ai.Containers[ig.container]
+			if !ok {
+				continue
+			}
+			stopPod()
+			ig.Lock()
+			ig.podIP = ai.PodIp
+			ig.ftpPort = ai.FtpPort
+			ig.sftpPort = ai.SftpPort
+			ig.mountPoint = ci.MountPoint
+			rm := ig.remoteMount
+			ig.Unlock()
+			if !awaitAgentPortForward(ctx, s.rootDaemon, rm.podIP) {
+				continue
+			}
+			podCtx, cancel := context.WithCancel(ctx)
+			podCancel = cancel
+			dlog.Debugf(ctx, "Mounting volumes of ingest %s from pod %s", ig.ingestKey, ai.PodName)
+			ig.Mounter = rm.start(podCtx, ig.ctx, ig.Mounter, &ig.wg, &podWG)
+			mountedIP = rm.podIP
+		}
+		return nil
+	})
+}
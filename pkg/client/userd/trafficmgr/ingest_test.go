@@ -0,0 +1,219 @@
+package trafficmgr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	grpcCodes "google.golang.org/grpc/codes"
+	grpcStatus "google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/userd/k8s"
+)
+
+func newIngestSession(namespace string) *session {
+	return &session{
+		Cluster:        &k8s.Cluster{Kubeconfig: &client.Kubeconfig{Namespace: namespace}},
+		currentIngests: make(map[ingestKey]*ingest),
+	}
+}
+
+func agentInfo(name, namespace, podName, podIP string, containers ...string) *manager.AgentInfo {
+	ai := &manager.AgentInfo{Name: name, Namespace: namespace, PodName: podName, PodIp: podIP}
+	if len(containers) > 0 {
+		ai.Containers = make(map[string]*manager.AgentInfo_ContainerInfo, len(containers))
+		for _, cn := range containers {
+			ai.Containers[cn] = &manager.AgentInfo_ContainerInfo{MountPoint: "/tel_app_mounts/" + cn}
+		}
+	}
+	return ai
+}
+
+func TestChooseIngestAgent(t *testing.T) {
+	s := newIngestSession("default")
+	agents := []*manager.AgentInfo{
+		agentInfo("echo", "other", "echo-a", "10.0.0.1", "echo"),
+		agentInfo("hello", "default", "hello-a", "10.0.0.2", "hello"),
+		agentInfo("echo", "default", "echo-c", "10.0.0.3", "echo"),
+		agentInfo("echo", "default", "echo-b", "10.0.0.4", "echo"),
+	}
+
+	// The first pod sorted by name is chosen when no pod IP is preferred.
+	ai, outdated := s.chooseIngestAgent(agents, "echo", "")
+	require.NotNil(t, ai)
+	assert.False(t, outdated)
+	assert.Equal(t, "echo-b", ai.PodName)
+
+	// The pod with the preferred IP is chosen when it's still present.
+	ai, _ = s.chooseIngestAgent(agents, "echo", "10.0.0.3")
+	require.NotNil(t, ai)
+	assert.Equal(t, "echo-c", ai.PodName)
+
+	// Agents in other namespaces are never chosen.
+	ai, outdated = s.chooseIngestAgent(agents, "echo", "10.0.0.1")
+	require.NotNil(t, ai)
+	assert.False(t, outdated)
+	assert.Equal(t, "echo-b", ai.PodName)
+
+	ai, outdated = s.chooseIngestAgent(agents, "unknown", "")
+	assert.Nil(t, ai)
+	assert.False(t, outdated)
+
+	// Agents that provide no container information are outdated.
+	ai, outdated = s.chooseIngestAgent([]*manager.AgentInfo{agentInfo("echo", "default", "echo-a", "10.0.0.1")}, "echo", "")
+	assert.Nil(t, ai)
+	assert.True(t, outdated)
+}
+
+func TestIngestContainer(t *testing.T) {
+	tests := []struct {
+		name       string
+		containers []string
+		container  string
+		want       string
+		wantErr    string
+	}{
+		{
+			name:       "only container",
+			containers: []string{"echo"},
+			want:       "echo",
+		},
+		{
+			name:       "named container",
+			containers: []string{"echo", "sidecar"},
+			container:  "sidecar",
+			want:       "sidecar",
+		},
+		{
+			name:       "unknown container",
+			containers: []string{"echo"},
+			container:  "sidecar",
+			wantErr:    "workload echo has no container named sidecar that can be ingested",
+		},
+		{
+			name:       "ambiguous container",
+			containers: []string{"sidecar", "echo"},
+			wantErr:    "workload echo has more than one container that can be ingested. Please use --container to select one of echo, sidecar",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ingestContainer(agentInfo("echo", "default", "echo-a", "10.0.0.1", tt.containers...), tt.container)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, grpcCodes.InvalidArgument, grpcStatus.Code(err))
+				assert.Equal(t, tt.wantErr, grpcStatus.Convert(err).Message())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// addIngest adds an ingest to the session, together with a goroutine that runs until the ingest ends.
+func addIngest(ctx context.Context, s *session, workload, container string) (*ingest, <-chan struct{}) {
+	ig := &ingest{ingestKey: ingestKey{workload: workload, container: container}}
+	ig.ctx, ig.cancel = context.WithCancel(ctx)
+	s.currentIngests[ig.ingestKey] = ig
+	done := make(chan struct{})
+	ig.wg.Add(1)
+	go func() {
+		defer ig.wg.Done()
+		<-ig.ctx.Done()
+		close(done)
+	}()
+	return ig, done
+}
+
+func TestGetIngest(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	s := newIngestSession("default")
+	echo, _ := addIngest(ctx, s, "echo", "echo")
+	hello, _ := addIngest(ctx, s, "hello", "hello")
+	sidecar, _ := addIngest(ctx, s, "hello", "sidecar")
+	defer s.clearIngests(ctx)
+
+	assert.Same(t, echo, s.getIngest(&rpc.IngestIdentifier{WorkloadName: "echo"}))
+	assert.Same(t, echo, s.getIngest(&rpc.IngestIdentifier{WorkloadName: "echo", ContainerName: "echo"}))
+	assert.Nil(t, s.getIngest(&rpc.IngestIdentifier{WorkloadName: "echo", ContainerName: "sidecar"}))
+	assert.Same(t, hello, s.getIngest(&rpc.IngestIdentifier{WorkloadName: "hello", ContainerName: "hello"}))
+	assert.Same(t, sidecar, s.getIngest(&rpc.IngestIdentifier{WorkloadName: "hello", ContainerName: "sidecar"}))
+
+	// The container must be named when the workload has more than one ingest.
+	assert.Nil(t, s.getIngest(&rpc.IngestIdentifier{WorkloadName: "hello"}))
+}
+
+func TestIngest_invalid(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	s := newIngestSession("default")
+	_, err := s.Ingest(ctx, &rpc.IngestRequest{})
+	assert.Equal(t, grpcCodes.InvalidArgument, grpcStatus.Code(err))
+
+	addIngest(ctx, s, "echo", "echo")
+	defer s.clearIngests(ctx)
+	_, err = s.Ingest(ctx, &rpc.IngestRequest{Identifier: &rpc.IngestIdentifier{WorkloadName: "echo"}})
+	require.Error(t, err)
+	assert.Equal(t, grpcCodes.AlreadyExists, grpcStatus.Code(err))
+	assert.Equal(t, "echo/echo is already ingested", grpcStatus.Convert(err).Message())
+}
+
+func TestLeaveIngest(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	s := newIngestSession("default")
+	_, err := s.LeaveIngest(ctx, &rpc.IngestIdentifier{WorkloadName: "echo", ContainerName: "sidecar"})
+	require.Error(t, err)
+	assert.Equal(t, grpcCodes.NotFound, grpcStatus.Code(err))
+	assert.Equal(t, "ingest echo/sidecar not found", grpcStatus.Convert(err).Message())
+
+	_, echoDone := addIngest(ctx, s, "echo", "echo")
+	_, helloDone := addIngest(ctx, s, "hello", "hello")
+	info, err := s.LeaveIngest(ctx, &rpc.IngestIdentifier{WorkloadName: "echo"})
+	require.NoError(t, err)
+	assert.Equal(t, "echo", info.Workload)
+	assert.Equal(t, "echo", info.Container)
+
+	// LeaveIngest doesn't return until the goroutines of the ingest have ended.
+	select {
+	case <-echoDone:
+	default:
+		t.Fatal("ingest goroutine is still running")
+	}
+	select {
+	case <-helloDone:
+		t.Fatal("ingest goroutine of another ingest was stopped")
+	default:
+	}
+	assert.Nil(t, s.getIngest(&rpc.IngestIdentifier{WorkloadName: "echo"}))
+
+	_, err = s.LeaveIngest(ctx, &rpc.IngestIdentifier{WorkloadName: "echo"})
+	assert.Equal(t, grpcCodes.NotFound, grpcStatus.Code(err))
+
+	s.clearIngests(ctx)
+	<-helloDone
+	assert.Empty(t, s.getCurrentIngestInfos())
+}
+
+func TestRemoteMount_shouldMount(t *testing.T) {
+	tests := []struct {
+		name string
+		rm   remoteMount
+		want bool
+	}{
+		{"no ports", remoteMount{clientMountPoint: "/tmp/mnt"}, false},
+		{"no mount point", remoteMount{sftpPort: 2222}, false},
+		{"sftp", remoteMount{sftpPort: 2222, clientMountPoint: "/tmp/mnt"}, true},
+		{"ftp", remoteMount{ftpPort: 2121, clientMountPoint: "/tmp/mnt"}, true},
+		{"local mount port", remoteMount{sftpPort: 2222, localMountPort: 8022}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.rm.shouldMount())
+		})
+	}
+}
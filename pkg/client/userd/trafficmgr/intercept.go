@@ -25,6 +25,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/docker"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/recording"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/remotefs"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/userd"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
@@ -68,6 +69,11 @@ type intercept struct {
 
 	// Use bridged ftp/sftp mount through this local port
 	localMountPort int32
+
+	// recorder records the intercepted connections in recordFile. Only present when
+	// the intercept was created with --record.
+	recorder   *recording.Writer
+	recordFile string
 }
 
 // interceptResult is what gets written to the awaitIntercept's waitCh channel when the
@@ -89,6 +95,11 @@ type awaitIntercept struct {
 	// the mount to take place in a host
 	mountPort int32
 
+	// recorder is optional and is handed over to the intercept when it arrives. It records
+	// the intercepted connections in recordFile.
+	recorder   *recording.Writer
+	recordFile string
+
 	waitCh chan<- interceptResult
 }
 
@@ -215,23 +226,8 @@ func (lpf *podIntercepts) start(ctx context.Context, ic *intercept, rd daemon.Da
 		return
 	}
 
-	if client.GetConfig(ctx).Cluster().AgentPortForward {
-		// An agent port-forward to the pod with a designated to the PodIP is necessary in order to
-		// mount or port-forward to localhost.
-		_, err := rd.WaitForAgentIP(ctx, &daemon.WaitForAgentIPRequest{
-			Ip:      iputil.Parse(ic.PodIp),
-			Timeout: durationpb.New(10 * time.Second),
-		})
-		switch grpcStatus.Code(err) {
-		// Unavailable means that the feature disabled. This is OK, the traffic-manager will do the forwarding
-		case grpcCodes.OK, grpcCodes.Unavailable:
-		case grpcCodes.DeadlineExceeded:
-			dlog.Errorf(ctx, "timeout waiting for port-forward to traffic-agent with pod-ip %s", ic.PodIp)
-			return
-		default:
-			dlog.Errorf(ctx, "unexpected error for port-forward to traffic-agent with pod-ip %s: %v", ic.PodIp, err)
-			return
-		}
+	if !awaitAgentPortForward(ctx, rd, ic.PodIp) {
+		return
 	}
 
 	ctx, cancel := context.WithCancel(ic.ctx)
@@ -246,6 +242,30 @@ func (lpf *podIntercepts) start(ctx context.Context, ic *intercept, rd daemon.Da
 	lpf.alivePods[fk] = lp
 }
 
+// awaitAgentPortForward waits for the agent port-forward to the given pod IP, provided that agent port-forwards
+// are enabled. It returns false if the port-forward doesn't become available.
+func awaitAgentPortForward(ctx context.Context, rd daemon.DaemonClient, podIP string) bool {
+	if !client.GetConfig(ctx).Cluster().AgentPortForward {
+		return true
+	}
+	// An agent port-forward to the pod with a designated to the PodIP is necessary in order to
+	// mount or port-forward to localhost.
+	_, err := rd.WaitForAgentIP(ctx, &daemon.WaitForAgentIPRequest{
+		Ip:      iputil.Parse(podIP),
+		Timeout: durationpb.New(10 * time.Second),
+	})
+	switch grpcStatus.Code(err) {
+	// Unavailable means that the feature disabled. This is OK, the traffic-manager will do the forwarding
+	case grpcCodes.OK, grpcCodes.Unavailable:
+		return true
+	case grpcCodes.DeadlineExceeded:
+		dlog.Errorf(ctx, "timeout waiting for port-forward to traffic-agent with pod-ip %s", podIP)
+	default:
+		dlog.Errorf(ctx, "unexpected error for port-forward to traffic-agent with pod-ip %s: %v", podIP, err)
+	}
+	return false
+}
+
 // initSnapshot prepares this instance for a new round of start calls followed by a cancelUnwanted.
 func (lpf *podIntercepts) initSnapshot() {
 	lpf.snapshot = make(map[podInterceptKey]struct{})
@@ -410,9 +430,17 @@ func (s *session) setCurrentIntercepts(ctx context.Context, iis []*manager.Inter
 			ic = &intercept{InterceptInfo: ii}
 			ic.ctx, ic.cancel = context.WithCancel(ctx)
 			dlog.Debugf(ctx, "Received new intercept %s", ic.Spec.Name)
+			if !strings.HasPrefix(ii.Id, ii.ClientSession.SessionId+":") {
+				dlog.Infof(ctx, "Intercept %s was handed over to this session", ic.Spec.Name)
+			}
 			if aw, ok := s.interceptWaiters[ii.Spec.Name]; ok {
 				ic.ClientMountPoint = aw.mountPoint
 				ic.localMountPort = aw.mountPort
+				if aw.recorder != nil {
+					ic.recorder, ic.recordFile = aw.recorder, aw.recordFile
+					aw.recorder = nil
+					s.startRecording(ctx, ic)
+				}
 			}
 		}
 		intercepts[ii.Id] = ic
@@ -429,6 +457,9 @@ func (s *session) setCurrentIntercepts(ctx context.Context, iis []*manager.Inter
 	// Cancel those that no longer exists
 	for id, ic := range s.currentIntercepts {
 		if _, ok := intercepts[id]; !ok {
+			if ea := ic.ExpiresAt; ea != nil && !time.Now().Before(ea.AsTime()) {
+				dlog.Infof(ctx, "Intercept %s expired and was removed by the traffic-manager", ic.Spec.Name)
+			}
 			dlog.Debugf(ctx, "Cancelling context for intercept %s", ic.Spec.Name)
 			ic.cancel()
 		}
@@ -581,6 +612,10 @@ func (s *session) AddIntercept(c context.Context, ir *rpc.CreateInterceptRequest
 	}
 	spec.Protocol = pi.Protocol
 	spec.ContainerPort = pi.ContainerPort
+	if spec.ContainerPort == 0 && spec.ContainerName == "" {
+		// A port-less intercept of a batch workload. The agent must know what container it targets.
+		spec.ContainerName = pi.ContainerName
+	}
 	result = iInfo.InterceptResult()
 
 	spec.ServiceUid = result.ServiceUid
@@ -596,12 +631,20 @@ func (s *session) AddIntercept(c context.Context, ir *rpc.CreateInterceptRequest
 	// The agent is in place and the traffic-manager has acknowledged the creation of the intercept. It
 	// should become active within a few seconds.
 	waitCh := make(chan interceptResult, 2) // Need a buffer because reply can come before we're reading the channel,
-	s.currentInterceptsLock.Lock()
-	s.interceptWaiters[spec.Name] = &awaitIntercept{
+	aw := &awaitIntercept{
 		mountPoint: ir.MountPoint,
 		mountPort:  ir.LocalMountPort,
+		recordFile: ir.RecordFile,
 		waitCh:     waitCh,
 	}
+	if ir.RecordFile != "" {
+		var err error
+		if aw.recorder, err = recording.Create(ir.RecordFile, spec.Name); err != nil {
+			return InterceptError(common.InterceptError_INTERNAL, errcat.User.Newf("unable to create recording: %w", err))
+		}
+	}
+	s.currentInterceptsLock.Lock()
+	s.interceptWaiters[spec.Name] = aw
 	s.currentInterceptsLock.Unlock()
 	defer func() {
 		s.currentInterceptsLock.Lock()
@@ -609,6 +652,10 @@ func (s *session) AddIntercept(c context.Context, ir *rpc.CreateInterceptRequest
 			delete(s.interceptWaiters, spec.Name)
 			close(waitCh)
 		}
+		if aw.recorder != nil {
+			// The intercept never arrived.
+			_ = aw.recorder.Close()
+		}
 		s.currentInterceptsLock.Unlock()
 	}()
 
@@ -733,6 +780,22 @@ func (s *session) AddInterceptor(id string, ih *rpc.Interceptor) error {
 	return nil
 }
 
+// HandoverIntercept hands over the intercept with the given name to another client session. The
+// intercept disappears from this session when the traffic-manager has moved it.
+func (s *session) HandoverIntercept(c context.Context, rq *manager.HandoverInterceptRequest) (*manager.InterceptInfo, error) {
+	if s.getInterceptByName(rq.Name) == nil {
+		return nil, grpcStatus.Errorf(grpcCodes.NotFound, "intercept %s not found", rq.Name)
+	}
+	dlog.Debugf(c, "telling manager to hand over intercept %s to %s", rq.Name, rq.ToClient)
+	c, cancel := client.GetConfig(c).Timeouts().TimeoutContext(c, client.TimeoutTrafficManagerAPI)
+	defer cancel()
+	return s.managerClient.HandoverIntercept(c, &manager.HandoverInterceptRequest{
+		Session:  s.SessionInfo(),
+		Name:     rq.Name,
+		ToClient: rq.ToClient,
+	})
+}
+
 func (s *session) RemoveInterceptor(id string) error {
 	s.currentInterceptsLock.Lock()
 	if ci, ok := s.currentIntercepts[id]; ok {
@@ -790,8 +853,9 @@ func (s *session) InterceptsForWorkload(workloadName, namespace string) []*manag
 	return wlis
 }
 
-// ClearIntercepts removes all intercepts.
+// ClearIntercepts removes all intercepts and ingests.
 func (s *session) ClearIntercepts(c context.Context) error {
+	s.clearIngests(c)
 	for _, ic := range s.getCurrentIntercepts() {
 		dlog.Debugf(c, "Clearing intercept %s", ic.Spec.Name)
 		err := s.removeIntercept(c, ic)
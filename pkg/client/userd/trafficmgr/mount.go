@@ -12,56 +12,90 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
 )
 
+func (ic *intercept) remoteMount() *remoteMount {
+	return &remoteMount{
+		id:               ic.Id,
+		podIP:            ic.PodIp,
+		ftpPort:          ic.FtpPort,
+		sftpPort:         ic.SftpPort,
+		localMountPort:   ic.localMountPort,
+		mountPoint:       ic.MountPoint,
+		clientMountPoint: ic.ClientMountPoint,
+	}
+}
+
 func (ic *intercept) shouldMount() bool {
-	return (ic.FtpPort > 0 || ic.SftpPort > 0) && (ic.localMountPort > 0 || ic.ClientMountPoint != "")
+	return ic.remoteMount().shouldMount()
 }
 
 // startMount starts the mount for the given podInterceptKey.
 // It assumes that the user has called shouldMount and is sure that something will be started.
 func (ic *intercept) startMount(ctx context.Context, iceptWG, podWG *sync.WaitGroup) {
+	ic.Mounter = ic.remoteMount().start(ctx, ic.ctx, ic.Mounter, iceptWG, podWG)
+}
+
+// remoteMount contains what's needed to mount the volumes of a container that a traffic-agent
+// provides using its FTP or SFTP server.
+type remoteMount struct {
+	id               string
+	podIP            string
+	ftpPort          int32
+	sftpPort         int32
+	localMountPort   int32
+	mountPoint       string
+	clientMountPoint string
+}
+
+func (rm *remoteMount) shouldMount() bool {
+	return (rm.ftpPort > 0 || rm.sftpPort > 0) && (rm.localMountPort > 0 || rm.clientMountPoint != "")
+}
+
+// start starts the mount using the given mounter, or a new mounter if m is nil, and returns the mounter.
+// The ownerCtx is the context of the intercept or ingest that owns the mount. The FTP mounter uses it
+// because it survives pod changes.
+func (rm *remoteMount) start(ctx, ownerCtx context.Context, m remotefs.Mounter, iceptWG, podWG *sync.WaitGroup) remotefs.Mounter {
 	var fuseftp rpc.FuseFTPClient
 	useFtp := client.GetConfig(ctx).Intercept().UseFtp
 	var port int32
 	mountCtx := ctx
 	if useFtp {
-		if ic.FtpPort == 0 {
+		if rm.ftpPort == 0 {
 			dlog.Errorf(ctx, "Client is configured to perform remote mounts using FTP, but only SFTP is provided by the traffic-agent")
-			return
+			return m
 		}
-		if ic.localMountPort > 0 {
+		if rm.localMountPort > 0 {
 			dlog.Errorf(ctx, "Client is configured to perform remote mounts using FTP, but only SFTP can be used with --local-mount-port")
-			return
+			return m
 		}
 		// The FTP mounter survives multiple starts for the same intercept. It just resets the address
-		mountCtx = ic.ctx
+		mountCtx = ownerCtx
 		if fuseftp = userd.GetService(ctx).FuseFTPMgr().GetFuseFTPClient(ctx); fuseftp == nil {
 			dlog.Errorf(ctx, "Client is configured to perform remote mounts using FTP, but the fuseftp server was unable to start")
-			return
+			return m
 		}
-		port = ic.FtpPort
+		port = rm.ftpPort
 	} else {
-		if ic.SftpPort == 0 {
+		if rm.sftpPort == 0 {
 			dlog.Errorf(ctx, "Client is configured to perform remote mounts using SFTP, but only FTP is provided by the traffic-agent")
-			return
+			return m
 		}
-		port = ic.SftpPort
+		port = rm.sftpPort
 	}
 
-	m := ic.Mounter
 	if m == nil {
 		switch {
-		case ic.localMountPort != 0:
+		case rm.localMountPort != 0:
 			session := userd.GetSession(ctx)
-			m = remotefs.NewBridgeMounter(session.SessionInfo().SessionId, session.ManagerClient(), uint16(ic.localMountPort))
+			m = remotefs.NewBridgeMounter(session.SessionInfo().SessionId, session.ManagerClient(), uint16(rm.localMountPort))
 		case useFtp:
 			m = remotefs.NewFTPMounter(fuseftp, iceptWG)
 		default:
 			m = remotefs.NewSFTPMounter(iceptWG, podWG)
 		}
-		ic.Mounter = m
 	}
-	err := m.Start(mountCtx, ic.Id, ic.ClientMountPoint, ic.MountPoint, iputil.Parse(ic.PodIp), uint16(port))
+	err := m.Start(mountCtx, rm.id, rm.clientMountPoint, rm.mountPoint, iputil.Parse(rm.podIP), uint16(port))
 	if err != nil && ctx.Err() == nil {
 		dlog.Error(ctx, err)
 	}
+	return m
 }
@@ -0,0 +1,57 @@
+package trafficmgr
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/datawire/dlib/dlog"
+	rootdRpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/recording"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// startRecording makes the dialers of this process and of the root daemon record the connections to the
+// intercept's target using the intercept's recorder. The root daemon streams its events to this process,
+// so that only this process writes to the recording. The recording stops when the intercept ends.
+func (s *session) startRecording(ctx context.Context, ic *intercept) {
+	spec := ic.Spec
+	ip := iputil.Parse(spec.TargetHost)
+	port := uint16(spec.TargetPort)
+	w := ic.recorder
+	dlog.Infof(ctx, "Recording connections for intercept %s in %s", spec.Name, ic.recordFile)
+	tunnel.SetRecorder(ip, port, w)
+	go func() {
+		s.recordRootEvents(ic.ctx, ip, port, w)
+		<-ic.ctx.Done()
+		tunnel.RemoveRecorder(ip, port, w)
+		if err := w.Close(); err != nil {
+			dlog.Errorf(ctx, "error when writing recording %s: %v", ic.recordFile, err)
+		}
+	}()
+}
+
+// recordRootEvents writes the events of the connections that the root daemon dials to the given destination
+// until the given context is cancelled or the root daemon's session ends. Events that the root daemon had to
+// drop are recorded as a Dropped event, so that a replay of the recording knows that it's incomplete.
+func (s *session) recordRootEvents(ctx context.Context, ip net.IP, port uint16, w *recording.Writer) {
+	rs, err := s.rootDaemon.Record(ctx, &rootdRpc.Recording{Ip: ip, Port: int32(port)})
+	if err == nil {
+		var re *rootdRpc.RecordedEvents
+		for {
+			if re, err = rs.Recv(); err != nil {
+				break
+			}
+			if re.Dropped > 0 {
+				dlog.Warnf(ctx, "%d recorded events were dropped by the root daemon", re.Dropped)
+				w.RecordDropped(re.Dropped)
+			}
+			w.WriteEvents(re.Events)
+		}
+	}
+	if err != nil && !errors.Is(err, io.EOF) && ctx.Err() == nil {
+		dlog.Errorf(ctx, "failed to record connections in root daemon: %v", err)
+	}
+}
@@ -49,9 +49,11 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/daemon"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/k8sclient"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/portforward"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/proxy"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/rootd"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/scout"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/socket"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/tunnelstats"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/userd"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/userd/k8s"
 	"github.com/telepresenceio/telepresence/v2/pkg/errcat"
@@ -59,6 +61,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
 	"github.com/telepresenceio/telepresence/v2/pkg/proc"
 	"github.com/telepresenceio/telepresence/v2/pkg/restapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 	"github.com/telepresenceio/telepresence/v2/pkg/workload"
 )
 
@@ -89,6 +92,9 @@ type session struct {
 	rootDaemon         rootdRpc.DaemonClient
 	subnetViaWorkloads []*rootdRpc.SubnetViaWorkload
 
+	// proxyAddress is the address of the SOCKS5 and HTTP CONNECT proxy when the session is proxy-only.
+	proxyAddress string
+
 	// local information
 	installID string // telepresence's install ID
 	clientID  string // "laptop-username@laptop-hostname"
@@ -138,8 +144,17 @@ type session struct {
 	// are deleted as soon as the intercept arrives and gets stored in currentIntercepts
 	interceptWaiters map[string]*awaitIntercept
 
+	// currentIngestsLock ensures that all accesses to currentIngests are synchronized.
+	currentIngestsLock sync.Mutex
+
+	// currentIngests are the ingests of this session.
+	currentIngests map[ingestKey]*ingest
+
 	ingressInfo []*manager.IngressInfo
 
+	// tunnelStats contains the latency and throughput statistics of the connections that this process dials.
+	tunnelStats *tunnelstats.Registry
+
 	isPodDaemon bool
 
 	// done is closed when the session ends
@@ -243,6 +258,7 @@ func NewSession(
 		rt.NeverProxy = append(rt.NeverProxy, tmCfg.Routing().NeverProxy...)
 		ctx = client.WithConfig(ctx, cfg)
 	}
+	ctx = tunnel.WithCompression(ctx, cfg.Grpc().CompressTunnels)
 	if err = tmgr.ApplyConfig(ctx); err != nil {
 		dlog.Warn(ctx, err.Error())
 	}
@@ -257,7 +273,7 @@ func NewSession(
 	}
 
 	oi := tmgr.getNetworkInfo(ctx, cr)
-	if !userd.GetService(ctx).RootSessionInProcess() {
+	if !(cr.ProxyOnly || userd.GetService(ctx).RootSessionInProcess()) {
 		// Connect to the root daemon if it is running. It's the CLI that starts it initially
 		rootRunning, err := socket.IsRunning(ctx, socket.RootDaemonPath(ctx))
 		if err != nil {
@@ -284,7 +300,13 @@ func NewSession(
 		}
 	}
 
-	tmgr.rootDaemon, err = tmgr.connectRootDaemon(ctx, oi, cr.IsPodDaemon)
+	if cr.ProxyOnly {
+		tmgr.proxyAddress = cr.ProxyAddress
+		if tmgr.proxyAddress == "" {
+			tmgr.proxyAddress = proxy.DefaultAddress
+		}
+	}
+	tmgr.rootDaemon, err = tmgr.connectRootDaemon(ctx, oi, cr.IsPodDaemon, tmgr.proxyAddress)
 	if err != nil {
 		tmgr.managerConn.Close()
 		return ctx, nil, connectError(rpc.ConnectInfo_DAEMON_FAILED, err)
@@ -446,6 +468,8 @@ func connectMgr(
 		sessionInfo:        si,
 		workloads:          make(map[string]map[workloadInfoKey]workloadInfo),
 		interceptWaiters:   make(map[string]*awaitIntercept),
+		currentIngests:     make(map[ingestKey]*ingest),
+		tunnelStats:        tunnelstats.NewRegistry(),
 		isPodDaemon:        cr.IsPodDaemon,
 		done:               make(chan struct{}),
 		subnetViaWorkloads: cr.SubnetViaWorkloads,
@@ -535,6 +559,7 @@ func (s *session) StartServices(g *dgroup.Group) {
 	g.Go("remain", s.remainLoop)
 	g.Go("intercept-port-forward", s.watchInterceptsHandler)
 	g.Go("dial-request-watcher", s.dialRequestWatcher)
+	g.Go("tunnel-stats", s.tunnelStatsHandler)
 }
 
 func runWithRetry(ctx context.Context, f func(context.Context) error) error {
@@ -578,6 +603,7 @@ func (s *session) ApplyConfig(ctx context.Context) error {
 func (s *session) getInfosForWorkloads(
 	namespaces []string,
 	iMap map[string][]*manager.InterceptInfo,
+	gMap map[string][]*rpc.IngestInfo,
 	sMap map[string]*rpc.WorkloadInfo_Sidecar,
 	filter rpc.ListRequest_Filter,
 ) []*rpc.WorkloadInfo {
@@ -595,7 +621,8 @@ func (s *session) getInfosForWorkloads(
 		}
 
 		var ok bool
-		if wlInfo.InterceptInfos, ok = iMap[name]; !ok && filter <= rpc.ListRequest_INTERCEPTS {
+		wlInfo.IngestInfos = gMap[name]
+		if wlInfo.InterceptInfos, ok = iMap[name]; !ok && len(wlInfo.IngestInfos) == 0 && filter <= rpc.ListRequest_INTERCEPTS {
 			return
 		}
 		if wlInfo.Sidecar, ok = sMap[name]; !ok && filter <= rpc.ListRequest_INSTALLED_AGENTS {
@@ -726,6 +753,13 @@ nextIs:
 		}
 	}
 
+	gMap := make(map[string][]*rpc.IngestInfo)
+	for _, ig := range s.getCurrentIngestInfos() {
+		if slices.Contains(nss, ig.Namespace) {
+			gMap[ig.Workload] = append(gMap[ig.Workload], ig)
+		}
+	}
+
 	sMap := make(map[string]*rpc.WorkloadInfo_Sidecar)
 	for _, ns := range nss {
 		for k, v := range s.getCurrentSidecarsInNamespace(ctx, ns) {
@@ -737,7 +771,7 @@ nextIs:
 		}
 	}
 
-	workloadInfos := s.getInfosForWorkloads(nss, iMap, sMap, filter)
+	workloadInfos := s.getInfosForWorkloads(nss, iMap, gMap, sMap, filter)
 	return &rpc.WorkloadInfoSnapshot{Workloads: workloadInfos}, nil
 }
 
@@ -842,12 +876,14 @@ func (s *session) status(c context.Context, initial bool) *rpc.ConnectInfo {
 		KubeFlags:        s.OriginalFlagMap,
 		Namespace:        s.Namespace,
 		Intercepts:       &manager.InterceptInfoSnapshot{Intercepts: s.getCurrentInterceptInfos()},
+		Ingests:          s.getCurrentIngestInfos(),
 		ManagerVersion: &manager.VersionInfo2{
 			Name:    s.managerName,
 			Version: "v" + s.managerVersion.String(),
 		},
 		ManagerNamespace:   k8s.GetManagerNamespace(c),
 		SubnetViaWorkloads: s.subnetViaWorkloads,
+		ProxyAddress:       s.proxyAddress,
 		Version: &common.VersionInfo{
 			ApiVersion: client.APIVersion,
 			Version:    client.Version(),
@@ -984,13 +1020,20 @@ func (s *session) getNetworkInfo(ctx context.Context, cr *rpc.ConnectRequest) *r
 	}
 }
 
-func (s *session) connectRootDaemon(ctx context.Context, nc *rootdRpc.NetworkConfig, isPodDaemon bool) (rd rootdRpc.DaemonClient, err error) {
+// connectRootDaemon connects to the root daemon, or runs the root session in-process. The latter is always
+// the case when a proxyAddress is given, because the session then runs without a VIF and needs no privileges.
+func (s *session) connectRootDaemon(
+	ctx context.Context,
+	nc *rootdRpc.NetworkConfig,
+	isPodDaemon bool,
+	proxyAddress string,
+) (rd rootdRpc.DaemonClient, err error) {
 	// establish a connection to the root daemon gRPC grpcService
 	dlog.Info(ctx, "Connecting to root daemon...")
 	svc := userd.GetService(ctx)
-	if svc.RootSessionInProcess() {
+	if proxyAddress != "" || svc.RootSessionInProcess() {
 		// Just run the root session in-process.
-		_, rootSession, err := rootd.NewInProcSession(ctx, nc, s.managerClient, s.managerVersion, isPodDaemon)
+		_, rootSession, err := rootd.NewInProcSession(ctx, nc, s.managerClient, s.managerVersion, isPodDaemon, proxyAddress)
 		if err != nil {
 			return nil, err
 		}
@@ -1077,6 +1120,12 @@ func rpcKind(s string) manager.WorkloadInfo_Kind {
 		return manager.WorkloadInfo_REPLICASET
 	case "statefulset":
 		return manager.WorkloadInfo_STATEFULSET
+	case "daemonset":
+		return manager.WorkloadInfo_DAEMONSET
+	case "job":
+		return manager.WorkloadInfo_JOB
+	case "cronjob":
+		return manager.WorkloadInfo_CRONJOB
 	case "rollout":
 		return manager.WorkloadInfo_ROLLOUT
 	default:
@@ -1124,6 +1173,15 @@ func (s *session) localWorkloadsWatcher(ctx context.Context, namespace string, s
 		case manager.WorkloadInfo_STATEFULSET:
 			enabledWorkloadKinds[i] = workload.StatefulSetWorkloadKind
 			workload.StartStatefulSets(ctx, namespace)
+		case manager.WorkloadInfo_DAEMONSET:
+			enabledWorkloadKinds[i] = workload.DaemonSetWorkloadKind
+			workload.StartDaemonSets(ctx, namespace)
+		case manager.WorkloadInfo_JOB:
+			enabledWorkloadKinds[i] = workload.JobWorkloadKind
+			workload.StartJobs(ctx, namespace)
+		case manager.WorkloadInfo_CRONJOB:
+			enabledWorkloadKinds[i] = workload.CronJobWorkloadKind
+			workload.StartCronJobs(ctx, namespace)
 		case manager.WorkloadInfo_ROLLOUT:
 			enabledWorkloadKinds[i] = workload.RolloutWorkloadKind
 			workload.StartRollouts(ctx, namespace)
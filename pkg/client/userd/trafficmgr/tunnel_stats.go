@@ -0,0 +1,52 @@
+package trafficmgr
+
+import (
+	"context"
+
+	empty "google.golang.org/protobuf/types/known/emptypb"
+
+	rootdRpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/tunnelstats"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// TunnelStats returns the tunnel statistics of this daemon, followed by those of the root daemon.
+func (s *session) TunnelStats(ctx context.Context) (*rootdRpc.TunnelStats, error) {
+	ts, err := s.rootDaemon.GetTunnelStats(ctx, &empty.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	ts.Destinations = append(s.tunnelStats.Snapshot("user"), ts.Destinations...)
+	return ts, nil
+}
+
+// tunnelStatsHandler records the statistics of the connections that the dialers of this process establish,
+// and serves the tunnel statistics as Prometheus metrics when a metrics.prometheusPort is configured.
+func (s *session) tunnelStatsHandler(ctx context.Context) error {
+	r := s.tunnelStats.Recorder(s.interceptDestination)
+	tunnel.AddTap(r)
+	defer tunnel.RemoveTap(r)
+
+	port := client.GetConfig(ctx).Metrics().PrometheusPort
+	if port == 0 {
+		<-ctx.Done()
+		return nil
+	}
+	return tunnelstats.ServePrometheus(ctx, port, s.TunnelStats)
+}
+
+// interceptDestination returns the intercept that targets the destination of the given connection, or
+// the destination address when no such intercept exists.
+func (s *session) interceptDestination(id tunnel.ConnID) string {
+	s.currentInterceptsLock.Lock()
+	defer s.currentInterceptsLock.Unlock()
+	for _, ic := range s.currentIntercepts {
+		spec := ic.Spec
+		if uint16(spec.TargetPort) == id.DestinationPort() && iputil.Parse(spec.TargetHost).Equal(id.Destination()) {
+			return "intercept " + spec.Name
+		}
+	}
+	return iputil.JoinIpPort(id.Destination(), id.DestinationPort())
+}
@@ -0,0 +1,315 @@
+package forwarder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+// httpMethods are the methods that are recognized when sniffing the first bytes of a connection. The
+// "PRI" method is the start of the HTTP/2 connection preface.
+var httpMethods = [][]byte{ //nolint:gochecknoglobals // constant
+	[]byte("GET "),
+	[]byte("HEAD "),
+	[]byte("POST "),
+	[]byte("PUT "),
+	[]byte("DELETE "),
+	[]byte("CONNECT "),
+	[]byte("OPTIONS "),
+	[]byte("TRACE "),
+	[]byte("PATCH "),
+	[]byte("PRI "),
+}
+
+// isHTTP returns true if the given reader starts with something that looks like an HTTP/1.x request
+// line or the HTTP/2 connection preface.
+func isHTTP(br *bufio.Reader) bool {
+	// The longest method, including the trailing space, is 8 bytes.
+	start, _ := br.Peek(8)
+	for _, m := range httpMethods {
+		if bytes.HasPrefix(start, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedConn is a connection where the first bytes have been consumed into a bufio.Reader. The
+// struct deliberately doesn't embed the *net.TCPConn, because that would promote its WriteTo and
+// ReadFrom methods, and make io.Copy bypass the reader.
+type bufferedConn struct {
+	net.Conn
+	br        *bufio.Reader
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newBufferedConn(conn net.Conn, br *bufio.Reader) *bufferedConn {
+	return &bufferedConn{Conn: conn, br: br, closed: make(chan struct{})}
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.br.Read(b)
+}
+
+func (c *bufferedConn) CloseWrite() error {
+	if hc, ok := c.Conn.(halfCloser); ok {
+		return hc.CloseWrite()
+	}
+	return nil
+}
+
+func (c *bufferedConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return c.Conn.Close()
+}
+
+// connListener is a net.Listener that returns one single connection and then blocks until
+// that connection is closed.
+type connListener struct {
+	conn *bufferedConn
+	once sync.Once
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	var conn net.Conn
+	l.once.Do(func() { conn = l.conn })
+	if conn != nil {
+		return conn, nil
+	}
+	<-l.conn.closed
+	return nil, net.ErrClosed
+}
+
+func (l *connListener) Close() error {
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
+
+// protoTransport is an http.RoundTripper that uses HTTP/2 without TLS for requests that
+// arrived using HTTP/2, and HTTP/1.1 for all others.
+type protoTransport struct {
+	h1 *http.Transport
+	h2 *http2.Transport
+}
+
+func newProtoTransport(dial func(ctx context.Context, network, addr string) (net.Conn, error)) *protoTransport {
+	return &protoTransport{
+		h1: &http.Transport{
+			DialContext:        dial,
+			DisableCompression: true,
+
+			// Connections to the client are identified by the source address of the intercepted connection,
+			// so there must never be more than one at a time.
+			MaxConnsPerHost: 1,
+		},
+		h2: &http2.Transport{
+			AllowHTTP:          true,
+			DisableCompression: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dial(ctx, network, addr)
+			},
+		},
+	}
+}
+
+func (t *protoTransport) RoundTrip(rq *http.Request) (*http.Response, error) {
+	if rq.ProtoMajor == 2 {
+		return t.h2.RoundTrip(rq)
+	}
+	return t.h1.RoundTrip(rq)
+}
+
+func (t *protoTransport) CloseIdleConnections() {
+	t.h1.CloseIdleConnections()
+	t.h2.CloseIdleConnections()
+}
+
+func newReverseProxy(ctx context.Context, t http.RoundTripper, name string) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			// The host doesn't matter, because the transport dials a fixed destination.
+			pr.Out.URL.Scheme = "http"
+			pr.Out.URL.Host = pr.In.Host
+			pr.Out.Host = pr.In.Host
+		},
+		Transport:     t,
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, rq *http.Request, err error) {
+			if !errors.Is(err, context.Canceled) {
+				dlog.Errorf(ctx, "%s %s to %s failed: %v", rq.Method, rq.URL.Path, name, err)
+			}
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+}
+
+// httpInterceptConn serves the given connection using HTTP/1.1 or HTTP/2 without TLS, and dispatches each
+// request to the intercepting client of the intercept with the highest precedence that matches the request,
+// or to the original target when no intercept matches. A copy of a request that is served by the original
+// target is sent to the mirroring client of the mirror with the highest precedence that matches the request.
+// An HTTP/2 connection is demultiplexed into its streams, and each stream is dispatched on its own, so the
+// gRPC calls that share a connection can be served by different clients and by the original target.
+// Connections that don't contain HTTP are forwarded to the original target.
+func (f *tcp) httpInterceptConn(ctx context.Context, conn *net.TCPConn) error {
+	ctx, span := otel.Tracer("").Start(ctx, "httpInterceptConn")
+	defer span.End()
+
+	f.mu.Lock()
+	targetHost := f.targetHost
+	targetPort := f.targetPort
+	mirror := f.globalMirror()
+	f.mu.Unlock()
+
+	bc := newBufferedConn(conn, bufio.NewReader(conn))
+	if !isHTTP(bc.br) {
+		dlog.Debugf(ctx, "Connection from %s is not HTTP. Forwarding it to %s",
+			conn.RemoteAddr(), iputil.JoinHostPort(targetHost, targetPort))
+		if mirror != nil {
+			return f.mirrorConn(ctx, bc, targetHost, targetPort, mirror)
+		}
+		return forwardToTarget(ctx, bc, targetHost, targetPort)
+	}
+
+	addr := conn.RemoteAddr()
+	dlog.Debugf(ctx, "Accept got HTTP connection from %s", addr)
+	defer dlog.Debugf(ctx, "Done serving HTTP connection from %s", addr)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		_ = bc.Close()
+	}()
+
+	targetAddr := iputil.JoinHostPort(targetHost, targetPort)
+	toTarget := newProtoTransport(func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, network, targetAddr)
+	})
+	defer toTarget.CloseIdleConnections()
+	targetProxy := newReverseProxy(ctx, toTarget, targetAddr)
+
+	// One proxy per intercepting client, created on demand.
+	var clientsLock sync.Mutex
+	clients := make(map[string]*clientProxy)
+	defer func() {
+		for _, cp := range clients {
+			cp.transport.CloseIdleConnections()
+		}
+	}()
+
+	// Mirrored requests are sent asynchronously, and must complete before the proxies are closed.
+	var mirrored taskGroup
+	defer mirrored.Wait()
+
+	proxyFor := func(ic *activeIntercept) *clientProxy {
+		clientsLock.Lock()
+		defer clientsLock.Unlock()
+		cp, ok := clients[ic.Id]
+		if !ok {
+			iCept := ic.InterceptInfo
+			cp = &clientProxy{}
+			cp.transport = newProtoTransport(func(context.Context, string, string) (net.Conn, error) {
+				// The endpoint owns one end of the pipe, and the transport gets the other.
+				ours, theirs := net.Pipe()
+				if _, err := f.clientEndpoint(ctx, &pipeConn{Conn: theirs, remote: addr}, iCept); err != nil {
+					_ = ours.Close()
+					return nil, err
+				}
+				return ours, nil
+			})
+			name := "intercepting client "
+			if iCept.Spec.Mirror {
+				name = "mirroring client "
+			}
+			cp.ReverseProxy = newReverseProxy(ctx, cp.transport, name+iCept.Spec.Client)
+			clients[ic.Id] = cp
+		}
+		return cp
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		if ic := f.matchingIntercept(rq.URL.Path, rq.Header); ic != nil {
+			dlog.Tracef(ctx, "%s %s matches intercept %s", rq.Method, rq.URL.Path, ic.Id)
+			proxyFor(ic).ServeHTTP(w, rq)
+			return
+		}
+		mc := f.matchingMirror(rq.URL.Path, rq.Header)
+		if mc == nil || rq.Header.Get("Upgrade") != "" {
+			targetProxy.ServeHTTP(w, rq)
+			return
+		}
+
+		// Serve the request using the original target while keeping a copy of its body, and then
+		// send the copy to the mirroring client.
+		mrq := rq.Clone(ctx)
+		body := &bodyCopy{ReadCloser: rq.Body}
+		rq.Body = body
+		targetProxy.ServeHTTP(w, rq)
+		if body.overflow || !(body.eof || rq.ContentLength == 0) {
+			dlog.Debugf(ctx, "%s %s is not mirrored because its body was not fully read", rq.Method, rq.URL.Path)
+			return
+		}
+		mrq.Body = io.NopCloser(bytes.NewReader(body.buf.Bytes()))
+		mirrored.Go(func() {
+			dlog.Tracef(ctx, "%s %s mirrored to intercept %s", mrq.Method, mrq.URL.Path, mc.Id)
+			proxyFor(mc).ServeHTTP(&discardResponse{}, mrq)
+		})
+	})
+
+	srv := &http.Server{
+		Handler:     h2c.NewHandler(handler, &http2.Server{}),
+		BaseContext: func(net.Listener) context.Context { return ctx },
+		ErrorLog:    dlog.StdLogger(ctx, dlog.LogLevelDebug),
+	}
+	err := srv.Serve(&connListener{conn: bc})
+	if errors.Is(err, net.ErrClosed) || errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+	return err
+}
+
+// clientProxy is a reverse proxy that sends requests to an intercepting client.
+type clientProxy struct {
+	*httputil.ReverseProxy
+	transport *protoTransport
+}
+
+// pipeConn is one end of a net.Pipe that reports the address of the intercepted connection as its
+// remote address, so that the stream to the client is identified by that address.
+type pipeConn struct {
+	net.Conn
+	remote net.Addr
+}
+
+// Read translates the io.ErrClosedPipe returned by a closed net.Pipe into the net.ErrClosed that the
+// tunnel endpoint expects from a closed connection.
+func (c *pipeConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if errors.Is(err, io.ErrClosedPipe) {
+		err = net.ErrClosed
+	}
+	return n, err
+}
+
+func (c *pipeConn) RemoteAddr() net.Addr {
+	return c.remote
+}
@@ -0,0 +1,311 @@
+package forwarder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// connStream is a tunnel.Stream that stands in for the stream to an intercepting client. It writes the
+// payload of the messages that it's sent to a connection, and receives what's read from that connection.
+type connStream struct {
+	id        tunnel.ConnID
+	sessionID string
+	conn      *net.TCPConn
+	buf       []byte
+}
+
+func (s *connStream) Tag() string                     { return "TST" }
+func (s *connStream) ID() tunnel.ConnID               { return s.id }
+func (s *connStream) PeerVersion() uint16             { return 2 }
+func (s *connStream) SessionID() string               { return s.sessionID }
+func (s *connStream) DialTimeout() time.Duration      { return time.Second }
+func (s *connStream) RoundtripLatency() time.Duration { return 0 }
+
+func (s *connStream) Receive(context.Context) (tunnel.Message, error) {
+	n, err := s.conn.Read(s.buf)
+	if n > 0 {
+		return tunnel.NewMessage(tunnel.Normal, s.buf[:n]), nil
+	}
+	return nil, err
+}
+
+func (s *connStream) Send(_ context.Context, m tunnel.Message) error {
+	switch m.Code() {
+	case tunnel.Normal:
+		_, err := s.conn.Write(m.Payload())
+		return err
+	case tunnel.Disconnect:
+		return s.conn.Close()
+	}
+	return nil
+}
+
+func (s *connStream) CloseSend(context.Context) error {
+	return s.conn.CloseWrite()
+}
+
+// streamProvider is a tunnel.ClientStreamProvider that connects the streams of each client session to
+// the address of a local server.
+type streamProvider struct {
+	clients map[string]string
+}
+
+func (p *streamProvider) CreateClientStream(ctx context.Context, clientSessionID string, id tunnel.ConnID, _, _ time.Duration) (tunnel.Stream, error) {
+	addr, ok := p.clients[clientSessionID]
+	if !ok {
+		return nil, fmt.Errorf("unknown client session %s", clientSessionID)
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &connStream{id: id, sessionID: clientSessionID, conn: conn.(*net.TCPConn), buf: make([]byte, 0x10000)}, nil
+}
+
+func (p *streamProvider) ReportMetrics(context.Context, *manager.TunnelMetrics) {}
+
+// lockedBuffer is a bytes.Buffer that can be written to from several goroutines.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(data []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(data)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// testContext returns a context that is cancelled when the test ends. The goroutines of the forwarder may
+// log after the test has completed, so the log is kept in a buffer that is written to the test log when
+// the test fails.
+func testContext(t *testing.T) context.Context {
+	out := &lockedBuffer{}
+	logger := logrus.New()
+	logger.SetLevel(logrus.DebugLevel)
+	logger.SetOutput(out)
+	ctx, cancel := context.WithCancel(dlog.WithLogger(context.Background(), dlog.WrapLogrus(logger)))
+	t.Cleanup(func() {
+		cancel()
+		if t.Failed() {
+			t.Log(out.String())
+		}
+	})
+	return ctx
+}
+
+// newNamedServer starts a server that serves HTTP/1.1 and HTTP/2 without TLS, and responds to each request
+// with its name, the protocol of the request, and the request path.
+func newNamedServer(t *testing.T, name string) *httptest.Server {
+	srv := httptest.NewUnstartedServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		_, _ = io.Copy(io.Discard, rq.Body)
+		_, _ = fmt.Fprintf(w, "%s %s %s", name, rq.Proto, rq.URL.Path)
+	}), &http2.Server{}))
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// startForwarder starts a TCP interceptor that forwards to the given target address, and returns it
+// together with the address that it listens to.
+func startForwarder(ctx context.Context, t *testing.T, target net.Addr, sp tunnel.ClientStreamProvider) (Interceptor, string) {
+	_, port, err := iputil.SplitToIPPort(target)
+	require.NoError(t, err)
+	f := NewInterceptor(&net.TCPAddr{IP: net.IP{127, 0, 0, 1}}, "127.0.0.1", port)
+	f.SetStreamProvider(sp)
+	initCh := make(chan net.Addr)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- f.Serve(ctx, initCh)
+	}()
+	select {
+	case addr := <-initCh:
+		return f, addr.String()
+	case err := <-errCh:
+		require.NoError(t, err)
+		return nil, ""
+	}
+}
+
+// httpIntercept returns an intercept that uses the "http" mechanism with the given arguments, and sends its
+// traffic to the client session with the given id.
+func httpIntercept(id, sessionID string, mirror bool, args ...string) *manager.InterceptInfo {
+	return &manager.InterceptInfo{
+		Id: id,
+		Spec: &manager.InterceptSpec{
+			Name:          id,
+			Client:        sessionID + "@example",
+			Mechanism:     "http",
+			MechanismArgs: args,
+			TargetHost:    "127.0.0.1",
+			TargetPort:    8080,
+			Mirror:        mirror,
+		},
+		ClientSession: &manager.SessionInfo{SessionId: sessionID},
+	}
+}
+
+// countingDialer dials the same address regardless of the address that it's asked to dial, and counts
+// the number of connections that it makes.
+type countingDialer struct {
+	addr  string
+	dials atomic.Int32
+}
+
+func (d *countingDialer) DialContext(ctx context.Context, network, _ string) (net.Conn, error) {
+	d.dials.Add(1)
+	var nd net.Dialer
+	return nd.DialContext(ctx, network, d.addr)
+}
+
+// newHTTPClient returns a client that uses HTTP/2 without TLS or HTTP/1.1, and the dialer that it uses.
+func newHTTPClient(addr string, useH2 bool) (*http.Client, *countingDialer) {
+	d := &countingDialer{addr: addr}
+	var rt http.RoundTripper
+	if useH2 {
+		rt = &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return d.DialContext(ctx, network, addr)
+			},
+		}
+	} else {
+		rt = &http.Transport{DialContext: d.DialContext, MaxConnsPerHost: 1}
+	}
+	return &http.Client{Transport: rt, Timeout: 10 * time.Second}, d
+}
+
+func doRequest(t *testing.T, c *http.Client, method, url string, body io.Reader, header http.Header) (int, string) {
+	rq, err := http.NewRequest(method, url, body)
+	require.NoError(t, err)
+	for k, vs := range header {
+		rq.Header[k] = vs
+	}
+	rs, err := c.Do(rq)
+	require.NoError(t, err)
+	defer rs.Body.Close()
+	data, err := io.ReadAll(rs.Body)
+	require.NoError(t, err)
+	return rs.StatusCode, string(data)
+}
+
+func TestIsHTTP(t *testing.T) {
+	for _, s := range []string{"GET / HTTP/1.1\r\n", "OPTIONS * HTTP/1.1\r\n", http2.ClientPreface} {
+		assert.True(t, isHTTP(bufio.NewReader(strings.NewReader(s))), s)
+	}
+	for _, s := range []string{"", "GE", "SSH-2.0-OpenSSH_9.6\r\n", "get / HTTP/1.1\r\n"} {
+		assert.False(t, isHTTP(bufio.NewReader(strings.NewReader(s))), s)
+	}
+}
+
+func TestHTTPInterceptConn(t *testing.T) {
+	for _, useH2 := range []bool{false, true} {
+		proto := "HTTP/1.1"
+		if useH2 {
+			proto = "HTTP/2.0"
+		}
+		t.Run(proto, func(t *testing.T) {
+			ctx := testContext(t)
+			target := newNamedServer(t, "target")
+			alice := newNamedServer(t, "alice")
+			bob := newNamedServer(t, "bob")
+			sp := &streamProvider{clients: map[string]string{
+				"alice": alice.Listener.Addr().String(),
+				"bob":   bob.Listener.Addr().String(),
+			}}
+			f, addr := startForwarder(ctx, t, target.Listener.Addr(), sp)
+			f.SetIntercepting([]*manager.InterceptInfo{
+				httpIntercept("alice-icept", "alice", false, matcher.HeaderArg+"=x-user=alice"),
+				httpIntercept("bob-icept", "bob", false, matcher.PathPrefixArg+"=/api/"),
+			})
+
+			c, d := newHTTPClient(addr, useH2)
+			tests := []struct {
+				path   string
+				header http.Header
+				want   string
+			}{
+				{"/hello", nil, "target"},
+				{"/hello", http.Header{"X-User": {"alice"}}, "alice"},
+				{"/hello", http.Header{"X-User": {"bob"}}, "target"},
+				{"/api/items", nil, "bob"},
+				{"/api/items", http.Header{"X-User": {"alice"}}, "alice"},
+				{"/apis", nil, "target"},
+				{"/hello", nil, "target"},
+			}
+			for _, tt := range tests {
+				code, body := doRequest(t, c, http.MethodGet, "http://"+addr+tt.path, nil, tt.header)
+				assert.Equal(t, http.StatusOK, code)
+				assert.Equal(t, fmt.Sprintf("%s %s %s", tt.want, proto, tt.path), body, "%s %v", tt.path, tt.header)
+			}
+
+			// Concurrent requests are dispatched on their own too, and they share the connection.
+			wg := sync.WaitGroup{}
+			for i := range 10 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					tt := tests[i%len(tests)]
+					_, body := doRequest(t, c, http.MethodGet, "http://"+addr+tt.path, nil, tt.header)
+					assert.Equal(t, fmt.Sprintf("%s %s %s", tt.want, proto, tt.path), body)
+				}()
+			}
+			wg.Wait()
+			assert.Equal(t, int32(1), d.dials.Load())
+		})
+	}
+}
+
+// TestHTTPInterceptConn_keepAlive verifies that the requests that arrive on one single HTTP/1.1 connection are
+// dispatched one by one.
+func TestHTTPInterceptConn_keepAlive(t *testing.T) {
+	ctx := testContext(t)
+	target := newNamedServer(t, "target")
+	alice := newNamedServer(t, "alice")
+	f, addr := startForwarder(ctx, t, target.Listener.Addr(), &streamProvider{clients: map[string]string{"alice": alice.Listener.Addr().String()}})
+	f.SetIntercepting([]*manager.InterceptInfo{httpIntercept("alice-icept", "alice", false, matcher.HeaderArg+"=x-user=alice")})
+
+	c, d := newHTTPClient(addr, false)
+	for i := range 6 {
+		var header http.Header
+		want := "target"
+		if i%2 == 1 {
+			header = http.Header{"X-User": {"alice"}}
+			want = "alice"
+		}
+		_, body := doRequest(t, c, http.MethodPost, "http://"+addr+"/echo", strings.NewReader("hello"), header)
+		assert.Equal(t, want+" HTTP/1.1 /echo", body)
+	}
+	assert.Equal(t, int32(1), d.dials.Load())
+}
@@ -5,25 +5,37 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/http"
+	"sort"
 	"sync"
 
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
 	"github.com/telepresenceio/telepresence/v2/pkg/restapi"
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 )
 
 type Interceptor interface {
 	io.Closer
-	InterceptId() string
-	InterceptInfo() *restapi.InterceptInfo
+	InterceptIds() []string
+	InterceptInfo(path string, headers http.Header) *restapi.InterceptInfo
 	Serve(context.Context, chan<- net.Addr) error
-	SetIntercepting(*manager.InterceptInfo)
+	SetIntercepting([]*manager.InterceptInfo)
 	SetStreamProvider(tunnel.ClientStreamProvider)
 	Target() (string, uint16)
 }
 
+// activeIntercept is an intercept that is served by an interceptor.
+type activeIntercept struct {
+	*manager.InterceptInfo
+
+	// requestMatcher is non-nil when the intercept uses the "http" mechanism. Only requests
+	// that match are then sent to the intercepting client.
+	requestMatcher matcher.Request
+}
+
 type interceptor struct {
 	mu sync.Mutex
 
@@ -37,7 +49,13 @@ type interceptor struct {
 	targetPort     uint16
 	streamProvider tunnel.ClientStreamProvider
 
-	intercept *manager.InterceptInfo
+	// intercepts are sorted in the order of precedence. There's either one single intercept
+	// without a request matcher, or any number of intercepts that all have a request matcher.
+	intercepts []*activeIntercept
+
+	// mirrors are the intercepts that receive a copy of the traffic that is served by the
+	// original target. They follow the same rules as the intercepts.
+	mirrors []*activeIntercept
 }
 
 func NewInterceptor(addr net.Addr, targetHost string, targetPort uint16) Interceptor {
@@ -69,27 +87,79 @@ func (f *interceptor) Target() (string, uint16) {
 	return f.targetHost, f.targetPort
 }
 
-func (f *interceptor) InterceptInfo() *restapi.InterceptInfo {
+// InterceptInfo returns information about whether a request with the given path and headers is intercepted.
+func (f *interceptor) InterceptInfo(path string, headers http.Header) *restapi.InterceptInfo {
 	ii := &restapi.InterceptInfo{}
-	f.mu.Lock()
-	if f.intercept != nil {
+	if ic := f.matchingIntercept(path, headers); ic != nil {
 		ii.Intercepted = true
-		ii.Metadata = f.intercept.Metadata
+		ii.Metadata = ic.Metadata
 	}
-	f.mu.Unlock()
 	return ii
 }
 
-func (f *interceptor) InterceptId() (id string) {
+// matchingIntercept returns the intercept with the highest precedence that matches the given path and
+// headers, or nil if no intercept matches.
+func (f *interceptor) matchingIntercept(path string, headers http.Header) *activeIntercept {
 	f.mu.Lock()
-	if f.intercept != nil {
-		id = f.intercept.Id
+	defer f.mu.Unlock()
+	return firstMatch(f.intercepts, path, headers)
+}
+
+// matchingMirror returns the mirror with the highest precedence that matches the given path and
+// headers, or nil if no mirror matches.
+func (f *interceptor) matchingMirror(path string, headers http.Header) *activeIntercept {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return firstMatch(f.mirrors, path, headers)
+}
+
+func firstMatch(ics []*activeIntercept, path string, headers http.Header) *activeIntercept {
+	for _, ic := range ics {
+		if ic.requestMatcher == nil || ic.requestMatcher.Matches(path, headers) {
+			return ic
+		}
+	}
+	return nil
+}
+
+// globalIntercept returns the intercept that intercepts all traffic, or nil if no such intercept exists.
+// Assumes that f.mu is locked.
+func (f *interceptor) globalIntercept() *manager.InterceptInfo {
+	if len(f.intercepts) == 1 && f.intercepts[0].requestMatcher == nil {
+		return f.intercepts[0].InterceptInfo
+	}
+	return nil
+}
+
+// globalMirror returns the mirror that receives a copy of all traffic, or nil if no such mirror exists.
+// Assumes that f.mu is locked.
+func (f *interceptor) globalMirror() *manager.InterceptInfo {
+	if len(f.mirrors) == 1 && f.mirrors[0].requestMatcher == nil {
+		return f.mirrors[0].InterceptInfo
+	}
+	return nil
+}
+
+// InterceptIds returns the ids of the active intercepts in the order of precedence, followed by
+// the ids of the active mirrors in the order of precedence.
+func (f *interceptor) InterceptIds() []string {
+	f.mu.Lock()
+	ids := make([]string, 0, len(f.intercepts)+len(f.mirrors))
+	for _, ic := range f.intercepts {
+		ids = append(ids, ic.Id)
+	}
+	for _, ic := range f.mirrors {
+		ids = append(ids, ic.Id)
 	}
 	f.mu.Unlock()
-	return id
+	return ids
 }
 
-func (f *interceptor) SetIntercepting(intercept *manager.InterceptInfo) {
+// SetIntercepting sets the intercepts that this interceptor serves. Only one intercept can be
+// given unless all intercepts use the "http" mechanism, and the same is true for mirroring
+// intercepts. Existing connections are dropped when intercepts that don't use the "http" mechanism
+// are added or removed. Mirrors never affect existing connections.
+func (f *interceptor) SetIntercepting(intercepts []*manager.InterceptInfo) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
@@ -97,28 +167,102 @@ func (f *interceptor) SetIntercepting(intercept *manager.InterceptInfo) {
 		is := ii.Spec
 		return fmt.Sprintf("'%s' (%s)", is.Name, iputil.JoinHostPort(is.Client, uint16(is.TargetPort)))
 	}
-	if intercept == nil {
-		if f.intercept == nil {
-			return
+
+	var ics, mirrors []*activeIntercept
+	for _, ii := range intercepts {
+		ic := &activeIntercept{InterceptInfo: ii}
+		if ii.Spec.Mechanism == "http" {
+			rm, err := matcher.NewRequestFromArgs(ii.Spec.MechanismArgs)
+			if err != nil {
+				// The agent will not accept an intercept with invalid arguments, so this should never happen.
+				dlog.Errorf(f.lCtx, "unable to create request matcher for intercept %s: %v", iceptInfo(ii), err)
+				continue
+			}
+			ic.requestMatcher = rm
 		}
-		dlog.Debugf(f.lCtx, "Forward target changed from intercept %s to %s",
-			iceptInfo(f.intercept), iputil.JoinHostPort(f.targetHost, f.targetPort))
-	} else {
-		if f.intercept == nil {
-			dlog.Debugf(f.lCtx, "Forward target changed from %s to intercept %s",
-				iputil.JoinHostPort(f.targetHost, f.targetPort), iceptInfo(intercept))
+		if ii.Spec.Mirror {
+			mirrors = append(mirrors, ic)
 		} else {
-			if f.intercept.Id == intercept.Id {
-				return
-			}
-			dlog.Debugf(f.lCtx, "Forward target changed from intercept %s to intercept %q", iceptInfo(f.intercept), iceptInfo(intercept))
+			ics = append(ics, ic)
 		}
 	}
+	sortByPrecedence(ics)
+	sortByPrecedence(mirrors)
+
+	if !sameIntercepts(f.mirrors, mirrors) {
+		ids := make([]string, len(mirrors))
+		for i, ic := range mirrors {
+			ids[i] = ic.Id
+		}
+		dlog.Debugf(f.lCtx, "Mirrors changed to %v", ids)
+	}
+	f.mirrors = mirrors
+
+	if sameIntercepts(f.intercepts, ics) {
+		f.intercepts = ics
+		return
+	}
+	target := iputil.JoinHostPort(f.targetHost, f.targetPort)
+	describe := func(ics []*activeIntercept) string {
+		switch len(ics) {
+		case 0:
+			return target
+		case 1:
+			return "intercept " + iceptInfo(ics[0].InterceptInfo)
+		default:
+			return fmt.Sprintf("%d intercepts", len(ics))
+		}
+	}
+	dlog.Debugf(f.lCtx, "Forward target changed from %s to %s", describe(f.intercepts), describe(ics))
+
+	// Connections that are served using HTTP will find the matching intercept for each request, so
+	// they can remain when the set of intercepts changes. All other connections must be dropped.
+	keep := len(f.intercepts) > 0 && f.globalIntercept() == nil && len(ics) > 0 && ics[0].requestMatcher != nil
+	f.intercepts = ics
+	if keep {
+		return
+	}
 
 	// Drop existing connections
 	f.tCancel()
 
 	// Set up new target and lifetime
 	f.tCtx, f.tCancel = context.WithCancel(f.lCtx)
-	f.intercept = intercept
+}
+
+// sameIntercepts returns true if the two slices contain the same intercepts in the same order.
+func sameIntercepts(a, b []*activeIntercept) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, ic := range a {
+		// An intercept that is handed over to another client session retains its id.
+		if ic.Id != b[i].Id || ic.Spec.Priority != b[i].Spec.Priority || ic.ClientSession.GetSessionId() != b[i].ClientSession.GetSessionId() {
+			return false
+		}
+	}
+	return true
+}
+
+// sortByPrecedence sorts the given slice in the order that its intercepts are considered when a
+// request is dispatched. Intercepts with a higher priority come first. Intercepts with equal
+// priority are ordered by the number of conditions in their HTTP filter, so that the most specific
+// filter comes first, and finally by their id.
+func sortByPrecedence(ics []*activeIntercept) {
+	conditions := func(ic *activeIntercept) int {
+		if ic.requestMatcher == nil {
+			return 0
+		}
+		return len(ic.requestMatcher.Map())
+	}
+	sort.Slice(ics, func(i, j int) bool {
+		a, b := ics[i], ics[j]
+		if a.Spec.Priority != b.Spec.Priority {
+			return a.Spec.Priority > b.Spec.Priority
+		}
+		if ca, cb := conditions(a), conditions(b); ca != cb {
+			return ca > cb
+		}
+		return a.Id < b.Id
+	})
 }
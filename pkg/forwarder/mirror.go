@@ -0,0 +1,189 @@
+package forwarder
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+const (
+	// mirrorQueueSize is the number of reads from an intercepted connection that can be queued for a
+	// mirroring client before the mirror is dropped.
+	mirrorQueueSize = 64
+
+	// maxMirroredBody is the maximum size of a request body that is mirrored. Requests with larger bodies
+	// are served by the original target, but they are not mirrored.
+	maxMirroredBody = 4 * 1024 * 1024
+)
+
+// mirror is an io.Writer that sends a copy of everything written to it to a mirroring client. Writes
+// never block. The mirror is dropped if the client can't keep up, so that the original connection is
+// never slowed down.
+type mirror struct {
+	ctx     context.Context
+	mu      sync.Mutex
+	ch      chan []byte
+	stopped bool
+}
+
+// newMirror creates a stream to the mirroring client and returns a mirror that writes to it.
+func (f *tcp) newMirror(ctx context.Context, remote net.Addr, iCept *manager.InterceptInfo) (*mirror, error) {
+	ours, theirs := net.Pipe()
+	if _, err := f.clientEndpoint(ctx, &pipeConn{Conn: theirs, remote: remote}, iCept); err != nil {
+		_ = ours.Close()
+		return nil, err
+	}
+	m := &mirror{ctx: ctx, ch: make(chan []byte, mirrorQueueSize)}
+	go func() {
+		// The client's responses are thrown away.
+		_, _ = io.Copy(io.Discard, ours)
+	}()
+	go func() {
+		defer ours.Close()
+		failed := false
+		for b := range m.ch {
+			if failed {
+				continue
+			}
+			if _, err := ours.Write(b); err != nil {
+				dlog.Debugf(ctx, "Mirror to %s failed: %v", iCept.Spec.Client, err)
+				failed = true
+				m.stop()
+			}
+		}
+	}()
+	return m, nil
+}
+
+func (m *mirror) Write(b []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.stopped {
+		select {
+		case m.ch <- bytes.Clone(b):
+		default:
+			dlog.Debug(m.ctx, "Mirroring client is too slow; dropping mirror")
+			m.stopped = true
+			close(m.ch)
+		}
+	}
+	return len(b), nil
+}
+
+// stop ensures that nothing more is written to the mirror and closes its stream once
+// everything that has been queued is written.
+func (m *mirror) stop() {
+	m.mu.Lock()
+	if !m.stopped {
+		m.stopped = true
+		close(m.ch)
+	}
+	m.mu.Unlock()
+}
+
+// teeConn is a connection that writes everything that is read from it to a writer.
+type teeConn struct {
+	halfCloser
+	w io.Writer
+}
+
+func (c *teeConn) Read(b []byte) (int, error) {
+	n, err := c.halfCloser.Read(b)
+	if n > 0 {
+		_, _ = c.w.Write(b[:n])
+	}
+	return n, err
+}
+
+// mirrorConn forwards the given connection to the given target host and port, and sends a copy of
+// everything that the connection sends to the target to the mirroring client.
+func (f *tcp) mirrorConn(ctx context.Context, conn halfCloser, targetHost string, targetPort uint16, iCept *manager.InterceptInfo) error {
+	m, err := f.newMirror(ctx, conn.RemoteAddr(), iCept)
+	if err != nil {
+		// The original connection must not suffer because the mirror is unavailable.
+		dlog.Errorf(ctx, "Unable to mirror connection from %s: %v", conn.RemoteAddr(), err)
+		return forwardToTarget(ctx, conn, targetHost, targetPort)
+	}
+	defer m.stop()
+	return forwardToTarget(ctx, &teeConn{halfCloser: conn, w: m}, targetHost, targetPort)
+}
+
+// bodyCopy is an io.ReadCloser that keeps a copy of everything that is read from it, up to
+// maxMirroredBody bytes.
+type bodyCopy struct {
+	io.ReadCloser
+	buf      bytes.Buffer
+	eof      bool
+	overflow bool
+}
+
+func (b *bodyCopy) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && !b.overflow {
+		if b.buf.Len()+n > maxMirroredBody {
+			b.overflow = true
+			b.buf.Reset()
+		} else {
+			b.buf.Write(p[:n])
+		}
+	}
+	if err == io.EOF {
+		b.eof = true
+	}
+	return n, err
+}
+
+// discardResponse is an http.ResponseWriter that throws away everything that is written to it.
+type discardResponse struct {
+	header http.Header
+}
+
+func (r *discardResponse) Header() http.Header {
+	if r.header == nil {
+		r.header = make(http.Header)
+	}
+	return r.header
+}
+
+func (r *discardResponse) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (r *discardResponse) WriteHeader(int) {}
+
+func (r *discardResponse) Flush() {}
+
+// taskGroup is a sync.WaitGroup that refuses to start new tasks once Wait has been called.
+type taskGroup struct {
+	mu     sync.Mutex
+	wg     sync.WaitGroup
+	closed bool
+}
+
+// Go runs the given function in a new goroutine unless Wait has been called.
+func (g *taskGroup) Go(fn func()) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return
+	}
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		fn()
+	}()
+}
+
+// Wait waits for all running tasks to complete.
+func (g *taskGroup) Wait() {
+	g.mu.Lock()
+	g.closed = true
+	g.mu.Unlock()
+	g.wg.Wait()
+}
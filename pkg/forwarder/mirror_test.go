@@ -0,0 +1,199 @@
+package forwarder
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
+)
+
+// mirroredRequest is a request that was received by a mirroring client.
+type mirroredRequest struct {
+	path string
+	body []byte
+}
+
+// newMirrorServer starts a server that sends each request that it receives to the returned channel, and
+// responds with an error that must never reach the caller.
+func newMirrorServer(t *testing.T) (*httptest.Server, <-chan mirroredRequest) {
+	ch := make(chan mirroredRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		body, _ := io.ReadAll(rq.Body)
+		ch <- mirroredRequest{path: rq.URL.Path, body: body}
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = io.WriteString(w, "mirror")
+	}))
+	t.Cleanup(srv.Close)
+	return srv, ch
+}
+
+// newSizeServer starts a server that responds with its name, the path, and the size of the request body.
+func newSizeServer(t *testing.T, name string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		n, _ := io.Copy(io.Discard, rq.Body)
+		_, _ = fmt.Fprintf(w, "%s %s %d", name, rq.URL.Path, n)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func nextMirrored(t *testing.T, ch <-chan mirroredRequest) mirroredRequest {
+	select {
+	case mr := <-ch:
+		return mr
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for mirrored request")
+		return mirroredRequest{}
+	}
+}
+
+func TestMirror_http(t *testing.T) {
+	ctx := testContext(t)
+	target := newSizeServer(t, "target")
+	mirrorSrv, mirrored := newMirrorServer(t)
+	f, addr := startForwarder(ctx, t, target.Listener.Addr(), &streamProvider{clients: map[string]string{"alice": mirrorSrv.Listener.Addr().String()}})
+	f.SetIntercepting([]*manager.InterceptInfo{httpIntercept("alice-mirror", "alice", true, matcher.HeaderArg+"=x-user=alice")})
+
+	c, _ := newHTTPClient(addr, false)
+	alice := http.Header{"X-User": {"alice"}}
+
+	// The mirrored request reaches both the target and the mirroring client, but only the target's response
+	// is returned to the caller.
+	code, body := doRequest(t, c, http.MethodPost, "http://"+addr+"/first", strings.NewReader("hello"), alice)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "target /first 5", body)
+	mr := nextMirrored(t, mirrored)
+	assert.Equal(t, "/first", mr.path)
+	assert.Equal(t, []byte("hello"), mr.body)
+
+	// Requests that don't match the filter are not mirrored.
+	code, body = doRequest(t, c, http.MethodPost, "http://"+addr+"/unmatched", strings.NewReader("hello"), nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "target /unmatched 5", body)
+
+	// A request with a body that exceeds the cap is served in full by the target, but not mirrored.
+	large := bytes.Repeat([]byte{'x'}, maxMirroredBody+1)
+	code, body = doRequest(t, c, http.MethodPost, "http://"+addr+"/large", bytes.NewReader(large), alice)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, fmt.Sprintf("target /large %d", len(large)), body)
+
+	// A body of exactly the cap is mirrored.
+	capped := large[:maxMirroredBody]
+	code, body = doRequest(t, c, http.MethodPost, "http://"+addr+"/capped", bytes.NewReader(capped), alice)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, fmt.Sprintf("target /capped %d", len(capped)), body)
+
+	// The mirrored requests arrive in order, so the next one proves that the others were never mirrored.
+	mr = nextMirrored(t, mirrored)
+	assert.Equal(t, "/capped", mr.path)
+	assert.Equal(t, capped, mr.body)
+}
+
+// startEchoServer starts a TCP server that echoes everything that it receives.
+func startEchoServer(t *testing.T) *net.TCPListener {
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IP{127, 0, 0, 1}})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return l
+}
+
+func TestMirror_conn(t *testing.T) {
+	ctx := testContext(t)
+	target := startEchoServer(t)
+
+	// The mirroring client responds with something else than the target, and sends everything that
+	// it receives to the mirrored channel.
+	ml, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IP{127, 0, 0, 1}})
+	require.NoError(t, err)
+	defer ml.Close()
+	mirrored := make(chan []byte, 1)
+	go func() {
+		conn, err := ml.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = conn.Write([]byte("mirror"))
+		data, _ := io.ReadAll(conn)
+		mirrored <- data
+	}()
+
+	f, addr := startForwarder(ctx, t, target.Addr(), &streamProvider{clients: map[string]string{"alice": ml.Addr().String()}})
+	ii := httpIntercept("alice-mirror", "alice", true)
+	ii.Spec.Mechanism = "tcp"
+	ii.Spec.MechanismArgs = nil
+	f.SetIntercepting([]*manager.InterceptInfo{ii})
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, conn.(*net.TCPConn).CloseWrite())
+
+	// Only the target's echo is returned to the caller.
+	data, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	select {
+	case data = <-mirrored:
+		assert.Equal(t, "hello", string(data))
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for mirrored data")
+	}
+}
+
+func TestBodyCopy(t *testing.T) {
+	small := []byte("hello")
+	b := &bodyCopy{ReadCloser: io.NopCloser(bytes.NewReader(small))}
+	data, err := io.ReadAll(b)
+	require.NoError(t, err)
+	assert.Equal(t, small, data)
+	assert.True(t, b.eof)
+	assert.False(t, b.overflow)
+	assert.Equal(t, small, b.buf.Bytes())
+
+	// The copy is dropped when the body exceeds the cap, but the body is still read in full.
+	large := bytes.Repeat([]byte{'x'}, maxMirroredBody+1)
+	b = &bodyCopy{ReadCloser: io.NopCloser(bytes.NewReader(large))}
+	data, err = io.ReadAll(b)
+	require.NoError(t, err)
+	assert.Equal(t, large, data)
+	assert.True(t, b.eof)
+	assert.True(t, b.overflow)
+	assert.Zero(t, b.buf.Len())
+}
+
+func TestDiscardResponse(t *testing.T) {
+	r := &discardResponse{}
+	r.Header().Set("Content-Type", "text/plain")
+	assert.Equal(t, "text/plain", r.Header().Get("Content-Type"))
+	r.WriteHeader(http.StatusTeapot)
+	n, err := r.Write([]byte("mirror"))
+	require.NoError(t, err)
+	assert.Equal(t, 6, n)
+}
@@ -9,6 +9,7 @@ import (
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
@@ -93,15 +94,34 @@ func (f *tcp) listen(ctx context.Context) (*net.TCPListener, error) {
 func (f *tcp) forwardConn(clientConn *net.TCPConn) error {
 	f.mu.Lock()
 	ctx := f.tCtx
-	ctx, span := otel.Tracer("").Start(ctx, "forwardConn")
-	defer span.End()
 	targetHost := f.targetHost
 	targetPort := f.targetPort
-	intercept := f.intercept
+	global := f.globalIntercept()
+	mirror := f.globalMirror()
+	httpRouted := len(f.intercepts) > 0 || len(f.mirrors) > 0 && mirror == nil
 	f.mu.Unlock()
-	if intercept != nil {
-		return f.interceptConn(ctx, clientConn, intercept)
+	switch {
+	case global != nil:
+		return f.interceptConn(ctx, clientConn, global)
+	case httpRouted:
+		return f.httpInterceptConn(ctx, clientConn)
+	case mirror != nil:
+		return f.mirrorConn(ctx, clientConn, targetHost, targetPort, mirror)
+	default:
+		return forwardToTarget(ctx, clientConn, targetHost, targetPort)
 	}
+}
+
+// halfCloser is a net.Conn that can close its write side.
+type halfCloser interface {
+	net.Conn
+	CloseWrite() error
+}
+
+// forwardToTarget forwards the given connection to the given target host and port.
+func forwardToTarget(ctx context.Context, clientConn halfCloser, targetHost string, targetPort uint16) error {
+	ctx, span := otel.Tracer("").Start(ctx, "forwardConn")
+	defer span.End()
 
 	targetAddr, err := net.ResolveTCPAddr("tcp", iputil.JoinHostPort(targetHost, targetPort))
 	if err != nil {
@@ -163,16 +183,29 @@ func (f *tcp) interceptConn(ctx context.Context, conn net.Conn, iCept *manager.I
 	dlog.Debugf(ctx, "Accept got connection from %s", addr)
 	defer dlog.Debugf(ctx, "Done serving connection from %s", addr)
 
+	d, err := f.clientEndpoint(ctx, conn, iCept)
+	if err != nil {
+		return err
+	}
+	<-d.Done()
+	return nil
+}
+
+// clientEndpoint creates a stream to the intercepting client and starts an endpoint that
+// connects that stream to the given connection. The metrics of the stream are reported
+// once the endpoint is done.
+func (f *tcp) clientEndpoint(ctx context.Context, conn net.Conn, iCept *manager.InterceptInfo) (tunnel.Endpoint, error) {
+	addr := conn.RemoteAddr()
 	srcIp, srcPort, err := iputil.SplitToIPPort(addr)
 	if err != nil {
-		return fmt.Errorf("failed to parse intercept source address %s: %w", addr, err)
+		return nil, fmt.Errorf("failed to parse intercept source address %s: %w", addr, err)
 	}
 
 	spec := iCept.Spec
 	destIp := iputil.Parse(spec.TargetHost)
 	clientSession := iCept.ClientSession.SessionId
 	id := tunnel.NewConnID(ipproto.Parse(addr.Network()), srcIp, destIp, srcPort, uint16(spec.TargetPort))
-	id.SpanRecord(span)
+	id.SpanRecord(trace.SpanFromContext(ctx))
 	ctx, cancel := context.WithCancel(ctx)
 	f.mu.Lock()
 	sp := f.streamProvider
@@ -180,7 +213,7 @@ func (f *tcp) interceptConn(ctx context.Context, conn net.Conn, iCept *manager.I
 	s, err := sp.CreateClientStream(ctx, clientSession, id, time.Duration(spec.RoundtripLatency), time.Duration(spec.DialTimeout))
 	if err != nil {
 		cancel()
-		return err
+		return nil, err
 	}
 
 	ingressBytes := tunnel.NewCounterProbe("FromClientBytes")
@@ -190,12 +223,16 @@ func (f *tcp) interceptConn(ctx context.Context, conn net.Conn, iCept *manager.I
 	// where the stream is attached to a connection *to* the client, not *from* the client.
 	d := tunnel.NewConnEndpoint(s, conn, cancel, egressBytes, ingressBytes)
 	d.Start(ctx)
-	<-d.Done()
-
-	sp.ReportMetrics(ctx, &manager.TunnelMetrics{
-		ClientSessionId: clientSession,
-		IngressBytes:    ingressBytes.GetValue(),
-		EgressBytes:     egressBytes.GetValue(),
-	})
-	return nil
+	go func() {
+		<-d.Done()
+		uncompressed, compressed := tunnel.GetCompressionStats(s)
+		sp.ReportMetrics(ctx, &manager.TunnelMetrics{
+			ClientSessionId:   clientSession,
+			IngressBytes:      ingressBytes.GetValue(),
+			EgressBytes:       egressBytes.GetValue(),
+			UncompressedBytes: uncompressed,
+			CompressedBytes:   compressed,
+		})
+	}()
+	return d, nil
 }
@@ -52,7 +52,7 @@ func (f *udp) Serve(ctx context.Context, initCh chan<- net.Addr) error {
 	for first := true; ; first = false {
 		f.mu.Lock()
 		ctx = f.tCtx
-		intercept := f.intercept
+		intercept := f.globalIntercept()
 		f.mu.Unlock()
 		if ctx.Err() != nil {
 			return nil
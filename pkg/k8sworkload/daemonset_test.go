@@ -0,0 +1,44 @@
+package k8sworkload
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWrap_daemonSet(t *testing.T) {
+	ds := &apps.DaemonSet{
+		ObjectMeta: meta.ObjectMeta{Name: "node-agent", Namespace: "default", Generation: 2},
+		Spec: apps.DaemonSetSpec{
+			Selector: &meta.LabelSelector{MatchLabels: map[string]string{"app": "node-agent"}},
+		},
+		Status: apps.DaemonSetStatus{
+			CurrentNumberScheduled: 3,
+			DesiredNumberScheduled: 3,
+			UpdatedNumberScheduled: 2,
+			NumberAvailable:        3,
+			ObservedGeneration:     2,
+		},
+	}
+	wl, err := Wrap(ds)
+	require.NoError(t, err)
+	assert.Equal(t, "DaemonSet", wl.GetKind())
+	assert.Equal(t, 3, wl.Replicas())
+
+	sel, err := wl.Selector()
+	require.NoError(t, err)
+	assert.Equal(t, "app=node-agent", sel.String())
+
+	impl, ok := DaemonSetImpl(wl)
+	require.True(t, ok)
+	assert.Same(t, ds, impl)
+
+	// One pod hasn't been updated yet.
+	assert.False(t, wl.Updated(2))
+	ds.Status.UpdatedNumberScheduled = 3
+	assert.True(t, wl.Updated(2))
+	assert.False(t, wl.Updated(3))
+}
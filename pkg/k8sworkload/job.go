@@ -0,0 +1,214 @@
+package k8sworkload
+
+import (
+	"context"
+	"maps"
+
+	batch "k8s.io/api/batch/v1"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	typedBatch "k8s.io/client-go/kubernetes/typed/batch/v1"
+
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+)
+
+type job struct {
+	*batch.Job
+}
+
+// Job returns the given Job as a k8sapi.Workload.
+func Job(j *batch.Job) k8sapi.Workload {
+	return &job{j}
+}
+
+// JobImpl casts the given Object as a *batch.Job and returns
+// it together with a status flag indicating whether the cast was possible.
+func JobImpl(o k8sapi.Object) (*batch.Job, bool) {
+	if s, ok := o.(*job); ok {
+		return s.Job, true
+	}
+	return nil, false
+}
+
+// GetJob returns the Job with the given name and namespace using a direct API call.
+func GetJob(c context.Context, name, namespace string) (k8sapi.Workload, error) {
+	j, err := jobs(c, namespace).Get(c, name, meta.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &job{j}, nil
+}
+
+func jobs(c context.Context, namespace string) typedBatch.JobInterface {
+	return k8sapi.GetK8sInterface(c).BatchV1().Jobs(namespace)
+}
+
+func (o *job) ki(c context.Context) typedBatch.JobInterface {
+	return jobs(c, o.Namespace)
+}
+
+func (o *job) GetKind() string {
+	return "Job"
+}
+
+func (o *job) Delete(c context.Context) error {
+	// Background propagation ensures that the pods of the Job are deleted too.
+	pp := meta.DeletePropagationBackground
+	return o.ki(c).Delete(c, o.Name, meta.DeleteOptions{PropagationPolicy: &pp})
+}
+
+func (o *job) GetPodTemplate() *core.PodTemplateSpec {
+	return &o.Spec.Template
+}
+
+func (o *job) Patch(c context.Context, pt types.PatchType, data []byte, subresources ...string) error {
+	j, err := o.ki(c).Patch(c, o.Name, pt, data, meta.PatchOptions{}, subresources...)
+	if err == nil {
+		o.Job = j
+	}
+	return err
+}
+
+func (o *job) Refresh(c context.Context) error {
+	j, err := o.ki(c).Get(c, o.Name, meta.GetOptions{})
+	if err == nil {
+		o.Job = j
+	}
+	return err
+}
+
+// Replicas returns the number of pods of the Job that are currently running.
+func (o *job) Replicas() int {
+	return int(o.Status.Active)
+}
+
+func (o *job) Selector() (labels.Selector, error) {
+	return meta.LabelSelectorAsSelector(o.Spec.Selector)
+}
+
+func (o *job) Update(c context.Context) error {
+	j, err := o.ki(c).Update(c, o.Job, meta.UpdateOptions{})
+	if err == nil {
+		o.Job = j
+	}
+	return err
+}
+
+// Updated returns true when the given generation has been applied. A Job has no rollout status, because
+// its pod template is immutable.
+func (o *job) Updated(origGeneration int64) bool {
+	return o.ObjectMeta.Generation >= origGeneration
+}
+
+type cronJob struct {
+	*batch.CronJob
+}
+
+// CronJob returns the given CronJob as a k8sapi.Workload.
+func CronJob(cj *batch.CronJob) k8sapi.Workload {
+	return &cronJob{cj}
+}
+
+// CronJobImpl casts the given Object as a *batch.CronJob and returns
+// it together with a status flag indicating whether the cast was possible.
+func CronJobImpl(o k8sapi.Object) (*batch.CronJob, bool) {
+	if s, ok := o.(*cronJob); ok {
+		return s.CronJob, true
+	}
+	return nil, false
+}
+
+// GetCronJob returns the CronJob with the given name and namespace using a direct API call.
+func GetCronJob(c context.Context, name, namespace string) (k8sapi.Workload, error) {
+	cj, err := cronJobs(c, namespace).Get(c, name, meta.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &cronJob{cj}, nil
+}
+
+func cronJobs(c context.Context, namespace string) typedBatch.CronJobInterface {
+	return k8sapi.GetK8sInterface(c).BatchV1().CronJobs(namespace)
+}
+
+func (o *cronJob) ki(c context.Context) typedBatch.CronJobInterface {
+	return cronJobs(c, o.Namespace)
+}
+
+func (o *cronJob) GetKind() string {
+	return "CronJob"
+}
+
+func (o *cronJob) Delete(c context.Context) error {
+	return o.ki(c).Delete(c, o.Name, meta.DeleteOptions{})
+}
+
+// GetPodTemplate returns the pod template of the CronJob's job template.
+func (o *cronJob) GetPodTemplate() *core.PodTemplateSpec {
+	return &o.Spec.JobTemplate.Spec.Template
+}
+
+func (o *cronJob) Patch(c context.Context, pt types.PatchType, data []byte, subresources ...string) error {
+	cj, err := o.ki(c).Patch(c, o.Name, pt, data, meta.PatchOptions{}, subresources...)
+	if err == nil {
+		o.CronJob = cj
+	}
+	return err
+}
+
+func (o *cronJob) Refresh(c context.Context) error {
+	cj, err := o.ki(c).Get(c, o.Name, meta.GetOptions{})
+	if err == nil {
+		o.CronJob = cj
+	}
+	return err
+}
+
+// Replicas returns the number of Jobs of the CronJob that are currently running.
+func (o *cronJob) Replicas() int {
+	return len(o.Status.Active)
+}
+
+// Selector returns a selector for the labels of the job template's pod template, because a CronJob has no selector.
+func (o *cronJob) Selector() (labels.Selector, error) {
+	return labels.SelectorFromSet(o.Spec.JobTemplate.Spec.Template.Labels), nil
+}
+
+func (o *cronJob) Update(c context.Context) error {
+	cj, err := o.ki(c).Update(c, o.CronJob, meta.UpdateOptions{})
+	if err == nil {
+		o.CronJob = cj
+	}
+	return err
+}
+
+// Updated returns true when the given generation has been applied. Changes to a CronJob
+// only affect the Jobs that it creates after the change.
+func (o *cronJob) Updated(origGeneration int64) bool {
+	return o.ObjectMeta.Generation >= origGeneration
+}
+
+// NewJobFromCronJob returns a Job, created from the given CronJob's job template, that is
+// controlled by the CronJob. This is equivalent to "kubectl create job --from=cronjob/<name>".
+func NewJobFromCronJob(cj *batch.CronJob, name string) *batch.Job {
+	jt := &cj.Spec.JobTemplate
+	anns := make(map[string]string, len(jt.Annotations)+1)
+	maps.Copy(anns, jt.Annotations)
+	anns["cronjob.kubernetes.io/instantiate"] = "manual"
+	return &batch.Job{
+		TypeMeta: meta.TypeMeta{
+			Kind:       "Job",
+			APIVersion: batch.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: meta.ObjectMeta{
+			Name:            name,
+			Namespace:       cj.Namespace,
+			Labels:          maps.Clone(jt.Labels),
+			Annotations:     anns,
+			OwnerReferences: []meta.OwnerReference{*meta.NewControllerRef(cj, batch.SchemeGroupVersion.WithKind("CronJob"))},
+		},
+		Spec: *jt.Spec.DeepCopy(),
+	}
+}
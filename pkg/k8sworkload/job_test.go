@@ -0,0 +1,89 @@
+package k8sworkload
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batch "k8s.io/api/batch/v1"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+func TestWrap_job(t *testing.T) {
+	j := &batch.Job{
+		ObjectMeta: meta.ObjectMeta{Name: "migrate", Namespace: "default", Generation: 1},
+		Spec: batch.JobSpec{
+			Selector: &meta.LabelSelector{MatchLabels: map[string]string{"job-name": "migrate"}},
+		},
+		Status: batch.JobStatus{Active: 2},
+	}
+	wl, err := Wrap(j)
+	require.NoError(t, err)
+	assert.Equal(t, "Job", wl.GetKind())
+	assert.Equal(t, 2, wl.Replicas())
+	assert.True(t, agentconfig.IsBatchKind(wl.GetKind()))
+
+	sel, err := wl.Selector()
+	require.NoError(t, err)
+	assert.Equal(t, "job-name=migrate", sel.String())
+
+	impl, ok := JobImpl(wl)
+	require.True(t, ok)
+	assert.Same(t, j, impl)
+}
+
+func TestWrap_cronJob(t *testing.T) {
+	cj := &batch.CronJob{
+		ObjectMeta: meta.ObjectMeta{Name: "nightly", Namespace: "default", UID: types.UID("cj-uid"), Generation: 3},
+		Spec: batch.CronJobSpec{
+			Schedule: "0 2 * * *",
+			JobTemplate: batch.JobTemplateSpec{
+				ObjectMeta: meta.ObjectMeta{
+					Labels:      map[string]string{"team": "reports"},
+					Annotations: map[string]string{"owner": "reports"},
+				},
+				Spec: batch.JobSpec{
+					Template: core.PodTemplateSpec{
+						ObjectMeta: meta.ObjectMeta{Labels: map[string]string{"app": "nightly"}},
+						Spec: core.PodSpec{
+							Containers:    []core.Container{{Name: "report", Image: "report:1.0"}},
+							RestartPolicy: core.RestartPolicyNever,
+						},
+					},
+				},
+			},
+		},
+		Status: batch.CronJobStatus{Active: []core.ObjectReference{{Name: "nightly-1"}}},
+	}
+	wl, err := Wrap(cj)
+	require.NoError(t, err)
+	assert.Equal(t, "CronJob", wl.GetKind())
+	assert.Equal(t, 1, wl.Replicas())
+	assert.Same(t, &cj.Spec.JobTemplate.Spec.Template, wl.GetPodTemplate())
+	assert.True(t, wl.Updated(3))
+	assert.False(t, wl.Updated(4))
+
+	sel, err := wl.Selector()
+	require.NoError(t, err)
+	assert.Equal(t, "app=nightly", sel.String())
+
+	job := NewJobFromCronJob(cj, "nightly-manual")
+	assert.Equal(t, "nightly-manual", job.Name)
+	assert.Equal(t, "default", job.Namespace)
+	assert.Equal(t, map[string]string{"team": "reports"}, job.Labels)
+	assert.Equal(t, map[string]string{"owner": "reports", "cronjob.kubernetes.io/instantiate": "manual"}, job.Annotations)
+	require.Len(t, job.OwnerReferences, 1)
+	or := job.OwnerReferences[0]
+	assert.Equal(t, "CronJob", or.Kind)
+	assert.Equal(t, cj.UID, or.UID)
+	assert.True(t, *or.Controller)
+
+	// The Job's spec must be a copy of the template's spec.
+	job.Spec.Template.Labels["extra"] = "yes"
+	assert.NotContains(t, cj.Spec.JobTemplate.Spec.Template.Labels, "extra")
+	assert.NotContains(t, cj.Spec.JobTemplate.Annotations, "cronjob.kubernetes.io/instantiate")
+}
@@ -0,0 +1,22 @@
+package k8sworkload
+
+import (
+	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/datawire/k8sapi/pkg/k8sapi"
+)
+
+// Wrap is like k8sapi.WrapWorkload, but also wraps DaemonSets, Jobs, and CronJobs.
+func Wrap(workload runtime.Object) (k8sapi.Workload, error) {
+	switch wl := workload.(type) {
+	case *apps.DaemonSet:
+		return DaemonSet(wl), nil
+	case *batch.Job:
+		return Job(wl), nil
+	case *batch.CronJob:
+		return CronJob(wl), nil
+	}
+	return k8sapi.WrapWorkload(workload)
+}
@@ -0,0 +1,95 @@
+package matcher
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The mechanism args used by intercepts that use the "http" mechanism.
+const (
+	HeaderArg     = "--http-header"
+	PathEqualArg  = "--http-path-equal"
+	PathPrefixArg = "--http-path-prefix"
+	PathRegexArg  = "--http-path-regex"
+	GRPCMethodArg = "--grpc-method"
+)
+
+// NewRequestFromArgs creates a new Request based on the mechanism args of an intercept that uses the
+// "http" mechanism. Each arg must be in the form <flag>=<value>, where flag is one of HeaderArg, PathEqualArg,
+// PathPrefixArg, PathRegexArg, or GRPCMethodArg. The value of a HeaderArg must be in the form <name>=<value>.
+// The GRPCMethodArg can be repeated, but cannot be combined with the path args.
+func NewRequestFromArgs(args []string) (Request, error) {
+	m, err := MapFromArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	return NewRequestFromMap(m)
+}
+
+// MapFromArgs converts mechanism args into the map that is used by NewRequestFromMap.
+func MapFromArgs(args []string) (map[string]string, error) {
+	m := make(map[string]string, len(args))
+	hasPath := false
+	for _, arg := range args {
+		flag, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid http mechanism argument %q, expected <flag>=<value>", arg)
+		}
+		var key string
+		switch flag {
+		case HeaderArg:
+			var hv string
+			if key, hv, ok = strings.Cut(value, "="); !ok || key == "" {
+				return nil, fmt.Errorf("invalid %s %q, expected <name>=<value>", HeaderArg, value)
+			}
+			value = hv
+		case PathEqualArg:
+			key = ":path-equal:"
+		case PathPrefixArg:
+			key = ":path-prefix:"
+		case PathRegexArg:
+			key = ":path-regex:"
+		case GRPCMethodArg:
+			key = ":grpc-method:"
+			if prev, ok := m[key]; ok {
+				m[key] = prev + "," + value
+				continue
+			}
+		default:
+			return nil, fmt.Errorf("unknown http mechanism argument %q", flag)
+		}
+		if key[0] == ':' {
+			if hasPath {
+				return nil, fmt.Errorf("only one of %s, %s, %s, or %s can be used", PathEqualArg, PathPrefixArg, PathRegexArg, GRPCMethodArg)
+			}
+			hasPath = true
+		}
+		m[key] = value
+	}
+	return m, nil
+}
+
+// ArgsFromMap converts a map that is suitable as an argument to NewRequestFromMap into mechanism args. The
+// args are sorted to give a consistent result.
+func ArgsFromMap(m map[string]string) []string {
+	args := make([]string, 0, len(m))
+	for k, v := range m {
+		switch k {
+		case ":path-equal:":
+			args = append(args, PathEqualArg+"="+v)
+		case ":path-prefix:":
+			args = append(args, PathPrefixArg+"="+v)
+		case ":path-regex:":
+			args = append(args, PathRegexArg+"="+v)
+		case ":grpc-method:":
+			for _, gm := range strings.Split(v, ",") {
+				args = append(args, GRPCMethodArg+"="+gm)
+			}
+		default:
+			args = append(args, HeaderArg+"="+k+"="+v)
+		}
+	}
+	sort.Strings(args)
+	return args
+}
@@ -0,0 +1,101 @@
+package matcher
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRequestFromArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "empty",
+			args: nil,
+			want: map[string]string{},
+		},
+		{
+			name: "header",
+			args: []string{"--http-header=x-user=jane"},
+			want: map[string]string{"X-User": "jane"},
+		},
+		{
+			name: "header with equal sign in value",
+			args: []string{"--http-header=x-query=a=b"},
+			want: map[string]string{"X-Query": "a=b"},
+		},
+		{
+			name: "path-prefix and header",
+			args: []string{"--http-path-prefix=/api", "--http-header=x-user=jane"},
+			want: map[string]string{":path-prefix:": "/api", "X-User": "jane"},
+		},
+		{
+			name:    "two paths",
+			args:    []string{"--http-path-prefix=/api", "--http-path-equal=/api/v1"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown flag",
+			args:    []string{"--http-method=GET"},
+			wantErr: true,
+		},
+		{
+			name:    "header without value",
+			args:    []string{"--http-header=x-user"},
+			wantErr: true,
+		},
+		{
+			name: "grpc methods and header",
+			args: []string{"--grpc-method=pkg.Svc/Get", "--grpc-method=pkg.Other", "--http-header=x-user=jane"},
+			want: map[string]string{":grpc-method:": "pkg.Other,pkg.Svc/Get", "X-User": "jane"},
+		},
+		{
+			name:    "grpc method and path",
+			args:    []string{"--grpc-method=pkg.Svc/Get", "--http-path-prefix=/api"},
+			wantErr: true,
+		},
+		{
+			name:    "grpc method with empty method name",
+			args:    []string{"--grpc-method=pkg.Svc/"},
+			wantErr: true,
+		},
+		{
+			name:    "bad regex",
+			args:    []string{"--http-path-regex=/a(b"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewRequestFromArgs(tt.args)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			m := got.Map()
+			if m == nil {
+				m = map[string]string{}
+			}
+			assert.Equal(t, tt.want, m)
+		})
+	}
+}
+
+func TestArgsFromMap(t *testing.T) {
+	m := map[string]string{":path-regex:": "/api/.*", "X-User": "jane"}
+	args := ArgsFromMap(m)
+	assert.Equal(t, []string{"--http-header=X-User=jane", "--http-path-regex=/api/.*"}, args)
+
+	rq, err := NewRequestFromArgs(args)
+	require.NoError(t, err)
+	assert.True(t, rq.Matches("/api/v1", http.Header{"X-User": []string{"jane"}}))
+	assert.False(t, rq.Matches("/api/v1", http.Header{"X-User": []string{"john"}}))
+	assert.False(t, rq.Matches("/health", http.Header{"X-User": []string{"jane"}}))
+}
@@ -34,11 +34,12 @@ type request struct {
 }
 
 // NewRequestFromMap creates a new Request based on the values of the given map. Aside from http headers,
-// the map may contain one of three special keys.
+// the map may contain one of four special keys.
 //
 //	:path-equal: path will match if equal to the value
 //	:path-prefix: path will match prefixed by the value
 //	:path-regex: path will match it matches the regexp value
+//	:grpc-method: path will match a comma separated list of gRPC methods and services
 func NewRequestFromMap(m map[string]string) (Request, error) {
 	var pm Value
 	hm := make(HeaderMap, len(m))
@@ -54,6 +55,10 @@ func NewRequestFromMap(m map[string]string) (Request, error) {
 			if pm, err = NewRegex(v); err != nil {
 				return nil, err
 			}
+		case ":grpc-method:":
+			if pm, err = NewGRPC(strings.Split(v, ",")); err != nil {
+				return nil, err
+			}
 		default:
 			vm, err := NewValue(v)
 			if err != nil {
@@ -88,6 +93,8 @@ func (r *request) Map() map[string]string {
 			pm[":path-prefix:"] = p.String()
 		case rxValue:
 			pm[":path-regex:"] = p.String()
+		case grpcValue:
+			pm[":grpc-method:"] = p.String()
 		}
 		maps.Merge(pm, m)
 		m = pm
@@ -121,7 +128,11 @@ func (r *request) String() string {
 		if r.headers != nil {
 			sb.WriteString("\n ")
 		}
-		fmt.Fprintf(&sb, " path %s %s", r.path.Op(), r.path.String())
+		if g, ok := r.path.(grpcValue); ok {
+			fmt.Fprintf(&sb, " gRPC method %s", g.describe())
+		} else {
+			fmt.Fprintf(&sb, " path %s %s", r.path.Op(), r.path.String())
+		}
 	}
 	if r.headers != nil {
 		indent := "  "
@@ -40,6 +40,11 @@ func TestNewRequest(t *testing.T) {
 			args: map[string]string{":path-regex:": ".*/path", "A": "b"},
 			want: &request{path: rxValue{regexp.MustCompile(".*/path")}, headers: HeaderMap(map[string]Value{"A": NewEqual("b")})},
 		},
+		{
+			name: "grpc-method",
+			args: map[string]string{":grpc-method:": "pkg.Svc/Get,pkg.Other"},
+			want: &request{path: grpcValue{"pkg.Other", "pkg.Svc/Get"}},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -83,6 +88,11 @@ func Test_request_Map(t *testing.T) {
 			request{path: rxValue{regexp.MustCompile(".*/path")}, headers: HeaderMap(map[string]Value{"A": NewEqual("b")})},
 			map[string]string{":path-regex:": ".*/path", "A": "b"},
 		},
+		{
+			"grpc-method",
+			request{path: grpcValue{"pkg.Other", "pkg.Svc/Get"}},
+			map[string]string{":grpc-method:": "pkg.Other,pkg.Svc/Get"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -163,6 +173,30 @@ func Test_request_Matches(t *testing.T) {
 			path:    "/some/road",
 			want:    false,
 		},
+		{
+			name:    "grpc-method",
+			request: request{path: grpcValue{"pkg.Svc/Get"}},
+			path:    "/pkg.Svc/Get",
+			want:    true,
+		},
+		{
+			name:    "grpc-method mismatch",
+			request: request{path: grpcValue{"pkg.Svc/Get"}},
+			path:    "/pkg.Svc/List",
+			want:    false,
+		},
+		{
+			name:    "grpc-service",
+			request: request{path: grpcValue{"pkg.Svc"}},
+			path:    "/pkg.Svc/List",
+			want:    true,
+		},
+		{
+			name:    "grpc-service mismatch",
+			request: request{path: grpcValue{"pkg.Svc"}},
+			path:    "/pkg.SvcV2/List",
+			want:    false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -206,6 +240,11 @@ func Test_request_String(t *testing.T) {
 			request: request{path: rxValue{regexp.MustCompile(".*/path")}, headers: HeaderMap(map[string]Value{"A": NewEqual("b")})},
 			want:    "requests with\n  path =~ .*/path\n  headers\n    'A: b'",
 		},
+		{
+			name:    "grpc-method",
+			request: request{path: grpcValue{"pkg.Other", "pkg.Svc/Get"}},
+			want:    "requests with gRPC method pkg.Other/*, pkg.Svc/Get",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1,20 +1,23 @@
 package matcher
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 )
 
-// Value comes in three flavors. One that performs an exact match against a string, one that
-// uses a regular expression, and one that uses prefix matching.
+// Value comes in four flavors. One that performs an exact match against a string, one that
+// uses a regular expression, one that uses prefix matching, and one that matches the path
+// of gRPC requests.
 type Value interface {
 	fmt.Stringer
 
 	// Matches returns true if the given string matches this Value
 	Matches(value string) bool
 
-	// Op returns either ==, =~, or prefix
+	// Op returns either ==, =~, prefix, or grpc
 	Op() string
 }
 
@@ -87,3 +90,63 @@ func NewPrefix(v string) Value {
 func NewEqual(v string) Value {
 	return textValue(v)
 }
+
+// grpcValue matches the path of a gRPC request, which is /<service>/<method> where the service
+// is fully qualified, against a sorted set of fully qualified methods and services. A method is
+// given as <service>/<method> and matches that method only. A service matches all of its methods.
+type grpcValue []string
+
+// NewGRPC returns a Value that matches the paths of gRPC requests for the given methods and services.
+// An error is returned if a method or service isn't fully qualified.
+func NewGRPC(methods []string) (Value, error) {
+	if len(methods) == 0 {
+		return nil, errors.New("at least one gRPC method or service must be given")
+	}
+	gv := make(grpcValue, 0, len(methods))
+	for _, m := range methods {
+		m = strings.TrimPrefix(m, "/")
+		svc, method, isMethod := strings.Cut(m, "/")
+		if svc == "" || isMethod && (method == "" || strings.Contains(method, "/")) || strings.ContainsAny(m, ", \t") {
+			return nil, fmt.Errorf("invalid gRPC method %q, expected <package>.<service> or <package>.<service>/<method>", m)
+		}
+		if !slices.Contains(gv, m) {
+			gv = append(gv, m)
+		}
+	}
+	slices.Sort(gv)
+	return gv, nil
+}
+
+func (g grpcValue) Matches(path string) bool {
+	svc, method, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !ok || method == "" {
+		return false
+	}
+	for _, m := range g {
+		if ms, mm, isMethod := strings.Cut(m, "/"); ms == svc && (!isMethod || mm == method) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g grpcValue) String() string {
+	return strings.Join(g, ",")
+}
+
+func (g grpcValue) Op() string {
+	return "grpc"
+}
+
+// describe returns a human-readable list of the matched methods, where a service is
+// written as <service>/*.
+func (g grpcValue) describe() string {
+	ms := make([]string, len(g))
+	for i, m := range g {
+		if !strings.Contains(m, "/") {
+			m += "/*"
+		}
+		ms[i] = m
+	}
+	return strings.Join(ms, ", ")
+}
@@ -50,6 +50,24 @@ func DefaultRoute(ctx context.Context) (*Route, error) {
 	return nil, errors.New("unable to find a default route")
 }
 
+// DefaultRouteFor returns the default route for the address family of the given IP.
+func DefaultRouteFor(ctx context.Context, ip netip.Addr) (*Route, error) {
+	rt, err := GetRoutingTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rt {
+		if r.Default && r.RoutedNet.Addr().Is4() == ip.Is4() {
+			return r, nil
+		}
+	}
+	family := "IPv4"
+	if !ip.Is4() {
+		family = "IPv6"
+	}
+	return nil, fmt.Errorf("unable to find a default %s route", family)
+}
+
 type rtError string
 
 func (r rtError) Error() string {
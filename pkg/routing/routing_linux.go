@@ -2,6 +2,7 @@ package routing
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/netip"
@@ -29,7 +30,7 @@ var (
 
 type table struct {
 	index int
-	rule  *netlink.Rule
+	rules []*netlink.Rule
 }
 
 type rtmsg struct {
@@ -208,17 +209,25 @@ func openTable(ctx context.Context) (Table, error) {
 		}
 	}
 	dlog.Infof(ctx, "Creating routing table with index %d and priority %d", index, priority)
-	rule := netlink.NewRule()
-	rule.Table = index
-	rule.Priority = priority
-	rule.Family = netlink.FAMILY_V4
-	if err := netlink.RuleAdd(rule); err != nil {
-		return nil, fmt.Errorf("netlink.RuleAdd: %w", err)
+	t := &table{index: index}
+
+	// A rule only applies to one address family, so one rule is needed for each family. The IPv6 rule is
+	// optional, because IPv6 might be disabled on the host.
+	for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		rule := netlink.NewRule()
+		rule.Table = index
+		rule.Priority = priority
+		rule.Family = family
+		if err := netlink.RuleAdd(rule); err != nil {
+			if family == netlink.FAMILY_V6 {
+				dlog.Infof(ctx, "Unable to add IPv6 rule for routing table %d: %v", index, err)
+				continue
+			}
+			return nil, fmt.Errorf("netlink.RuleAdd: %w", err)
+		}
+		t.rules = append(t.rules, rule)
 	}
-	return &table{
-		index: index,
-		rule:  rule,
-	}, nil
+	return t, nil
 }
 
 func (t *table) routeToNetlink(route *Route) *netlink.Route {
@@ -233,7 +242,13 @@ func (t *table) routeToNetlink(route *Route) *netlink.Route {
 }
 
 func (t *table) Close(ctx context.Context) error {
-	return netlink.RuleDel(t.rule)
+	var errs []error
+	for _, rule := range t.rules {
+		if err := netlink.RuleDel(rule); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
 
 func (t *table) Add(ctx context.Context, r *Route) error {
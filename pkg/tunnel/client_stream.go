@@ -13,13 +13,20 @@ type GRPCClientStream interface {
 }
 
 func NewClientStream(ctx context.Context, grpcStream GRPCClientStream, id ConnID, sessionID string, callDelay, dialTimeout time.Duration) (Stream, error) {
+	return newClientStream(ctx, grpcStream, id, sessionID, callDelay, dialTimeout, 0)
+}
+
+func newClientStream(ctx context.Context, grpcStream GRPCClientStream, id ConnID, sessionID string, callDelay, dialTimeout time.Duration, flags uint64) (*clientStream, error) {
 	s := &clientStream{stream: newStream("CLI", grpcStream)}
 	s.id = id
 	s.roundtripLatency = callDelay
 	s.dialTimeout = dialTimeout
 	s.sessionID = sessionID
+	if enabled, _ := compressionSetting(ctx); enabled {
+		flags |= flagCompress
+	}
 
-	if err := s.Send(ctx, StreamInfoMessage(id, sessionID, callDelay, dialTimeout)); err != nil {
+	if err := s.Send(ctx, streamInfoMessage(id, sessionID, callDelay, dialTimeout, flags)); err != nil {
 		_ = s.CloseSend(ctx)
 		return nil, err
 	}
@@ -33,6 +40,8 @@ func NewClientStream(ctx context.Context, grpcStream GRPCClientStream, id ConnID
 		return nil, errors.New("initial message was not StreamOK")
 	}
 	s.peerVersion = getVersion(m)
+	s.flags = getStreamOKFlags(m)
+	s.comp.enabled = s.flags&flagCompress != 0
 	return s, nil
 }
 
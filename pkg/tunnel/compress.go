@@ -0,0 +1,117 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression of a stream is negotiated using flagCompress. A client that wants its stream to be compressed
+// sets the flag in its StreamInfo message, and a server that agrees sets the flag in its StreamOK message.
+// Both peers then compress the payload of Normal messages that they send, using zstd, unless the message is
+// small or the payload doesn't compress well. A compressed payload is sent as a compressedNormal message,
+// and the receiver hands it over as a Normal message.
+
+// compressMinSize is the smallest payload that is considered for compression. Smaller payloads gain too
+// little from compression to be worth the effort.
+const compressMinSize = 512
+
+// incompressibleBackoff is the number of Normal messages that are sent without an attempt to compress
+// them after a payload that didn't compress well. Streams that carry already compressed or encrypted
+// data would otherwise waste CPU on every message.
+const incompressibleBackoff = 16
+
+var zstdCodec = sync.OnceValues(func() (*zstd.Encoder, *zstd.Decoder) { //nolint:gochecknoglobals // lazy singleton
+	enc, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+	dec, _ := zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(64*1024*1024))
+	return enc, dec
+})
+
+type compressionKey struct{}
+
+// WithCompression returns a context that controls the compression of the streams that are created or served
+// using it. A client stream asks its peer for compression only when compression is enabled, and a server
+// stream agrees to compress unless compression is disabled.
+func WithCompression(ctx context.Context, enabled bool) context.Context {
+	return context.WithValue(ctx, compressionKey{}, enabled)
+}
+
+// compressionSetting returns the compression setting of the given context, and false if it has none.
+func compressionSetting(ctx context.Context) (enabled, ok bool) {
+	enabled, ok = ctx.Value(compressionKey{}).(bool)
+	return enabled, ok
+}
+
+// compressor compresses the Normal messages of a stream, and counts the payload bytes of the compressed
+// messages that are sent and received. A nil compressor never compresses, but it can decompress.
+type compressor struct {
+	enabled      bool
+	uncompressed atomic.Uint64
+	compressed   atomic.Uint64
+	skip         atomic.Int32
+}
+
+// compress returns the given message in compressed form, or the message itself if it shouldn't be compressed.
+func (c *compressor) compress(m Message) Message {
+	if c == nil || !c.enabled || m.Code() != Normal {
+		return m
+	}
+	pl := m.Payload()
+	if len(pl) < compressMinSize {
+		return m
+	}
+	if c.skip.Load() > 0 {
+		c.skip.Add(-1)
+		return m
+	}
+	enc, _ := zstdCodec()
+	cm := enc.EncodeAll(pl, makeMessage(compressedNormal, 0))
+
+	// Compression must save at least 1/8 of the payload to be worth the decompression at the other end.
+	if len(cm)-1 > len(pl)-len(pl)/8 {
+		c.skip.Store(incompressibleBackoff)
+		return m
+	}
+	c.uncompressed.Add(uint64(len(pl)))
+	c.compressed.Add(uint64(len(cm) - 1))
+	return msg(cm)
+}
+
+// decompress returns the Normal message of the given compressedNormal message.
+func (c *compressor) decompress(m Message) (Message, error) {
+	_, dec := zstdCodec()
+	pl := m.Payload()
+	dm, err := dec.DecodeAll(pl, makeMessage(Normal, 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress message: %w", err)
+	}
+	if c != nil {
+		c.uncompressed.Add(uint64(len(dm) - 1))
+		c.compressed.Add(uint64(len(pl)))
+	}
+	return msg(dm), nil
+}
+
+func (c *compressor) stats() (uncompressed, compressed uint64) {
+	if c == nil {
+		return 0, 0
+	}
+	return c.uncompressed.Load(), c.compressed.Load()
+}
+
+type compressionStatser interface {
+	compressionStats() (uncompressed, compressed uint64)
+}
+
+// GetCompressionStats returns the number of payload bytes of the compressed messages that the given stream
+// has sent and received, before compression and after compression. Both numbers are zero for a stream that
+// doesn't use compression.
+func GetCompressionStats(s Stream) (uncompressed, compressed uint64) {
+	if cs, ok := s.(compressionStatser); ok {
+		return cs.compressionStats()
+	}
+	return 0, 0
+}
@@ -0,0 +1,155 @@
+package tunnel
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+// chattyJSON returns a payload that compresses well.
+func chattyJSON() []byte {
+	sb := strings.Builder{}
+	sb.WriteByte('[')
+	for i := 0; i < 100; i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, `{"id":%d,"name":"item-%d","status":"active","tags":["alpha","beta","gamma"]}`, i, i)
+	}
+	sb.WriteByte(']')
+	return []byte(sb.String())
+}
+
+func TestStream_compression(t *testing.T) {
+	random := make([]byte, 0x1000)
+	_, _ = rand.Read(random)
+
+	tests := []struct {
+		name           string
+		clientSetting  *bool
+		serverSetting  *bool
+		payload        []byte
+		wantCompressed bool
+	}{
+		{
+			name:           "negotiated",
+			clientSetting:  ptr(true),
+			payload:        chattyJSON(),
+			wantCompressed: true,
+		},
+		{
+			name:    "not requested",
+			payload: chattyJSON(),
+		},
+		{
+			name:          "refused by server",
+			clientSetting: ptr(true),
+			serverSetting: ptr(false),
+			payload:       chattyJSON(),
+		},
+		{
+			name:          "small messages",
+			clientSetting: ptr(true),
+			payload:       []byte(`{"id":1}`),
+		},
+		{
+			name:          "incompressible",
+			clientSetting: ptr(true),
+			payload:       random,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := testContext(t, 30*time.Second)
+			defer cancel()
+			clientCtx, serverCtx := ctx, ctx
+			if tt.clientSetting != nil {
+				clientCtx = WithCompression(ctx, *tt.clientSetting)
+			}
+			if tt.serverSetting != nil {
+				serverCtx = WithCompression(ctx, *tt.serverSetting)
+			}
+
+			id := NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("192.168.0.1"), 1001, 8080)
+			tunnel := newBidi(10, ctx.Done())
+			errs := make(chan error, 10)
+			var client, server Stream
+			wg := sync.WaitGroup{}
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				var err error
+				if client, err = NewClientStream(clientCtx, tunnel.clientSide(), id, uuid.New().String(), 0, 0); err != nil {
+					errs <- err
+				} else {
+					produce(ctx, client, NewMessage(Normal, tt.payload), errs)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				var err error
+				if server, err = NewServerStream(serverCtx, tunnel.serverSide()); err != nil {
+					errs <- err
+				} else {
+					consume(ctx, server, tt.payload, errs)
+				}
+			}()
+			wg.Wait()
+			requireNoErrs(t, errs)
+
+			cu, cc := GetCompressionStats(client)
+			su, sc := GetCompressionStats(server)
+			if tt.wantCompressed {
+				assert.Equal(t, uint64(100*len(tt.payload)), cu)
+				assert.Less(t, cc, cu/4)
+				assert.Equal(t, cu, su)
+				assert.Equal(t, cc, sc)
+			} else {
+				assert.Zero(t, cu)
+				assert.Zero(t, su)
+			}
+		})
+	}
+}
+
+func TestMux_compression(t *testing.T) {
+	ctx, cancel := testContext(t, 30*time.Second)
+	defer cancel()
+
+	payload := chattyJSON()
+	errs := make(chan error, 10)
+	tunnel := newBidi(10, ctx.Done())
+	go func() {
+		_ = ServeStreams(ctx, tunnel.serverSide(), func(ctx context.Context, s Stream) error {
+			consume(ctx, s, payload, errs)
+			return nil
+		})
+	}()
+
+	id := NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("192.168.0.1"), 1001, 8080)
+	client, err := NewMuxPool(ctx).CreateStream(WithCompression(ctx, true), "peer", id, "session", 0, 0, func(context.Context) (GRPCClientStream, error) {
+		return tunnel.clientSide(), nil
+	})
+	require.NoError(t, err)
+	produce(ctx, client, NewMessage(Normal, payload), errs)
+	requireNoErrs(t, errs)
+
+	u, c := GetCompressionStats(client)
+	assert.Equal(t, uint64(100*len(payload)), u)
+	assert.Less(t, c, u/4)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
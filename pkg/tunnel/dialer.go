@@ -51,6 +51,7 @@ type dialer struct {
 	conn      net.Conn
 	connected int32
 	done      chan struct{}
+	recorder  Recorder
 
 	ingressBytesProbe *CounterProbe
 	egressBytesProbe  *CounterProbe
@@ -77,8 +78,11 @@ func NewDialerTTL(stream Stream, cancel context.CancelFunc, ttl time.Duration, i
 
 func NewConnEndpoint(stream Stream, conn net.Conn, cancel context.CancelFunc, ingressBytesProbe, egressBytesProbe *CounterProbe) Endpoint {
 	ttl := tcpConnTTL
-	if stream.ID().Protocol() == ipproto.UDP {
+	switch id := stream.ID(); {
+	case id.Protocol() == ipproto.UDP:
 		ttl = udpConnTTL
+	case id.IsICMP():
+		ttl = icmpConnTTL
 	}
 	return NewConnEndpointTTL(stream, conn, cancel, ttl, ingressBytesProbe, egressBytesProbe)
 }
@@ -122,8 +126,14 @@ func (h *dialer) Start(ctx context.Context) {
 			h.connected = connecting
 
 			dlog.Tracef(ctx, "   CONN %s, dialing", id)
-			d := net.Dialer{Timeout: h.stream.DialTimeout()}
-			conn, err := d.DialContext(ctx, id.DestinationProtocolString(), id.DestinationAddr().String())
+			var conn net.Conn
+			var err error
+			if id.IsICMP() {
+				conn, err = dialICMP(id)
+			} else {
+				d := net.Dialer{Timeout: h.stream.DialTimeout()}
+				conn, err = d.DialContext(ctx, id.DestinationProtocolString(), id.DestinationAddr().String())
+			}
 			if err != nil {
 				dlog.Errorf(ctx, "!! CONN %s, failed to establish connection: %v", id, err)
 				span.SetStatus(codes.Error, err.Error())
@@ -144,6 +154,10 @@ func (h *dialer) Start(ctx context.Context) {
 			}
 			dlog.Tracef(ctx, "   CONN %s, dial answered", id)
 			h.conn = conn
+			if h.recorder = recorderFor(id); h.recorder != nil {
+				h.recorder.RecordOpen(id)
+				defer h.recorder.RecordClose(id)
+			}
 
 		case connecting:
 		default:
@@ -213,6 +227,9 @@ func (h *dialer) connToStreamLoop(ctx context.Context, wg *sync.WaitGroup) {
 		n, err := h.conn.Read(buf)
 		if n > 0 {
 			dlog.Tracef(ctx, "<- CONN %s, len %d", id, n)
+			if h.recorder != nil {
+				h.recorder.RecordConnData(id, buf[:n])
+			}
 			select {
 			case <-ctx.Done():
 				endReason = ctx.Err().Error()
@@ -247,7 +264,11 @@ func (h *dialer) getStream() Stream {
 }
 
 func (h *dialer) reply(data []byte) (int, error) {
-	return h.conn.Write(data)
+	n, err := h.conn.Write(data)
+	if n > 0 && h.recorder != nil {
+		h.recorder.RecordPeerData(h.stream.ID(), data[:n])
+	}
+	return n, err
 }
 
 func (h *dialer) streamToConnLoop(ctx context.Context, wg *sync.WaitGroup) {
@@ -0,0 +1,100 @@
+package tunnel
+
+import (
+	"encoding/binary"
+	"net"
+	"time"
+
+	"golang.org/x/net/icmp"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+)
+
+// icmpConnTTL is how long an ICMP echo "connection" remains without any requests or replies.
+const icmpConnTTL = 30 * time.Second
+
+// ICMP echo message types.
+const (
+	icmpv4EchoReply = 0
+	icmpv6EchoReply = 129
+)
+
+// IsICMP returns true if the protocol of the given ConnID is ICMP or ICMPv6. The source port of
+// such a ConnID is the identifier of the ICMP echo requests, and the destination port is zero.
+func (id ConnID) IsICMP() bool {
+	p := id.Protocol()
+	return p == ipproto.ICMP || p == ipproto.ICMPV6
+}
+
+// icmpConn is a net.Conn that sends the ICMP echo requests that are written to it to the destination of
+// a ConnID, and returns the echo replies from that destination when read. Each Write and Read transfers
+// one complete ICMP message.
+type icmpConn struct {
+	*icmp.PacketConn
+	dst        net.Addr
+	ident      uint16
+	replyType  byte
+	privileged bool
+}
+
+// dialICMP creates an icmpConn for the given ConnID. An unprivileged ICMP socket is used when the
+// system permits it, and a raw socket otherwise.
+func dialICMP(id ConnID) (net.Conn, error) {
+	network, rawNetwork, address, replyType := "udp4", "ip4:icmp", "0.0.0.0", byte(icmpv4EchoReply)
+	if !id.IsDestinationIPv4() {
+		network, rawNetwork, address, replyType = "udp6", "ip6:ipv6-icmp", "::", icmpv6EchoReply
+	}
+	c := &icmpConn{ident: id.SourcePort(), replyType: replyType}
+	var err error
+	if c.PacketConn, err = icmp.ListenPacket(network, address); err == nil {
+		c.dst = &net.UDPAddr{IP: id.Destination()}
+		return c, nil
+	}
+	if c.PacketConn, err = icmp.ListenPacket(rawNetwork, address); err != nil {
+		return nil, err
+	}
+	c.dst = &net.IPAddr{IP: id.Destination()}
+	c.privileged = true
+	return c, nil
+}
+
+func (c *icmpConn) Read(b []byte) (int, error) {
+	for {
+		n, from, err := c.ReadFrom(b)
+		if err != nil {
+			return 0, err
+		}
+		if n < 8 || b[0] != c.replyType || !sameIP(from, c.dst) {
+			continue
+		}
+		// A raw socket receives the replies of all echo requests. An unprivileged socket only receives its
+		// own, but the kernel replaces their identifier with the port of the socket.
+		if c.privileged && binary.BigEndian.Uint16(b[4:]) != c.ident {
+			continue
+		}
+		binary.BigEndian.PutUint16(b[4:], c.ident)
+		return n, nil
+	}
+}
+
+func (c *icmpConn) Write(b []byte) (int, error) {
+	return c.WriteTo(b, c.dst)
+}
+
+func (c *icmpConn) RemoteAddr() net.Addr {
+	return c.dst
+}
+
+func sameIP(a, b net.Addr) bool {
+	ip := func(a net.Addr) net.IP {
+		switch a := a.(type) {
+		case *net.UDPAddr:
+			return a.IP
+		case *net.IPAddr:
+			return a.IP
+		default:
+			return nil
+		}
+	}
+	return ip(a).Equal(ip(b))
+}
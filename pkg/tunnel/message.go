@@ -57,6 +57,10 @@ const (
 
 	KeepAlive
 	Session
+
+	// compressedNormal carries the zstd compressed payload of a Normal message. It's only sent on streams
+	// where compression has been negotiated, and it's never returned from a Stream's Receive.
+	compressedNormal
 )
 
 func (c MessageCode) String() string {
@@ -77,6 +81,8 @@ func (c MessageCode) String() string {
 		return "KEEP_ALIVE"
 	case Session:
 		return "SESSION"
+	case compressedNormal:
+		return "COMPRESSED"
 	default:
 		return fmt.Sprintf("** unknown control code: %d **", c)
 	}
@@ -119,7 +125,31 @@ func NewMessage(code MessageCode, payload []byte) Message {
 	return msg{byte(code)}
 }
 
+// Flags that are appended to a StreamInfo message. Peers that don't know about the flags ignore them.
+const (
+	// flagMux asks the peer to multiplex many connections over the stream.
+	flagMux = uint64(1 << iota)
+
+	// flagCompress asks the peer to compress the stream. The server appends it to its StreamOK message
+	// when it agrees.
+	flagCompress
+
+	// flagResumable asks the peer to keep the connections of a multiplexed stream when the gRPC stream is
+	// lost, so that they can be resumed on a new gRPC stream. The server appends it to its StreamOK message
+	// when it agrees.
+	flagResumable
+
+	// flagReattach tells the peer that the gRPC stream resumes the multiplexed stream that was created for
+	// the connection and session of the StreamInfo message. The server appends it to its StreamOK message
+	// when it found that stream.
+	flagReattach
+)
+
 func StreamInfoMessage(id ConnID, sessionID string, callDelay, dialTimeout time.Duration) Message {
+	return streamInfoMessage(id, sessionID, callDelay, dialTimeout, 0)
+}
+
+func streamInfoMessage(id ConnID, sessionID string, callDelay, dialTimeout time.Duration, flags uint64) Message {
 	b := bytes.Buffer{}
 	b.WriteByte(byte(streamInfo))
 
@@ -142,12 +172,25 @@ func StreamInfoMessage(id ConnID, sessionID string, callDelay, dialTimeout time.
 	n = binary.PutUvarint(buf, uint64(len(sb)))
 	b.Write(buf[:n])
 	b.Write(sb)
+
+	if flags != 0 {
+		buf = make([]byte, binary.MaxVarintLen64)
+		n = binary.PutUvarint(buf, flags)
+		b.Write(buf[:n])
+	}
 	return msg(b.Bytes())
 }
 
 func StreamOKMessage() Message {
-	m := makeMessage(streamOK, 4)
+	return streamOKMessage(0)
+}
+
+func streamOKMessage(flags uint64) Message {
+	m := makeMessage(streamOK, 2*binary.MaxVarintLen64)
 	n := binary.PutUvarint(m.Payload(), uint64(Version))
+	if flags != 0 {
+		n += binary.PutUvarint(m.Payload()[n:], flags)
+	}
 	return m[:n+1]
 }
 
@@ -171,6 +214,17 @@ func getVersion(m Message) uint16 {
 	return uint16(v)
 }
 
+// getStreamOKFlags returns the flags of a StreamOK message. Older peers don't send flags.
+func getStreamOKFlags(m Message) uint64 {
+	pl := m.Payload()
+	if _, n := binary.Uvarint(pl); n > 0 && n < len(pl) {
+		if flags, fn := binary.Uvarint(pl[n:]); fn > 0 {
+			return flags
+		}
+	}
+	return 0
+}
+
 var errMalformedConnect = errors.New("malformed Connect message")
 
 // connectInfo returns the connectInfo that this Message represents.
@@ -212,5 +266,14 @@ func setConnectInfo(m Message, s *stream) error {
 	}
 	pl = pl[n:]
 	s.sessionID = string(pl[:v])
+	pl = pl[v:]
+
+	// The flags are optional, because older peers don't send them.
+	if len(pl) > 0 {
+		if v, n = binary.Uvarint(pl); n <= 0 {
+			return errMalformedConnect
+		}
+		s.flags = v
+	}
 	return nil
 }
@@ -27,6 +27,9 @@ type ClientStreamProvider interface {
 type TrafficManagerStreamProvider struct {
 	Manager        manager.ManagerClient
 	AgentSessionID string
+
+	// Muxes, when set, is used to multiplex the streams over one tunnel to the traffic-manager.
+	Muxes *MuxPool
 }
 
 func (sp *TrafficManagerStreamProvider) CreateClientStream(
@@ -37,12 +40,24 @@ func (sp *TrafficManagerStreamProvider) CreateClientStream(
 	dialTimeout time.Duration,
 ) (Stream, error) {
 	dlog.Debugf(ctx, "creating tunnel to manager for id %s", id)
-	ms, err := sp.Manager.Tunnel(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("call to manager.Tunnel() failed. Id %s: %v", id, err)
+	open := func(ctx context.Context) (GRPCClientStream, error) {
+		ms, err := sp.Manager.Tunnel(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("call to manager.Tunnel() failed. Id %s: %v", id, err)
+		}
+		return ms, nil
 	}
 
-	s, err := NewClientStream(ctx, ms, id, sp.AgentSessionID, roundTripLatency, dialTimeout)
+	var s Stream
+	var err error
+	if sp.Muxes != nil {
+		s, err = sp.Muxes.CreateStream(ctx, "traffic-manager", id, sp.AgentSessionID, roundTripLatency, dialTimeout, open)
+	} else {
+		var ms GRPCClientStream
+		if ms, err = open(ctx); err == nil {
+			s, err = NewClientStream(ctx, ms, id, sp.AgentSessionID, roundTripLatency, dialTimeout)
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
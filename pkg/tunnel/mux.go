@@ -0,0 +1,693 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// A multiplexed tunnel carries many connections over one gRPC stream. The client requests it by setting
+// flagMux in the initial StreamInfo message. A server that supports it answers with a StreamOK message
+// that reports a version >= muxVersion, after which all messages on the gRPC stream are frames. Older
+// servers ignore the flag, and the gRPC stream then carries the connection of the StreamInfo message.
+//
+// Each frame starts with a muxFrameCode and the varint encoded number of the connection that it concerns.
+
+type muxFrameCode byte
+
+const (
+	// muxOpen is sent by the client to open a connection. The rest of the frame is the ConnID.
+	muxOpen = muxFrameCode(iota)
+
+	// muxData carries a Message of a connection.
+	muxData
+
+	// muxWindow permits the peer to send more Normal messages on a connection. The rest of the frame is
+	// the varint encoded number of payload bytes that were consumed.
+	muxWindow
+
+	// muxFin is sent by the client when it will send no more messages on a connection, and by the server
+	// when its handler of the connection has returned.
+	muxFin
+
+	// muxAck acknowledges the frames of a connection on a resumable Mux. The rest of the frame is the varint
+	// encoded number of frames that have been received on the connection.
+	muxAck
+
+	// muxResume is the first frame that each peer sends on a gRPC stream that resumes a Mux. The rest of
+	// the frame is a list of varint encoded connection numbers, each followed by the number of frames that
+	// have been received on that connection.
+	muxResume
+)
+
+func (c muxFrameCode) String() string {
+	switch c {
+	case muxOpen:
+		return "MUX_OPEN"
+	case muxData:
+		return "MUX_DATA"
+	case muxWindow:
+		return "MUX_WINDOW"
+	case muxFin:
+		return "MUX_FIN"
+	case muxAck:
+		return "MUX_ACK"
+	case muxResume:
+		return "MUX_RESUME"
+	default:
+		return fmt.Sprintf("** unknown mux frame code: %d **", c)
+	}
+}
+
+// muxWindowSize is the number of payload bytes in Normal messages that may be in flight on a connection
+// before the sender must wait for the receiver to consume them. Other messages aren't flow controlled.
+const muxWindowSize = 256 * 1024
+
+func muxFrame(code muxFrameCode, num uint64, body []byte) *rpc.TunnelMessage {
+	b := make([]byte, 1+binary.MaxVarintLen64+len(body))
+	b[0] = byte(code)
+	n := binary.PutUvarint(b[1:], num)
+	n += copy(b[1+n:], body)
+	return &rpc.TunnelMessage{Payload: b[:1+n]}
+}
+
+func parseMuxFrame(tm *rpc.TunnelMessage) (muxFrameCode, uint64, []byte, error) {
+	pl := tm.Payload
+	if len(pl) < 2 {
+		return 0, 0, nil, errors.New("malformed mux frame")
+	}
+	num, n := binary.Uvarint(pl[1:])
+	if n <= 0 {
+		return 0, 0, nil, errors.New("malformed mux frame")
+	}
+	return muxFrameCode(pl[0]), num, pl[1+n:], nil
+}
+
+// Mux multiplexes connections over one gRPC stream.
+type Mux struct {
+	base   *stream
+	client bool
+
+	// onOpen is called when the peer opens a connection. It's nil for a client Mux.
+	onOpen func(*muxStream)
+
+	// sendLock serializes the frames that are sent on the gRPC stream, which is replaced when a
+	// resumable Mux is reattached.
+	sendLock   sync.Mutex
+	grpcStream GRPCStream
+
+	lock    sync.Mutex
+	streams map[uint64]*muxStream
+	nextNum uint64
+	err     error
+	done    chan struct{}
+
+	// resume is nil unless the Mux is resumable.
+	resume *resumeState
+}
+
+func newMux(base *stream, client bool) *Mux {
+	return &Mux{
+		base:       base,
+		client:     client,
+		grpcStream: base.grpcStream,
+		streams:    make(map[uint64]*muxStream),
+		done:       make(chan struct{}),
+	}
+}
+
+// Done returns a channel that is closed when the gRPC stream of the Mux is closed.
+func (m *Mux) Done() <-chan struct{} {
+	return m.done
+}
+
+// sendFrame sends a frame that isn't part of a connection's sequence of frames. Such frames are dropped
+// while a resumable Mux is detached from its gRPC stream.
+func (m *Mux) sendFrame(code muxFrameCode, num uint64, body []byte) error {
+	m.sendLock.Lock()
+	defer m.sendLock.Unlock()
+	if m.resume != nil && !m.resume.attached {
+		return nil
+	}
+	return m.grpcStream.Send(muxFrame(code, num, body))
+}
+
+// sendStreamFrame sends a frame of the given connection. A resumable Mux keeps the frame until the peer
+// acknowledges it, so that it can be sent again when the Mux is reattached.
+func (m *Mux) sendStreamFrame(ms *muxStream, code muxFrameCode, body []byte) error {
+	tm := muxFrame(code, ms.num, body)
+	m.sendLock.Lock()
+	defer m.sendLock.Unlock()
+	r := m.resume
+	if r == nil {
+		return m.grpcStream.Send(tm)
+	}
+	r.replayLock.Lock()
+	ms.replay = append(ms.replay, tm)
+	r.replayLock.Unlock()
+	if r.attached {
+		// A failed send means that the gRPC stream is lost. The read loop will notice that too, and the
+		// frame is sent again when the Mux is reattached.
+		_ = m.grpcStream.Send(tm)
+	}
+	return nil
+}
+
+// OpenStream opens a new connection with the given id on the Mux. The connection is closed when
+// its CloseSend is called and the peer has sent its last message, or when the context is cancelled.
+func (m *Mux) OpenStream(ctx context.Context, id ConnID) (Stream, error) {
+	m.lock.Lock()
+	if m.err != nil {
+		m.lock.Unlock()
+		return nil, m.err
+	}
+	m.nextNum++
+	ms := m.newStream(m.nextNum, id)
+	m.streams[ms.num] = ms
+	m.lock.Unlock()
+
+	if err := m.sendStreamFrame(ms, muxOpen, []byte(id)); err != nil {
+		m.remove(ms)
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = ms.CloseSend(ctx)
+		m.remove(ms)
+	})
+	ms.lock.Lock()
+	ms.stopWatch = stop
+	ms.lock.Unlock()
+	return ms, nil
+}
+
+func (m *Mux) newStream(num uint64, id ConnID) *muxStream {
+	return &muxStream{
+		mux:      m,
+		num:      num,
+		id:       id,
+		credit:   muxWindowSize,
+		comp:     &compressor{enabled: m.base.comp != nil && m.base.comp.enabled},
+		notify:   make(chan struct{}, 1),
+		creditCh: make(chan struct{}, 1),
+	}
+}
+
+func (m *Mux) remove(ms *muxStream) {
+	m.lock.Lock()
+	if m.streams[ms.num] == ms {
+		delete(m.streams, ms.num)
+		if r := m.resume; r != nil {
+			r.linger(ms)
+		}
+	}
+	m.lock.Unlock()
+
+	ms.lock.Lock()
+	stop := ms.stopWatch
+	ms.lock.Unlock()
+	if stop != nil {
+		stop()
+	}
+}
+
+func (m *Mux) getStream(num uint64) *muxStream {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.streams[num]
+}
+
+// close terminates all connections of the Mux with the given error.
+func (m *Mux) close(err error) {
+	m.lock.Lock()
+	if m.err != nil {
+		m.lock.Unlock()
+		return
+	}
+	m.err = err
+	for _, ms := range m.streams {
+		ms.setErr(err)
+	}
+	m.streams = nil
+	r := m.resume
+	if r != nil {
+		r.lingering = nil
+	}
+	close(m.done)
+	m.lock.Unlock()
+	if r != nil {
+		r.closed()
+	}
+}
+
+// readLoop dispatches the frames of the given gRPC stream to the connections until the gRPC stream is
+// closed, and returns the error that closed it. The gen is the generation of the gRPC stream of a
+// resumable Mux.
+func (m *Mux) readLoop(ctx context.Context, gs GRPCStream, gen uint64) error {
+	for {
+		tm, err := gs.Recv()
+		if err != nil {
+			return err
+		}
+		code, num, body, err := parseMuxFrame(tm)
+		if err != nil {
+			return err
+		}
+		if m.resume != nil {
+			err = m.resume.dispatch(ctx, gen, code, num, body)
+		} else {
+			m.dispatch(ctx, code, num, body)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// closeOnEnd closes the Mux when its gRPC stream has ended with the given error.
+func (m *Mux) closeOnEnd(ctx context.Context, err error) {
+	if errors.Is(err, net.ErrClosed) || ctx.Err() != nil {
+		err = io.EOF
+	}
+	m.close(err)
+}
+
+// dispatch dispatches one frame to its connection and returns the connection, or nil when the connection
+// is unknown.
+func (m *Mux) dispatch(ctx context.Context, code muxFrameCode, num uint64, body []byte) *muxStream {
+	if code == muxOpen {
+		if m.onOpen == nil {
+			dlog.Errorf(ctx, "!! %s mux, unexpected %s %d", m.base.tag, code, num)
+			return nil
+		}
+		ms := m.newStream(num, ConnID(body))
+		m.lock.Lock()
+		if m.err == nil {
+			m.streams[num] = ms
+		}
+		m.lock.Unlock()
+		m.onOpen(ms)
+		return ms
+	}
+
+	// Frames for connections that are already closed are silently dropped.
+	ms := m.getStream(num)
+	if ms == nil {
+		return nil
+	}
+	switch code {
+	case muxData:
+		if len(body) == 0 {
+			break
+		}
+		var qm Message = msg(body)
+		if qm.Code() == compressedNormal {
+			var err error
+			if qm, err = ms.comp.decompress(qm); err != nil {
+				// The connection can't continue with a gap in its data.
+				ms.setErr(err)
+				break
+			}
+		}
+		ms.lock.Lock()
+		ms.queue = append(ms.queue, qm)
+		ms.lock.Unlock()
+		ms.signal()
+	case muxWindow:
+		if v, n := binary.Uvarint(body); n > 0 {
+			ms.lock.Lock()
+			ms.credit += int(v)
+			ms.lock.Unlock()
+			select {
+			case ms.creditCh <- struct{}{}:
+			default:
+			}
+		}
+	case muxFin:
+		ms.lock.Lock()
+		ms.finRecv = true
+		done := ms.finSent
+		ms.lock.Unlock()
+		ms.signal()
+		if done && m.client {
+			m.remove(ms)
+		}
+	default:
+		dlog.Errorf(ctx, "!! %s mux, %s %d", m.base.tag, code, num)
+	}
+	return ms
+}
+
+// muxStream is a Stream that represents one connection of a Mux.
+type muxStream struct {
+	mux       *Mux
+	num       uint64
+	id        ConnID
+	stopWatch func() bool
+	comp      *compressor
+
+	lock     sync.Mutex
+	queue    []Message
+	notify   chan struct{}
+	credit   int // bytes that may be sent in Normal messages
+	creditCh chan struct{}
+	consumed int // bytes consumed since the last muxWindow frame was sent
+	finSent  bool
+	finRecv  bool
+	err      error
+
+	// Used when the Mux is resumable. The replay buffer holds the frames that have been sent but not yet
+	// acknowledged by the peer, and acked is the number of frames that the peer has acknowledged. Both
+	// are guarded by the replayLock of the resumeState.
+	replay  []*rpc.TunnelMessage
+	acked   uint64
+	removed bool
+
+	// recv is the number of frames received. The unacked counters are guarded by the recvLock of the resumeState.
+	recv         atomic.Uint64
+	unacked      int
+	unackedBytes int
+}
+
+// setErr terminates the connection with the given error, unless it's already terminated.
+func (ms *muxStream) setErr(err error) {
+	ms.lock.Lock()
+	if ms.err == nil {
+		ms.err = err
+	}
+	ms.lock.Unlock()
+	ms.signal()
+}
+
+func (ms *muxStream) signal() {
+	select {
+	case ms.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (ms *muxStream) Tag() string {
+	return ms.mux.base.tag
+}
+
+func (ms *muxStream) ID() ConnID {
+	return ms.id
+}
+
+func (ms *muxStream) PeerVersion() uint16 {
+	return ms.mux.base.peerVersion
+}
+
+func (ms *muxStream) SessionID() string {
+	return ms.mux.base.sessionID
+}
+
+func (ms *muxStream) DialTimeout() time.Duration {
+	return ms.mux.base.dialTimeout
+}
+
+func (ms *muxStream) RoundtripLatency() time.Duration {
+	return ms.mux.base.roundtripLatency
+}
+
+func (ms *muxStream) Receive(ctx context.Context) (Message, error) {
+	for {
+		ms.lock.Lock()
+		if len(ms.queue) > 0 {
+			m := ms.queue[0]
+			ms.queue[0] = nil
+			ms.queue = ms.queue[1:]
+			windowUpdate := 0
+			if m.Code() == Normal {
+				ms.consumed += len(m.Payload())
+				if ms.consumed >= muxWindowSize/4 {
+					windowUpdate = ms.consumed
+					ms.consumed = 0
+				}
+			}
+			ms.lock.Unlock()
+			if windowUpdate > 0 {
+				buf := make([]byte, binary.MaxVarintLen64)
+				n := binary.PutUvarint(buf, uint64(windowUpdate))
+				if err := ms.mux.sendStreamFrame(ms, muxWindow, buf[:n]); err != nil {
+					return nil, err
+				}
+			}
+			if m.Code() == closeSend {
+				dlog.Tracef(ctx, "<- %s %s, close send", ms.Tag(), ms.id)
+				return nil, net.ErrClosed
+			}
+			dlog.Tracef(ctx, "<- %s %s, %s", ms.Tag(), ms.id, m)
+			return m, nil
+		}
+		finRecv, err := ms.finRecv, ms.err
+		ms.lock.Unlock()
+		if finRecv {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ms.notify:
+		}
+	}
+}
+
+func (ms *muxStream) Send(ctx context.Context, m Message) error {
+	for {
+		ms.lock.Lock()
+		switch {
+		case ms.err != nil:
+			err := ms.err
+			ms.lock.Unlock()
+			return err
+		case ms.finSent, ms.mux.client && ms.finRecv:
+			// A client can't send after its CloseSend, and the server's muxFin means that it's gone.
+			ms.lock.Unlock()
+			return net.ErrClosed
+		}
+		// The credit may become negative, so that a message larger than the window can be sent.
+		if m.Code() != Normal || ms.credit > 0 {
+			if m.Code() == Normal {
+				ms.credit -= len(m.Payload())
+			}
+			ms.lock.Unlock()
+			break
+		}
+		ms.lock.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ms.mux.done:
+		case <-ms.creditCh:
+		}
+	}
+	if err := ms.mux.sendStreamFrame(ms, muxData, ms.comp.compress(m).TunnelMessage().Payload); err != nil {
+		if ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+			dlog.Errorf(ctx, "!! %s %s, Send failed: %v", ms.Tag(), ms.id, err)
+		}
+		return err
+	}
+	dlog.Tracef(ctx, "-> %s %s, %s", ms.Tag(), ms.id, m)
+	return nil
+}
+
+func (ms *muxStream) compressionStats() (uncompressed, compressed uint64) {
+	return ms.comp.stats()
+}
+
+// CloseSend of a client connection sends a muxFin. The server connection sends a closeSend message
+// just like a server Stream does.
+func (ms *muxStream) CloseSend(ctx context.Context) error {
+	if !ms.mux.client {
+		if err := ms.Send(ctx, NewMessage(closeSend, nil)); err != nil {
+			if ctx.Err() == nil && !(errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed)) {
+				return fmt.Errorf("send of closeSend message failed: %w", err)
+			}
+		}
+		return nil
+	}
+	return ms.sendFin()
+}
+
+func (ms *muxStream) sendFin() error {
+	ms.lock.Lock()
+	if ms.finSent || ms.err != nil {
+		ms.lock.Unlock()
+		return nil
+	}
+	ms.finSent = true
+	done := ms.finRecv
+	ms.lock.Unlock()
+	if done {
+		ms.mux.remove(ms)
+	}
+	if err := ms.mux.sendStreamFrame(ms, muxFin, nil); err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	return nil
+}
+
+// negotiateMux sends a StreamInfo message that requests multiplexing on the given gRPC stream. The
+// returned Mux is nil when the peer doesn't support multiplexing, and the returned Stream then carries
+// the connection with the given id. The Mux serves its connections until the muxCtx is cancelled. It's
+// resumable when the muxCtx has a resume timeout and the peer agrees, and the open function is then used
+// to replace a lost gRPC stream.
+func negotiateMux(
+	ctx,
+	muxCtx context.Context,
+	grpcStream GRPCClientStream,
+	id ConnID,
+	sessionID string,
+	callDelay,
+	dialTimeout time.Duration,
+	open func(context.Context) (GRPCClientStream, error),
+) (Stream, *Mux, error) {
+	flags := flagMux
+	timeout := resumeTimeout(muxCtx)
+	if timeout > 0 {
+		flags |= flagResumable
+	}
+	s, err := newClientStream(ctx, grpcStream, id, sessionID, callDelay, dialTimeout, flags)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.peerVersion < muxVersion {
+		return s, nil, nil
+	}
+	m := newMux(&s.stream, true)
+	if s.flags&flagResumable != 0 {
+		m.resume = newResumeState(m, timeout)
+		m.resume.reopen = open
+	}
+	go m.serveClient(muxCtx)
+	return nil, m, nil
+}
+
+// serveClient serves the gRPC stream of a client Mux until it ends. A resumable Mux replaces a lost gRPC
+// stream unless the given context is cancelled.
+func (m *Mux) serveClient(ctx context.Context) {
+	gs, gen := m.grpcStream, uint64(0)
+	if r := m.resume; r != nil {
+		gen = r.gen
+	}
+	for {
+		err := m.readLoop(ctx, gs, gen)
+		r := m.resume
+		if r == nil || ctx.Err() != nil {
+			m.closeOnEnd(ctx, err)
+			return
+		}
+		dlog.Debugf(ctx, "   %s, multiplexed tunnel lost: %v", m.base.tag, err)
+		r.detach(gen)
+		if gs, gen, err = r.reconnect(ctx); err != nil {
+			dlog.Warnf(ctx, "   %s, unable to resume multiplexed tunnel: %v", m.base.tag, err)
+			m.close(err)
+			return
+		}
+		dlog.Debugf(ctx, "   %s, multiplexed tunnel resumed", m.base.tag)
+	}
+}
+
+// ServeStreams serves a gRPC stream that was opened by a client. The handler is called once with the
+// Stream when the client doesn't request multiplexing. Otherwise, the handler is called in a goroutine
+// for each connection that the client opens on the stream, and ServeStreams returns when the client
+// closes the stream and all handlers have returned.
+//
+// A multiplexed stream is resumable when the context has a resume timeout and the client asks for it.
+// ServeStreams then returns when the gRPC stream is lost, but the handlers continue to run until the
+// client resumes the stream on a new gRPC stream, or the resume timeout expires.
+func ServeStreams(ctx context.Context, grpcStream GRPCStream, handler func(context.Context, Stream) error) error {
+	base, err := acceptStream(ctx, grpcStream)
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "failed to connect stream: %v", err)
+	}
+	if base.flags&flagReattach != 0 {
+		return reattachMux(ctx, base)
+	}
+	flags := base.compressionFlags(ctx)
+	timeout := resumeTimeout(ctx)
+	resumable := base.flags&flagMux != 0 && base.flags&flagResumable != 0 && timeout > 0
+	if resumable {
+		flags |= flagResumable
+	}
+	if err = base.sendStreamOK(ctx, flags); err != nil {
+		return status.Errorf(codes.FailedPrecondition, "failed to connect stream: %v", err)
+	}
+	if base.flags&flagMux == 0 {
+		return handler(ctx, base)
+	}
+
+	dlog.Debugf(ctx, "   %s, multiplexing connections of session %s", base.tag, base.sessionID)
+	m := newMux(base, false)
+	hCtx := ctx
+	if resumable {
+		// The connections outlive a lost gRPC stream, so their context must not end with it.
+		hCtx = context.WithoutCancel(ctx)
+	}
+	hCtx, cancel := context.WithCancel(hCtx)
+	wg := sync.WaitGroup{}
+	m.onOpen = func(ms *muxStream) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := handler(hCtx, ms); err != nil && hCtx.Err() == nil {
+				dlog.Errorf(hCtx, "!! %s %s, %v", ms.Tag(), ms.id, err)
+			}
+			_ = ms.sendFin()
+			m.remove(ms)
+		}()
+	}
+	if !resumable {
+		m.closeOnEnd(ctx, m.readLoop(ctx, grpcStream, 0))
+		cancel()
+		wg.Wait()
+		return nil
+	}
+
+	m.resume = newResumeState(m, timeout)
+	resumableMuxes.add(m)
+	go func() {
+		<-m.done
+		cancel()
+	}()
+	m.resume.serveServer(ctx, grpcStream, m.resume.gen)
+	select {
+	case <-m.done:
+		wg.Wait()
+	default:
+	}
+	return nil
+}
+
+// reattachMux attaches the gRPC stream of the given server stream to the resumable Mux that it asks for,
+// and serves it until it ends.
+func reattachMux(ctx context.Context, s *stream) error {
+	m := resumableMuxes.get(s.sessionID, s.id)
+	if m == nil {
+		// A StreamOK message without flagReattach tells the client that its connections are lost.
+		dlog.Debugf(ctx, "   %s, no multiplexed tunnel of session %s to resume", s.tag, s.sessionID)
+		_ = s.sendStreamOK(ctx, 0)
+		return nil
+	}
+	if err := s.sendStreamOK(ctx, flagMux|flagResumable|flagReattach); err != nil {
+		return status.Errorf(codes.FailedPrecondition, "failed to connect stream: %v", err)
+	}
+	dlog.Debugf(ctx, "   %s, resuming multiplexed tunnel of session %s", s.tag, s.sessionID)
+	m.resume.serveServer(ctx, s.grpcStream, m.resume.attach(s.grpcStream, nil))
+	return nil
+}
@@ -0,0 +1,182 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+func TestMux_Xfer(t *testing.T) {
+	ctx, cancel := testContext(t, 30*time.Second)
+	defer cancel()
+
+	si := uuid.New().String()
+	b := make([]byte, 0x1000)
+	for i := range b {
+		b[i] = byte(i & 0xff)
+	}
+	large := NewMessage(Normal, b)
+	errs := make(chan error, 20)
+
+	tunnel := newBidi(10, ctx.Done())
+	serverDone := make(chan struct{})
+	go func() {
+		defer close(serverDone)
+		err := ServeStreams(ctx, tunnel.serverSide(), func(ctx context.Context, s Stream) error {
+			assert.Equal(t, si, s.SessionID())
+			assert.Equal(t, muxVersion, s.PeerVersion())
+			consume(ctx, s, b, errs)
+			return nil
+		})
+		assert.NoError(t, err)
+	}()
+
+	var opens atomic.Int32
+	open := func(context.Context) (GRPCClientStream, error) {
+		opens.Add(1)
+		return tunnel.clientSide(), nil
+	}
+	pool := NewMuxPool(ctx)
+	wg := sync.WaitGroup{}
+	for i := uint16(0); i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("192.168.0.1"), 1001+i, 8080)
+			client, err := pool.CreateStream(ctx, "peer", id, si, 0, 0, open)
+			if err != nil {
+				errs <- err
+				return
+			}
+			assert.Equal(t, id, client.ID())
+			produce(ctx, client, large, errs)
+		}()
+	}
+	wg.Wait()
+	cancel()
+	<-serverDone
+	requireNoErrs(t, errs)
+
+	// All connections shared one gRPC stream.
+	assert.Equal(t, int32(1), opens.Load())
+}
+
+// oldServerStream behaves like NewServerStream in a peer that doesn't know about multiplexing.
+func oldServerStream(ctx context.Context, grpcStream GRPCStream) (Stream, error) {
+	s := &stream{tag: "SRV", grpcStream: grpcStream, syncRatio: 8, ackWindow: 1}
+	m, err := s.Receive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = setConnectInfo(m, s); err != nil {
+		return nil, err
+	}
+	ok := makeMessage(streamOK, 4)
+	n := binary.PutUvarint(ok.Payload(), 2)
+	if err = s.Send(ctx, ok[:n+1]); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func TestMux_oldPeer(t *testing.T) {
+	ctx, cancel := testContext(t, 30*time.Second)
+	defer cancel()
+
+	si := uuid.New().String()
+	b := []byte("hello")
+	errs := make(chan error, 10)
+	pool := NewMuxPool(ctx)
+
+	// Each connection gets a gRPC stream of its own, and the first one is used to negotiate.
+	for i := uint16(0); i < 2; i++ {
+		tunnel := newBidi(10, ctx.Done())
+		id := NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("192.168.0.1"), 1001+i, 8080)
+		wg := sync.WaitGroup{}
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			server, err := oldServerStream(ctx, tunnel.serverSide())
+			if err != nil {
+				errs <- err
+				return
+			}
+			assert.Equal(t, id, server.ID())
+			consume(ctx, server, b, errs)
+		}()
+		go func() {
+			defer wg.Done()
+			client, err := pool.CreateStream(ctx, "peer", id, si, 0, 0, func(context.Context) (GRPCClientStream, error) {
+				return tunnel.clientSide(), nil
+			})
+			if err != nil {
+				errs <- err
+				return
+			}
+			assert.Equal(t, uint16(2), client.PeerVersion())
+			produce(ctx, client, NewMessage(Normal, b), errs)
+		}()
+		wg.Wait()
+		errs = requireNoErrs(t, errs)
+	}
+}
+
+func TestMux_flowControl(t *testing.T) {
+	ctx, cancel := testContext(t, 30*time.Second)
+	defer cancel()
+
+	tunnel := newBidi(100, ctx.Done())
+	release := make(chan struct{})
+	received := make(chan int, 1)
+	go func() {
+		_ = ServeStreams(ctx, tunnel.serverSide(), func(ctx context.Context, s Stream) error {
+			<-release
+			count := 0
+			for {
+				m, err := s.Receive(ctx)
+				if err != nil {
+					received <- count
+					return nil
+				}
+				count += len(m.Payload())
+			}
+		})
+	}()
+
+	id := NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("192.168.0.1"), 1001, 8080)
+	client, err := NewMuxPool(ctx).CreateStream(ctx, "peer", id, "session", 0, 0, func(context.Context) (GRPCClientStream, error) {
+		return tunnel.clientSide(), nil
+	})
+	require.NoError(t, err)
+
+	// The whole window can be sent without the peer consuming anything.
+	chunk := NewMessage(Normal, make([]byte, 0x4000))
+	for i := 0; i < muxWindowSize/0x4000; i++ {
+		require.NoError(t, client.Send(ctx, chunk))
+	}
+
+	// The next message must wait for the peer.
+	tc, tCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	assert.ErrorIs(t, client.Send(tc, chunk), context.DeadlineExceeded)
+	tCancel()
+
+	close(release)
+	require.NoError(t, client.Send(ctx, chunk))
+	require.NoError(t, client.CloseSend(ctx))
+	select {
+	case n := <-received:
+		assert.Equal(t, muxWindowSize+0x4000, n)
+	case <-ctx.Done():
+		t.Fatal("server didn't receive all messages")
+	}
+}
@@ -0,0 +1,98 @@
+package tunnel
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+// MuxPool keeps one multiplexed tunnel per peer, so that connections to the same peer share one gRPC stream
+// instead of creating one stream each. Peers that don't support multiplexing get one gRPC stream per
+// connection.
+type MuxPool struct {
+	ctx   context.Context
+	lock  sync.Mutex
+	peers map[string]*muxPeer
+}
+
+type muxPeer struct {
+	// The lock is held while the peer is negotiated with, so that only one Mux is created for it.
+	sync.Mutex
+	mux    *Mux
+	legacy bool
+}
+
+// NewMuxPool creates a MuxPool. The multiplexed tunnels of the pool are closed when the given
+// context is cancelled.
+func NewMuxPool(ctx context.Context) *MuxPool {
+	return &MuxPool{ctx: ctx, peers: make(map[string]*muxPeer)}
+}
+
+// CreateStream creates a Stream for the connection with the given id. The key identifies the peer, and the
+// open function opens a new gRPC stream to it.
+func (p *MuxPool) CreateStream(
+	ctx context.Context,
+	key string,
+	id ConnID,
+	sessionID string,
+	callDelay,
+	dialTimeout time.Duration,
+	open func(context.Context) (GRPCClientStream, error),
+) (Stream, error) {
+	p.lock.Lock()
+	peer, ok := p.peers[key]
+	if !ok {
+		peer = &muxPeer{}
+		p.peers[key] = peer
+	}
+	p.lock.Unlock()
+
+	peer.Lock()
+	defer peer.Unlock()
+	if peer.mux != nil {
+		s, err := peer.mux.OpenStream(ctx, id)
+		if err == nil {
+			return s, nil
+		}
+		// The gRPC stream of the Mux is gone, so a new one must be negotiated.
+		dlog.Debugf(ctx, "multiplexed tunnel to %s closed: %v", key, err)
+		peer.mux = nil
+	}
+
+	if peer.legacy {
+		gs, err := open(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return NewClientStream(ctx, gs, id, sessionID, callDelay, dialTimeout)
+	}
+
+	// The gRPC stream must outlive the connection if it becomes a Mux, and end with the connection otherwise.
+	muxCtx, cancel := context.WithCancel(p.ctx)
+	gs, err := open(muxCtx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	s, mux, err := negotiateMux(ctx, muxCtx, gs, id, sessionID, callDelay, dialTimeout, open)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if mux == nil {
+		dlog.Debugf(ctx, "peer %s doesn't support multiplexed tunnels", key)
+		peer.legacy = true
+		context.AfterFunc(ctx, cancel)
+		return s, nil
+	}
+	dlog.Debugf(ctx, "multiplexing tunnels to %s", key)
+	context.AfterFunc(muxCtx, func() { mux.close(context.Canceled) })
+	go func() {
+		<-mux.Done()
+		cancel()
+	}()
+	peer.mux = mux
+	return mux.OpenStream(ctx, id)
+}
@@ -0,0 +1,99 @@
+package tunnel
+
+import (
+	"net"
+
+	"github.com/puzpuzpuz/xsync/v3"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+// A Recorder records the payload of the connections that a dialer establishes on behalf of its peer. This is
+// typically used on the workstation to record intercepted connections.
+type Recorder interface {
+	// RecordOpen is called when the dialer has established the connection with the given id.
+	RecordOpen(id ConnID)
+
+	// RecordPeerData is called with data that the peer sent, and that was written to the connection.
+	RecordPeerData(id ConnID, data []byte)
+
+	// RecordConnData is called with data that was read from the connection, and that is sent to the peer.
+	RecordConnData(id ConnID, data []byte)
+
+	// RecordClose is called when the connection with the given id has been closed.
+	RecordClose(id ConnID)
+}
+
+var recorders = xsync.NewMapOf[string, Recorder]() //nolint:gochecknoglobals // registry
+
+var taps = xsync.NewMapOf[Recorder, struct{}]() //nolint:gochecknoglobals // registry
+
+// SetRecorder makes all dialers that dial the given destination IP and port use the given recorder.
+func SetRecorder(ip net.IP, port uint16, r Recorder) {
+	recorders.Store(iputil.JoinIpPort(ip, port), r)
+}
+
+// RemoveRecorder removes the recorder for the given destination IP and port, provided that it is the given
+// recorder. The recorder may otherwise have been replaced by another recorder that must be retained.
+func RemoveRecorder(ip net.IP, port uint16, r Recorder) {
+	recorders.Compute(iputil.JoinIpPort(ip, port), func(old Recorder, loaded bool) (Recorder, bool) {
+		return old, !loaded || old == r
+	})
+}
+
+// AddTap makes all dialers that establish a connection after this call record it using the given recorder,
+// regardless of its destination and in addition to the recorder that is set for that destination.
+func AddTap(r Recorder) {
+	taps.Store(r, struct{}{})
+}
+
+// RemoveTap removes a recorder that was added using AddTap.
+func RemoveTap(r Recorder) {
+	taps.Delete(r)
+}
+
+func recorderFor(id ConnID) Recorder {
+	r, _ := recorders.Load(iputil.JoinIpPort(id.Destination(), id.DestinationPort()))
+	if taps.Size() == 0 {
+		return r
+	}
+	var rs multiRecorder
+	if r != nil {
+		rs = append(rs, r)
+	}
+	taps.Range(func(t Recorder, _ struct{}) bool {
+		rs = append(rs, t)
+		return true
+	})
+	if len(rs) == 1 {
+		return rs[0]
+	}
+	return rs
+}
+
+// multiRecorder is a Recorder that passes everything on to several recorders.
+type multiRecorder []Recorder
+
+func (rs multiRecorder) RecordOpen(id ConnID) {
+	for _, r := range rs {
+		r.RecordOpen(id)
+	}
+}
+
+func (rs multiRecorder) RecordPeerData(id ConnID, data []byte) {
+	for _, r := range rs {
+		r.RecordPeerData(id, data)
+	}
+}
+
+func (rs multiRecorder) RecordConnData(id ConnID, data []byte) {
+	for _, r := range rs {
+		r.RecordConnData(id, data)
+	}
+}
+
+func (rs multiRecorder) RecordClose(id ConnID) {
+	for _, r := range rs {
+		r.RecordClose(id)
+	}
+}
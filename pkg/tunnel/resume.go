@@ -0,0 +1,514 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// A resumable Mux survives the loss of its gRPC stream, e.g. when the port-forward that carries it is
+// interrupted. The client asks for it by setting flagResumable in its StreamInfo message, and a server
+// that agrees sets the same flag in its StreamOK message.
+//
+// Each peer counts the frames that it receives on a connection, and keeps the frames that it sends in a
+// replay buffer until the other peer acknowledges them with a muxAck frame. The flow control of the
+// connection bounds the size of the buffer.
+//
+// When the gRPC stream is lost, the server keeps the connections of the Mux for the duration of the resume
+// timeout, and the client opens new gRPC streams until the server accepts one or the timeout expires. Frames
+// that are sent in the meantime are just added to the replay buffers. The StreamInfo message of the new gRPC
+// stream carries flagReattach and the ConnID and session that the Mux was negotiated with, which identifies
+// the Mux to the server. Both peers then send a muxResume frame with the number of frames that they have
+// received on each connection, and replay the frames that the other peer hasn't received.
+
+// DefaultResumeTimeout is the time that the connections of a resumable Mux are kept after its gRPC stream
+// is lost, unless another timeout is configured.
+const DefaultResumeTimeout = 30 * time.Second
+
+const (
+	// A connection acknowledges the frames that it receives when muxAckFrames frames or muxAckBytes bytes
+	// have been received since its last acknowledgement, and when it receives a muxFin.
+	muxAckFrames = 16
+	muxAckBytes  = muxWindowSize / 4
+
+	// resumeMaxBackoff is the max time that a client waits between its attempts to resume a Mux.
+	resumeMaxBackoff = 2 * time.Second
+)
+
+var (
+	// errStaleStream ends the read loop of a gRPC stream that has been replaced by another gRPC stream.
+	errStaleStream = errors.New("multiplexed tunnel was resumed on another stream")
+
+	// errResumeRefused is returned when the server doesn't know the Mux that the client wants to resume.
+	errResumeRefused = errors.New("peer has no multiplexed tunnel to resume")
+
+	// errResumeTimeout closes a Mux that wasn't resumed within the resume timeout.
+	errResumeTimeout = errors.New("multiplexed tunnel wasn't resumed in time")
+
+	// errConnectionLost terminates a connection that the peer no longer knows about when a Mux is resumed.
+	errConnectionLost = errors.New("connection was lost when the multiplexed tunnel was resumed")
+)
+
+type resumeTimeoutKey struct{}
+
+// WithResumeTimeout returns a context that makes the multiplexed streams that are created or served using
+// it resumable. The connections of such a stream survive the loss of its gRPC stream, provided that a new
+// gRPC stream is established within the given timeout. A zero timeout disables resumption.
+func WithResumeTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, resumeTimeoutKey{}, timeout)
+}
+
+// resumeTimeout returns the resume timeout of the given context, or zero if it has none.
+func resumeTimeout(ctx context.Context) time.Duration {
+	timeout, _ := ctx.Value(resumeTimeoutKey{}).(time.Duration)
+	return timeout
+}
+
+type muxKey struct {
+	sessionID string
+	id        ConnID
+}
+
+// muxRegistry keeps track of the resumable server Muxes, so that a client can find them when it resumes.
+type muxRegistry struct {
+	sync.Mutex
+	muxes map[muxKey]*Mux
+}
+
+var resumableMuxes = muxRegistry{muxes: make(map[muxKey]*Mux)} //nolint:gochecknoglobals // registry of open tunnels
+
+func (r *muxRegistry) add(m *Mux) {
+	r.Lock()
+	r.muxes[muxKey{sessionID: m.base.sessionID, id: m.base.id}] = m
+	r.Unlock()
+}
+
+func (r *muxRegistry) get(sessionID string, id ConnID) *Mux {
+	r.Lock()
+	defer r.Unlock()
+	return r.muxes[muxKey{sessionID: sessionID, id: id}]
+}
+
+func (r *muxRegistry) remove(m *Mux) {
+	key := muxKey{sessionID: m.base.sessionID, id: m.base.id}
+	r.Lock()
+	if r.muxes[key] == m {
+		delete(r.muxes, key)
+	}
+	r.Unlock()
+}
+
+// resumeState is the state of a resumable Mux.
+//
+// The locks must be acquired in this order: recvLock, the sendLock of the Mux, the lock of the Mux, and
+// replayLock. The replayLock is never held during network I/O.
+type resumeState struct {
+	mux     *Mux
+	timeout time.Duration
+
+	// reopen opens a new gRPC stream to the server. It's only used by a client.
+	reopen func(context.Context) (GRPCClientStream, error)
+
+	// recvLock is held while a frame is dispatched, so that the numbers of received frames don't change
+	// while they are reported to the peer.
+	recvLock sync.Mutex
+
+	// gen is incremented each time the Mux is attached to a new gRPC stream. It's guarded by both the
+	// recvLock and the sendLock of the Mux, so holding either one is enough to read it.
+	gen uint64
+
+	// attached is false from the loss of the gRPC stream until the frames that the peer hasn't received
+	// have been sent on the new gRPC stream. Guarded by the sendLock of the Mux.
+	attached bool
+
+	// cancelStream cancels the gRPC stream that a client opened to resume the Mux. Guarded by the sendLock
+	// of the Mux.
+	cancelStream context.CancelFunc
+
+	// replayLock guards the replay buffers of the connections.
+	replayLock sync.Mutex
+
+	// lingering are the connections that have been removed while the peer still hasn't acknowledged
+	// all of their frames. Guarded by the lock of the Mux.
+	lingering map[uint64]*muxStream
+
+	// acks are the connections that must acknowledge the frames that they have received. A nil connection
+	// is unknown, and acknowledges all frames. Guarded by the lock of the Mux.
+	acks  map[uint64]*muxStream
+	ackCh chan struct{}
+}
+
+func newResumeState(m *Mux, timeout time.Duration) *resumeState {
+	r := &resumeState{
+		mux:       m,
+		timeout:   timeout,
+		gen:       1,
+		attached:  true,
+		lingering: make(map[uint64]*muxStream),
+		acks:      make(map[uint64]*muxStream),
+		ackCh:     make(chan struct{}, 1),
+	}
+	go r.ackLoop()
+	return r
+}
+
+// dispatch dispatches a frame that was received on the gRPC stream with the given generation.
+func (r *resumeState) dispatch(ctx context.Context, gen uint64, code muxFrameCode, num uint64, body []byte) error {
+	r.recvLock.Lock()
+	defer r.recvLock.Unlock()
+	if r.gen != gen {
+		return errStaleStream
+	}
+	m := r.mux
+	switch code {
+	case muxAck:
+		if count, n := binary.Uvarint(body); n > 0 {
+			r.ack(num, count)
+		}
+		return nil
+	case muxResume:
+		peerRecv, err := parseResumeFrame(body)
+		if err != nil {
+			return err
+		}
+		go r.replay(ctx, gen, peerRecv)
+		return nil
+	}
+	ms := m.dispatch(ctx, code, num, body)
+	if ms == nil {
+		m.lock.Lock()
+		ms = r.lingering[num]
+		m.lock.Unlock()
+	}
+	r.received(ms, num, code, len(body))
+	return nil
+}
+
+// received counts a frame that was received on the given connection, and schedules an acknowledgement
+// when one is due. Frames of unknown connections are acknowledged right away, so that the peer can drop
+// them.
+func (r *resumeState) received(ms *muxStream, num uint64, code muxFrameCode, size int) {
+	due := ms == nil || code == muxFin
+	if ms != nil {
+		ms.recv.Add(1)
+		ms.unacked++
+		ms.unackedBytes += size
+		if due || ms.unacked >= muxAckFrames || ms.unackedBytes >= muxAckBytes {
+			ms.unacked, ms.unackedBytes = 0, 0
+			due = true
+		}
+	}
+	if due {
+		m := r.mux
+		m.lock.Lock()
+		r.acks[num] = ms
+		m.lock.Unlock()
+		select {
+		case r.ackCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// ackLoop sends the acknowledgements that are scheduled by received.
+func (r *resumeState) ackLoop() {
+	m := r.mux
+	buf := make([]byte, binary.MaxVarintLen64)
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-r.ackCh:
+		}
+		m.lock.Lock()
+		acks := r.acks
+		r.acks = make(map[uint64]*muxStream)
+		m.lock.Unlock()
+		for num, ms := range acks {
+			count := uint64(math.MaxUint64)
+			if ms != nil {
+				count = ms.recv.Load()
+			}
+			n := binary.PutUvarint(buf, count)
+			_ = m.sendFrame(muxAck, num, buf[:n])
+		}
+	}
+}
+
+// ack drops the frames that the peer has acknowledged from the replay buffer of a connection.
+func (r *resumeState) ack(num, count uint64) {
+	m := r.mux
+	m.lock.Lock()
+	ms, ok := m.streams[num]
+	if !ok {
+		ms = r.lingering[num]
+	}
+	m.lock.Unlock()
+	if ms == nil {
+		return
+	}
+	r.replayLock.Lock()
+	ms.trim(count)
+	gone := ms.removed && len(ms.replay) == 0
+	r.replayLock.Unlock()
+	if gone {
+		m.lock.Lock()
+		if r.lingering[num] == ms {
+			delete(r.lingering, num)
+		}
+		m.lock.Unlock()
+	}
+}
+
+// trim drops the frames that the peer has received from the replay buffer. It must be called with the
+// replayLock held.
+func (ms *muxStream) trim(count uint64) {
+	if count > ms.acked {
+		n := min(count-ms.acked, uint64(len(ms.replay)))
+		clear(ms.replay[:n])
+		ms.replay = ms.replay[n:]
+		ms.acked += n
+	}
+}
+
+// linger keeps a connection that is removed until the peer has acknowledged all of its frames. It must
+// be called with the lock of the Mux held.
+func (r *resumeState) linger(ms *muxStream) {
+	r.replayLock.Lock()
+	ms.removed = true
+	keep := len(ms.replay) > 0
+	r.replayLock.Unlock()
+	if keep && r.lingering != nil {
+		r.lingering[ms.num] = ms
+	}
+}
+
+// detach detaches the Mux from the gRPC stream with the given generation, so that frames are kept in the
+// replay buffers until the Mux is attached to a new gRPC stream. It returns false if the Mux is already
+// attached to another gRPC stream.
+func (r *resumeState) detach(gen uint64) bool {
+	m := r.mux
+	m.sendLock.Lock()
+	defer m.sendLock.Unlock()
+	if r.gen != gen {
+		return false
+	}
+	r.attached = false
+	if r.cancelStream != nil {
+		r.cancelStream()
+		r.cancelStream = nil
+	}
+	return true
+}
+
+// attach attaches the Mux to a new gRPC stream, and sends the muxResume frame on it. The returned
+// generation identifies the gRPC stream. A client passes the function that cancels the gRPC stream.
+func (r *resumeState) attach(gs GRPCStream, cancelStream context.CancelFunc) uint64 {
+	m := r.mux
+	r.recvLock.Lock()
+	defer r.recvLock.Unlock()
+	m.sendLock.Lock()
+	defer m.sendLock.Unlock()
+	r.gen++
+	r.attached = false
+	m.grpcStream = gs
+	if r.cancelStream != nil {
+		r.cancelStream()
+	}
+	r.cancelStream = cancelStream
+
+	var body []byte
+	m.lock.Lock()
+	for _, ms := range m.streams {
+		body = binary.AppendUvarint(body, ms.num)
+		body = binary.AppendUvarint(body, ms.recv.Load())
+	}
+	for _, ms := range r.lingering {
+		body = binary.AppendUvarint(body, ms.num)
+		body = binary.AppendUvarint(body, ms.recv.Load())
+	}
+	m.lock.Unlock()
+
+	// A failed send means that the new gRPC stream is lost too, which its read loop will notice.
+	_ = gs.Send(muxFrame(muxResume, 0, body))
+	return r.gen
+}
+
+func parseResumeFrame(body []byte) (map[uint64]uint64, error) {
+	peerRecv := make(map[uint64]uint64)
+	for len(body) > 0 {
+		num, n := binary.Uvarint(body)
+		if n <= 0 {
+			return nil, errors.New("malformed mux resume frame")
+		}
+		body = body[n:]
+		count, n := binary.Uvarint(body)
+		if n <= 0 {
+			return nil, errors.New("malformed mux resume frame")
+		}
+		body = body[n:]
+		peerRecv[num] = count
+	}
+	return peerRecv, nil
+}
+
+// replay sends the frames that the peer hasn't received on the gRPC stream with the given generation,
+// and then lets the connections send on it. The peerRecv map contains the number of frames that the peer
+// has received on each of the connections that it knows about.
+func (r *resumeState) replay(ctx context.Context, gen uint64, peerRecv map[uint64]uint64) {
+	m := r.mux
+	m.sendLock.Lock()
+	defer m.sendLock.Unlock()
+	if r.gen != gen {
+		return
+	}
+	m.lock.Lock()
+	streams := make([]*muxStream, 0, len(m.streams)+len(r.lingering))
+	for _, ms := range m.streams {
+		streams = append(streams, ms)
+	}
+	for _, ms := range r.lingering {
+		streams = append(streams, ms)
+	}
+	m.lock.Unlock()
+
+	replayed := 0
+	for _, ms := range streams {
+		r.replayLock.Lock()
+		count, ok := peerRecv[ms.num]
+		if !ok && m.client && ms.acked == 0 {
+			// The peer hasn't received the muxOpen of the connection.
+			ok = true
+		}
+		var frames []*rpc.TunnelMessage
+		if ok {
+			ms.trim(count)
+			frames = slices.Clone(ms.replay)
+		} else {
+			ms.acked += uint64(len(ms.replay))
+			ms.replay = nil
+		}
+		removed := ms.removed
+		r.replayLock.Unlock()
+
+		if !ok {
+			// The peer is done with the connection.
+			if removed {
+				m.lock.Lock()
+				if r.lingering[ms.num] == ms {
+					delete(r.lingering, ms.num)
+				}
+				m.lock.Unlock()
+			} else {
+				dlog.Debugf(ctx, "   %s %s, %v", m.base.tag, ms.id, errConnectionLost)
+				ms.setErr(errConnectionLost)
+				m.remove(ms)
+			}
+			continue
+		}
+		for _, tm := range frames {
+			if err := m.grpcStream.Send(tm); err != nil {
+				// The new gRPC stream is lost too, which its read loop will notice.
+				return
+			}
+		}
+		replayed += len(frames)
+	}
+	r.attached = true
+	dlog.Debugf(ctx, "   %s, resumed %d connections, replayed %d frames", m.base.tag, len(streams), replayed)
+}
+
+// serveServer serves a gRPC stream of a resumable server Mux until it ends. The Mux is closed when the
+// client closes the gRPC stream. Otherwise, it's detached, and closed unless it's attached to a new gRPC
+// stream within the resume timeout.
+func (r *resumeState) serveServer(ctx context.Context, gs GRPCStream, gen uint64) {
+	m := r.mux
+	err := m.readLoop(ctx, gs, gen)
+	switch {
+	case errors.Is(err, errStaleStream):
+	case errors.Is(err, io.EOF), errors.Is(err, net.ErrClosed):
+		m.close(io.EOF)
+	default:
+		if r.detach(gen) {
+			dlog.Debugf(ctx, "   %s, multiplexed tunnel of session %s lost, waiting %s for it to resume: %v",
+				m.base.tag, m.base.sessionID, r.timeout, err)
+			time.AfterFunc(r.timeout, func() {
+				m.sendLock.Lock()
+				expired := r.gen == gen
+				m.sendLock.Unlock()
+				if expired {
+					m.close(errResumeTimeout)
+				}
+			})
+		}
+	}
+}
+
+// reconnect opens new gRPC streams until the server accepts one as a replacement of the lost gRPC stream,
+// or until the resume timeout expires. It returns the new gRPC stream and its generation.
+func (r *resumeState) reconnect(ctx context.Context) (GRPCStream, uint64, error) {
+	deadline := time.Now().Add(r.timeout)
+	backoff := 100 * time.Millisecond
+	for {
+		gs, gen, err := r.reattach(ctx, deadline)
+		if err == nil || errors.Is(err, errResumeRefused) {
+			return gs, gen, err
+		}
+		dlog.Debugf(ctx, "   %s, attempt to resume multiplexed tunnel failed: %v", r.mux.base.tag, err)
+		if time.Now().Add(backoff).After(deadline) {
+			return nil, 0, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, resumeMaxBackoff)
+	}
+}
+
+// reattach opens a new gRPC stream and asks the server to attach it to the Mux.
+func (r *resumeState) reattach(ctx context.Context, deadline time.Time) (GRPCStream, uint64, error) {
+	// The gRPC stream must outlive this call, so the deadline only applies until the server has accepted it.
+	sCtx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(time.Until(deadline), cancel)
+	gs, err := r.reopen(sCtx)
+	if err == nil {
+		b := r.mux.base
+		var s *clientStream
+		s, err = newClientStream(sCtx, gs, b.id, b.sessionID, b.roundtripLatency, b.dialTimeout, flagMux|flagResumable|flagReattach)
+		if err == nil && s.flags&flagReattach == 0 {
+			_ = gs.CloseSend()
+			err = errResumeRefused
+		}
+	}
+	if !timer.Stop() && err == nil {
+		err = context.DeadlineExceeded
+	}
+	if err != nil {
+		cancel()
+		return nil, 0, err
+	}
+	return gs, r.attach(gs, cancel), nil
+}
+
+// closed releases the resources of a Mux that has been closed.
+func (r *resumeState) closed() {
+	m := r.mux
+	m.sendLock.Lock()
+	if r.cancelStream != nil {
+		r.cancelStream()
+		r.cancelStream = nil
+	}
+	m.sendLock.Unlock()
+	resumableMuxes.remove(m)
+}
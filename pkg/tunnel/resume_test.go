@@ -0,0 +1,183 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+// flakyLink opens gRPC streams that are served by ServeStreams, and that can be broken at will.
+type flakyLink struct {
+	ctx     context.Context
+	handler func(context.Context, Stream) error
+	opens   atomic.Int32
+	down    atomic.Bool
+
+	lock  sync.Mutex
+	abort context.CancelFunc
+}
+
+// newFlakyLink creates a flakyLink with servers that resume their tunnels within the given timeout.
+func newFlakyLink(ctx context.Context, timeout time.Duration, handler func(context.Context, Stream) error) *flakyLink {
+	return &flakyLink{ctx: WithResumeTimeout(ctx, timeout), handler: handler}
+}
+
+func (l *flakyLink) open(context.Context) (GRPCClientStream, error) {
+	if l.down.Load() {
+		return nil, errors.New("link is down")
+	}
+	l.opens.Add(1)
+
+	// Like a gRPC server, the context of the server ends when its stream is lost.
+	ctx, abort := context.WithCancel(l.ctx)
+	l.lock.Lock()
+	l.abort = abort
+	l.lock.Unlock()
+	b := newBidi(10, ctx.Done())
+	go func() {
+		_ = ServeStreams(ctx, b.serverSide(), l.handler)
+	}()
+	return b.clientSide(), nil
+}
+
+// breakStream breaks the current gRPC stream. Messages that are in flight are lost.
+func (l *flakyLink) breakStream() {
+	l.lock.Lock()
+	l.abort()
+	l.lock.Unlock()
+}
+
+func echo(ctx context.Context, s Stream) error {
+	for {
+		m, err := s.Receive(ctx)
+		if err != nil {
+			return nil
+		}
+		if err = s.Send(ctx, m); err != nil {
+			return err
+		}
+	}
+}
+
+func payload(conn, seq int) []byte {
+	b := make([]byte, 1024)
+	copy(b, fmt.Sprintf("%d-%d", conn, seq))
+	return b
+}
+
+func TestMux_resume(t *testing.T) {
+	ctx, cancel := testContext(t, 30*time.Second)
+	defer cancel()
+
+	link := newFlakyLink(ctx, 5*time.Second, echo)
+	pool := NewMuxPool(WithResumeTimeout(ctx, 5*time.Second))
+
+	const conns = 3
+	const msgs = 400
+	errs := make(chan error, conns)
+	wg := sync.WaitGroup{}
+	for c := 0; c < conns; c++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("192.168.0.1"), uint16(1001+c), 8080)
+			s, err := pool.CreateStream(ctx, "peer", id, "session", 0, 0, link.open)
+			if err != nil {
+				errs <- err
+				return
+			}
+			go func() {
+				for i := 0; i < msgs; i++ {
+					if err := s.Send(ctx, NewMessage(Normal, payload(c, i))); err != nil {
+						errs <- err
+						return
+					}
+				}
+				_ = s.CloseSend(ctx)
+			}()
+
+			// Every message must be echoed exactly once, and in order.
+			for i := 0; i < msgs; i++ {
+				m, err := s.Receive(ctx)
+				if err != nil {
+					errs <- fmt.Errorf("connection %d, message %d: %w", c, i, err)
+					return
+				}
+				if !assert.Equal(t, payload(c, i), m.Payload(), "connection %d, message %d", c, i) {
+					return
+				}
+			}
+		}()
+	}
+
+	// Break the gRPC stream a couple of times while the connections are busy.
+	for i := 0; i < 3; i++ {
+		time.Sleep(100 * time.Millisecond)
+		link.breakStream()
+	}
+	wg.Wait()
+	requireNoErrs(t, errs)
+	assert.Greater(t, link.opens.Load(), int32(1))
+}
+
+func TestMux_resumeDisabled(t *testing.T) {
+	ctx, cancel := testContext(t, 30*time.Second)
+	defer cancel()
+
+	// The server doesn't agree to resume, so the connection ends with the gRPC stream.
+	link := newFlakyLink(ctx, 0, echo)
+	pool := NewMuxPool(WithResumeTimeout(ctx, 5*time.Second))
+	id := NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("192.168.0.1"), 1001, 8080)
+	s, err := pool.CreateStream(ctx, "peer", id, "session", 0, 0, link.open)
+	require.NoError(t, err)
+	require.NoError(t, s.Send(ctx, NewMessage(Normal, payload(0, 0))))
+	_, err = s.Receive(ctx)
+	require.NoError(t, err)
+
+	link.breakStream()
+	_, err = s.Receive(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), link.opens.Load())
+}
+
+func TestMux_resumeTimeout(t *testing.T) {
+	ctx, cancel := testContext(t, 30*time.Second)
+	defer cancel()
+
+	handlerDone := make(chan struct{})
+	link := newFlakyLink(ctx, 200*time.Millisecond, func(ctx context.Context, s Stream) error {
+		defer close(handlerDone)
+		return echo(ctx, s)
+	})
+	pool := NewMuxPool(WithResumeTimeout(ctx, 5*time.Second))
+	id := NewConnID(ipproto.TCP, iputil.Parse("127.0.0.1"), iputil.Parse("192.168.0.1"), 1001, 8080)
+	s, err := pool.CreateStream(ctx, "peer", id, "session", 0, 0, link.open)
+	require.NoError(t, err)
+	require.NoError(t, s.Send(ctx, NewMessage(Normal, payload(0, 0))))
+	_, err = s.Receive(ctx)
+	require.NoError(t, err)
+
+	// The link stays down for longer than the server's resume timeout, so the server drops the connection,
+	// and refuses to resume it when the link is back.
+	link.down.Store(true)
+	link.breakStream()
+	select {
+	case <-handlerDone:
+	case <-ctx.Done():
+		t.Fatal("server didn't drop the connection")
+	}
+	link.down.Store(false)
+
+	_, err = s.Receive(ctx)
+	assert.ErrorIs(t, err, errResumeRefused)
+}
@@ -7,7 +7,20 @@ import (
 )
 
 func NewServerStream(ctx context.Context, grpcStream GRPCStream) (Stream, error) {
-	s := &stream{tag: "SRV", grpcStream: grpcStream, syncRatio: 8, ackWindow: 1}
+	s, err := acceptStream(ctx, grpcStream)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.sendStreamOK(ctx, s.compressionFlags(ctx)); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// acceptStream reads the initial StreamInfo message of a server stream.
+func acceptStream(ctx context.Context, grpcStream GRPCStream) (*stream, error) {
+	ns := newStream("SRV", grpcStream)
+	s := &ns
 	m, err := s.Receive(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read initial StreamInfo message: %w", err)
@@ -18,8 +31,25 @@ func NewServerStream(ctx context.Context, grpcStream GRPCStream) (Stream, error)
 	if err = setConnectInfo(m, s); err != nil {
 		return nil, fmt.Errorf("failed to parse StreamInfo message: %w", err)
 	}
-	if err = s.Send(ctx, StreamOKMessage()); err != nil {
-		return nil, err
-	}
 	return s, nil
 }
+
+// compressionFlags returns flagCompress when the client asked for compression and the context doesn't disable it.
+func (s *stream) compressionFlags(ctx context.Context) uint64 {
+	if s.flags&flagCompress != 0 {
+		if enabled, ok := compressionSetting(ctx); enabled || !ok {
+			return flagCompress
+		}
+	}
+	return 0
+}
+
+// sendStreamOK answers the StreamInfo message of a server stream with a StreamOK message that carries the
+// given flags.
+func (s *stream) sendStreamOK(ctx context.Context, flags uint64) error {
+	if err := s.Send(ctx, streamOKMessage(flags)); err != nil {
+		return err
+	}
+	s.comp.enabled = flags&flagCompress != 0
+	return nil
+}
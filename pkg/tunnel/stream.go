@@ -21,7 +21,12 @@ import (
 //
 //	0 which didn't report versions and didn't do synchronization
 //	1 used MuxTunnel instead of one tunnel per connection.
-const Version = uint16(2)
+//	2 used one tunnel per connection.
+//	3 multiplexes many connections over one tunnel when both peers support it.
+const Version = uint16(3)
+
+// muxVersion is the first Version that can multiplex connections.
+const muxVersion = uint16(3)
 
 // Endpoint is an endpoint for a Stream such as a Dialer or a bidirectional pipe.
 type Endpoint interface {
@@ -185,10 +190,12 @@ type stream struct {
 	syncRatio        uint32 // send and check sync after each syncRatio message
 	ackWindow        uint32 // maximum permitted difference between sent and received ack
 	peerVersion      uint16
+	flags            uint64 // flags of the StreamInfo or StreamOK message received from the peer
+	comp             *compressor
 }
 
 func newStream(tag string, grpcStream GRPCStream) stream {
-	return stream{tag: tag, grpcStream: grpcStream, syncRatio: 8, ackWindow: 1}
+	return stream{tag: tag, grpcStream: grpcStream, syncRatio: 8, ackWindow: 1, comp: &compressor{}}
 }
 
 func (s *stream) Tag() string {
@@ -227,6 +234,9 @@ func (s *stream) Receive(ctx context.Context) (Message, error) {
 		return nil, net.ErrClosed
 	case streamInfo:
 		dlog.Tracef(ctx, "<- %s, %s", s.tag, m)
+	case compressedNormal:
+		dlog.Tracef(ctx, "<- %s %s, %s", s.tag, s.id, m)
+		return s.comp.decompress(m)
 	default:
 		dlog.Tracef(ctx, "<- %s %s, %s", s.tag, s.id, m)
 	}
@@ -234,6 +244,7 @@ func (s *stream) Receive(ctx context.Context) (Message, error) {
 }
 
 func (s *stream) Send(ctx context.Context, m Message) error {
+	m = s.comp.compress(m)
 	if err := s.grpcStream.Send(m.TunnelMessage()); err != nil {
 		if ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
 			dlog.Errorf(ctx, "!! %s %s, Send failed: %v", s.tag, s.id, err)
@@ -244,6 +255,10 @@ func (s *stream) Send(ctx context.Context, m Message) error {
 	return nil
 }
 
+func (s *stream) compressionStats() (uncompressed, compressed uint64) {
+	return s.comp.stats()
+}
+
 func (s *stream) CloseSend(ctx context.Context) error {
 	if err := s.Send(ctx, NewMessage(closeSend, nil)); err != nil {
 		if ctx.Err() == nil && !(errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed)) {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/netip"
 	"sync"
+	"sync/atomic"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -24,8 +25,13 @@ type device struct {
 	ctx context.Context
 	wg  sync.WaitGroup
 	dev *nativeDevice
+	tap atomic.Pointer[PacketTap]
 }
 
+// PacketTap is called with each IP packet that is read from, or written to, the TUN device. The data is
+// only valid during the call.
+type PacketTap func(data []byte)
+
 type Device interface {
 	stack.LinkEndpoint
 	Index() int32
@@ -33,6 +39,7 @@ type Device interface {
 	AddSubnet(context.Context, netip.Prefix) error
 	RemoveSubnet(context.Context, netip.Prefix) error
 	SetDNS(context.Context, string, netip.Addr, []string) (err error)
+	SetTap(PacketTap)
 	WaitForDevice()
 }
 
@@ -113,6 +120,15 @@ func (d *device) RemoveSubnet(ctx context.Context, subnet netip.Prefix) (err err
 	return d.dev.removeSubnet(sCtx, subnet)
 }
 
+// SetTap sets the function that is called with each packet that passes this device. A nil tap removes it.
+func (d *device) SetTap(tap PacketTap) {
+	if tap == nil {
+		d.tap.Store(nil)
+	} else {
+		d.tap.Store(&tap)
+	}
+}
+
 func (d *device) WaitForDevice() {
 	d.wg.Wait()
 	dlog.Info(d.ctx, "Endpoint done")
@@ -147,6 +163,9 @@ func (d *device) tunToDispatch(cancel context.CancelFunc) {
 		default:
 			continue
 		}
+		if tap := d.tap.Load(); tap != nil {
+			(*tap)(data[:n])
+		}
 
 		pb := stack.NewPacketBuffer(stack.PacketBufferOptions{
 			Payload: buffer.MakeWithData(data[:n]),
@@ -172,6 +191,9 @@ func (d *device) dispatchToTun(ctx context.Context) {
 			b = b[len(s):]
 		}
 		pb.DecRef()
+		if tap := d.tap.Load(); tap != nil {
+			(*tap)(buf.Buf())
+		}
 		if _, err := d.dev.writePacket(buf, 0); err != nil {
 			dlog.Errorf(ctx, "WritePacket failed: %v", err)
 		}
@@ -0,0 +1,254 @@
+package vif
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/checksum"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/link/nested"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// icmpIdleTimeout is how long an echo stream remains open when no requests are sent on it.
+const icmpIdleTimeout = 30 * time.Second
+
+// icmpEndpoint is a link endpoint that takes the ICMP echo requests that arrive from the TUN device and
+// sends them through the tunnel, so that they are answered by the cluster instead of by the gVisor stack.
+// All other packets are delivered to the stack.
+type icmpEndpoint struct {
+	nested.Endpoint
+	ctx           context.Context
+	child         stack.LinkEndpoint
+	streamCreator tunnel.StreamCreator
+	lock          sync.Mutex
+	handlers      map[tunnel.ConnID]*icmpHandler
+}
+
+// icmpHandler sends the echo requests of one ping session, i.e. one source, destination, and identifier,
+// through one stream, and writes the replies that arrive on that stream to the TUN device.
+type icmpHandler struct {
+	id       tunnel.ConnID
+	requests chan []byte
+}
+
+func newICMPEndpoint(ctx context.Context, child stack.LinkEndpoint, streamCreator tunnel.StreamCreator) *icmpEndpoint {
+	e := &icmpEndpoint{
+		ctx:           ctx,
+		child:         child,
+		streamCreator: streamCreator,
+		handlers:      make(map[tunnel.ConnID]*icmpHandler),
+	}
+	e.Init(child, e)
+	return e
+}
+
+// DeliverNetworkPacket implements stack.NetworkDispatcher.
+func (e *icmpEndpoint) DeliverNetworkPacket(protocol tcpip.NetworkProtocolNumber, pkt *stack.PacketBuffer) {
+	// The IP header and the first eight bytes of the ICMP header are enough to tell if it's an echo request.
+	hdr, _ := pkt.Data().PullUp(min(pkt.Data().Size(), header.IPv6MinimumSize+header.ICMPv6MinimumSize))
+	if _, _, ok := parseEchoRequest(hdr); !ok {
+		e.Endpoint.DeliverNetworkPacket(protocol, pkt)
+		return
+	}
+	v := pkt.ToView()
+	id, msg, ok := parseEchoRequest(v.AsSlice())
+	if ok {
+		e.forward(id, append([]byte(nil), msg...))
+	}
+	v.Release()
+}
+
+func (e *icmpEndpoint) forward(id tunnel.ConnID, msg []byte) {
+	e.lock.Lock()
+	h, ok := e.handlers[id]
+	if !ok {
+		h = &icmpHandler{id: id, requests: make(chan []byte, 16)}
+		e.handlers[id] = h
+		go e.run(h)
+	}
+	e.lock.Unlock()
+	select {
+	case h.requests <- msg:
+	default:
+		dlog.Tracef(e.ctx, "!! ICMP %s, request dropped", id)
+	}
+}
+
+// run creates the stream of the given handler and sends its requests until it has been idle for
+// icmpIdleTimeout or the stream is closed by the peer.
+func (e *icmpEndpoint) run(h *icmpHandler) {
+	ctx, cancel := context.WithCancel(e.ctx)
+	defer func() {
+		e.lock.Lock()
+		delete(e.handlers, h.id)
+		e.lock.Unlock()
+		cancel()
+	}()
+
+	stream, err := e.streamCreator(ctx, h.id)
+	if err != nil {
+		dlog.Errorf(ctx, "forward %s: %s", h.id, err)
+		return
+	}
+	defer func() {
+		_ = stream.CloseSend(ctx)
+	}()
+
+	replies, errs := tunnel.ReadLoop(ctx, stream, nil)
+	go func() {
+		defer cancel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err, ok := <-errs:
+				if ok {
+					dlog.Errorf(ctx, "!! ICMP %s, %v", h.id, err)
+				}
+			case m, ok := <-replies:
+				if !ok {
+					return
+				}
+				switch m.Code() {
+				case tunnel.Normal:
+					e.writeReply(ctx, h.id, m.Payload())
+				case tunnel.DialReject, tunnel.Disconnect:
+					return
+				}
+			}
+		}
+	}()
+
+	idle := time.NewTimer(icmpIdleTimeout)
+	defer idle.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-idle.C:
+			return
+		case msg := <-h.requests:
+			if err = stream.Send(ctx, tunnel.NewMessage(tunnel.Normal, msg)); err != nil {
+				dlog.Errorf(ctx, "!! ICMP %s, send failed: %v", h.id, err)
+				return
+			}
+			idle.Reset(icmpIdleTimeout)
+		}
+	}
+}
+
+func (e *icmpEndpoint) writeReply(ctx context.Context, id tunnel.ConnID, msg []byte) {
+	pkt, proto, ok := buildEchoReply(id, msg)
+	if !ok {
+		dlog.Tracef(ctx, "!! ICMP %s, discarding malformed reply", id)
+		return
+	}
+	pb := stack.NewPacketBuffer(stack.PacketBufferOptions{Payload: buffer.MakeWithData(pkt)})
+	pb.NetworkProtocolNumber = proto
+	var pbs stack.PacketBufferList
+	pbs.PushBack(pb)
+	if _, err := e.child.WritePackets(pbs); err != nil {
+		dlog.Errorf(ctx, "!! ICMP %s, write failed: %v", id, err)
+	}
+	pbs.DecRef()
+}
+
+// parseEchoRequest checks if the given IP packet is an unfragmented ICMP or ICMPv6 echo request. If it is,
+// the ConnID of the request is returned together with the ICMP message. The source port of the ConnID is
+// the identifier of the request.
+func parseEchoRequest(pkt []byte) (tunnel.ConnID, []byte, bool) {
+	if len(pkt) == 0 {
+		return "", nil, false
+	}
+	switch header.IPVersion(pkt) {
+	case header.IPv4Version:
+		if len(pkt) < header.IPv4MinimumSize {
+			break
+		}
+		ip := header.IPv4(pkt)
+		hl := int(ip.HeaderLength())
+		if hl < header.IPv4MinimumSize || len(pkt) < hl+header.ICMPv4MinimumSize ||
+			ip.Protocol() != ipproto.ICMP || ip.More() || ip.FragmentOffset() != 0 {
+			break
+		}
+		msg := header.ICMPv4(pkt[hl:])
+		if msg.Type() != header.ICMPv4Echo || msg.Code() != 0 {
+			break
+		}
+		return tunnel.NewConnID(ipproto.ICMP, ip.SourceAddressSlice(), ip.DestinationAddressSlice(), msg.Ident(), 0), msg, true
+	case header.IPv6Version:
+		if len(pkt) < header.IPv6MinimumSize+header.ICMPv6MinimumSize {
+			break
+		}
+		ip := header.IPv6(pkt)
+		if ip.NextHeader() != ipproto.ICMPV6 {
+			break
+		}
+		msg := header.ICMPv6(pkt[header.IPv6MinimumSize:])
+		if msg.Type() != header.ICMPv6EchoRequest || msg.Code() != 0 {
+			break
+		}
+		return tunnel.NewConnID(ipproto.ICMPV6, ip.SourceAddressSlice(), ip.DestinationAddressSlice(), msg.Ident(), 0), msg, true
+	}
+	return "", nil, false
+}
+
+// buildEchoReply creates the IP packet that carries the given ICMP echo reply from the destination of
+// the given ConnID back to its source. The identifier and checksum of the reply are restored, because
+// the socket that received it might have changed them.
+func buildEchoReply(id tunnel.ConnID, msg []byte) ([]byte, tcpip.NetworkProtocolNumber, bool) {
+	src := tcpip.AddrFromSlice(id.Destination())
+	dst := tcpip.AddrFromSlice(id.Source())
+	if id.Protocol() == ipproto.ICMP {
+		if len(msg) < header.ICMPv4MinimumSize || header.ICMPv4(msg).Type() != header.ICMPv4EchoReply {
+			return nil, 0, false
+		}
+		pkt := make([]byte, header.IPv4MinimumSize+len(msg))
+		ip := header.IPv4(pkt)
+		ip.Encode(&header.IPv4Fields{
+			TotalLength: uint16(len(pkt)),
+			TTL:         64,
+			Protocol:    ipproto.ICMP,
+			SrcAddr:     src,
+			DstAddr:     dst,
+		})
+		ip.SetChecksum(^ip.CalculateChecksum())
+		reply := header.ICMPv4(pkt[header.IPv4MinimumSize:])
+		copy(reply, msg)
+		reply.SetIdent(id.SourcePort())
+		reply.SetChecksum(0)
+		reply.SetChecksum(^checksum.Checksum(reply, 0))
+		return pkt, header.IPv4ProtocolNumber, true
+	}
+
+	if len(msg) < header.ICMPv6MinimumSize || header.ICMPv6(msg).Type() != header.ICMPv6EchoReply {
+		return nil, 0, false
+	}
+	pkt := make([]byte, header.IPv6MinimumSize+len(msg))
+	ip := header.IPv6(pkt)
+	ip.Encode(&header.IPv6Fields{
+		PayloadLength:     uint16(len(msg)),
+		TransportProtocol: header.ICMPv6ProtocolNumber,
+		HopLimit:          64,
+		SrcAddr:           src,
+		DstAddr:           dst,
+	})
+	reply := header.ICMPv6(pkt[header.IPv6MinimumSize:])
+	copy(reply, msg)
+	reply.SetIdent(id.SourcePort())
+	reply.SetChecksum(0)
+	reply.SetChecksum(header.ICMPv6Checksum(header.ICMPv6ChecksumParams{
+		Header: reply,
+		Src:    src,
+		Dst:    dst,
+	}))
+	return pkt, header.IPv6ProtocolNumber, true
+}
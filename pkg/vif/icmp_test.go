@@ -0,0 +1,124 @@
+package vif
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/checksum"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+func echoRequestV4(src, dst string, ident uint16, payload string) []byte {
+	pkt := make([]byte, header.IPv4MinimumSize+header.ICMPv4MinimumSize+len(payload))
+	ip := header.IPv4(pkt)
+	ip.Encode(&header.IPv4Fields{
+		TotalLength: uint16(len(pkt)),
+		TTL:         64,
+		Protocol:    ipproto.ICMP,
+		SrcAddr:     tcpip.AddrFromSlice(iputil.Parse(src).To4()),
+		DstAddr:     tcpip.AddrFromSlice(iputil.Parse(dst).To4()),
+	})
+	msg := header.ICMPv4(pkt[header.IPv4MinimumSize:])
+	msg.SetType(header.ICMPv4Echo)
+	msg.SetIdent(ident)
+	msg.SetSequence(1)
+	copy(msg.Payload(), payload)
+	msg.SetChecksum(^checksum.Checksum(msg, 0))
+	return pkt
+}
+
+func echoRequestV6(src, dst string, ident uint16, payload string) []byte {
+	pkt := make([]byte, header.IPv6MinimumSize+header.ICMPv6MinimumSize+len(payload))
+	srcAddr := tcpip.AddrFromSlice(iputil.Parse(src))
+	dstAddr := tcpip.AddrFromSlice(iputil.Parse(dst))
+	header.IPv6(pkt).Encode(&header.IPv6Fields{
+		PayloadLength:     uint16(header.ICMPv6MinimumSize + len(payload)),
+		TransportProtocol: header.ICMPv6ProtocolNumber,
+		HopLimit:          64,
+		SrcAddr:           srcAddr,
+		DstAddr:           dstAddr,
+	})
+	msg := header.ICMPv6(pkt[header.IPv6MinimumSize:])
+	msg.SetType(header.ICMPv6EchoRequest)
+	msg.SetIdent(ident)
+	msg.SetSequence(1)
+	copy(msg.Payload(), payload)
+	msg.SetChecksum(header.ICMPv6Checksum(header.ICMPv6ChecksumParams{Header: msg, Src: srcAddr, Dst: dstAddr}))
+	return pkt
+}
+
+func TestEchoRequestV4(t *testing.T) {
+	pkt := echoRequestV4("10.0.0.1", "10.96.0.10", 0x1234, "ping")
+	id, msg, ok := parseEchoRequest(pkt)
+	require.True(t, ok)
+	assert.Equal(t, ipproto.ICMP, id.Protocol())
+	assert.Equal(t, "10.0.0.1", id.Source().String())
+	assert.Equal(t, "10.96.0.10", id.Destination().String())
+	assert.Equal(t, uint16(0x1234), id.SourcePort())
+
+	// The socket in the cluster may change the identifier of the reply.
+	reply := append([]byte(nil), msg...)
+	header.ICMPv4(reply).SetType(header.ICMPv4EchoReply)
+	header.ICMPv4(reply).SetIdent(0x4711)
+
+	rp, proto, ok := buildEchoReply(id, reply)
+	require.True(t, ok)
+	assert.Equal(t, header.IPv4ProtocolNumber, proto)
+	ip := header.IPv4(rp)
+	require.True(t, ip.IsValid(len(rp)))
+	assert.True(t, ip.IsChecksumValid())
+	assert.Equal(t, "10.96.0.10", ip.SourceAddress().String())
+	assert.Equal(t, "10.0.0.1", ip.DestinationAddress().String())
+	rm := header.ICMPv4(ip.Payload())
+	assert.Equal(t, header.ICMPv4EchoReply, rm.Type())
+	assert.Equal(t, uint16(0x1234), rm.Ident())
+	assert.Equal(t, "ping", string(rm.Payload()))
+	assert.Equal(t, uint16(0xffff), checksum.Checksum(rm, 0))
+
+	// Only echo replies are passed back.
+	_, _, ok = buildEchoReply(id, msg)
+	assert.False(t, ok)
+
+	// Anything but an echo request is left to the stack.
+	header.ICMPv4(pkt[header.IPv4MinimumSize:]).SetType(header.ICMPv4EchoReply)
+	_, _, ok = parseEchoRequest(pkt)
+	assert.False(t, ok)
+}
+
+func TestEchoRequestV6(t *testing.T) {
+	pkt := echoRequestV6("fd00::1", "fd00:96::10", 0x1234, "ping")
+	id, msg, ok := parseEchoRequest(pkt)
+	require.True(t, ok)
+	assert.Equal(t, ipproto.ICMPV6, id.Protocol())
+	assert.Equal(t, uint16(0x1234), id.SourcePort())
+
+	reply := append([]byte(nil), msg...)
+	header.ICMPv6(reply).SetType(header.ICMPv6EchoReply)
+	header.ICMPv6(reply).SetIdent(0x4711)
+
+	rp, proto, ok := buildEchoReply(id, reply)
+	require.True(t, ok)
+	assert.Equal(t, header.IPv6ProtocolNumber, proto)
+	ip := header.IPv6(rp)
+	require.True(t, ip.IsValid(len(rp)))
+	assert.Equal(t, "fd00:96::10", ip.SourceAddress().String())
+	assert.Equal(t, "fd00::1", ip.DestinationAddress().String())
+	rm := header.ICMPv6(ip.Payload())
+	assert.Equal(t, header.ICMPv6EchoReply, rm.Type())
+	assert.Equal(t, uint16(0x1234), rm.Ident())
+	assert.Equal(t, rm.Checksum(), header.ICMPv6Checksum(header.ICMPv6ChecksumParams{
+		Header: rm,
+		Src:    ip.SourceAddress(),
+		Dst:    ip.DestinationAddress(),
+	}))
+
+	// Packets with extension headers are left to the stack.
+	header.IPv6(pkt).SetNextHeader(uint8(header.IPv6HopByHopOptionsExtHdrIdentifier))
+	_, _, ok = parseEchoRequest(pkt)
+	assert.False(t, ok)
+}
@@ -118,12 +118,13 @@ func (rt *Router) UpdateRoutes(ctx context.Context, pleaseProxy, dontProxy, dont
 		}
 	}
 
+	// Subnets that are too small to be assigned to the TUN-device are instead routed using a static route through
+	// the route of another subnet of the same address family. Primary routes are keyed by that family (true for IPv4).
 	var staticNets []netip.Prefix
-	var pr *routing.Route
+	primaryRoutes := make(map[bool]*routing.Route, 2)
 	for _, sn := range added {
 		var err error
-		bits := sn.Bits()
-		if sn.Addr().Is4() && bits > 30 {
+		if sn.Bits() > sn.Addr().BitLen()-2 {
 			staticNets = append(staticNets, sn)
 			continue
 		}
@@ -133,27 +134,39 @@ func (rt *Router) UpdateRoutes(ctx context.Context, pleaseProxy, dontProxy, dont
 			continue
 		}
 
-		if pr == nil {
-			if pr, err = routing.GetRoute(ctx, sn); err != nil {
+		is4 := sn.Addr().Is4()
+		if primaryRoutes[is4] == nil {
+			pr, err := routing.GetRoute(ctx, sn)
+			if err != nil {
 				dlog.Errorf(ctx, "failed to retrieve route for subnet %s: %v", sn, err)
+			} else {
+				primaryRoutes[is4] = pr
 			}
 		}
 	}
-	if len(staticNets) > 0 && pr == nil {
-		return fmt.Errorf("unable to route subnets %v, because there's no subnet with a mask smaller than 31 bits", staticNets)
+	for _, sn := range staticNets {
+		if primaryRoutes[sn.Addr().Is4()] == nil {
+			return fmt.Errorf("unable to route subnet %s, because there's no subnet of the same address family with a mask smaller than %d bits",
+				sn, sn.Addr().BitLen()-1)
+		}
 	}
-	return rt.addStaticOverrides(ctx, dontProxy, dontProxyOverrides, staticNets, pr)
+	return rt.addStaticOverrides(ctx, dontProxy, dontProxyOverrides, staticNets, primaryRoutes)
 }
 
-func (rt *Router) addStaticOverrides(ctx context.Context, neverProxy, neverProxyOverrides, staticNets []netip.Prefix, primaryRoute *routing.Route) (err error) {
-	desired := make([]*routing.Route, 0, len(neverProxy)+len(neverProxyOverrides))
-	dr, err := routing.DefaultRoute(ctx)
-	if err != nil {
-		return err
-	}
+func (rt *Router) addStaticOverrides(ctx context.Context, neverProxy, neverProxyOverrides, staticNets []netip.Prefix, primaryRoutes map[bool]*routing.Route) (err error) {
+	desired := make([]*routing.Route, 0, len(neverProxy)+len(neverProxyOverrides)+len(staticNets))
+	defaultRoutes := make(map[bool]*routing.Route, 2)
 	for _, sn := range neverProxy {
 		// All subnets in neverProxy have been verified as being routed by the TUN-device, so we
-		// route them to the default route instead.
+		// route them to the default route of their address family instead.
+		is4 := sn.Addr().Is4()
+		dr := defaultRoutes[is4]
+		if dr == nil {
+			if dr, err = routing.DefaultRouteFor(ctx, sn.Addr()); err != nil {
+				return err
+			}
+			defaultRoutes[is4] = dr
+		}
 		desired = append(desired, &routing.Route{
 			LocalIP:   dr.LocalIP,
 			RoutedNet: sn,
@@ -179,6 +192,7 @@ func (rt *Router) addStaticOverrides(ctx context.Context, neverProxy, neverProxy
 	}
 
 	for _, sn := range staticNets {
+		primaryRoute := primaryRoutes[sn.Addr().Is4()]
 		desired = append(desired, &routing.Route{
 			LocalIP:   primaryRoute.LocalIP,
 			RoutedNet: sn,
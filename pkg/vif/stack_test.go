@@ -0,0 +1,186 @@
+package vif
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/checksum"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/link/channel"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// ipPacket wraps the given transport segment in an IPv4 or IPv6 header, depending on the address family of src
+// and dst, and sets the checksum of the segment.
+func ipPacket(src, dst netip.Addr, proto tcpip.TransportProtocolNumber, segment []byte) []byte {
+	srcAddr := tcpip.AddrFromSlice(src.AsSlice())
+	dstAddr := tcpip.AddrFromSlice(dst.AsSlice())
+	xsum := header.PseudoHeaderChecksum(proto, srcAddr, dstAddr, uint16(len(segment)))
+	xsum = ^checksum.Checksum(segment, xsum)
+	switch proto {
+	case header.TCPProtocolNumber:
+		header.TCP(segment).SetChecksum(xsum)
+	case header.UDPProtocolNumber:
+		header.UDP(segment).SetChecksum(xsum)
+	}
+
+	if src.Is4() {
+		pkt := make([]byte, header.IPv4MinimumSize+len(segment))
+		ip := header.IPv4(pkt)
+		ip.Encode(&header.IPv4Fields{
+			TotalLength: uint16(len(pkt)),
+			TTL:         64,
+			Protocol:    uint8(proto),
+			SrcAddr:     srcAddr,
+			DstAddr:     dstAddr,
+		})
+		ip.SetChecksum(^ip.CalculateChecksum())
+		copy(pkt[header.IPv4MinimumSize:], segment)
+		return pkt
+	}
+	pkt := make([]byte, header.IPv6MinimumSize+len(segment))
+	header.IPv6(pkt).Encode(&header.IPv6Fields{
+		PayloadLength:     uint16(len(segment)),
+		TransportProtocol: proto,
+		HopLimit:          64,
+		SrcAddr:           srcAddr,
+		DstAddr:           dstAddr,
+	})
+	copy(pkt[header.IPv6MinimumSize:], segment)
+	return pkt
+}
+
+func udpPacket(src, dst netip.AddrPort, payload string) []byte {
+	segment := make([]byte, header.UDPMinimumSize+len(payload))
+	header.UDP(segment).Encode(&header.UDPFields{
+		SrcPort: src.Port(),
+		DstPort: dst.Port(),
+		Length:  uint16(len(segment)),
+	})
+	copy(segment[header.UDPMinimumSize:], payload)
+	return ipPacket(src.Addr(), dst.Addr(), header.UDPProtocolNumber, segment)
+}
+
+func tcpPacket(src, dst netip.AddrPort, flags header.TCPFlags, seq, ack uint32) []byte {
+	segment := make([]byte, header.TCPMinimumSize)
+	header.TCP(segment).Encode(&header.TCPFields{
+		SrcPort:    src.Port(),
+		DstPort:    dst.Port(),
+		SeqNum:     seq,
+		AckNum:     ack,
+		DataOffset: header.TCPMinimumSize,
+		Flags:      flags,
+		WindowSize: 65535,
+	})
+	return ipPacket(src.Addr(), dst.Addr(), header.TCPProtocolNumber, segment)
+}
+
+func inject(ep *channel.Endpoint, pkt []byte) {
+	proto := header.IPv4ProtocolNumber
+	if header.IPVersion(pkt) == header.IPv6Version {
+		proto = header.IPv6ProtocolNumber
+	}
+	ep.InjectInbound(proto, stack.NewPacketBuffer(stack.PacketBufferOptions{Payload: buffer.MakeWithData(pkt)}))
+}
+
+// tcpHandshake performs the client side of a TCP handshake with the stack. The stack doesn't forward a
+// TCP connection until the handshake is complete.
+func tcpHandshake(ctx context.Context, t *testing.T, ep *channel.Endpoint, src, dst netip.AddrPort) {
+	inject(ep, tcpPacket(src, dst, header.TCPFlagSyn, 1, 0))
+	pb := ep.ReadContext(ctx)
+	require.NotNil(t, pb, "no SYN-ACK from stack")
+	defer pb.DecRef()
+	raw := pb.ToView().AsSlice()
+	var seg header.TCP
+	if src.Addr().Is4() {
+		seg = header.IPv4(raw).Payload()
+	} else {
+		seg = header.IPv6(raw).Payload()
+	}
+	require.Equal(t, header.TCPFlagSyn|header.TCPFlagAck, seg.Flags())
+	inject(ep, tcpPacket(src, dst, header.TCPFlagAck, 2, seg.SequenceNumber()+1))
+}
+
+func TestStackDualStack(t *testing.T) {
+	tests := []struct {
+		name  string
+		proto int
+		src   string
+		dst   string
+	}{
+		{
+			name:  "UDP IPv4",
+			proto: ipproto.UDP,
+			src:   "10.0.0.1:40000",
+			dst:   "10.96.0.10:53",
+		},
+		{
+			name:  "UDP IPv6",
+			proto: ipproto.UDP,
+			src:   "[fd00::1]:40000",
+			dst:   "[fd00:10:96::a]:53",
+		},
+		{
+			name:  "TCP IPv4",
+			proto: ipproto.TCP,
+			src:   "10.0.0.1:40000",
+			dst:   "10.96.0.10:80",
+		},
+		{
+			name:  "TCP IPv6",
+			proto: ipproto.TCP,
+			src:   "[fd00::1]:40000",
+			dst:   "[fd00:10:96::a]:80",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+			defer cancel()
+
+			ids := make(chan tunnel.ConnID, 1)
+			streamCreator := func(ctx context.Context, id tunnel.ConnID) (tunnel.Stream, error) {
+				select {
+				case ids <- id:
+				default:
+				}
+				return nil, errors.New("no cluster in this test")
+			}
+
+			ep := channel.New(16, 1500, "")
+			s, err := NewStack(ctx, ep, streamCreator)
+			require.NoError(t, err)
+			defer s.Destroy()
+
+			src := netip.MustParseAddrPort(tt.src)
+			dst := netip.MustParseAddrPort(tt.dst)
+			if tt.proto == ipproto.TCP {
+				tcpHandshake(ctx, t, ep, src, dst)
+			} else {
+				inject(ep, udpPacket(src, dst, "hello"))
+			}
+
+			select {
+			case id := <-ids:
+				assert.Equal(t, tt.proto, id.Protocol())
+				assert.Equal(t, src.Addr().String(), id.Source().String())
+				assert.Equal(t, dst.Addr().String(), id.Destination().String())
+				assert.Equal(t, src.Port(), id.SourcePort())
+				assert.Equal(t, dst.Port(), id.DestinationPort())
+			case <-time.After(5 * time.Second):
+				t.Fatal("timeout waiting for the stack to create a stream")
+			}
+		})
+	}
+}
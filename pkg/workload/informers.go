@@ -4,6 +4,7 @@ import (
 	"context"
 
 	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/cache"
 
@@ -83,6 +84,69 @@ func StartStatefulSets(ctx context.Context, ns string) cache.SharedIndexInformer
 	return ix
 }
 
+func StartDaemonSets(ctx context.Context, ns string) cache.SharedIndexInformer {
+	f := informer.GetK8sFactory(ctx, ns)
+	ix := f.Apps().V1().DaemonSets().Informer()
+	_ = ix.SetTransform(func(o any) (any, error) {
+		// Strip the parts of the daemonset that we don't care about. Saves memory
+		if dep, ok := o.(*apps.DaemonSet); ok {
+			om := &dep.ObjectMeta
+			if an := om.Annotations; an != nil {
+				delete(an, core.LastAppliedConfigAnnotation)
+			}
+			dep.ManagedFields = nil
+			dep.Finalizers = nil
+		}
+		return o, nil
+	})
+	_ = ix.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		dlog.Errorf(ctx, "watcher for DaemonSet %s: %v", whereWeWatch(ns), err)
+	})
+	return ix
+}
+
+func StartJobs(ctx context.Context, ns string) cache.SharedIndexInformer {
+	f := informer.GetK8sFactory(ctx, ns)
+	ix := f.Batch().V1().Jobs().Informer()
+	_ = ix.SetTransform(func(o any) (any, error) {
+		// Strip the parts of the job that we don't care about. Saves memory
+		if dep, ok := o.(*batch.Job); ok {
+			om := &dep.ObjectMeta
+			if an := om.Annotations; an != nil {
+				delete(an, core.LastAppliedConfigAnnotation)
+			}
+			dep.ManagedFields = nil
+			dep.Finalizers = nil
+		}
+		return o, nil
+	})
+	_ = ix.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		dlog.Errorf(ctx, "watcher for Jobs %s: %v", whereWeWatch(ns), err)
+	})
+	return ix
+}
+
+func StartCronJobs(ctx context.Context, ns string) cache.SharedIndexInformer {
+	f := informer.GetK8sFactory(ctx, ns)
+	ix := f.Batch().V1().CronJobs().Informer()
+	_ = ix.SetTransform(func(o any) (any, error) {
+		// Strip the parts of the cronjob that we don't care about. Saves memory
+		if dep, ok := o.(*batch.CronJob); ok {
+			om := &dep.ObjectMeta
+			if an := om.Annotations; an != nil {
+				delete(an, core.LastAppliedConfigAnnotation)
+			}
+			dep.ManagedFields = nil
+			dep.Finalizers = nil
+		}
+		return o, nil
+	})
+	_ = ix.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		dlog.Errorf(ctx, "watcher for CronJobs %s: %v", whereWeWatch(ns), err)
+	})
+	return ix
+}
+
 func StartRollouts(ctx context.Context, ns string) cache.SharedIndexInformer {
 	f := informer.GetArgoRolloutsFactory(ctx, ns)
 	dlog.Infof(ctx, "Watching Rollouts in %s", ns)
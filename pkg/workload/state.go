@@ -4,11 +4,13 @@ import (
 	"sort"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
 
 	argorollouts "github.com/datawire/argo-rollouts-go-client/pkg/apis/rollouts/v1alpha1"
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sworkload"
 )
 
 type State int
@@ -60,6 +62,36 @@ func statefulSetState(_ *appsv1.StatefulSet) State {
 	return StateAvailable
 }
 
+func daemonSetState(d *appsv1.DaemonSet) State {
+	st := &d.Status
+	if st.ObservedGeneration < d.Generation || st.UpdatedNumberScheduled < st.DesiredNumberScheduled {
+		return StateProgressing
+	}
+	return StateAvailable
+}
+
+func jobState(j *batchv1.Job) State {
+	for _, c := range j.Status.Conditions {
+		if c.Status != core.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobFailed:
+			return StateFailure
+		case batchv1.JobComplete:
+			return StateAvailable
+		}
+	}
+	if j.Status.Active > 0 {
+		return StateAvailable
+	}
+	return StateProgressing
+}
+
+func cronJobState(_ *batchv1.CronJob) State {
+	return StateAvailable
+}
+
 func rolloutSetState(r *argorollouts.Rollout) State {
 	conds := r.Status.Conditions
 	sort.Slice(conds, func(i, j int) bool {
@@ -113,6 +145,15 @@ func GetWorkloadState(wl k8sapi.Workload) State {
 	if rt, ok := k8sapi.RolloutImpl(wl); ok {
 		return rolloutSetState(rt)
 	}
+	if ds, ok := k8sworkload.DaemonSetImpl(wl); ok {
+		return daemonSetState(ds)
+	}
+	if j, ok := k8sworkload.JobImpl(wl); ok {
+		return jobState(j)
+	}
+	if cj, ok := k8sworkload.CronJobImpl(wl); ok {
+		return cronJobState(cj)
+	}
 	return StateUnknown
 }
 
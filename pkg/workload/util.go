@@ -5,6 +5,7 @@ import (
 
 	"github.com/datawire/k8sapi/pkg/k8sapi"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sworkload"
 )
 
 const (
@@ -17,7 +18,7 @@ const (
 
 func FromAny(obj any) (k8sapi.Workload, bool) {
 	if ro, ok := obj.(runtime.Object); ok {
-		if wl, err := k8sapi.WrapWorkload(ro); err == nil {
+		if wl, err := k8sworkload.Wrap(ro); err == nil {
 			return wl, true
 		}
 	}
@@ -42,10 +42,16 @@ const (
 	StatefulSetWorkloadKind WorkloadKind = "StatefulSet"
 	ReplicaSetWorkloadKind  WorkloadKind = "ReplicaSet"
 	RolloutWorkloadKind     WorkloadKind = "Rollout"
+	DaemonSetWorkloadKind   WorkloadKind = "DaemonSet"
+	JobWorkloadKind         WorkloadKind = "Job"
+	CronJobWorkloadKind     WorkloadKind = "CronJob"
 )
 
 func (w *WorkloadKind) IsValid() bool {
-	return w != nil && slices.Contains([]WorkloadKind{DeploymentWorkloadKind, StatefulSetWorkloadKind, ReplicaSetWorkloadKind, RolloutWorkloadKind}, *w)
+	return w != nil && slices.Contains([]WorkloadKind{
+		DeploymentWorkloadKind, StatefulSetWorkloadKind, ReplicaSetWorkloadKind, RolloutWorkloadKind, DaemonSetWorkloadKind,
+		JobWorkloadKind, CronJobWorkloadKind,
+	}, *w)
 }
 
 func (e EventType) String() string {
@@ -119,6 +125,10 @@ func hasValidReplicasetOwner(wl k8sapi.Workload, enabledWorkloadKinds []Workload
 				if slices.Contains(enabledWorkloadKinds, RolloutWorkloadKind) {
 					return true
 				}
+			case "CronJob":
+				if slices.Contains(enabledWorkloadKinds, CronJobWorkloadKind) {
+					return true
+				}
 			}
 		}
 	}
@@ -173,6 +183,43 @@ func (w *watcher) Subscribe(ctx context.Context) <-chan []WorkloadEvent {
 			}
 		}
 	}
+	if slices.Contains(w.enabledWorkloadKinds, DaemonSetWorkloadKind) {
+		if dss, err := ai.DaemonSets().Lister().DaemonSets(w.namespace).List(labels.Everything()); err == nil {
+			for _, obj := range dss {
+				if wl, ok := FromAny(obj); ok {
+					initialEvents = append(initialEvents, WorkloadEvent{
+						Type:     EventTypeAdd,
+						Workload: wl,
+					})
+				}
+			}
+		}
+	}
+	bi := kf.GetK8sInformerFactory().Batch().V1()
+	if slices.Contains(w.enabledWorkloadKinds, JobWorkloadKind) {
+		if jbs, err := bi.Jobs().Lister().Jobs(w.namespace).List(labels.Everything()); err == nil {
+			for _, obj := range jbs {
+				if wl, ok := FromAny(obj); ok && !hasValidReplicasetOwner(wl, w.enabledWorkloadKinds) {
+					initialEvents = append(initialEvents, WorkloadEvent{
+						Type:     EventTypeAdd,
+						Workload: wl,
+					})
+				}
+			}
+		}
+	}
+	if slices.Contains(w.enabledWorkloadKinds, CronJobWorkloadKind) {
+		if cjs, err := bi.CronJobs().Lister().CronJobs(w.namespace).List(labels.Everything()); err == nil {
+			for _, obj := range cjs {
+				if wl, ok := FromAny(obj); ok {
+					initialEvents = append(initialEvents, WorkloadEvent{
+						Type:     EventTypeAdd,
+						Workload: wl,
+					})
+				}
+			}
+		}
+	}
 	if slices.Contains(w.enabledWorkloadKinds, RolloutWorkloadKind) {
 		ri := kf.GetArgoRolloutsInformerFactory().Argoproj().V1alpha1()
 		if sps, err := ri.Rollouts().Lister().Rollouts(w.namespace).List(labels.Everything()); err == nil {
@@ -280,6 +327,12 @@ func (w *watcher) addEventHandler(ctx context.Context, ns string) error {
 			ssi = ai.ReplicaSets().Informer()
 		case StatefulSetWorkloadKind:
 			ssi = ai.StatefulSets().Informer()
+		case DaemonSetWorkloadKind:
+			ssi = ai.DaemonSets().Informer()
+		case JobWorkloadKind:
+			ssi = kf.GetK8sInformerFactory().Batch().V1().Jobs().Informer()
+		case CronJobWorkloadKind:
+			ssi = kf.GetK8sInformerFactory().Batch().V1().CronJobs().Informer()
 		case RolloutWorkloadKind:
 			ri := kf.GetArgoRolloutsInformerFactory().Argoproj().V1alpha1()
 			ssi = ri.Rollouts().Informer()
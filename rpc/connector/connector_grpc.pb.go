@@ -36,6 +36,7 @@ const (
 	Connector_CreateIntercept_FullMethodName         = "/telepresence.connector.Connector/CreateIntercept"
 	Connector_RemoveIntercept_FullMethodName         = "/telepresence.connector.Connector/RemoveIntercept"
 	Connector_UpdateIntercept_FullMethodName         = "/telepresence.connector.Connector/UpdateIntercept"
+	Connector_HandoverIntercept_FullMethodName       = "/telepresence.connector.Connector/HandoverIntercept"
 	Connector_Uninstall_FullMethodName               = "/telepresence.connector.Connector/Uninstall"
 	Connector_List_FullMethodName                    = "/telepresence.connector.Connector/List"
 	Connector_WatchWorkloads_FullMethodName          = "/telepresence.connector.Connector/WatchWorkloads"
@@ -51,6 +52,15 @@ const (
 	Connector_GetConfig_FullMethodName               = "/telepresence.connector.Connector/GetConfig"
 	Connector_SetDNSExcludes_FullMethodName          = "/telepresence.connector.Connector/SetDNSExcludes"
 	Connector_SetDNSMappings_FullMethodName          = "/telepresence.connector.Connector/SetDNSMappings"
+	Connector_Ingest_FullMethodName                  = "/telepresence.connector.Connector/Ingest"
+	Connector_LeaveIngest_FullMethodName             = "/telepresence.connector.Connector/LeaveIngest"
+	Connector_GetConnections_FullMethodName          = "/telepresence.connector.Connector/GetConnections"
+	Connector_GetTunnelStats_FullMethodName          = "/telepresence.connector.Connector/GetTunnelStats"
+	Connector_WatchDNSQueries_FullMethodName         = "/telepresence.connector.Connector/WatchDNSQueries"
+	Connector_GetDNSCache_FullMethodName             = "/telepresence.connector.Connector/GetDNSCache"
+	Connector_FlushDNSCache_FullMethodName           = "/telepresence.connector.Connector/FlushDNSCache"
+	Connector_ExportDNS_FullMethodName               = "/telepresence.connector.Connector/ExportDNS"
+	Connector_Capture_FullMethodName                 = "/telepresence.connector.Connector/Capture"
 )
 
 // ConnectorClient is the client API for Connector service.
@@ -93,6 +103,8 @@ type ConnectorClient interface {
 	// Requires having already called Connect.
 	RemoveIntercept(ctx context.Context, in *manager.RemoveInterceptRequest2, opts ...grpc.CallOption) (*InterceptResult, error)
 	UpdateIntercept(ctx context.Context, in *manager.UpdateInterceptRequest, opts ...grpc.CallOption) (*manager.InterceptInfo, error)
+	// HandoverIntercept moves an intercept of this session to another client session.
+	HandoverIntercept(ctx context.Context, in *manager.HandoverInterceptRequest, opts ...grpc.CallOption) (*manager.InterceptInfo, error)
 	// Uninstalls traffic-agents from the cluster.
 	// Requires having already called Connect.
 	Uninstall(ctx context.Context, in *UninstallRequest, opts ...grpc.CallOption) (*common.Result, error)
@@ -129,6 +141,29 @@ type ConnectorClient interface {
 	SetDNSExcludes(ctx context.Context, in *daemon.SetDNSExcludesRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// SetDNSMappings sets the Mappings field of DNSConfig.
 	SetDNSMappings(ctx context.Context, in *daemon.SetDNSMappingsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Ingest makes the environment and the volumes of a container available on the
+	// workstation without intercepting any of its traffic.
+	Ingest(ctx context.Context, in *IngestRequest, opts ...grpc.CallOption) (*IngestInfo, error)
+	// LeaveIngest ends an ingest and returns the info of the ingest that ended.
+	LeaveIngest(ctx context.Context, in *IngestIdentifier, opts ...grpc.CallOption) (*IngestInfo, error)
+	// GetConnections returns the connections that the root daemon currently tunnels to the cluster.
+	GetConnections(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*daemon.Connections, error)
+	// GetTunnelStats returns the latency and throughput statistics of the destinations that the user daemon
+	// and the root daemon have tunneled connections to.
+	GetTunnelStats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*daemon.TunnelStats, error)
+	// WatchDNSQueries streams the queries that the root daemon's DNS server handles.
+	WatchDNSQueries(ctx context.Context, in *daemon.WatchDNSQueriesRequest, opts ...grpc.CallOption) (Connector_WatchDNSQueriesClient, error)
+	// GetDNSCache returns the entries of the root daemon's DNS cache.
+	GetDNSCache(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*daemon.DNSCache, error)
+	// FlushDNSCache removes all entries, or the entries of one name, from the root daemon's DNS cache.
+	FlushDNSCache(ctx context.Context, in *daemon.FlushDNSCacheRequest, opts ...grpc.CallOption) (*daemon.FlushDNSCacheResponse, error)
+	// ExportDNS looks up the addresses of the services and pods of one namespace, or of all mapped namespaces.
+	// A new response is sent each time the names known to the traffic-manager change. The stream doesn't end
+	// until it is cancelled or the session ends.
+	ExportDNS(ctx context.Context, in *ExportDNSRequest, opts ...grpc.CallOption) (Connector_ExportDNSClient, error)
+	// Capture streams the packets that pass the VIF, and packets that represent the intercepted connections
+	// that the daemons dial, as pcapng blocks. The stream doesn't end until it is cancelled or the session ends.
+	Capture(ctx context.Context, in *daemon.CaptureRequest, opts ...grpc.CallOption) (Connector_CaptureClient, error)
 }
 
 type connectorClient struct {
@@ -269,6 +304,16 @@ func (c *connectorClient) UpdateIntercept(ctx context.Context, in *manager.Updat
 	return out, nil
 }
 
+func (c *connectorClient) HandoverIntercept(ctx context.Context, in *manager.HandoverInterceptRequest, opts ...grpc.CallOption) (*manager.InterceptInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(manager.InterceptInfo)
+	err := c.cc.Invoke(ctx, Connector_HandoverIntercept_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *connectorClient) Uninstall(ctx context.Context, in *UninstallRequest, opts ...grpc.CallOption) (*common.Result, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(common.Result)
@@ -442,6 +487,165 @@ func (c *connectorClient) SetDNSMappings(ctx context.Context, in *daemon.SetDNSM
 	return out, nil
 }
 
+func (c *connectorClient) Ingest(ctx context.Context, in *IngestRequest, opts ...grpc.CallOption) (*IngestInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IngestInfo)
+	err := c.cc.Invoke(ctx, Connector_Ingest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) LeaveIngest(ctx context.Context, in *IngestIdentifier, opts ...grpc.CallOption) (*IngestInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IngestInfo)
+	err := c.cc.Invoke(ctx, Connector_LeaveIngest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) GetConnections(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*daemon.Connections, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(daemon.Connections)
+	err := c.cc.Invoke(ctx, Connector_GetConnections_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) GetTunnelStats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*daemon.TunnelStats, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(daemon.TunnelStats)
+	err := c.cc.Invoke(ctx, Connector_GetTunnelStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) WatchDNSQueries(ctx context.Context, in *daemon.WatchDNSQueriesRequest, opts ...grpc.CallOption) (Connector_WatchDNSQueriesClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Connector_ServiceDesc.Streams[1], Connector_WatchDNSQueries_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &connectorWatchDNSQueriesClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Connector_WatchDNSQueriesClient interface {
+	Recv() (*daemon.DNSQuery, error)
+	grpc.ClientStream
+}
+
+type connectorWatchDNSQueriesClient struct {
+	grpc.ClientStream
+}
+
+func (x *connectorWatchDNSQueriesClient) Recv() (*daemon.DNSQuery, error) {
+	m := new(daemon.DNSQuery)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *connectorClient) GetDNSCache(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*daemon.DNSCache, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(daemon.DNSCache)
+	err := c.cc.Invoke(ctx, Connector_GetDNSCache_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) FlushDNSCache(ctx context.Context, in *daemon.FlushDNSCacheRequest, opts ...grpc.CallOption) (*daemon.FlushDNSCacheResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(daemon.FlushDNSCacheResponse)
+	err := c.cc.Invoke(ctx, Connector_FlushDNSCache_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *connectorClient) ExportDNS(ctx context.Context, in *ExportDNSRequest, opts ...grpc.CallOption) (Connector_ExportDNSClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Connector_ServiceDesc.Streams[2], Connector_ExportDNS_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &connectorExportDNSClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Connector_ExportDNSClient interface {
+	Recv() (*daemon.LookupHostsResponse, error)
+	grpc.ClientStream
+}
+
+type connectorExportDNSClient struct {
+	grpc.ClientStream
+}
+
+func (x *connectorExportDNSClient) Recv() (*daemon.LookupHostsResponse, error) {
+	m := new(daemon.LookupHostsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *connectorClient) Capture(ctx context.Context, in *daemon.CaptureRequest, opts ...grpc.CallOption) (Connector_CaptureClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Connector_ServiceDesc.Streams[3], Connector_Capture_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &connectorCaptureClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Connector_CaptureClient interface {
+	Recv() (*daemon.CaptureBlocks, error)
+	grpc.ClientStream
+}
+
+type connectorCaptureClient struct {
+	grpc.ClientStream
+}
+
+func (x *connectorCaptureClient) Recv() (*daemon.CaptureBlocks, error) {
+	m := new(daemon.CaptureBlocks)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // ConnectorServer is the server API for Connector service.
 // All implementations must embed UnimplementedConnectorServer
 // for forward compatibility
@@ -482,6 +686,8 @@ type ConnectorServer interface {
 	// Requires having already called Connect.
 	RemoveIntercept(context.Context, *manager.RemoveInterceptRequest2) (*InterceptResult, error)
 	UpdateIntercept(context.Context, *manager.UpdateInterceptRequest) (*manager.InterceptInfo, error)
+	// HandoverIntercept moves an intercept of this session to another client session.
+	HandoverIntercept(context.Context, *manager.HandoverInterceptRequest) (*manager.InterceptInfo, error)
 	// Uninstalls traffic-agents from the cluster.
 	// Requires having already called Connect.
 	Uninstall(context.Context, *UninstallRequest) (*common.Result, error)
@@ -518,6 +724,29 @@ type ConnectorServer interface {
 	SetDNSExcludes(context.Context, *daemon.SetDNSExcludesRequest) (*emptypb.Empty, error)
 	// SetDNSMappings sets the Mappings field of DNSConfig.
 	SetDNSMappings(context.Context, *daemon.SetDNSMappingsRequest) (*emptypb.Empty, error)
+	// Ingest makes the environment and the volumes of a container available on the
+	// workstation without intercepting any of its traffic.
+	Ingest(context.Context, *IngestRequest) (*IngestInfo, error)
+	// LeaveIngest ends an ingest and returns the info of the ingest that ended.
+	LeaveIngest(context.Context, *IngestIdentifier) (*IngestInfo, error)
+	// GetConnections returns the connections that the root daemon currently tunnels to the cluster.
+	GetConnections(context.Context, *emptypb.Empty) (*daemon.Connections, error)
+	// GetTunnelStats returns the latency and throughput statistics of the destinations that the user daemon
+	// and the root daemon have tunneled connections to.
+	GetTunnelStats(context.Context, *emptypb.Empty) (*daemon.TunnelStats, error)
+	// WatchDNSQueries streams the queries that the root daemon's DNS server handles.
+	WatchDNSQueries(*daemon.WatchDNSQueriesRequest, Connector_WatchDNSQueriesServer) error
+	// GetDNSCache returns the entries of the root daemon's DNS cache.
+	GetDNSCache(context.Context, *emptypb.Empty) (*daemon.DNSCache, error)
+	// FlushDNSCache removes all entries, or the entries of one name, from the root daemon's DNS cache.
+	FlushDNSCache(context.Context, *daemon.FlushDNSCacheRequest) (*daemon.FlushDNSCacheResponse, error)
+	// ExportDNS looks up the addresses of the services and pods of one namespace, or of all mapped namespaces.
+	// A new response is sent each time the names known to the traffic-manager change. The stream doesn't end
+	// until it is cancelled or the session ends.
+	ExportDNS(*ExportDNSRequest, Connector_ExportDNSServer) error
+	// Capture streams the packets that pass the VIF, and packets that represent the intercepted connections
+	// that the daemons dial, as pcapng blocks. The stream doesn't end until it is cancelled or the session ends.
+	Capture(*daemon.CaptureRequest, Connector_CaptureServer) error
 	mustEmbedUnimplementedConnectorServer()
 }
 
@@ -564,6 +793,9 @@ func (UnimplementedConnectorServer) RemoveIntercept(context.Context, *manager.Re
 func (UnimplementedConnectorServer) UpdateIntercept(context.Context, *manager.UpdateInterceptRequest) (*manager.InterceptInfo, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateIntercept not implemented")
 }
+func (UnimplementedConnectorServer) HandoverIntercept(context.Context, *manager.HandoverInterceptRequest) (*manager.InterceptInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HandoverIntercept not implemented")
+}
 func (UnimplementedConnectorServer) Uninstall(context.Context, *UninstallRequest) (*common.Result, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Uninstall not implemented")
 }
@@ -609,6 +841,33 @@ func (UnimplementedConnectorServer) SetDNSExcludes(context.Context, *daemon.SetD
 func (UnimplementedConnectorServer) SetDNSMappings(context.Context, *daemon.SetDNSMappingsRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetDNSMappings not implemented")
 }
+func (UnimplementedConnectorServer) Ingest(context.Context, *IngestRequest) (*IngestInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ingest not implemented")
+}
+func (UnimplementedConnectorServer) LeaveIngest(context.Context, *IngestIdentifier) (*IngestInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LeaveIngest not implemented")
+}
+func (UnimplementedConnectorServer) GetConnections(context.Context, *emptypb.Empty) (*daemon.Connections, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConnections not implemented")
+}
+func (UnimplementedConnectorServer) GetTunnelStats(context.Context, *emptypb.Empty) (*daemon.TunnelStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTunnelStats not implemented")
+}
+func (UnimplementedConnectorServer) WatchDNSQueries(*daemon.WatchDNSQueriesRequest, Connector_WatchDNSQueriesServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchDNSQueries not implemented")
+}
+func (UnimplementedConnectorServer) GetDNSCache(context.Context, *emptypb.Empty) (*daemon.DNSCache, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDNSCache not implemented")
+}
+func (UnimplementedConnectorServer) FlushDNSCache(context.Context, *daemon.FlushDNSCacheRequest) (*daemon.FlushDNSCacheResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FlushDNSCache not implemented")
+}
+func (UnimplementedConnectorServer) ExportDNS(*ExportDNSRequest, Connector_ExportDNSServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportDNS not implemented")
+}
+func (UnimplementedConnectorServer) Capture(*daemon.CaptureRequest, Connector_CaptureServer) error {
+	return status.Errorf(codes.Unimplemented, "method Capture not implemented")
+}
 func (UnimplementedConnectorServer) mustEmbedUnimplementedConnectorServer() {}
 
 // UnsafeConnectorServer may be embedded to opt out of forward compatibility for this service.
@@ -856,6 +1115,24 @@ func _Connector_UpdateIntercept_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Connector_HandoverIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(manager.HandoverInterceptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).HandoverIntercept(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_HandoverIntercept_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).HandoverIntercept(ctx, req.(*manager.HandoverInterceptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Connector_Uninstall_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(UninstallRequest)
 	if err := dec(in); err != nil {
@@ -1129,6 +1406,177 @@ func _Connector_SetDNSMappings_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Connector_Ingest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IngestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).Ingest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_Ingest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).Ingest(ctx, req.(*IngestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_LeaveIngest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IngestIdentifier)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).LeaveIngest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_LeaveIngest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).LeaveIngest(ctx, req.(*IngestIdentifier))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_GetConnections_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).GetConnections(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_GetConnections_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).GetConnections(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_GetTunnelStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).GetTunnelStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_GetTunnelStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).GetTunnelStats(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_WatchDNSQueries_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(daemon.WatchDNSQueriesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConnectorServer).WatchDNSQueries(m, &connectorWatchDNSQueriesServer{ServerStream: stream})
+}
+
+type Connector_WatchDNSQueriesServer interface {
+	Send(*daemon.DNSQuery) error
+	grpc.ServerStream
+}
+
+type connectorWatchDNSQueriesServer struct {
+	grpc.ServerStream
+}
+
+func (x *connectorWatchDNSQueriesServer) Send(m *daemon.DNSQuery) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Connector_GetDNSCache_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).GetDNSCache(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_GetDNSCache_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).GetDNSCache(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_FlushDNSCache_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(daemon.FlushDNSCacheRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectorServer).FlushDNSCache(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Connector_FlushDNSCache_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectorServer).FlushDNSCache(ctx, req.(*daemon.FlushDNSCacheRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Connector_ExportDNS_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportDNSRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConnectorServer).ExportDNS(m, &connectorExportDNSServer{ServerStream: stream})
+}
+
+type Connector_ExportDNSServer interface {
+	Send(*daemon.LookupHostsResponse) error
+	grpc.ServerStream
+}
+
+type connectorExportDNSServer struct {
+	grpc.ServerStream
+}
+
+func (x *connectorExportDNSServer) Send(m *daemon.LookupHostsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Connector_Capture_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(daemon.CaptureRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConnectorServer).Capture(m, &connectorCaptureServer{ServerStream: stream})
+}
+
+type Connector_CaptureServer interface {
+	Send(*daemon.CaptureBlocks) error
+	grpc.ServerStream
+}
+
+type connectorCaptureServer struct {
+	grpc.ServerStream
+}
+
+func (x *connectorCaptureServer) Send(m *daemon.CaptureBlocks) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // Connector_ServiceDesc is the grpc.ServiceDesc for Connector service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -1188,6 +1636,10 @@ var Connector_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateIntercept",
 			Handler:    _Connector_UpdateIntercept_Handler,
 		},
+		{
+			MethodName: "HandoverIntercept",
+			Handler:    _Connector_HandoverIntercept_Handler,
+		},
 		{
 			MethodName: "Uninstall",
 			Handler:    _Connector_Uninstall_Handler,
@@ -1244,6 +1696,30 @@ var Connector_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SetDNSMappings",
 			Handler:    _Connector_SetDNSMappings_Handler,
 		},
+		{
+			MethodName: "Ingest",
+			Handler:    _Connector_Ingest_Handler,
+		},
+		{
+			MethodName: "LeaveIngest",
+			Handler:    _Connector_LeaveIngest_Handler,
+		},
+		{
+			MethodName: "GetConnections",
+			Handler:    _Connector_GetConnections_Handler,
+		},
+		{
+			MethodName: "GetTunnelStats",
+			Handler:    _Connector_GetTunnelStats_Handler,
+		},
+		{
+			MethodName: "GetDNSCache",
+			Handler:    _Connector_GetDNSCache_Handler,
+		},
+		{
+			MethodName: "FlushDNSCache",
+			Handler:    _Connector_FlushDNSCache_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -1251,6 +1727,21 @@ var Connector_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Connector_WatchWorkloads_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "WatchDNSQueries",
+			Handler:       _Connector_WatchDNSQueries_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ExportDNS",
+			Handler:       _Connector_ExportDNS_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Capture",
+			Handler:       _Connector_Capture_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "connector/connector.proto",
 }
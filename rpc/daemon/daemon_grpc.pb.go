@@ -34,6 +34,14 @@ const (
 	Daemon_SetLogLevel_FullMethodName           = "/telepresence.daemon.Daemon/SetLogLevel"
 	Daemon_WaitForNetwork_FullMethodName        = "/telepresence.daemon.Daemon/WaitForNetwork"
 	Daemon_WaitForAgentIP_FullMethodName        = "/telepresence.daemon.Daemon/WaitForAgentIP"
+	Daemon_Record_FullMethodName                = "/telepresence.daemon.Daemon/Record"
+	Daemon_GetConnections_FullMethodName        = "/telepresence.daemon.Daemon/GetConnections"
+	Daemon_GetTunnelStats_FullMethodName        = "/telepresence.daemon.Daemon/GetTunnelStats"
+	Daemon_Capture_FullMethodName               = "/telepresence.daemon.Daemon/Capture"
+	Daemon_WatchDNSQueries_FullMethodName       = "/telepresence.daemon.Daemon/WatchDNSQueries"
+	Daemon_GetDNSCache_FullMethodName           = "/telepresence.daemon.Daemon/GetDNSCache"
+	Daemon_FlushDNSCache_FullMethodName         = "/telepresence.daemon.Daemon/FlushDNSCache"
+	Daemon_LookupHosts_FullMethodName           = "/telepresence.daemon.Daemon/LookupHosts"
 )
 
 // DaemonClient is the client API for Daemon service.
@@ -67,6 +75,28 @@ type DaemonClient interface {
 	WaitForNetwork(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// WaitForAgentIP waits for the network of an intercepted agent to become ready.
 	WaitForAgentIP(ctx context.Context, in *WaitForAgentIPRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Record streams the events of the connections that this daemon dials to an intercept destination, so
+	// that the caller can write them to its recording. The stream doesn't end until it is cancelled or the
+	// session ends.
+	Record(ctx context.Context, in *Recording, opts ...grpc.CallOption) (Daemon_RecordClient, error)
+	// GetConnections returns the connections that are currently tunneled to the cluster.
+	GetConnections(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Connections, error)
+	// GetTunnelStats returns the latency and throughput statistics of the destinations that the daemon has
+	// tunneled connections to.
+	GetTunnelStats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*TunnelStats, error)
+	// Capture streams the packets that pass the VIF, and packets that represent the intercepted connections
+	// that this daemon dials, as pcapng blocks. The stream doesn't end until it is cancelled or the session ends.
+	Capture(ctx context.Context, in *CaptureRequest, opts ...grpc.CallOption) (Daemon_CaptureClient, error)
+	// WatchDNSQueries streams the queries that the DNS server handles, starting with the most recent
+	// ones that it has kept. The stream ends after those unless follow is set.
+	WatchDNSQueries(ctx context.Context, in *WatchDNSQueriesRequest, opts ...grpc.CallOption) (Daemon_WatchDNSQueriesClient, error)
+	// GetDNSCache returns the entries of the DNS server's cache.
+	GetDNSCache(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*DNSCache, error)
+	// FlushDNSCache removes all entries, or the entries of one name, from the DNS server's cache.
+	FlushDNSCache(ctx context.Context, in *FlushDNSCacheRequest, opts ...grpc.CallOption) (*FlushDNSCacheResponse, error)
+	// LookupHosts looks up the addresses of the given cluster names the same way that the DNS server does, so
+	// virtual IPs are returned for names that resolve to translated subnets.
+	LookupHosts(ctx context.Context, in *LookupHostsRequest, opts ...grpc.CallOption) (*LookupHostsResponse, error)
 }
 
 type daemonClient struct {
@@ -197,6 +227,155 @@ func (c *daemonClient) WaitForAgentIP(ctx context.Context, in *WaitForAgentIPReq
 	return out, nil
 }
 
+func (c *daemonClient) Record(ctx context.Context, in *Recording, opts ...grpc.CallOption) (Daemon_RecordClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Daemon_ServiceDesc.Streams[0], Daemon_Record_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &daemonRecordClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Daemon_RecordClient interface {
+	Recv() (*RecordedEvents, error)
+	grpc.ClientStream
+}
+
+type daemonRecordClient struct {
+	grpc.ClientStream
+}
+
+func (x *daemonRecordClient) Recv() (*RecordedEvents, error) {
+	m := new(RecordedEvents)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *daemonClient) GetConnections(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Connections, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Connections)
+	err := c.cc.Invoke(ctx, Daemon_GetConnections_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) GetTunnelStats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*TunnelStats, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TunnelStats)
+	err := c.cc.Invoke(ctx, Daemon_GetTunnelStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) Capture(ctx context.Context, in *CaptureRequest, opts ...grpc.CallOption) (Daemon_CaptureClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Daemon_ServiceDesc.Streams[1], Daemon_Capture_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &daemonCaptureClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Daemon_CaptureClient interface {
+	Recv() (*CaptureBlocks, error)
+	grpc.ClientStream
+}
+
+type daemonCaptureClient struct {
+	grpc.ClientStream
+}
+
+func (x *daemonCaptureClient) Recv() (*CaptureBlocks, error) {
+	m := new(CaptureBlocks)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *daemonClient) WatchDNSQueries(ctx context.Context, in *WatchDNSQueriesRequest, opts ...grpc.CallOption) (Daemon_WatchDNSQueriesClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Daemon_ServiceDesc.Streams[2], Daemon_WatchDNSQueries_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &daemonWatchDNSQueriesClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Daemon_WatchDNSQueriesClient interface {
+	Recv() (*DNSQuery, error)
+	grpc.ClientStream
+}
+
+type daemonWatchDNSQueriesClient struct {
+	grpc.ClientStream
+}
+
+func (x *daemonWatchDNSQueriesClient) Recv() (*DNSQuery, error) {
+	m := new(DNSQuery)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *daemonClient) GetDNSCache(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*DNSCache, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DNSCache)
+	err := c.cc.Invoke(ctx, Daemon_GetDNSCache_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) FlushDNSCache(ctx context.Context, in *FlushDNSCacheRequest, opts ...grpc.CallOption) (*FlushDNSCacheResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FlushDNSCacheResponse)
+	err := c.cc.Invoke(ctx, Daemon_FlushDNSCache_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) LookupHosts(ctx context.Context, in *LookupHostsRequest, opts ...grpc.CallOption) (*LookupHostsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LookupHostsResponse)
+	err := c.cc.Invoke(ctx, Daemon_LookupHosts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DaemonServer is the server API for Daemon service.
 // All implementations must embed UnimplementedDaemonServer
 // for forward compatibility
@@ -228,6 +407,28 @@ type DaemonServer interface {
 	WaitForNetwork(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
 	// WaitForAgentIP waits for the network of an intercepted agent to become ready.
 	WaitForAgentIP(context.Context, *WaitForAgentIPRequest) (*emptypb.Empty, error)
+	// Record streams the events of the connections that this daemon dials to an intercept destination, so
+	// that the caller can write them to its recording. The stream doesn't end until it is cancelled or the
+	// session ends.
+	Record(*Recording, Daemon_RecordServer) error
+	// GetConnections returns the connections that are currently tunneled to the cluster.
+	GetConnections(context.Context, *emptypb.Empty) (*Connections, error)
+	// GetTunnelStats returns the latency and throughput statistics of the destinations that the daemon has
+	// tunneled connections to.
+	GetTunnelStats(context.Context, *emptypb.Empty) (*TunnelStats, error)
+	// Capture streams the packets that pass the VIF, and packets that represent the intercepted connections
+	// that this daemon dials, as pcapng blocks. The stream doesn't end until it is cancelled or the session ends.
+	Capture(*CaptureRequest, Daemon_CaptureServer) error
+	// WatchDNSQueries streams the queries that the DNS server handles, starting with the most recent
+	// ones that it has kept. The stream ends after those unless follow is set.
+	WatchDNSQueries(*WatchDNSQueriesRequest, Daemon_WatchDNSQueriesServer) error
+	// GetDNSCache returns the entries of the DNS server's cache.
+	GetDNSCache(context.Context, *emptypb.Empty) (*DNSCache, error)
+	// FlushDNSCache removes all entries, or the entries of one name, from the DNS server's cache.
+	FlushDNSCache(context.Context, *FlushDNSCacheRequest) (*FlushDNSCacheResponse, error)
+	// LookupHosts looks up the addresses of the given cluster names the same way that the DNS server does, so
+	// virtual IPs are returned for names that resolve to translated subnets.
+	LookupHosts(context.Context, *LookupHostsRequest) (*LookupHostsResponse, error)
 	mustEmbedUnimplementedDaemonServer()
 }
 
@@ -271,6 +472,30 @@ func (UnimplementedDaemonServer) WaitForNetwork(context.Context, *emptypb.Empty)
 func (UnimplementedDaemonServer) WaitForAgentIP(context.Context, *WaitForAgentIPRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method WaitForAgentIP not implemented")
 }
+func (UnimplementedDaemonServer) Record(*Recording, Daemon_RecordServer) error {
+	return status.Errorf(codes.Unimplemented, "method Record not implemented")
+}
+func (UnimplementedDaemonServer) GetConnections(context.Context, *emptypb.Empty) (*Connections, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConnections not implemented")
+}
+func (UnimplementedDaemonServer) GetTunnelStats(context.Context, *emptypb.Empty) (*TunnelStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTunnelStats not implemented")
+}
+func (UnimplementedDaemonServer) Capture(*CaptureRequest, Daemon_CaptureServer) error {
+	return status.Errorf(codes.Unimplemented, "method Capture not implemented")
+}
+func (UnimplementedDaemonServer) WatchDNSQueries(*WatchDNSQueriesRequest, Daemon_WatchDNSQueriesServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchDNSQueries not implemented")
+}
+func (UnimplementedDaemonServer) GetDNSCache(context.Context, *emptypb.Empty) (*DNSCache, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDNSCache not implemented")
+}
+func (UnimplementedDaemonServer) FlushDNSCache(context.Context, *FlushDNSCacheRequest) (*FlushDNSCacheResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FlushDNSCache not implemented")
+}
+func (UnimplementedDaemonServer) LookupHosts(context.Context, *LookupHostsRequest) (*LookupHostsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LookupHosts not implemented")
+}
 func (UnimplementedDaemonServer) mustEmbedUnimplementedDaemonServer() {}
 
 // UnsafeDaemonServer may be embedded to opt out of forward compatibility for this service.
@@ -500,6 +725,159 @@ func _Daemon_WaitForAgentIP_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Daemon_Record_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Recording)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DaemonServer).Record(m, &daemonRecordServer{ServerStream: stream})
+}
+
+type Daemon_RecordServer interface {
+	Send(*RecordedEvents) error
+	grpc.ServerStream
+}
+
+type daemonRecordServer struct {
+	grpc.ServerStream
+}
+
+func (x *daemonRecordServer) Send(m *RecordedEvents) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Daemon_GetConnections_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).GetConnections(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Daemon_GetConnections_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).GetConnections(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_GetTunnelStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).GetTunnelStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Daemon_GetTunnelStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).GetTunnelStats(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_Capture_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CaptureRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DaemonServer).Capture(m, &daemonCaptureServer{ServerStream: stream})
+}
+
+type Daemon_CaptureServer interface {
+	Send(*CaptureBlocks) error
+	grpc.ServerStream
+}
+
+type daemonCaptureServer struct {
+	grpc.ServerStream
+}
+
+func (x *daemonCaptureServer) Send(m *CaptureBlocks) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Daemon_WatchDNSQueries_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchDNSQueriesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DaemonServer).WatchDNSQueries(m, &daemonWatchDNSQueriesServer{ServerStream: stream})
+}
+
+type Daemon_WatchDNSQueriesServer interface {
+	Send(*DNSQuery) error
+	grpc.ServerStream
+}
+
+type daemonWatchDNSQueriesServer struct {
+	grpc.ServerStream
+}
+
+func (x *daemonWatchDNSQueriesServer) Send(m *DNSQuery) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Daemon_GetDNSCache_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).GetDNSCache(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Daemon_GetDNSCache_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).GetDNSCache(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_FlushDNSCache_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FlushDNSCacheRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).FlushDNSCache(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Daemon_FlushDNSCache_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).FlushDNSCache(ctx, req.(*FlushDNSCacheRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_LookupHosts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupHostsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).LookupHosts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Daemon_LookupHosts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).LookupHosts(ctx, req.(*LookupHostsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Daemon_ServiceDesc is the grpc.ServiceDesc for Daemon service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -555,7 +933,43 @@ var Daemon_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "WaitForAgentIP",
 			Handler:    _Daemon_WaitForAgentIP_Handler,
 		},
+		{
+			MethodName: "GetConnections",
+			Handler:    _Daemon_GetConnections_Handler,
+		},
+		{
+			MethodName: "GetTunnelStats",
+			Handler:    _Daemon_GetTunnelStats_Handler,
+		},
+		{
+			MethodName: "GetDNSCache",
+			Handler:    _Daemon_GetDNSCache_Handler,
+		},
+		{
+			MethodName: "FlushDNSCache",
+			Handler:    _Daemon_FlushDNSCache_Handler,
+		},
+		{
+			MethodName: "LookupHosts",
+			Handler:    _Daemon_LookupHosts_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Record",
+			Handler:       _Daemon_Record_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Capture",
+			Handler:       _Daemon_Capture_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchDNSQueries",
+			Handler:       _Daemon_WatchDNSQueries_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "daemon/daemon.proto",
 }
@@ -42,6 +42,7 @@ const (
 	Manager_WatchAgentsNS_FullMethodName             = "/telepresence.manager.Manager/WatchAgentsNS"
 	Manager_WatchIntercepts_FullMethodName           = "/telepresence.manager.Manager/WatchIntercepts"
 	Manager_WatchWorkloads_FullMethodName            = "/telepresence.manager.Manager/WatchWorkloads"
+	Manager_WatchClusterHosts_FullMethodName         = "/telepresence.manager.Manager/WatchClusterHosts"
 	Manager_WatchClusterInfo_FullMethodName          = "/telepresence.manager.Manager/WatchClusterInfo"
 	Manager_EnsureAgent_FullMethodName               = "/telepresence.manager.Manager/EnsureAgent"
 	Manager_PrepareIntercept_FullMethodName          = "/telepresence.manager.Manager/PrepareIntercept"
@@ -49,6 +50,7 @@ const (
 	Manager_RemoveIntercept_FullMethodName           = "/telepresence.manager.Manager/RemoveIntercept"
 	Manager_UpdateIntercept_FullMethodName           = "/telepresence.manager.Manager/UpdateIntercept"
 	Manager_GetIntercept_FullMethodName              = "/telepresence.manager.Manager/GetIntercept"
+	Manager_HandoverIntercept_FullMethodName         = "/telepresence.manager.Manager/HandoverIntercept"
 	Manager_ReviewIntercept_FullMethodName           = "/telepresence.manager.Manager/ReviewIntercept"
 	Manager_GetKnownWorkloadKinds_FullMethodName     = "/telepresence.manager.Manager/GetKnownWorkloadKinds"
 	Manager_LookupDNS_FullMethodName                 = "/telepresence.manager.Manager/LookupDNS"
@@ -118,6 +120,9 @@ type ManagerClient interface {
 	// WatchWorkloads notifies a client of the set of Workloads from the client
 	// connection's namespace.
 	WatchWorkloads(ctx context.Context, in *WorkloadEventsRequest, opts ...grpc.CallOption) (Manager_WatchWorkloadsClient, error)
+	// WatchClusterHosts sends the names of the services and pods of a namespace,
+	// and a new snapshot each time those names change.
+	WatchClusterHosts(ctx context.Context, in *ClusterHostsRequest, opts ...grpc.CallOption) (Manager_WatchClusterHostsClient, error)
 	// WatchClusterInfo returns information needed when establishing
 	// connectivity to the cluster.
 	WatchClusterInfo(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchClusterInfoClient, error)
@@ -137,6 +142,10 @@ type ManagerClient interface {
 	UpdateIntercept(ctx context.Context, in *UpdateInterceptRequest, opts ...grpc.CallOption) (*InterceptInfo, error)
 	// GetIntercept gets info from intercept name
 	GetIntercept(ctx context.Context, in *GetInterceptRequest, opts ...grpc.CallOption) (*InterceptInfo, error)
+	// HandoverIntercept moves an intercept from the calling client session to
+	// another client session. The intercept retains its id and disposition, so
+	// the intercepted traffic never returns to the intercepted container.
+	HandoverIntercept(ctx context.Context, in *HandoverInterceptRequest, opts ...grpc.CallOption) (*InterceptInfo, error)
 	// ReviewIntercept lets an agent approve or reject an intercept by
 	// changing the disposition from "WATING" to "ACTIVE" or to an
 	// error, and setting a human-readable status message.
@@ -478,9 +487,42 @@ func (x *managerWatchWorkloadsClient) Recv() (*WorkloadEventsDelta, error) {
 	return m, nil
 }
 
+func (c *managerClient) WatchClusterHosts(ctx context.Context, in *ClusterHostsRequest, opts ...grpc.CallOption) (Manager_WatchClusterHostsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[5], Manager_WatchClusterHosts_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &managerWatchClusterHostsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Manager_WatchClusterHostsClient interface {
+	Recv() (*ClusterHosts, error)
+	grpc.ClientStream
+}
+
+type managerWatchClusterHostsClient struct {
+	grpc.ClientStream
+}
+
+func (x *managerWatchClusterHostsClient) Recv() (*ClusterHosts, error) {
+	m := new(ClusterHosts)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *managerClient) WatchClusterInfo(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchClusterInfoClient, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[5], Manager_WatchClusterInfo_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[6], Manager_WatchClusterInfo_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -571,6 +613,16 @@ func (c *managerClient) GetIntercept(ctx context.Context, in *GetInterceptReques
 	return out, nil
 }
 
+func (c *managerClient) HandoverIntercept(ctx context.Context, in *HandoverInterceptRequest, opts ...grpc.CallOption) (*InterceptInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InterceptInfo)
+	err := c.cc.Invoke(ctx, Manager_HandoverIntercept_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *managerClient) ReviewIntercept(ctx context.Context, in *ReviewInterceptRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(emptypb.Empty)
@@ -613,7 +665,7 @@ func (c *managerClient) AgentLookupDNSResponse(ctx context.Context, in *DNSAgent
 
 func (c *managerClient) WatchLookupDNS(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchLookupDNSClient, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[6], Manager_WatchLookupDNS_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[7], Manager_WatchLookupDNS_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -646,7 +698,7 @@ func (x *managerWatchLookupDNSClient) Recv() (*DNSRequest, error) {
 
 func (c *managerClient) WatchLogLevel(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (Manager_WatchLogLevelClient, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[7], Manager_WatchLogLevel_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[8], Manager_WatchLogLevel_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -679,7 +731,7 @@ func (x *managerWatchLogLevelClient) Recv() (*LogLevelRequest, error) {
 
 func (c *managerClient) Tunnel(ctx context.Context, opts ...grpc.CallOption) (Manager_TunnelClient, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[8], Manager_Tunnel_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[9], Manager_Tunnel_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -721,7 +773,7 @@ func (c *managerClient) ReportMetrics(ctx context.Context, in *TunnelMetrics, op
 
 func (c *managerClient) WatchDial(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchDialClient, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[9], Manager_WatchDial_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[10], Manager_WatchDial_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -810,6 +862,9 @@ type ManagerServer interface {
 	// WatchWorkloads notifies a client of the set of Workloads from the client
 	// connection's namespace.
 	WatchWorkloads(*WorkloadEventsRequest, Manager_WatchWorkloadsServer) error
+	// WatchClusterHosts sends the names of the services and pods of a namespace,
+	// and a new snapshot each time those names change.
+	WatchClusterHosts(*ClusterHostsRequest, Manager_WatchClusterHostsServer) error
 	// WatchClusterInfo returns information needed when establishing
 	// connectivity to the cluster.
 	WatchClusterInfo(*SessionInfo, Manager_WatchClusterInfoServer) error
@@ -829,6 +884,10 @@ type ManagerServer interface {
 	UpdateIntercept(context.Context, *UpdateInterceptRequest) (*InterceptInfo, error)
 	// GetIntercept gets info from intercept name
 	GetIntercept(context.Context, *GetInterceptRequest) (*InterceptInfo, error)
+	// HandoverIntercept moves an intercept from the calling client session to
+	// another client session. The intercept retains its id and disposition, so
+	// the intercepted traffic never returns to the intercepted container.
+	HandoverIntercept(context.Context, *HandoverInterceptRequest) (*InterceptInfo, error)
 	// ReviewIntercept lets an agent approve or reject an intercept by
 	// changing the disposition from "WATING" to "ACTIVE" or to an
 	// error, and setting a human-readable status message.
@@ -926,6 +985,9 @@ func (UnimplementedManagerServer) WatchIntercepts(*SessionInfo, Manager_WatchInt
 func (UnimplementedManagerServer) WatchWorkloads(*WorkloadEventsRequest, Manager_WatchWorkloadsServer) error {
 	return status.Errorf(codes.Unimplemented, "method WatchWorkloads not implemented")
 }
+func (UnimplementedManagerServer) WatchClusterHosts(*ClusterHostsRequest, Manager_WatchClusterHostsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchClusterHosts not implemented")
+}
 func (UnimplementedManagerServer) WatchClusterInfo(*SessionInfo, Manager_WatchClusterInfoServer) error {
 	return status.Errorf(codes.Unimplemented, "method WatchClusterInfo not implemented")
 }
@@ -947,6 +1009,9 @@ func (UnimplementedManagerServer) UpdateIntercept(context.Context, *UpdateInterc
 func (UnimplementedManagerServer) GetIntercept(context.Context, *GetInterceptRequest) (*InterceptInfo, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetIntercept not implemented")
 }
+func (UnimplementedManagerServer) HandoverIntercept(context.Context, *HandoverInterceptRequest) (*InterceptInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HandoverIntercept not implemented")
+}
 func (UnimplementedManagerServer) ReviewIntercept(context.Context, *ReviewInterceptRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ReviewIntercept not implemented")
 }
@@ -1326,6 +1391,27 @@ func (x *managerWatchWorkloadsServer) Send(m *WorkloadEventsDelta) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Manager_WatchClusterHosts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ClusterHostsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ManagerServer).WatchClusterHosts(m, &managerWatchClusterHostsServer{ServerStream: stream})
+}
+
+type Manager_WatchClusterHostsServer interface {
+	Send(*ClusterHosts) error
+	grpc.ServerStream
+}
+
+type managerWatchClusterHostsServer struct {
+	grpc.ServerStream
+}
+
+func (x *managerWatchClusterHostsServer) Send(m *ClusterHosts) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Manager_WatchClusterInfo_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(SessionInfo)
 	if err := stream.RecvMsg(m); err != nil {
@@ -1455,6 +1541,24 @@ func _Manager_GetIntercept_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Manager_HandoverIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HandoverInterceptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).HandoverIntercept(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_HandoverIntercept_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).HandoverIntercept(ctx, req.(*HandoverInterceptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Manager_ReviewIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ReviewInterceptRequest)
 	if err := dec(in); err != nil {
@@ -1717,6 +1821,10 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetIntercept",
 			Handler:    _Manager_GetIntercept_Handler,
 		},
+		{
+			MethodName: "HandoverIntercept",
+			Handler:    _Manager_HandoverIntercept_Handler,
+		},
 		{
 			MethodName: "ReviewIntercept",
 			Handler:    _Manager_ReviewIntercept_Handler,
@@ -1764,6 +1872,11 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Manager_WatchWorkloads_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "WatchClusterHosts",
+			Handler:       _Manager_WatchClusterHosts_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "WatchClusterInfo",
 			Handler:       _Manager_WatchClusterInfo_Handler,